@@ -34,9 +34,15 @@ import (
 	"github.com/haakenlabs/ember/core"
 	"github.com/haakenlabs/ember/gfx"
 	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/asset/animation"
+	"github.com/haakenlabs/ember/system/asset/atlas"
+	"github.com/haakenlabs/ember/system/asset/audio"
 	"github.com/haakenlabs/ember/system/asset/font"
+	"github.com/haakenlabs/ember/system/asset/material"
 	"github.com/haakenlabs/ember/system/asset/mesh"
+	"github.com/haakenlabs/ember/system/asset/model"
 	"github.com/haakenlabs/ember/system/asset/shader"
+	"github.com/haakenlabs/ember/system/asset/skeleton"
 	"github.com/haakenlabs/ember/system/asset/skybox"
 	"github.com/haakenlabs/ember/system/asset/texture"
 )
@@ -78,6 +84,10 @@ type App struct {
 	// PostTeardownFunc is a callback invoked after app teardown.
 	PostTeardownFunc func()
 
+	// DisableBuiltinAssets prevents the builtin asset manifest from being
+	// loaded during setup.
+	DisableBuiltinAssets bool
+
 	// systems is a list of systems used by this app.
 	systems []core.System
 
@@ -106,10 +116,11 @@ func (a *App) Setup() error {
 		}
 	}
 
-	//
-	//if err := asset.LoadManifest(builtinAssets); err != nil {
-	//	return err
-	//}
+	if !a.DisableBuiltinAssets {
+		if err := asset.LoadManifest(builtinAssets); err != nil {
+			return err
+		}
+	}
 
 	if a.PostSetupFunc != nil {
 		if err := a.PostSetupFunc(); err != nil {
@@ -222,7 +233,13 @@ func NewApp(renderer gfx.Renderer) *App {
 	asset.RegisterHandler(shader.NewHandler())
 	asset.RegisterHandler(mesh.NewHandler())
 	asset.RegisterHandler(font.NewHandler())
+	asset.RegisterHandler(atlas.NewHandler())
+	asset.RegisterHandler(material.NewHandler())
+	asset.RegisterHandler(skeleton.NewHandler())
+	asset.RegisterHandler(animation.NewHandler())
+	asset.RegisterHandler(model.NewHandler())
 	asset.RegisterHandler(skybox.NewHandler())
+	asset.RegisterHandler(audio.NewHandler())
 
 	return a
 }
@@ -112,6 +112,7 @@ func TestApp_Setup(t *testing.T) {
 	app.PostSetupFunc = func() error { return nil }
 	app.PreTeardownFunc = func() { }
 	app.PostTeardownFunc = func() { }
+	app.DisableBuiltinAssets = true
 
 	for i, v := range tests {
 
@@ -0,0 +1,337 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Command meshconv converts meshes to the binary mesh format.
+//
+// Usage:
+//
+//	meshconv [-quantize] [-compress] [-lods ratios] [-max-error e] [-out dir] file...
+//
+// Gob encoded meshes (.mdl), Wavefront OBJ files (.obj), STL and PLY files
+// (.stl, .ply) and glTF models (.gltf, .glb) are accepted. PLY point clouds
+// are not. Every mesh of the inputs is written to its own
+// file in the output directory, named after the mesh with slashes replaced
+// by underscores and a .mesh extension.
+//
+// With -lods, a comma separated list of triangle ratios such as 0.5,0.25,
+// each mesh is also simplified in to levels of detail, written to their own
+// files named like the mesh handler names generated levels.
+package main
+
+import (
+	"encoding/gob"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/pkg/gltf"
+	"github.com/haakenlabs/ember/pkg/meshfile"
+	"github.com/haakenlabs/ember/system/asset/mesh"
+	"github.com/haakenlabs/ember/system/asset/model"
+)
+
+func main() {
+	opts := &meshfile.Options{}
+
+	flag.BoolVar(&opts.Quantize, "quantize", false, "store attributes as 16 bit normalized values")
+	flag.BoolVar(&opts.CompressIndices, "compress", false, "store indices as variable length differences")
+	out := flag.String("out", ".", "output directory")
+	lods := flag.String("lods", "", "comma separated triangle ratios of levels of detail")
+	maxError := flag.Float64("max-error", 0, "maximum simplification error of levels of detail, or 0 for none")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: meshconv [flags] file...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ratios, err := parseRatios(*lods)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "meshconv: -lods: %v\n", err)
+		os.Exit(2)
+	}
+
+	for _, filename := range flag.Args() {
+		meshes, err := convert(filename)
+		if err == nil && len(ratios) > 0 {
+			meshes, err = addLODs(meshes, ratios, float32(*maxError))
+		}
+		if err == nil {
+			err = write(*out, meshes, opts)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "meshconv: %s: %v\n", filename, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// convert reads the meshes of a file.
+func convert(filename string) ([]*meshfile.Mesh, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".mdl":
+		return convertGob(filename)
+	case ".obj":
+		return convertOBJ(filename)
+	case ".stl":
+		return convertSTL(filename)
+	case ".ply":
+		return convertPLY(filename)
+	case ".gltf", ".glb":
+		return convertGLTF(filename)
+	}
+
+	return nil, fmt.Errorf("unsupported file type")
+}
+
+func convertGob(filename string) ([]*meshfile.Mesh, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	metadata := &mesh.Metadata{}
+	if err := gob.NewDecoder(f).Decode(metadata); err != nil {
+		return nil, err
+	}
+
+	return fromMetadata(metadata)
+}
+
+func convertOBJ(filename string) ([]*meshfile.Mesh, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	o, err := mesh.ParseOBJ(f, filepath.Base(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	return fromMetadata(o.Meshes...)
+}
+
+func convertSTL(filename string) ([]*meshfile.Mesh, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := mesh.ParseSTL(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*meshfile.Mesh{{Name: trimExt(filename), Geometry: g}}, nil
+}
+
+func convertPLY(filename string) ([]*meshfile.Mesh, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := mesh.ParsePLY(f)
+	if err != nil {
+		return nil, err
+	}
+	if p.Points {
+		return nil, fmt.Errorf("point clouds are not supported")
+	}
+
+	g := p.Mesh
+	if g.UVs == nil {
+		g.UVs = make([]mgl32.Vec2, len(g.Positions))
+	}
+	if g.Normals == nil {
+		g.SmoothNormals()
+	}
+
+	return []*meshfile.Mesh{{Name: trimExt(filename), Geometry: g}}, nil
+}
+
+// trimExt returns the base name of a file without its extension, as the
+// mesh handler names meshes of single mesh files.
+func trimExt(filename string) string {
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func fromMetadata(metadata ...*mesh.Metadata) ([]*meshfile.Mesh, error) {
+	var meshes []*meshfile.Mesh
+
+	for _, m := range metadata {
+		g, err := m.Geometry()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", m.Name, err)
+		}
+
+		meshes = append(meshes, &meshfile.Mesh{Name: m.Name, Materials: m.Materials, Geometry: g})
+	}
+
+	return meshes, nil
+}
+
+// convertGLTF reads the primitives of a glTF model, named like the model
+// handler names them.
+func convertGLTF(filename string) ([]*meshfile.Mesh, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := gltf.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := doc.ResolveBuffers(func(uri string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, filepath.FromSlash(uri)))
+	}); err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(filename)
+
+	var meshes []*meshfile.Mesh
+
+	for i, m := range doc.Meshes {
+		meshName := m.Name
+		if meshName == "" {
+			meshName = fmt.Sprintf("mesh%d", i)
+		}
+		meshName = base + "/" + meshName
+
+		for j := range m.Primitives {
+			p := &m.Primitives[j]
+
+			name := meshName
+			if len(m.Primitives) > 1 {
+				name = fmt.Sprintf("%s/%d", meshName, j)
+			}
+
+			g, err := model.PrimitiveGeometry(doc, p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			if g == nil {
+				fmt.Fprintf(os.Stderr, "meshconv: skipping %s: unsupported primitive mode %d\n", name, p.PrimitiveMode())
+				continue
+			}
+
+			meshes = append(meshes, &meshfile.Mesh{Name: name, Materials: materials(doc, base, p), Geometry: g})
+		}
+	}
+
+	return meshes, nil
+}
+
+// materials returns the name of the material of a primitive, as named by the
+// model handler.
+func materials(doc *gltf.Document, base string, p *gltf.Primitive) []string {
+	i := p.Material
+	if i == nil || *i < 0 || *i >= len(doc.Materials) {
+		return []string{base + "/default"}
+	}
+
+	name := doc.Materials[*i].Name
+	if name == "" {
+		name = fmt.Sprintf("material%d", *i)
+	}
+
+	return []string{base + "/" + name}
+}
+
+// parseRatios parses a comma separated list of ratios.
+func parseRatios(s string) ([]float32, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var ratios []float32
+	for _, f := range strings.Split(s, ",") {
+		r, err := strconv.ParseFloat(strings.TrimSpace(f), 32)
+		if err != nil {
+			return nil, err
+		}
+		ratios = append(ratios, float32(r))
+	}
+
+	return ratios, nil
+}
+
+// addLODs appends the levels of detail of each mesh to the meshes.
+func addLODs(meshes []*meshfile.Mesh, ratios []float32, maxError float32) ([]*meshfile.Mesh, error) {
+	out := meshes
+
+	for _, m := range meshes {
+		lods, err := m.Geometry.LODs(ratios, maxError)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", m.Name, err)
+		}
+
+		for i, g := range lods {
+			out = append(out, &meshfile.Mesh{Name: mesh.LODName(m.Name, i+1), Materials: m.Materials, Geometry: g})
+		}
+	}
+
+	return out, nil
+}
+
+// write writes each mesh to its own file in the directory.
+func write(dir string, meshes []*meshfile.Mesh, opts *meshfile.Options) error {
+	for _, m := range meshes {
+		filename := filepath.Join(dir, strings.ReplaceAll(m.Name, "/", "_")+".mesh")
+
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+
+		if err := meshfile.Encode(f, m, opts); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		fmt.Println(filename)
+	}
+
+	return nil
+}
@@ -28,9 +28,11 @@ import (
 	"io"
 	"os"
 	"path"
+	"sort"
 	"sync"
 
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 
 	"github.com/haakenlabs/ember/internal/builtin"
 )
@@ -83,20 +85,59 @@ type AssetHandler interface {
 
 	// Count returns the number of assets tracked by this handler.
 	Count() int
+
+	// Names returns the names of the assets tracked by this handler.
+	Names() []string
+}
+
+// StreamHandler is an AssetHandler which chooses how resources are accessed.
+// Handlers which do not implement this interface access resources buffered.
+type StreamHandler interface {
+	AssetHandler
+
+	// Access returns how the given resource is accessed. Streamed resources
+	// are not read before loading, the handler must open them with
+	// OpenResource instead.
+	Access(*Resource) ResourceAccess
+}
+
+// FallbackHandler is an AssetHandler which can provide an object to serve in
+// place of an asset which cannot be found.
+type FallbackHandler interface {
+	AssetHandler
+
+	// Fallback returns the object served in place of a missing asset.
+	Fallback() (Object, error)
+}
+
+// AssetFallback describes a fallback which was served in place of a
+// missing asset.
+type AssetFallback struct {
+	Kind  string
+	Name  string
+	Count int
 }
 
 var _ System = &AssetSystem{}
 
 type AssetSystem struct {
-	handlers map[string]AssetHandler
-	packages map[string]*Package
-	mu       *sync.RWMutex
+	handlers  map[string]AssetHandler
+	order     []string
+	packages  map[string]*Package
+	fallbacks map[string]*AssetFallback
+	sources   map[AssetRef]ResourceType
+	graph     *AssetGraph
+	lenient   bool
+	mu        *sync.RWMutex
+	fbMu      *sync.Mutex
+	srcMu     *sync.Mutex
 }
 
 type AssetManifest struct {
 	Name        string              `json:"name"`
 	Description string              `json:"description"`
 	Assets      map[string][]string `json:"assets,required"`
+	Import      []string            `json:"import"`
 }
 
 type AssetMetadata struct {
@@ -117,11 +158,16 @@ func (a *AssetSystem) Setup() error {
 	}
 	assetInst = a
 
+	if viper.GetBool("asset.lenient") {
+		a.SetLenient(true)
+	}
+
 	return nil
 }
 
 // Teardown tears down the System.
 func (a *AssetSystem) Teardown() {
+	a.logFallbacks()
 	a.ReleaseAll()
 	a.UnmountAllPackages()
 }
@@ -210,8 +256,19 @@ func (a *AssetSystem) LoadManifest(files ...string) error {
 			return err
 		}
 
-		// Load assets.
 		for t := range m.Assets {
+			if !a.HandlerRegistered(t) {
+				logrus.Error(ErrHandlerNotFound(t))
+			}
+		}
+
+		// Load assets. Kinds are loaded in the order their handlers were
+		// registered, so assets may depend on kinds registered before them.
+		for _, t := range a.handlerOrder() {
+			if _, ok := m.Assets[t]; !ok {
+				continue
+			}
+
 			h, err := a.GetHandler(t)
 			if err != nil {
 				logrus.Error(err)
@@ -227,24 +284,80 @@ func (a *AssetSystem) LoadManifest(files ...string) error {
 					return err
 				}
 
-				if err := a.ReadResource(ar); err != nil {
-					return err
-				}
-
-				logrus.Debug("Read asset: ", m.Assets[t][n])
-
-				if err := h.Load(ar); err != nil {
+				if err := a.load(h, ar); err != nil {
 					return err
 				}
 
 				logrus.Debug("Loaded asset: ", m.Assets[t][n])
 			}
 		}
+
+		// Import directories, detecting the kind of each asset.
+		for _, dir := range m.Import {
+			if err := a.ImportDir(path.Join(r.DirPrefix(), dir)); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// load loads the resource with the given handler, reading it first unless
+// the handler streams it.
+func (a *AssetSystem) load(h AssetHandler, r *Resource) error {
+	access := ResourceBuffered
+	if s, ok := h.(StreamHandler); ok {
+		access = s.Access(r)
+	}
+
+	if access == ResourceBuffered {
+		if err := a.ReadResource(r); err != nil {
+			return err
+		}
+
+		logrus.Debug("Read asset: ", r.Location())
+	}
+
+	return a.trackLoad(h, r, func() error {
+		return h.Load(r)
+	})
+}
+
+// OpenResource opens the resource as a stream, without reading it in to
+// memory. The caller must close the stream.
+func (a *AssetSystem) OpenResource(r *Resource) (io.ReadSeekCloser, error) {
+	switch r.resType {
+	case ResourceFile:
+		return os.Open(r.location)
+	case ResourcePackage:
+		a.mu.RLock()
+		p, ok := a.packages[r.container]
+		a.mu.RUnlock()
+
+		if !ok {
+			return nil, ErrPackageNotMounted(r.container)
+		}
+
+		return p.Open(r.location)
+	case ResourceBindata:
+		f, err := builtin.FS.Open(r.location)
+		if err != nil {
+			return nil, err
+		}
+
+		s, ok := f.(io.ReadSeekCloser)
+		if !ok {
+			f.Close()
+			return nil, fmt.Errorf("resource: builtin resource is not seekable: %s", r.location)
+		}
+
+		return s, nil
+	default:
+		return nil, fmt.Errorf("resource: unknown resource type for resource: %d", int(r.resType))
+	}
+}
+
 func (a *AssetSystem) ReadResource(r *Resource) error {
 	if r == nil {
 		return nil
@@ -271,7 +384,6 @@ func (a *AssetSystem) ReadResource(r *Resource) error {
 	case ResourceBindata:
 		data, err := builtin.Asset(r.location)
 		if err != nil {
-			panic(err)
 			return err
 		}
 
@@ -293,12 +405,24 @@ func (a *AssetSystem) RegisterHandler(h AssetHandler) error {
 	}
 
 	a.handlers[h.Name()] = h
+	a.order = append(a.order, h.Name())
 
 	logrus.Debug("registered handler: ", h.Name())
 
 	return nil
 }
 
+// handlerOrder returns the names of all handlers in registration order.
+func (a *AssetSystem) handlerOrder() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	order := make([]string, len(a.order))
+	copy(order, a.order)
+
+	return order
+}
+
 // HandlerRegistered returns true if there is a handler registered with the given name.
 func (a *AssetSystem) HandlerRegistered(name string) bool {
 	a.mu.RLock()
@@ -323,22 +447,118 @@ func (a *AssetSystem) GetHandler(name string) (AssetHandler, error) {
 
 // GetAsset gets an asset by name from a handler by kind.
 func (a *AssetSystem) GetAsset(kind, name string) (Object, error) {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+	h, err := a.GetHandler(kind)
+	if err != nil {
+		return nil, err
+	}
 
-	var asset Object
-	var err error
+	return a.Resolve(h, name)
+}
 
-	if _, ok := a.handlers[kind]; !ok {
-		return nil, ErrHandlerNotFound(kind)
+// Resolve gets an asset by name from the given handler. If the asset cannot
+// be found while the system is lenient and the handler is a FallbackHandler,
+// a warning is logged and the fallback of the handler is returned instead.
+func (a *AssetSystem) Resolve(h AssetHandler, name string) (Object, error) {
+	asset, err := h.GetAsset(name)
+	if err == nil {
+		return asset, nil
 	}
 
-	asset, err = a.handlers[kind].GetAsset(name)
-	if err != nil {
+	if _, ok := err.(ErrAssetNotFound); !ok || !a.Lenient() {
+		return nil, err
+	}
+
+	fh, ok := h.(FallbackHandler)
+	if !ok {
+		return nil, err
+	}
+
+	fallback, ferr := fh.Fallback()
+	if ferr != nil {
+		logrus.Errorf("asset: fallback for %s failed: %v", h.Name(), ferr)
 		return nil, err
 	}
 
-	return asset, nil
+	logrus.Warnf("asset: serving fallback for missing %s asset: %s", h.Name(), name)
+	a.recordFallback(h.Name(), name)
+
+	return fallback, nil
+}
+
+// Lenient reports whether fallbacks are served for missing assets.
+func (a *AssetSystem) Lenient() bool {
+	a.fbMu.Lock()
+	defer a.fbMu.Unlock()
+
+	return a.lenient
+}
+
+// SetLenient sets whether fallbacks are served for missing assets. When not
+// lenient, looking up a missing asset is an error.
+func (a *AssetSystem) SetLenient(lenient bool) {
+	a.fbMu.Lock()
+	defer a.fbMu.Unlock()
+
+	a.lenient = lenient
+}
+
+// Fallbacks reports all fallbacks served since the system was created, or
+// since the last call to ResetFallbacks, ordered by kind and name.
+func (a *AssetSystem) Fallbacks() []AssetFallback {
+	a.fbMu.Lock()
+	defer a.fbMu.Unlock()
+
+	fallbacks := make([]AssetFallback, 0, len(a.fallbacks))
+	for _, v := range a.fallbacks {
+		fallbacks = append(fallbacks, *v)
+	}
+
+	sort.Slice(fallbacks, func(i, j int) bool {
+		if fallbacks[i].Kind != fallbacks[j].Kind {
+			return fallbacks[i].Kind < fallbacks[j].Kind
+		}
+		return fallbacks[i].Name < fallbacks[j].Name
+	})
+
+	return fallbacks
+}
+
+// ResetFallbacks clears the record of served fallbacks.
+func (a *AssetSystem) ResetFallbacks() {
+	a.fbMu.Lock()
+	defer a.fbMu.Unlock()
+
+	a.fallbacks = make(map[string]*AssetFallback)
+}
+
+func (a *AssetSystem) recordFallback(kind, name string) {
+	a.fbMu.Lock()
+	defer a.fbMu.Unlock()
+
+	key := kind + ":" + name
+
+	if f, ok := a.fallbacks[key]; ok {
+		f.Count++
+		return
+	}
+
+	a.fallbacks[key] = &AssetFallback{
+		Kind:  kind,
+		Name:  name,
+		Count: 1,
+	}
+}
+
+func (a *AssetSystem) logFallbacks() {
+	fallbacks := a.Fallbacks()
+	if len(fallbacks) == 0 {
+		return
+	}
+
+	logrus.Warnf("asset: %d missing assets were served fallbacks", len(fallbacks))
+	for _, f := range fallbacks {
+		logrus.Warnf("asset: %s: %s (served %d times)", f.Kind, f.Name, f.Count)
+	}
 }
 
 // MustGetAsset is like GetAsset, but panics if an error occurs.
@@ -420,11 +640,31 @@ func (h *BaseAssetHandler) Count() int {
 	return len(h.Items)
 }
 
+// Names returns the names of the assets tracked by this handler.
+func (h *BaseAssetHandler) Names() []string {
+	h.Mu.RLock()
+	defer h.Mu.RUnlock()
+
+	names := make([]string, 0, len(h.Items))
+	for name := range h.Items {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
 func NewAssetSystem() *AssetSystem {
 	return &AssetSystem{
-		handlers: make(map[string]AssetHandler),
-		packages: make(map[string]*Package),
-		mu:       &sync.RWMutex{},
+		handlers:  make(map[string]AssetHandler),
+		packages:  make(map[string]*Package),
+		fallbacks: make(map[string]*AssetFallback),
+		sources:   make(map[AssetRef]ResourceType),
+		graph:     NewAssetGraph(),
+		mu:        &sync.RWMutex{},
+		fbMu:      &sync.Mutex{},
+		srcMu:     &sync.Mutex{},
 	}
 }
 
@@ -59,4 +59,7 @@ func loadDefaultSettings() {
 	viper.SetDefault("graphics.resolution", math.IVec2{1280, 720})
 	viper.SetDefault("graphics.mode", 0)
 	viper.SetDefault("graphics.vsync", true)
+
+	// Asset Options
+	viper.SetDefault("asset.lenient", false)
 }
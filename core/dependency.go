@@ -0,0 +1,312 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// AssetKindResource is the kind of nodes in the asset graph which are
+	// resources read by an asset, such as shader sources or images.
+	AssetKindResource = "resource"
+
+	// AssetKindScene is the kind of nodes in the asset graph which are
+	// scenes referencing assets.
+	AssetKindScene = "scene"
+)
+
+// AssetRef identifies an asset by kind and name.
+type AssetRef struct {
+	Kind string
+	Name string
+}
+
+func (r AssetRef) String() string {
+	return r.Kind + ":" + r.Name
+}
+
+// ResourceRef returns the reference of a resource in the asset graph.
+func ResourceRef(r *Resource) AssetRef {
+	name := r.Location()
+	if r.Container() != "" {
+		name = r.Container() + ":" + name
+	}
+
+	return AssetRef{Kind: AssetKindResource, Name: name}
+}
+
+// AssetGraph is a directed graph of dependencies between assets. An edge from
+// a to b records that a depends on b.
+type AssetGraph struct {
+	nodes map[AssetRef]struct{}
+	edges map[AssetRef]map[AssetRef]struct{}
+	mu    *sync.RWMutex
+}
+
+// NewAssetGraph creates a new, empty asset graph.
+func NewAssetGraph() *AssetGraph {
+	return &AssetGraph{
+		nodes: make(map[AssetRef]struct{}),
+		edges: make(map[AssetRef]map[AssetRef]struct{}),
+		mu:    &sync.RWMutex{},
+	}
+}
+
+// AddNode adds a node to the graph.
+func (g *AssetGraph) AddNode(r AssetRef) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.nodes[r] = struct{}{}
+}
+
+// AddEdge records that from depends on to, adding both nodes to the graph.
+func (g *AssetGraph) AddEdge(from, to AssetRef) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.nodes[from] = struct{}{}
+	g.nodes[to] = struct{}{}
+
+	if _, ok := g.edges[from]; !ok {
+		g.edges[from] = make(map[AssetRef]struct{})
+	}
+
+	g.edges[from][to] = struct{}{}
+}
+
+// Nodes returns all nodes of the graph.
+func (g *AssetGraph) Nodes() []AssetRef {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	nodes := make([]AssetRef, 0, len(g.nodes))
+	for n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+
+	sortRefs(nodes)
+
+	return nodes
+}
+
+// Dependencies returns the direct dependencies of r.
+func (g *AssetGraph) Dependencies(r AssetRef) []AssetRef {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var deps []AssetRef
+
+	for d := range g.edges[r] {
+		deps = append(deps, d)
+	}
+
+	sortRefs(deps)
+
+	return deps
+}
+
+// Dependents returns the nodes which directly depend on r.
+func (g *AssetGraph) Dependents(r AssetRef) []AssetRef {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var deps []AssetRef
+
+	for from, to := range g.edges {
+		if _, ok := to[r]; ok {
+			deps = append(deps, from)
+		}
+	}
+
+	sortRefs(deps)
+
+	return deps
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+func (g *AssetGraph) WriteDOT(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "digraph assets {"); err != nil {
+		return err
+	}
+
+	for _, n := range g.Nodes() {
+		if _, err := fmt.Fprintf(w, "\t%q [shape=%s];\n", n.String(), dotShape(n)); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range g.Nodes() {
+		for _, d := range g.Dependencies(n) {
+			if _, err := fmt.Fprintf(w, "\t%q -> %q;\n", n.String(), d.String()); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+
+	return err
+}
+
+func dotShape(r AssetRef) string {
+	switch r.Kind {
+	case AssetKindResource:
+		return "note"
+	case AssetKindScene:
+		return "doubleoctagon"
+	default:
+		return "box"
+	}
+}
+
+func sortRefs(refs []AssetRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Kind != refs[j].Kind {
+			return refs[i].Kind < refs[j].Kind
+		}
+		return refs[i].Name < refs[j].Name
+	})
+}
+
+// Graph returns the dependency graph of the loaded assets.
+func (a *AssetSystem) Graph() *AssetGraph {
+	return a.graph
+}
+
+// AddDependency records that the asset from depends on the asset to.
+func (a *AssetSystem) AddDependency(from, to AssetRef) {
+	a.graph.AddEdge(from, to)
+}
+
+// Require records that the asset from depends on the asset of the given kind
+// and name. If that asset is not loaded, it is loaded from the given path.
+// When the asset system is lenient, failing to load the asset is logged
+// instead, so a fallback is served when the asset is looked up.
+func (a *AssetSystem) Require(from AssetRef, kind, name, filename string) error {
+	h, err := a.GetHandler(kind)
+	if err != nil {
+		return err
+	}
+
+	to := AssetRef{Kind: kind, Name: name}
+
+	if _, err := h.GetAsset(name); err == nil {
+		a.AddDependency(from, to)
+		return nil
+	}
+
+	r, err := NewResource(filename)
+	if err == nil {
+		err = a.load(h, r)
+	}
+
+	if err != nil {
+		if !a.Lenient() {
+			return err
+		}
+
+		logrus.Warnf("asset: failed to load %s required by %s: %v", to, from, err)
+	}
+
+	a.AddDependency(from, to)
+
+	return nil
+}
+
+// Unused reports the loaded assets which are not referenced by a scene or
+// by another asset. Builtin assets are not reported.
+//
+// Scenes do not record the assets their components use: a scene references
+// only the assets passed to Scene.Reference. Assets a scene uses without
+// referencing them are reported too, so unloading what Unused reports is
+// only safe once every loaded scene references all of its assets.
+func (a *AssetSystem) Unused() []AssetRef {
+	var unused []AssetRef
+
+	for _, kind := range a.handlerOrder() {
+		h, err := a.GetHandler(kind)
+		if err != nil {
+			continue
+		}
+
+		for _, name := range h.Names() {
+			r := AssetRef{Kind: kind, Name: name}
+
+			if a.isBuiltin(r) {
+				continue
+			}
+			if len(a.graph.Dependents(r)) == 0 {
+				unused = append(unused, r)
+			}
+		}
+	}
+
+	sortRefs(unused)
+
+	return unused
+}
+
+// trackLoad records the resource from which the assets added to a handler
+// by fn were loaded.
+func (a *AssetSystem) trackLoad(h AssetHandler, r *Resource, fn func() error) error {
+	before := make(map[string]struct{})
+	for _, name := range h.Names() {
+		before[name] = struct{}{}
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	a.srcMu.Lock()
+	defer a.srcMu.Unlock()
+
+	for _, name := range h.Names() {
+		if _, ok := before[name]; ok {
+			continue
+		}
+
+		ref := AssetRef{Kind: h.Name(), Name: name}
+
+		a.graph.AddNode(ref)
+		a.sources[ref] = r.Type()
+	}
+
+	return nil
+}
+
+func (a *AssetSystem) isBuiltin(r AssetRef) bool {
+	a.srcMu.Lock()
+	defer a.srcMu.Unlock()
+
+	t, ok := a.sources[r]
+
+	return ok && t == ResourceBindata
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAssetGraph(t *testing.T) {
+	g := NewAssetGraph()
+
+	brick := AssetRef{Kind: "material", Name: "brick"}
+	standard := AssetRef{Kind: "shader", Name: "standard"}
+	albedo := AssetRef{Kind: "texture", Name: "brick.png"}
+	source := AssetRef{Kind: AssetKindResource, Name: "standard.glsl"}
+
+	g.AddEdge(brick, standard)
+	g.AddEdge(brick, albedo)
+	g.AddEdge(standard, source)
+
+	if got, want := g.Dependencies(brick), []AssetRef{standard, albedo}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies(%s) = %v, want %v", brick, got, want)
+	}
+	if got, want := g.Dependents(source), []AssetRef{standard}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents(%s) = %v, want %v", source, got, want)
+	}
+	if got := g.Dependents(brick); len(got) != 0 {
+		t.Errorf("Dependents(%s) = %v, want none", brick, got)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := g.WriteDOT(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph assets {
+	"material:brick" [shape=box];
+	"resource:standard.glsl" [shape=note];
+	"shader:standard" [shape=box];
+	"texture:brick.png" [shape=box];
+	"material:brick" -> "shader:standard";
+	"material:brick" -> "texture:brick.png";
+	"shader:standard" -> "resource:standard.glsl";
+}
+`
+	if buf.String() != want {
+		t.Errorf("WriteDOT() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestAssetSystem_Unused(t *testing.T) {
+	a := NewAssetSystem()
+
+	a.RegisterHandler(&fakeImporter{name: "mesh", names: []string{"crate", "rock"}})
+	a.RegisterHandler(&fakeImporter{name: "material", names: []string{"wood"}})
+
+	level := AssetRef{Kind: AssetKindScene, Name: "level"}
+	crate := AssetRef{Kind: "mesh", Name: "crate"}
+	rock := AssetRef{Kind: "mesh", Name: "rock"}
+	wood := AssetRef{Kind: "material", Name: "wood"}
+
+	// The level draws the crate with the wood material, but references only
+	// the material: the crate is reported although the level uses it.
+	a.AddDependency(level, wood)
+
+	if got, want := a.Unused(), []AssetRef{crate, rock}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unused() = %v, want %v", got, want)
+	}
+
+	a.AddDependency(level, crate)
+
+	if got, want := a.Unused(), []AssetRef{rock}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unused() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/ember/internal/builtin"
+)
+
+// sniffLen is the number of bytes read from a resource to detect its kind.
+const sniffLen = 512
+
+// ErrUnknownAssetKind reports that the kind of an asset could not be detected.
+type ErrUnknownAssetKind string
+
+func (e ErrUnknownAssetKind) Error() string {
+	return "asset: unable to detect kind of asset: " + string(e)
+}
+
+// AssetSignature identifies a file format by magic bytes found at an offset
+// from the start of the file.
+type AssetSignature struct {
+	Offset int
+	Magic  []byte
+}
+
+// Importer is an AssetHandler which declares the files it accepts, so that
+// assets can be imported by path alone.
+type Importer interface {
+	AssetHandler
+
+	// Extensions returns the file extensions accepted by this importer,
+	// including the leading dot, such as ".png".
+	Extensions() []string
+
+	// Signatures returns the signatures of the file formats accepted by
+	// this importer.
+	Signatures() []AssetSignature
+}
+
+// Match reports whether the signature matches the given file header.
+func (s AssetSignature) Match(header []byte) bool {
+	if s.Offset < 0 || len(header) < s.Offset+len(s.Magic) {
+		return false
+	}
+
+	return bytes.Equal(header[s.Offset:s.Offset+len(s.Magic)], s.Magic)
+}
+
+// DetectKind detects the kind of the resource. The content of the resource is
+// matched against the signatures of all importers
+// first, and the extension of the resource is used to select between several
+// matches, or when no signature matches. When several importers remain, the
+// one registered first is used.
+func (a *AssetSystem) DetectKind(r *Resource) (string, error) {
+	var byMagic, byExt []string
+
+	header, err := a.header(r)
+	if err != nil {
+		return "", err
+	}
+
+	ext := r.Ext()
+
+	for _, h := range a.importers() {
+		for _, s := range h.Signatures() {
+			if s.Match(header) {
+				byMagic = append(byMagic, h.Name())
+				break
+			}
+		}
+		for _, e := range h.Extensions() {
+			if strings.ToLower(e) == ext {
+				byExt = append(byExt, h.Name())
+				break
+			}
+		}
+	}
+
+	switch {
+	case len(byMagic) == 1:
+		return byMagic[0], nil
+	case len(byMagic) > 1:
+		for _, m := range byMagic {
+			for _, e := range byExt {
+				if m == e {
+					return m, nil
+				}
+			}
+		}
+		return byMagic[0], nil
+	case len(byExt) > 0:
+		return byExt[0], nil
+	}
+
+	return "", ErrUnknownAssetKind(r.Location())
+}
+
+// Import loads the asset at the given path with the handler detected
+// from its content and extension.
+func (a *AssetSystem) Import(filename string) error {
+	r, kind, err := a.detect(filename)
+	if err != nil {
+		return err
+	}
+
+	h, err := a.GetHandler(kind)
+	if err != nil {
+		return err
+	}
+
+	return a.load(h, r)
+}
+
+// ImportDir loads every asset found below the given directory, which may be
+// a directory on the filesystem, in a package or in the builtin assets. Files
+// with an undetectable kind are skipped. Kinds are loaded in the order their
+// handlers were registered.
+func (a *AssetSystem) ImportDir(dir string) error {
+	files, err := a.ListDir(dir)
+	if err != nil {
+		return err
+	}
+
+	kinds := make(map[string][]*Resource)
+
+	for _, f := range files {
+		r, kind, err := a.detect(f)
+		if err != nil {
+			if _, ok := err.(ErrUnknownAssetKind); ok {
+				logrus.Debug("Skipped asset: ", f)
+				continue
+			}
+			return err
+		}
+
+		kinds[kind] = append(kinds[kind], r)
+	}
+
+	for _, kind := range a.handlerOrder() {
+		if len(kinds[kind]) == 0 {
+			continue
+		}
+
+		h, err := a.GetHandler(kind)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range kinds[kind] {
+			if err := a.load(h, r); err != nil {
+				return err
+			}
+
+			logrus.Debug("Imported asset: ", r.Location())
+		}
+	}
+
+	return nil
+}
+
+// detect creates the resource for the given path and detects its kind.
+func (a *AssetSystem) detect(filename string) (*Resource, string, error) {
+	r, err := NewResource(filename)
+	if err != nil {
+		return nil, "", err
+	}
+
+	kind, err := a.DetectKind(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return r, kind, nil
+}
+
+// header returns the first bytes of the resource. Resources which have not
+// been read are opened as a stream, so they are not read in to memory.
+func (a *AssetSystem) header(r *Resource) ([]byte, error) {
+	if r.Size() > 0 {
+		return r.Bytes(), nil
+	}
+
+	s, err := a.OpenResource(r)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	header := make([]byte, sniffLen)
+
+	n, err := io.ReadFull(s, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+
+	return header[:n], err
+}
+
+// ListDir lists the resource paths of all files below the given directory,
+// which may be a directory on the filesystem, in a package or in the builtin
+// assets.
+func (a *AssetSystem) ListDir(dir string) ([]string, error) {
+	var files []string
+
+	r, err := NewResource(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.resType {
+	case ResourceFile:
+		err = filepath.Walk(r.location, func(name string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				files = append(files, name)
+			}
+			return nil
+		})
+	case ResourcePackage:
+		a.mu.RLock()
+		p, ok := a.packages[r.container]
+		a.mu.RUnlock()
+
+		if !ok {
+			return nil, ErrPackageNotMounted(r.container)
+		}
+
+		for _, f := range p.List(r.location) {
+			files = append(files, r.container+":"+f)
+		}
+	case ResourceBindata:
+		err = fs.WalkDir(builtin.FS, path.Clean(r.location), func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				files = append(files, bindataPrefix+name)
+			}
+			return nil
+		})
+	}
+
+	return files, err
+}
+
+// importers returns all registered importers in registration order.
+func (a *AssetSystem) importers() []Importer {
+	var importers []Importer
+
+	for _, name := range a.handlerOrder() {
+		h, err := a.GetHandler(name)
+		if err != nil {
+			continue
+		}
+		if i, ok := h.(Importer); ok {
+			importers = append(importers, i)
+		}
+	}
+
+	return importers
+}
@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package core
+
+import "testing"
+
+type fakeImporter struct {
+	name       string
+	names      []string
+	extensions []string
+	signatures []AssetSignature
+}
+
+func (f *fakeImporter) Load(*Resource) error            { return nil }
+func (f *fakeImporter) GetAsset(string) (Object, error) { return nil, nil }
+func (f *fakeImporter) MustGetAsset(string) Object      { return nil }
+func (f *fakeImporter) Name() string                    { return f.name }
+func (f *fakeImporter) Count() int                      { return 0 }
+func (f *fakeImporter) Names() []string                 { return f.names }
+func (f *fakeImporter) Extensions() []string            { return f.extensions }
+func (f *fakeImporter) Signatures() []AssetSignature    { return f.signatures }
+
+func TestAssetSystem_DetectKind(t *testing.T) {
+	a := NewAssetSystem()
+
+	a.RegisterHandler(&fakeImporter{
+		name:       "texture",
+		extensions: []string{".png", ".jpg"},
+		signatures: []AssetSignature{{Magic: []byte("\x89PNG")}},
+	})
+	a.RegisterHandler(&fakeImporter{
+		name:       "atlas",
+		extensions: []string{".atlas.png"},
+		signatures: []AssetSignature{{Magic: []byte("\x89PNG")}},
+	})
+	a.RegisterHandler(&fakeImporter{
+		name:       "audio",
+		extensions: []string{".WAV"},
+		signatures: []AssetSignature{{Offset: 8, Magic: []byte("WAVE")}},
+	})
+	a.RegisterHandler(&fakeImporter{
+		name:       "shader",
+		extensions: []string{".shader"},
+	})
+
+	var tests = []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "logo.png", data: "\x89PNG....", want: "texture"},
+		{name: "logo.bin", data: "\x89PNG....", want: "texture"},
+		{name: "music.dat", data: "RIFF\x00\x00\x00\x00WAVEfmt ", want: "audio"},
+		{name: "music.wav", data: "RIFF", want: "audio"},
+		{name: "basic.shader", data: "{}", want: "shader"},
+		{name: "photo.JPG", data: "\x00", want: "texture"},
+		{name: "notes.txt", data: "hello", wantErr: true},
+	}
+
+	for i, v := range tests {
+		r, _ := NewResource(v.name)
+		r.buffer.WriteString(v.data)
+
+		got, err := a.DetectKind(r)
+		if (err != nil) != v.wantErr {
+			t.Errorf("%s failed test case %d. err: %v wantErr: %v", t.Name(), i, err, v.wantErr)
+		} else if got != v.want {
+			t.Errorf("%s case %d value mismatch. want: %v got: %v", t.Name(), i, v.want, got)
+		}
+	}
+}
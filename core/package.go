@@ -24,8 +24,10 @@ package core
 
 import (
 	"archive/zip"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -42,7 +44,8 @@ var (
 type Package struct {
 	name   string
 	path   string
-	reader *zip.ReadCloser
+	file   *os.File
+	reader *zip.Reader
 }
 
 // ErrPackageNotFound reports that package was not found/mounted.
@@ -68,6 +71,16 @@ func (e ErrPackageFileNotFound) Error() string {
 	return fmt.Sprintf("fs: file '%s' in package '%s' not found", e.file, e.pkg)
 }
 
+// IsNotExist reports whether the error reports that a resource does not
+// exist, in a package or on the filesystem.
+func IsNotExist(err error) bool {
+	if _, ok := err.(ErrPackageFileNotFound); ok {
+		return true
+	}
+
+	return os.IsNotExist(err)
+}
+
 func NewPackage(name string) *Package {
 	p := &Package{
 		name: name,
@@ -88,11 +101,24 @@ func (p *Package) Mount() error {
 		return ErrPackageMounted(p.name)
 	}
 
-	reader, err := zip.OpenReader(p.path)
+	file, err := os.Open(p.path)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
 	if err != nil {
-		panic(err)
+		file.Close()
+		return err
 	}
 
+	reader, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	p.file = file
 	p.reader = reader
 
 	logrus.Info("Mounted package: ", p.name)
@@ -101,7 +127,8 @@ func (p *Package) Mount() error {
 }
 
 func (p *Package) Unmount() error {
-	err := p.reader.Close()
+	err := p.file.Close()
+	p.file = nil
 	p.reader = nil
 
 	logrus.Info("Unmounted package: ", p.name)
@@ -118,34 +145,146 @@ func (p *Package) Path() string {
 }
 
 func (p *Package) Read(filename string, w io.Writer) error {
+	file, err := p.find(filename)
+	if err != nil {
+		return err
+	}
+
+	fReader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer fReader.Close()
+
+	_, err = io.Copy(w, fReader)
+
+	return err
+}
+
+// Open opens a file in the package as a stream. Stored files are read directly
+// from the package. Compressed files are decompressed as they are read, and
+// seeking backwards in them restarts decompression.
+func (p *Package) Open(filename string) (io.ReadSeekCloser, error) {
+	file, err := p.find(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Method == zip.Store {
+		offset, err := file.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+
+		return &sectionReadCloser{io.NewSectionReader(p.file, offset, int64(file.UncompressedSize64))}, nil
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &entryReader{file: file, rc: rc}, nil
+}
+
+func (p *Package) find(filename string) (*zip.File, error) {
 	if p.reader == nil {
-		return ErrPackageNotMounted(p.name)
+		return nil, ErrPackageNotMounted(p.name)
+	}
+
+	for _, f := range p.reader.File {
+		if f.Name == filename {
+			return f, nil
+		}
 	}
 
-	var file *zip.File
+	return nil, ErrPackageFileNotFound{p.name, filename}
+}
+
+// List returns the names of all files in the package located below the
+// given directory. An empty directory lists every file in the package.
+func (p *Package) List(dir string) []string {
+	var names []string
+
+	if p.reader == nil {
+		return names
+	}
+
+	prefix := strings.Trim(dir, "/")
 
 	for _, f := range p.reader.File {
-		if f.Name != filename {
+		if strings.HasSuffix(f.Name, "/") {
 			continue
 		}
+		if prefix == "" || strings.HasPrefix(f.Name, prefix+"/") {
+			names = append(names, f.Name)
+		}
+	}
 
-		file = f
-		break
+	return names
+}
+
+// sectionReadCloser is a stream of a stored file in a package.
+type sectionReadCloser struct {
+	*io.SectionReader
+}
+
+func (s *sectionReadCloser) Close() error {
+	return nil
+}
+
+// entryReader is a stream of a compressed file in a package.
+type entryReader struct {
+	file *zip.File
+	rc   io.ReadCloser
+	pos  int64
+}
+
+func (e *entryReader) Read(b []byte) (int, error) {
+	n, err := e.rc.Read(b)
+	e.pos += int64(n)
+
+	return n, err
+}
+
+func (e *entryReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = e.pos + offset
+	case io.SeekEnd:
+		abs = int64(e.file.UncompressedSize64) + offset
+	default:
+		return e.pos, errors.New("fs: invalid whence")
 	}
 
-	if file == nil {
-		return ErrPackageFileNotFound{p.name, filename}
+	if abs < 0 {
+		return e.pos, errors.New("fs: negative position")
 	}
 
-	fReader, err := file.Open()
-	if err != nil {
-		return err
+	if abs < e.pos {
+		rc, err := e.file.Open()
+		if err != nil {
+			return e.pos, err
+		}
+
+		e.rc.Close()
+		e.rc = rc
+		e.pos = 0
 	}
-	defer fReader.Close()
 
-	_, err = io.Copy(w, fReader)
+	if _, err := io.CopyN(io.Discard, e, abs-e.pos); err != nil && err != io.EOF {
+		return e.pos, err
+	}
 
-	return nil
+	return e.pos, nil
+}
+
+func (e *entryReader) Close() error {
+	return e.rc.Close()
 }
 
 func IsPackagePath(filename string) bool {
@@ -43,6 +43,14 @@ const (
 	ResourceBindata                     // ResourceBindata is a file built in to the binary.
 )
 
+// ResourceAccess describes how a handler accesses the content of a resource.
+type ResourceAccess int
+
+const (
+	ResourceBuffered ResourceAccess = iota // ResourceBuffered resources are read in to memory before loading.
+	ResourceStreamed                       // ResourceStreamed resources are opened as a stream by the handler.
+)
+
 // Resource is a represents a read-only file that has an added layer of abstraction
 // in terms of underlying storage type. The resource itself does not know how to
 // read from the path provided, that is left up to a separate resource manager.
@@ -118,6 +126,12 @@ func (r *Resource) Base() string {
 	return filepath.Base(r.location)
 }
 
+// Ext returns the lowercase file name extension of the resource's location,
+// including the leading dot.
+func (r *Resource) Ext() string {
+	return strings.ToLower(filepath.Ext(r.location))
+}
+
 // Dir returns all but the last element of the resource's location.
 func (r *Resource) Dir() string {
 	return r.Path(filepath.Dir(r.location))
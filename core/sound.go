@@ -45,7 +45,19 @@ func NewSound(streamer beep.Streamer, format beep.Format) *Sound {
 	return s
 }
 
+// Dealloc closes the stream of the sound, if any.
+func (s *Sound) Dealloc() {
+	if c, ok := s.streamer.(beep.StreamCloser); ok {
+		c.Close()
+	}
+}
+
+// Play plays the sound from the start.
 func (s *Sound) Play() {
+	if ss, ok := s.streamer.(beep.StreamSeeker); ok {
+		ss.Seek(0)
+	}
+
 	GetAudioSystem().PlaySound(s)
 }
 
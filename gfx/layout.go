@@ -0,0 +1,300 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gfx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// VertexAttribute is a per-vertex stream of a mesh. Its value is the shader
+// location the stream is bound to.
+type VertexAttribute uint32
+
+// Vertex attributes.
+const (
+	AttributePosition VertexAttribute = iota
+	AttributeNormal
+	AttributeUV
+	AttributeColor
+	AttributeTangent
+	AttributeUV2
+	AttributeJoints
+	AttributeWeights
+
+	// VertexAttributeCount is the number of vertex attributes.
+	VertexAttributeCount
+)
+
+var attributeNames = [VertexAttributeCount]string{
+	"position", "normal", "uv", "color", "tangent", "uv2", "joints", "weights",
+}
+
+func (a VertexAttribute) String() string {
+	if a < VertexAttributeCount {
+		return attributeNames[a]
+	}
+
+	return fmt.Sprintf("attribute(%d)", uint32(a))
+}
+
+// Components returns the number of components of the attribute.
+func (a VertexAttribute) Components() int {
+	switch a {
+	case AttributePosition, AttributeNormal:
+		return 3
+	case AttributeUV, AttributeUV2:
+		return 2
+	}
+
+	return 4
+}
+
+// Integer reports whether the attribute holds unsigned 16 bit integers
+// rather than floats.
+func (a VertexAttribute) Integer() bool {
+	return a == AttributeJoints
+}
+
+// Size returns the size of the attribute in bytes.
+func (a VertexAttribute) Size() int {
+	if a.Integer() {
+		return a.Components() * 2
+	}
+
+	return a.Components() * 4
+}
+
+// VertexLayout is a set of vertex attributes, interleaved in the order of
+// their values.
+type VertexLayout uint32
+
+// DefaultVertexLayout holds positions, normals and uvs.
+const DefaultVertexLayout = VertexLayout(1<<AttributePosition | 1<<AttributeNormal | 1<<AttributeUV)
+
+// NewVertexLayout returns the layout of the given attributes.
+func NewVertexLayout(attributes ...VertexAttribute) VertexLayout {
+	var l VertexLayout
+
+	for _, a := range attributes {
+		l |= 1 << a
+	}
+
+	return l
+}
+
+// Has reports whether the layout holds the attribute.
+func (l VertexLayout) Has(a VertexAttribute) bool {
+	return a < VertexAttributeCount && l&(1<<a) != 0
+}
+
+// Attributes returns the attributes of the layout in order.
+func (l VertexLayout) Attributes() []VertexAttribute {
+	var attributes []VertexAttribute
+
+	for a := VertexAttribute(0); a < VertexAttributeCount; a++ {
+		if l.Has(a) {
+			attributes = append(attributes, a)
+		}
+	}
+
+	return attributes
+}
+
+// Stride returns the size of an interleaved vertex in bytes.
+func (l VertexLayout) Stride() int {
+	var stride int
+
+	for _, a := range l.Attributes() {
+		stride += a.Size()
+	}
+
+	return stride
+}
+
+// Offset returns the offset of the attribute within an interleaved vertex,
+// or -1 if the layout does not hold it.
+func (l VertexLayout) Offset(a VertexAttribute) int {
+	if !l.Has(a) {
+		return -1
+	}
+
+	var offset int
+
+	for _, b := range l.Attributes() {
+		if b == a {
+			break
+		}
+		offset += b.Size()
+	}
+
+	return offset
+}
+
+func (l VertexLayout) String() string {
+	s := ""
+
+	for i, a := range l.Attributes() {
+		if i > 0 {
+			s += "|"
+		}
+		s += a.String()
+	}
+
+	return s
+}
+
+// Vertex data errors.
+var (
+	ErrVertexDataEmpty      = errors.New("empty data")
+	ErrVertexDataAsymmetric = errors.New("asymmetric data")
+)
+
+// VertexData holds the vertex streams of a mesh. Positions, normals and uvs
+// are required, the other streams are optional and part of the layout when
+// present.
+type VertexData struct {
+	Positions []mgl32.Vec3
+	Normals   []mgl32.Vec3
+	UVs       []mgl32.Vec2
+	Colors    []mgl32.Vec4
+	Tangents  []mgl32.Vec4
+	UV2s      []mgl32.Vec2
+	Joints    [][4]uint16
+	Weights   []mgl32.Vec4
+}
+
+// Len returns the number of vertices.
+func (d *VertexData) Len() int {
+	return len(d.Positions)
+}
+
+// Layout returns the layout of the streams which are present.
+func (d *VertexData) Layout() VertexLayout {
+	l := DefaultVertexLayout
+
+	if len(d.Colors) != 0 {
+		l |= 1 << AttributeColor
+	}
+	if len(d.Tangents) != 0 {
+		l |= 1 << AttributeTangent
+	}
+	if len(d.UV2s) != 0 {
+		l |= 1 << AttributeUV2
+	}
+	if len(d.Joints) != 0 {
+		l |= 1 << AttributeJoints
+	}
+	if len(d.Weights) != 0 {
+		l |= 1 << AttributeWeights
+	}
+
+	return l
+}
+
+// Validate checks that the required streams are present and that all
+// streams have one element per vertex.
+func (d *VertexData) Validate() error {
+	n := d.Len()
+
+	if n == 0 || len(d.Normals) == 0 || len(d.UVs) == 0 {
+		return ErrVertexDataEmpty
+	}
+
+	for _, c := range []int{len(d.Normals), len(d.UVs)} {
+		if c != n {
+			return ErrVertexDataAsymmetric
+		}
+	}
+	for _, c := range []int{len(d.Colors), len(d.Tangents), len(d.UV2s), len(d.Joints), len(d.Weights)} {
+		if c != 0 && c != n {
+			return ErrVertexDataAsymmetric
+		}
+	}
+
+	return nil
+}
+
+// Clear empties all streams, keeping their storage.
+func (d *VertexData) Clear() {
+	d.Positions = d.Positions[:0]
+	d.Normals = d.Normals[:0]
+	d.UVs = d.UVs[:0]
+	d.Colors = d.Colors[:0]
+	d.Tangents = d.Tangents[:0]
+	d.UV2s = d.UV2s[:0]
+	d.Joints = d.Joints[:0]
+	d.Weights = d.Weights[:0]
+}
+
+// Interleave packs the streams in to a little endian buffer, with the
+// attributes of each vertex adjacent in layout order. The data must be
+// valid.
+func (d *VertexData) Interleave() []byte {
+	l := d.Layout()
+	stride := l.Stride()
+
+	buf := make([]byte, d.Len()*stride)
+
+	for i := 0; i < d.Len(); i++ {
+		b := buf[i*stride:]
+
+		b = putFloats(b, d.Positions[i][:])
+		b = putFloats(b, d.Normals[i][:])
+		b = putFloats(b, d.UVs[i][:])
+
+		if l.Has(AttributeColor) {
+			b = putFloats(b, d.Colors[i][:])
+		}
+		if l.Has(AttributeTangent) {
+			b = putFloats(b, d.Tangents[i][:])
+		}
+		if l.Has(AttributeUV2) {
+			b = putFloats(b, d.UV2s[i][:])
+		}
+		if l.Has(AttributeJoints) {
+			for _, j := range d.Joints[i] {
+				binary.LittleEndian.PutUint16(b, j)
+				b = b[2:]
+			}
+		}
+		if l.Has(AttributeWeights) {
+			putFloats(b, d.Weights[i][:])
+		}
+	}
+
+	return buf
+}
+
+func putFloats(b []byte, f []float32) []byte {
+	for _, v := range f {
+		binary.LittleEndian.PutUint32(b, math.Float32bits(v))
+		b = b[4:]
+	}
+
+	return b
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gfx
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestVertexLayout(t *testing.T) {
+	var tests = []struct {
+		layout  VertexLayout
+		stride  int
+		offsets map[VertexAttribute]int
+	}{
+		{
+			layout:  DefaultVertexLayout,
+			stride:  32,
+			offsets: map[VertexAttribute]int{AttributePosition: 0, AttributeNormal: 12, AttributeUV: 24, AttributeColor: -1},
+		},
+		{
+			layout:  NewVertexLayout(AttributePosition, AttributeNormal, AttributeUV, AttributeTangent),
+			stride:  48,
+			offsets: map[VertexAttribute]int{AttributeTangent: 32, AttributeUV2: -1},
+		},
+		{
+			layout:  DefaultVertexLayout | NewVertexLayout(AttributeJoints, AttributeWeights),
+			stride:  56,
+			offsets: map[VertexAttribute]int{AttributeJoints: 32, AttributeWeights: 40},
+		},
+	}
+
+	for i, v := range tests {
+		if v.layout.Stride() != v.stride {
+			t.Errorf("%s case %d: Stride() = %d, want %d", t.Name(), i, v.layout.Stride(), v.stride)
+		}
+		for a, want := range v.offsets {
+			if got := v.layout.Offset(a); got != want {
+				t.Errorf("%s case %d: Offset(%s) = %d, want %d", t.Name(), i, a, got, want)
+			}
+		}
+	}
+}
+
+func TestVertexData_Interleave(t *testing.T) {
+	d := &VertexData{
+		Positions: []mgl32.Vec3{{1, 2, 3}, {4, 5, 6}},
+		Normals:   []mgl32.Vec3{{0, 0, 1}, {0, 1, 0}},
+		UVs:       []mgl32.Vec2{{0.5, 0.25}, {1, 0}},
+		Joints:    [][4]uint16{{1, 2, 3, 4}, {5, 6, 7, 8}},
+	}
+
+	if err := d.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if l := d.Layout(); l != DefaultVertexLayout|NewVertexLayout(AttributeJoints) {
+		t.Fatalf("Layout() = %s", l)
+	}
+
+	buf := d.Interleave()
+	if len(buf) != 2*40 {
+		t.Fatalf("len = %d, want %d", len(buf), 80)
+	}
+
+	float := func(off int) float32 {
+		return math.Float32frombits(binary.LittleEndian.Uint32(buf[off:]))
+	}
+
+	if float(40) != 4 || float(40+16) != 1 || float(24) != 0.5 {
+		t.Errorf("unexpected floats in %v", buf)
+	}
+	if j := binary.LittleEndian.Uint16(buf[40+32+6:]); j != 8 {
+		t.Errorf("joint = %d, want 8", j)
+	}
+
+	d.Weights = []mgl32.Vec4{{1, 0, 0, 0}}
+	if err := d.Validate(); err != ErrVertexDataAsymmetric {
+		t.Errorf("err = %v, want %v", err, ErrVertexDataAsymmetric)
+	}
+}
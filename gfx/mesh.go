@@ -22,7 +22,11 @@ SOFTWARE.
 
 package gfx
 
-import "github.com/go-gl/mathgl/mgl32"
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/pkg/geometry"
+)
 
 type Vertex struct {
 	V mgl32.Vec3
@@ -30,6 +34,38 @@ type Vertex struct {
 	U mgl32.Vec2
 }
 
+// Topology is the kind of primitive the vertices of a mesh are drawn as.
+type Topology uint8
+
+const (
+	TopologyTriangles Topology = iota
+	TopologyLines
+	TopologyPoints
+)
+
+// Size returns the number of vertices of each primitive.
+func (t Topology) Size() int {
+	switch t {
+	case TopologyLines:
+		return 2
+	case TopologyPoints:
+		return 1
+	}
+
+	return 3
+}
+
+func (t Topology) String() string {
+	switch t {
+	case TopologyLines:
+		return "lines"
+	case TopologyPoints:
+		return "points"
+	}
+
+	return "triangles"
+}
+
 type Mesh interface {
 	Allocater
 	Binder
@@ -40,11 +76,27 @@ type Mesh interface {
 	Vertices() []mgl32.Vec3
 	Normals() []mgl32.Vec3
 	UVs() []mgl32.Vec2
+	Colors() []mgl32.Vec4
+	Tangents() []mgl32.Vec4
+	UV2s() []mgl32.Vec2
+	Joints() [][4]uint16
+	Weights() []mgl32.Vec4
+	MorphTargets() []geometry.MorphTarget
 	Triangles() []uint32
+	Layout() VertexLayout
 	Indexed() bool
 	ReversedWinding() bool
+	Topology() Topology
 	SetVertices(vertices []mgl32.Vec3)
 	SetNormals(normals []mgl32.Vec3)
 	SetUVs(uvs []mgl32.Vec2)
+	SetColors(colors []mgl32.Vec4)
+	SetTangents(tangents []mgl32.Vec4)
+	SetUV2s(uvs []mgl32.Vec2)
+	SetJoints(joints [][4]uint16)
+	SetWeights(weights []mgl32.Vec4)
+	SetMorphTargets(targets []geometry.MorphTarget)
+	SetTriangles(triangles []uint32)
 	SetReversedWinding(reverse bool)
+	SetTopology(topology Topology)
 }
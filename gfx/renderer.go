@@ -93,6 +93,15 @@ type Renderer interface {
 	// Dealloc allocates an object.
 	Dealloc(Allocater)
 
+	// SupportsTextureFormat reports whether textures of the format can be
+	// sampled.
+	SupportsTextureFormat(TextureFormat) bool
+
+	// Headless reports whether the renderer runs without a GPU, so that
+	// draws have no effect and work done on the GPU must be done on the
+	// CPU instead.
+	Headless() bool
+
 	// Init initializes the renderer.
 	Init(*glfw.Window) error
 
@@ -30,6 +30,7 @@ import (
 
 	"github.com/haakenlabs/ember/core"
 	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/geometry"
 	"github.com/haakenlabs/ember/system/instance"
 )
 
@@ -38,14 +39,14 @@ var _ gfx.Mesh = &Mesh{}
 type Mesh struct {
 	core.BaseObject
 
-	vertices       []mgl32.Vec3
-	normals        []mgl32.Vec3
-	uvs            []mgl32.Vec2
+	data           gfx.VertexData
+	targets        []geometry.MorphTarget
 	triangles      []uint32
 	vao            uint32
 	vbo            uint32
 	ibo            uint32
 	reverseWinding bool
+	topology       gfx.Topology
 }
 
 func (m *Mesh) Bind() {
@@ -69,16 +70,32 @@ func (m *Mesh) Alloc() error {
 	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
 	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
 
-	gl.EnableVertexAttribArray(0)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 32, gl.PtrOffset(0))
-	gl.EnableVertexAttribArray(1)
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 32, gl.PtrOffset(12))
-	gl.EnableVertexAttribArray(2)
-	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, 32, gl.PtrOffset(24))
-
 	return m.Upload()
 }
 
+// setLayout configures the vertex attributes of the bound vertex array to
+// read the interleaved buffer of the layout.
+func (m *Mesh) setLayout(layout gfx.VertexLayout) {
+	stride := int32(layout.Stride())
+
+	for a := gfx.VertexAttribute(0); a < gfx.VertexAttributeCount; a++ {
+		if !layout.Has(a) {
+			gl.DisableVertexAttribArray(uint32(a))
+			continue
+		}
+
+		offset := gl.PtrOffset(layout.Offset(a))
+		size := int32(a.Components())
+
+		gl.EnableVertexAttribArray(uint32(a))
+		if a.Integer() {
+			gl.VertexAttribIPointer(uint32(a), size, gl.UNSIGNED_SHORT, stride, offset)
+		} else {
+			gl.VertexAttribPointer(uint32(a), size, gl.FLOAT, false, stride, offset)
+		}
+	}
+}
+
 func (m *Mesh) Dealloc() {
 	gl.DeleteBuffers(1, &m.vbo)
 	gl.DeleteBuffers(1, &m.ibo)
@@ -90,62 +107,108 @@ func (m *Mesh) Deferred() bool {
 }
 
 func (m *Mesh) Draw() {
-	if len(m.vertices) == 0 {
+	if m.data.Len() == 0 {
+		return
+	}
+
+	mode := uint32(gl.TRIANGLES)
+	switch m.topology {
+	case gfx.TopologyLines:
+		mode = gl.LINES
+	case gfx.TopologyPoints:
+		mode = gl.POINTS
+	}
+
+	if m.Indexed() {
+		gl.DrawElements(mode, int32(len(m.triangles)), gl.UNSIGNED_INT, gl.PtrOffset(0))
 		return
 	}
 
-	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(m.vertices)))
+	gl.DrawArrays(mode, 0, int32(m.data.Len()))
 }
 
 func (m *Mesh) Clear() {
-	m.vertices = m.vertices[:0]
-	m.normals = m.normals[:0]
-	m.uvs = m.uvs[:0]
+	m.data.Clear()
+	m.targets = nil
 	m.triangles = m.triangles[:0]
 }
 
 func (m *Mesh) Upload() error {
-	if len(m.vertices) == 0 || len(m.normals) == 0 || len(m.uvs) == 0 {
-		return fmt.Errorf("mesh upload failed: vao %d has invalid geometry definition: empty data", m.vao)
+	if err := m.data.Validate(); err != nil {
+		return fmt.Errorf("mesh upload failed: vao %d has invalid geometry definition: %v", m.vao, err)
 	}
 
-	if len(m.vertices) != len(m.normals) || len(m.normals) != len(m.uvs) {
-		return fmt.Errorf("mesh upload failed: vao %d has invalid geometry definition: asymmetric data", m.vao)
+	if len(m.triangles)%m.topology.Size() != 0 {
+		return fmt.Errorf("mesh upload failed: vao %d has invalid geometry definition: partial primitive of %s", m.vao, m.topology)
 	}
 
-	data := make([]gfx.Vertex, len(m.vertices))
-	for idx := range m.vertices {
-		data[idx] = gfx.Vertex{
-			V: m.vertices[idx],
-			N: m.normals[idx],
-			U: m.uvs[idx],
+	for _, idx := range m.triangles {
+		if int(idx) >= m.data.Len() {
+			return fmt.Errorf("mesh upload failed: vao %d has invalid geometry definition: index %d out of range", m.vao, idx)
 		}
 	}
 
+	data := m.data.Interleave()
+
 	m.Bind()
 	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(data)*32, gl.Ptr(data), gl.STATIC_DRAW)
+	gl.BufferData(gl.ARRAY_BUFFER, len(data), gl.Ptr(data), gl.STATIC_DRAW)
+	m.setLayout(m.data.Layout())
+	if m.Indexed() {
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ibo)
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(m.triangles)*4, gl.Ptr(m.triangles), gl.STATIC_DRAW)
+	}
 	m.Unbind()
 
 	return nil
 }
 
 func (m *Mesh) Vertices() []mgl32.Vec3 {
-	return m.vertices
+	return m.data.Positions
 }
 
 func (m *Mesh) Normals() []mgl32.Vec3 {
-	return m.normals
+	return m.data.Normals
 }
 
 func (m *Mesh) UVs() []mgl32.Vec2 {
-	return m.uvs
+	return m.data.UVs
+}
+
+func (m *Mesh) Colors() []mgl32.Vec4 {
+	return m.data.Colors
+}
+
+func (m *Mesh) Tangents() []mgl32.Vec4 {
+	return m.data.Tangents
+}
+
+func (m *Mesh) UV2s() []mgl32.Vec2 {
+	return m.data.UV2s
+}
+
+func (m *Mesh) Joints() [][4]uint16 {
+	return m.data.Joints
+}
+
+func (m *Mesh) Weights() []mgl32.Vec4 {
+	return m.data.Weights
+}
+
+// MorphTargets returns the morph targets of the mesh. They are kept on the
+// CPU and are not uploaded.
+func (m *Mesh) MorphTargets() []geometry.MorphTarget {
+	return m.targets
 }
 
 func (m *Mesh) Triangles() []uint32 {
 	return m.triangles
 }
 
+func (m *Mesh) Layout() gfx.VertexLayout {
+	return m.data.Layout()
+}
+
 func (m *Mesh) Indexed() bool {
 	return len(m.triangles) != 0
 }
@@ -153,22 +216,58 @@ func (m *Mesh) ReversedWinding() bool {
 	return m.reverseWinding
 }
 
+func (m *Mesh) Topology() gfx.Topology {
+	return m.topology
+}
+
 func (m *Mesh) SetVertices(vertices []mgl32.Vec3) {
-	m.vertices = vertices
+	m.data.Positions = vertices
 }
 
 func (m *Mesh) SetNormals(normals []mgl32.Vec3) {
-	m.normals = normals
+	m.data.Normals = normals
 }
 
 func (m *Mesh) SetUVs(uvs []mgl32.Vec2) {
-	m.uvs = uvs
+	m.data.UVs = uvs
+}
+
+func (m *Mesh) SetColors(colors []mgl32.Vec4) {
+	m.data.Colors = colors
+}
+
+func (m *Mesh) SetTangents(tangents []mgl32.Vec4) {
+	m.data.Tangents = tangents
+}
+
+func (m *Mesh) SetUV2s(uvs []mgl32.Vec2) {
+	m.data.UV2s = uvs
+}
+
+func (m *Mesh) SetJoints(joints [][4]uint16) {
+	m.data.Joints = joints
+}
+
+func (m *Mesh) SetWeights(weights []mgl32.Vec4) {
+	m.data.Weights = weights
+}
+
+func (m *Mesh) SetMorphTargets(targets []geometry.MorphTarget) {
+	m.targets = targets
+}
+
+func (m *Mesh) SetTriangles(triangles []uint32) {
+	m.triangles = triangles
 }
 
 func (m *Mesh) SetReversedWinding(reverse bool) {
 	m.reverseWinding = reverse
 }
 
+func (m *Mesh) SetTopology(topology gfx.Topology) {
+	m.topology = topology
+}
+
 func NewMesh() *Mesh {
 	m := &Mesh{}
 
@@ -61,6 +61,25 @@ func (r *Renderer) Dealloc(gfx.Allocater) {
 
 }
 
+// SupportsTextureFormat reports whether the driver can sample textures of
+// the format.
+func (r *Renderer) SupportsTextureFormat(format gfx.TextureFormat) bool {
+	internal := TextureFormatToInternal(format)
+	if internal == 0 {
+		return false
+	}
+
+	var supported int32
+	gl.GetInternalformativ(gl.TEXTURE_2D, uint32(internal), gl.INTERNALFORMAT_SUPPORTED, 1, &supported)
+
+	return supported == gl.TRUE
+}
+
+// Headless reports false, as the renderer draws with OpenGL.
+func (r *Renderer) Headless() bool {
+	return false
+}
+
 func (r *Renderer) Init(window *glfw.Window) error {
 	glfw.WindowHint(glfw.ContextVersionMajor, 4)
 	glfw.WindowHint(glfw.ContextVersionMinor, 3)
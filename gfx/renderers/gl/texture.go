@@ -23,6 +23,8 @@ SOFTWARE.
 package gl
 
 import (
+	"unsafe"
+
 	"github.com/go-gl/gl/v4.3-core/gl"
 
 	"github.com/haakenlabs/ember/core"
@@ -43,6 +45,7 @@ type BaseTexture struct {
 	wrapS          int32
 	wrapT          int32
 	layers         int32
+	levels         uint32
 	reference      uint32
 	textureFormat  gfx.TextureFormat
 	size           math.IVec2
@@ -73,7 +76,12 @@ func (t *BaseTexture) Alloc() error {
 	t.wrapS = gl.CLAMP_TO_EDGE
 	t.wrapT = gl.CLAMP_TO_EDGE
 	t.resizable = true
-	t.layers = 1
+	if t.layers == 0 {
+		t.layers = 1
+	}
+	if t.MipLevels() > 1 {
+		t.filterMin = gl.LINEAR_MIPMAP_LINEAR
+	}
 
 	t.uploadFunc()
 
@@ -152,7 +160,54 @@ func (t *BaseTexture) SetLayers(layers int32) {
 
 // MipLevels
 func (t *BaseTexture) MipLevels() uint32 {
-	return 1
+	if t.levels == 0 {
+		return 1
+	}
+
+	return t.levels
+}
+
+// setLevel records that the texture has data for a mip level.
+func (t *BaseTexture) setLevel(level int32) {
+	if uint32(level+1) > t.levels {
+		t.levels = uint32(level + 1)
+	}
+}
+
+// setMaxLevel limits sampling to the mip levels the texture has data for.
+func (t *BaseTexture) setMaxLevel() {
+	gl.TexParameteri(t.textureType, gl.TEXTURE_MAX_LEVEL, int32(t.MipLevels()-1))
+}
+
+// texImage2D uploads a mip level of a 2D target of the texture. Data of
+// block compressed formats is uploaded as it is stored, and no storage is
+// allocated for them without data.
+func (t *BaseTexture) texImage2D(target uint32, level int32, data []uint8) {
+	w, h := mipSize(t.size.X(), level), mipSize(t.size.Y(), level)
+
+	if t.textureFormat.Compressed() {
+		if len(data) > 0 {
+			gl.CompressedTexImage2D(target, level, uint32(t.internalFormat), w, h, 0, int32(len(data)), gl.Ptr(data))
+		}
+		return
+	}
+
+	var ptr unsafe.Pointer
+	if len(data) > 0 {
+		ptr = gl.Ptr(data)
+	}
+
+	gl.TexImage2D(target, level, t.internalFormat, w, h, 0, t.glFormat, t.storageFormat, ptr)
+}
+
+// mipSize returns the size of a dimension of a mip level.
+func mipSize(size, level int32) int32 {
+	size >>= uint32(level)
+	if size < 1 {
+		return 1
+	}
+
+	return size
 }
 
 // SetFilter
@@ -185,6 +240,7 @@ func (t *BaseTexture) SetGLFormats(internalFormat int32, format uint32, storageF
 
 // SetTexFormat
 func (t *BaseTexture) SetFormat(format gfx.TextureFormat) {
+	t.textureFormat = format
 	t.SetGLFormats(TextureFormatToInternal(format), TextureFormatToFormat(format), TextureFormatToStorage(format))
 }
 
@@ -269,6 +325,21 @@ func (t *BaseTexture) SetHDRData([]float32) {}
 
 func (t *BaseTexture) SetHDRLayerData([]float32, int32) {}
 
+func (t *BaseTexture) SetMipData([]uint8, int32) {}
+
+func (t *BaseTexture) SetLayerMipData([]uint8, int32, int32) {}
+
+// S3TC formats, which are not part of the core profile.
+const (
+	compressedRGBAS3TCDXT1 = 0x83f1
+	compressedRGBAS3TCDXT3 = 0x83f2
+	compressedRGBAS3TCDXT5 = 0x83f3
+
+	compressedSRGBAlphaS3TCDXT1 = 0x8c4d
+	compressedSRGBAlphaS3TCDXT3 = 0x8c4e
+	compressedSRGBAlphaS3TCDXT5 = 0x8c4f
+)
+
 func TextureFormatToInternal(format gfx.TextureFormat) int32 {
 	switch format {
 	case gfx.TextureFormatR8:
@@ -315,6 +386,44 @@ func TextureFormatToInternal(format gfx.TextureFormat) int32 {
 		return gl.STENCIL_INDEX8
 	case gfx.TextureFormatRGBA16UI:
 		return gl.RGBA16UI
+	case gfx.TextureFormatBC1:
+		return compressedRGBAS3TCDXT1
+	case gfx.TextureFormatBC2:
+		return compressedRGBAS3TCDXT3
+	case gfx.TextureFormatBC3:
+		return compressedRGBAS3TCDXT5
+	case gfx.TextureFormatBC4:
+		return gl.COMPRESSED_RED_RGTC1
+	case gfx.TextureFormatBC5:
+		return gl.COMPRESSED_RG_RGTC2
+	case gfx.TextureFormatBC6H:
+		return gl.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT
+	case gfx.TextureFormatBC7:
+		return gl.COMPRESSED_RGBA_BPTC_UNORM
+	case gfx.TextureFormatETC2RGB8:
+		return gl.COMPRESSED_RGB8_ETC2
+	case gfx.TextureFormatETC2RGB8A1:
+		return gl.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2
+	case gfx.TextureFormatETC2RGBA8:
+		return gl.COMPRESSED_RGBA8_ETC2_EAC
+	case gfx.TextureFormatSRGB8:
+		return gl.SRGB8
+	case gfx.TextureFormatSRGBA8:
+		return gl.SRGB8_ALPHA8
+	case gfx.TextureFormatBC1SRGB:
+		return compressedSRGBAlphaS3TCDXT1
+	case gfx.TextureFormatBC2SRGB:
+		return compressedSRGBAlphaS3TCDXT3
+	case gfx.TextureFormatBC3SRGB:
+		return compressedSRGBAlphaS3TCDXT5
+	case gfx.TextureFormatBC7SRGB:
+		return gl.COMPRESSED_SRGB_ALPHA_BPTC_UNORM
+	case gfx.TextureFormatETC2SRGB8:
+		return gl.COMPRESSED_SRGB8_ETC2
+	case gfx.TextureFormatETC2SRGB8A1:
+		return gl.COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2
+	case gfx.TextureFormatETC2SRGBA8:
+		return gl.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC
 	}
 
 	return 0
@@ -336,6 +445,8 @@ func TextureFormatToFormat(format gfx.TextureFormat) uint32 {
 		return gl.RG
 	case gfx.TextureFormatRGB8:
 		fallthrough
+	case gfx.TextureFormatSRGB8:
+		fallthrough
 	case gfx.TextureFormatRGB16:
 		fallthrough
 	case gfx.TextureFormatRGB32:
@@ -346,6 +457,8 @@ func TextureFormatToFormat(format gfx.TextureFormat) uint32 {
 		fallthrough
 	case gfx.TextureFormatRGBA8:
 		fallthrough
+	case gfx.TextureFormatSRGBA8:
+		fallthrough
 	case gfx.TextureFormatDefaultHDRColor:
 		fallthrough
 	case gfx.TextureFormatRGBA16:
@@ -383,6 +496,10 @@ func TextureFormatToStorage(format gfx.TextureFormat) uint32 {
 		fallthrough
 	case gfx.TextureFormatRGBA8:
 		fallthrough
+	case gfx.TextureFormatSRGB8:
+		fallthrough
+	case gfx.TextureFormatSRGBA8:
+		fallthrough
 	case gfx.TextureFormatStencil8:
 		return gl.UNSIGNED_BYTE
 	case gfx.TextureFormatR16:
@@ -430,6 +547,8 @@ func (r *Renderer) MakeTexture(cfg *gfx.TextureConfig) gfx.Texture {
 		return NewTexture3D(cfg)
 	case gfx.TextureCubemap:
 		return NewTextureCubemap(cfg)
+	case gfx.Texture2DArray:
+		return NewTexture2DArray(cfg)
 	case gfx.TextureFont:
 		return NewTextureFont(cfg)
 	case gfx.TextureColor:
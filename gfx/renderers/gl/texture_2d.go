@@ -23,8 +23,6 @@ SOFTWARE.
 package gl
 
 import (
-	"unsafe"
-
 	"github.com/go-gl/gl/v4.3-core/gl"
 
 	"github.com/haakenlabs/ember/gfx"
@@ -38,6 +36,7 @@ type Texture2D struct {
 
 	data    []uint8
 	hdrData []float32
+	mips    [][]uint8
 }
 
 func NewTexture2D(cfg *gfx.TextureConfig) *Texture2D {
@@ -51,9 +50,11 @@ func NewTexture2D(cfg *gfx.TextureConfig) *Texture2D {
 	t.size = cfg.Size
 	t.uploadFunc = t.Upload
 
-	t.internalFormat = TextureFormatToInternal(cfg.Format)
-	t.glFormat = TextureFormatToFormat(cfg.Format)
-	t.storageFormat = TextureFormatToStorage(cfg.Format)
+	format := cfg.TextureFormat()
+	t.textureFormat = format
+	t.internalFormat = TextureFormatToInternal(format)
+	t.glFormat = TextureFormatToFormat(format)
+	t.storageFormat = TextureFormatToStorage(format)
 
 	return t
 }
@@ -65,15 +66,17 @@ func (t *Texture2D) Type() gfx.TextureType {
 func (t *Texture2D) Upload() {
 	t.Bind()
 
-	var ptr unsafe.Pointer
+	if len(t.hdrData) > 0 {
+		gl.TexImage2D(gl.TEXTURE_2D, 0, t.internalFormat, t.size.X(), t.size.Y(), 0, t.glFormat, t.storageFormat, gl.Ptr(t.hdrData))
+	} else {
+		t.texImage2D(gl.TEXTURE_2D, 0, t.data)
+	}
 
-	if t.hdrData != nil && len(t.hdrData) > 0 {
-		ptr = gl.Ptr(t.hdrData)
-	} else if len(t.data) > 0 {
-		ptr = gl.Ptr(t.data)
+	for i, data := range t.mips {
+		t.texImage2D(gl.TEXTURE_2D, int32(i+1), data)
 	}
 
-	gl.TexImage2D(gl.TEXTURE_2D, 0, t.internalFormat, t.size.X(), t.size.Y(), 0, t.glFormat, t.storageFormat, ptr)
+	t.setMaxLevel()
 }
 
 func (t *Texture2D) SetData(data []uint8) {
@@ -83,3 +86,21 @@ func (t *Texture2D) SetData(data []uint8) {
 func (t *Texture2D) SetHDRData(data []float32) {
 	t.hdrData = data
 }
+
+// SetMipData sets the data of a mip level. Level 0 is the data set by
+// SetData.
+func (t *Texture2D) SetMipData(data []uint8, level int32) {
+	if level < 0 {
+		return
+	}
+	if level == 0 {
+		t.SetData(data)
+		return
+	}
+
+	for int32(len(t.mips)) < level {
+		t.mips = append(t.mips, nil)
+	}
+	t.mips[level-1] = data
+	t.setLevel(level)
+}
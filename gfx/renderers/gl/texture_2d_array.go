@@ -0,0 +1,140 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gl
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.3-core/gl"
+
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/system/instance"
+)
+
+var _ gfx.Texture = &Texture2DArray{}
+
+// Texture2DArray is an array of 2D textures of the same size and format,
+// sampled by layer.
+type Texture2DArray struct {
+	BaseTexture
+
+	// data holds the data of each layer, by mip level.
+	data [][][]uint8
+}
+
+func NewTexture2DArray(cfg *gfx.TextureConfig) *Texture2DArray {
+	t := &Texture2DArray{}
+
+	t.textureType = gl.TEXTURE_2D_ARRAY
+
+	t.SetName("Texture2DArray")
+	instance.MustAssign(t)
+
+	t.size = cfg.Size
+	t.layers = cfg.Layers
+	if t.layers < 1 {
+		t.layers = 1
+	}
+	t.uploadFunc = t.Upload
+
+	format := cfg.TextureFormat()
+	t.textureFormat = format
+	t.internalFormat = TextureFormatToInternal(format)
+	t.glFormat = TextureFormatToFormat(format)
+	t.storageFormat = TextureFormatToStorage(format)
+
+	return t
+}
+
+func (t *Texture2DArray) Type() gfx.TextureType {
+	return gfx.Texture2DArray
+}
+
+// Upload uploads every mip level of the layers. Levels missing the data of
+// a layer are allocated without data.
+func (t *Texture2DArray) Upload() {
+	t.Bind()
+
+	for level := int32(0); level < int32(t.MipLevels()); level++ {
+		w, h := mipSize(t.size.X(), level), mipSize(t.size.Y(), level)
+		data := t.levelData(level)
+
+		if t.textureFormat.Compressed() {
+			if len(data) > 0 {
+				gl.CompressedTexImage3D(t.textureType, level, uint32(t.internalFormat), w, h, t.layers, 0, int32(len(data)), gl.Ptr(data))
+			}
+			continue
+		}
+
+		var ptr unsafe.Pointer
+		if len(data) > 0 {
+			ptr = gl.Ptr(data)
+		}
+
+		gl.TexImage3D(t.textureType, level, t.internalFormat, w, h, t.layers, 0, t.glFormat, t.storageFormat, ptr)
+	}
+
+	t.setMaxLevel()
+}
+
+// levelData returns the data of every layer of a mip level, or nil if any
+// layer has none.
+func (t *Texture2DArray) levelData(level int32) []uint8 {
+	if int(level) >= len(t.data) {
+		return nil
+	}
+
+	var data []uint8
+	for i := int32(0); i < t.layers; i++ {
+		if int(i) >= len(t.data[level]) || len(t.data[level][i]) == 0 {
+			return nil
+		}
+		data = append(data, t.data[level][i]...)
+	}
+
+	return data
+}
+
+func (t *Texture2DArray) SetData(data []uint8) {
+	t.SetLayerMipData(data, 0, 0)
+}
+
+func (t *Texture2DArray) SetLayerData(data []uint8, layer int32) {
+	t.SetLayerMipData(data, layer, 0)
+}
+
+func (t *Texture2DArray) SetMipData(data []uint8, level int32) {
+	t.SetLayerMipData(data, 0, level)
+}
+
+func (t *Texture2DArray) SetLayerMipData(data []uint8, layer, level int32) {
+	if layer < 0 || layer >= t.layers || level < 0 {
+		return
+	}
+
+	for int32(len(t.data)) <= level {
+		t.data = append(t.data, make([][]uint8, t.layers))
+	}
+	t.data[level][layer] = data
+	t.setLevel(level)
+}
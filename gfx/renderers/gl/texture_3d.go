@@ -23,6 +23,8 @@ SOFTWARE.
 package gl
 
 import (
+	"unsafe"
+
 	"github.com/go-gl/gl/v4.3-core/gl"
 
 	"github.com/haakenlabs/ember/gfx"
@@ -31,8 +33,14 @@ import (
 
 var _ gfx.Texture = &Texture3D{}
 
+// Texture3D is a volume texture. Its depth is the number of layers, each
+// layer being a slice of the volume.
 type Texture3D struct {
 	BaseTexture
+
+	// data and hdrData hold the data of each slice.
+	data    [][]uint8
+	hdrData [][]float32
 }
 
 func NewTexture3D(cfg *gfx.TextureConfig) *Texture3D {
@@ -44,11 +52,17 @@ func NewTexture3D(cfg *gfx.TextureConfig) *Texture3D {
 	instance.MustAssign(t)
 
 	t.size = cfg.Size
+	t.layers = cfg.Layers
+	if t.layers < 1 {
+		t.layers = 1
+	}
 	t.uploadFunc = t.Upload
 
-	t.internalFormat = TextureFormatToInternal(cfg.Format)
-	t.glFormat = TextureFormatToFormat(cfg.Format)
-	t.storageFormat = TextureFormatToStorage(cfg.Format)
+	format := cfg.TextureFormat()
+	t.textureFormat = format
+	t.internalFormat = TextureFormatToInternal(format)
+	t.glFormat = TextureFormatToFormat(format)
+	t.storageFormat = TextureFormatToStorage(format)
 
 	return t
 }
@@ -57,8 +71,83 @@ func (t *Texture3D) Type() gfx.TextureType {
 	return gfx.Texture3D
 }
 
+// Upload uploads the slices of the volume. Volumes missing the data of a
+// slice are allocated without data.
 func (t *Texture3D) Upload() {
 	t.Bind()
 
-	gl.TexImage3D(t.textureType, 0, t.internalFormat, t.size.X(), t.size.Y(), t.layers, 0, t.glFormat, t.storageFormat, nil)
+	var ptr unsafe.Pointer
+
+	if hdrData := t.volumeHDRData(); len(hdrData) > 0 {
+		ptr = gl.Ptr(hdrData)
+	} else if data := t.volumeData(); len(data) > 0 {
+		ptr = gl.Ptr(data)
+	}
+
+	gl.TexImage3D(t.textureType, 0, t.internalFormat, t.size.X(), t.size.Y(), t.layers, 0, t.glFormat, t.storageFormat, ptr)
+}
+
+// volumeData returns the data of every slice, or nil if any slice has none.
+func (t *Texture3D) volumeData() []uint8 {
+	if int32(len(t.data)) < t.layers {
+		return nil
+	}
+
+	var data []uint8
+	for _, slice := range t.data[:t.layers] {
+		if len(slice) == 0 {
+			return nil
+		}
+		data = append(data, slice...)
+	}
+
+	return data
+}
+
+// volumeHDRData returns the HDR data of every slice, or nil if any slice
+// has none.
+func (t *Texture3D) volumeHDRData() []float32 {
+	if int32(len(t.hdrData)) < t.layers {
+		return nil
+	}
+
+	var data []float32
+	for _, slice := range t.hdrData[:t.layers] {
+		if len(slice) == 0 {
+			return nil
+		}
+		data = append(data, slice...)
+	}
+
+	return data
+}
+
+func (t *Texture3D) SetData(data []uint8) {
+	t.SetLayerData(data, 0)
+}
+
+func (t *Texture3D) SetLayerData(data []uint8, layer int32) {
+	if layer < 0 || layer >= t.layers {
+		return
+	}
+
+	for int32(len(t.data)) <= layer {
+		t.data = append(t.data, nil)
+	}
+	t.data[layer] = data
+}
+
+func (t *Texture3D) SetHDRData(data []float32) {
+	t.SetHDRLayerData(data, 0)
+}
+
+func (t *Texture3D) SetHDRLayerData(data []float32, layer int32) {
+	if layer < 0 || layer >= t.layers {
+		return
+	}
+
+	for int32(len(t.hdrData)) <= layer {
+		t.hdrData = append(t.hdrData, nil)
+	}
+	t.hdrData[layer] = data
 }
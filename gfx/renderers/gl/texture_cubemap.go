@@ -36,6 +36,7 @@ type TextureCubemap struct {
 
 	data    [6][]uint8
 	hdrData [6][]float32
+	mips    [][6][]uint8
 }
 
 func NewTextureCubemap(cfg *gfx.TextureConfig) *TextureCubemap {
@@ -52,9 +53,11 @@ func NewTextureCubemap(cfg *gfx.TextureConfig) *TextureCubemap {
 	t.size = cfg.Size
 	t.uploadFunc = t.Upload
 
-	t.internalFormat = TextureFormatToInternal(cfg.Format)
-	t.glFormat = TextureFormatToFormat(cfg.Format)
-	t.storageFormat = TextureFormatToStorage(cfg.Format)
+	format := cfg.TextureFormat()
+	t.textureFormat = format
+	t.internalFormat = TextureFormatToInternal(format)
+	t.glFormat = TextureFormatToFormat(format)
+	t.storageFormat = TextureFormatToStorage(format)
 
 	return t
 }
@@ -82,10 +85,12 @@ func (t *TextureCubemap) Type() gfx.TextureType {
 func (t *TextureCubemap) Upload() {
 	t.Bind()
 
-	if len(t.hdrData[0]) > 0 {
-		for i := range t.hdrData {
+	for i := range t.data {
+		target := gl.TEXTURE_CUBE_MAP_POSITIVE_X + uint32(i)
+
+		if len(t.hdrData[0]) > 0 {
 			gl.TexImage2D(
-				gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i),
+				target,
 				0,
 				t.internalFormat,
 				t.size.X(),
@@ -95,34 +100,34 @@ func (t *TextureCubemap) Upload() {
 				t.storageFormat,
 				gl.Ptr(t.hdrData[i]),
 			)
+		} else {
+			t.texImage2D(target, 0, t.data[i])
 		}
-	} else if len(t.data[0]) > 0 {
-		for i := range t.data {
-			gl.TexImage2D(
-				gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i),
-				0,
-				t.internalFormat,
-				t.size.X(),
-				t.size.Y(),
-				0,
-				t.glFormat,
-				t.storageFormat,
-				gl.Ptr(t.data[i]),
-			)
-		}
-	} else {
-		for i := uint32(0); i < 6; i++ {
-			gl.TexImage2D(
-				gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i),
-				0,
-				t.internalFormat,
-				t.size.X(),
-				t.size.Y(),
-				0,
-				t.glFormat,
-				t.storageFormat,
-				nil,
-			)
+	}
+
+	for level, faces := range t.mips {
+		for i, data := range faces {
+			t.texImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(i), int32(level+1), data)
 		}
 	}
+
+	t.setMaxLevel()
+}
+
+// SetLayerMipData sets the data of a face of a mip level. Level 0 is the
+// data set by SetLayerData.
+func (t *TextureCubemap) SetLayerMipData(data []uint8, layer, level int32) {
+	if layer < 0 || layer > 5 || level < 0 {
+		return
+	}
+	if level == 0 {
+		t.SetLayerData(data, layer)
+		return
+	}
+
+	for int32(len(t.mips)) < level {
+		t.mips = append(t.mips, [6][]uint8{})
+	}
+	t.mips[level-1][layer] = data
+	t.setLevel(level)
 }
@@ -23,14 +23,27 @@ SOFTWARE.
 package mock
 
 import (
+	"fmt"
+
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/geometry"
 	"github.com/sirupsen/logrus"
 )
 
 var _ gfx.Mesh = &Mesh{}
 
-type Mesh struct{}
+// Mesh stores geometry without uploading it, so that tests can inspect what
+// would be drawn.
+type Mesh struct {
+	data           gfx.VertexData
+	targets        []geometry.MorphTarget
+	triangles      []uint32
+	reverseWinding bool
+	topology       gfx.Topology
+	uploads        int
+	draws          int
+}
 
 func (m *Mesh) Bind() {}
 
@@ -55,44 +68,157 @@ func (m *Mesh) Deferred() bool {
 	return true
 }
 
-func (m *Mesh) Draw() {}
+// Draw counts the draw calls of the mesh.
+func (m *Mesh) Draw() {
+	if m.data.Len() == 0 {
+		return
+	}
 
-func (m *Mesh) Clear() {}
+	m.draws++
+}
 
+func (m *Mesh) Clear() {
+	m.data.Clear()
+	m.targets = nil
+	m.triangles = m.triangles[:0]
+}
+
+// Upload validates the geometry like the GL backend does.
 func (m *Mesh) Upload() error {
+	if err := m.data.Validate(); err != nil {
+		return fmt.Errorf("mesh upload failed: invalid geometry definition: %v", err)
+	}
+
+	if len(m.triangles)%m.topology.Size() != 0 {
+		return fmt.Errorf("mesh upload failed: invalid geometry definition: partial primitive of %s", m.topology)
+	}
+
+	for _, idx := range m.triangles {
+		if int(idx) >= m.data.Len() {
+			return fmt.Errorf("mesh upload failed: invalid geometry definition: index %d out of range", idx)
+		}
+	}
+
+	m.uploads++
+
 	return nil
 }
 
 func (m *Mesh) Vertices() []mgl32.Vec3 {
-	return nil
+	return m.data.Positions
 }
 
 func (m *Mesh) Normals() []mgl32.Vec3 {
-	return nil
+	return m.data.Normals
 }
 
 func (m *Mesh) UVs() []mgl32.Vec2 {
-	return nil
+	return m.data.UVs
+}
+
+func (m *Mesh) Colors() []mgl32.Vec4 {
+	return m.data.Colors
+}
+
+func (m *Mesh) Tangents() []mgl32.Vec4 {
+	return m.data.Tangents
+}
+
+func (m *Mesh) UV2s() []mgl32.Vec2 {
+	return m.data.UV2s
+}
+
+func (m *Mesh) Joints() [][4]uint16 {
+	return m.data.Joints
+}
+
+func (m *Mesh) Weights() []mgl32.Vec4 {
+	return m.data.Weights
+}
+
+// MorphTargets returns the morph targets of the mesh. They are kept on the
+// CPU and are not uploaded.
+func (m *Mesh) MorphTargets() []geometry.MorphTarget {
+	return m.targets
 }
 
 func (m *Mesh) Triangles() []uint32 {
-	return nil
+	return m.triangles
+}
+
+func (m *Mesh) Layout() gfx.VertexLayout {
+	return m.data.Layout()
 }
 
 func (m *Mesh) Indexed() bool {
-	return false
+	return len(m.triangles) != 0
 }
+
 func (m *Mesh) ReversedWinding() bool {
-	return false
+	return m.reverseWinding
+}
+
+func (m *Mesh) Topology() gfx.Topology {
+	return m.topology
+}
+
+func (m *Mesh) SetVertices(vertices []mgl32.Vec3) {
+	m.data.Positions = vertices
+}
+
+func (m *Mesh) SetNormals(normals []mgl32.Vec3) {
+	m.data.Normals = normals
 }
 
-func (m *Mesh) SetVertices(vertices []mgl32.Vec3) {}
+func (m *Mesh) SetUVs(uvs []mgl32.Vec2) {
+	m.data.UVs = uvs
+}
+
+func (m *Mesh) SetColors(colors []mgl32.Vec4) {
+	m.data.Colors = colors
+}
 
-func (m *Mesh) SetNormals(normals []mgl32.Vec3) {}
+func (m *Mesh) SetTangents(tangents []mgl32.Vec4) {
+	m.data.Tangents = tangents
+}
 
-func (m *Mesh) SetUVs(uvs []mgl32.Vec2) {}
+func (m *Mesh) SetUV2s(uvs []mgl32.Vec2) {
+	m.data.UV2s = uvs
+}
+
+func (m *Mesh) SetJoints(joints [][4]uint16) {
+	m.data.Joints = joints
+}
 
-func (m *Mesh) SetReversedWinding(reverse bool) {}
+func (m *Mesh) SetWeights(weights []mgl32.Vec4) {
+	m.data.Weights = weights
+}
+
+func (m *Mesh) SetMorphTargets(targets []geometry.MorphTarget) {
+	m.targets = targets
+}
+
+func (m *Mesh) SetTriangles(triangles []uint32) {
+	m.triangles = triangles
+}
+
+func (m *Mesh) SetReversedWinding(reverse bool) {
+	m.reverseWinding = reverse
+}
+
+func (m *Mesh) SetTopology(topology gfx.Topology) {
+	m.topology = topology
+}
+
+// Uploads reports the number of successful uploads of the mesh.
+func (m *Mesh) Uploads() int {
+	return m.uploads
+}
+
+// Draws reports the number of draw calls of the mesh.
+func (m *Mesh) Draws() int {
+	return m.draws
+}
 
 func (r *Renderer) MakeMesh() gfx.Mesh {
 	return &Mesh{}
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mock
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/geometry"
+)
+
+func TestMesh_Upload(t *testing.T) {
+	quad := func(triangles []uint32) *Mesh {
+		m := &Mesh{}
+		m.SetVertices([]mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}})
+		m.SetNormals([]mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}})
+		m.SetUVs([]mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 1}})
+		m.SetTriangles(triangles)
+		return m
+	}
+
+	var tests = []struct {
+		triangles []uint32
+		topology  gfx.Topology
+		indexed   bool
+		wantErr   bool
+	}{
+		{triangles: nil, indexed: false},
+		{triangles: []uint32{0, 1, 2, 0, 2, 3}, indexed: true},
+		{triangles: []uint32{0, 1, 2, 0, 2}, wantErr: true},
+		{triangles: []uint32{0, 1, 4}, wantErr: true},
+		{triangles: []uint32{0, 1, 1, 2}, topology: gfx.TopologyLines, indexed: true},
+		{triangles: []uint32{0, 1, 2}, topology: gfx.TopologyLines, wantErr: true},
+		{triangles: []uint32{0, 2, 3}, topology: gfx.TopologyPoints, indexed: true},
+		{triangles: nil, topology: gfx.TopologyPoints, indexed: false},
+	}
+
+	for i, v := range tests {
+		m := quad(v.triangles)
+		m.SetTopology(v.topology)
+
+		err := m.Upload()
+		if (err != nil) != v.wantErr {
+			t.Errorf("%s failed test case %d. err: %v wantErr: %v", t.Name(), i, err, v.wantErr)
+			continue
+		}
+		if v.wantErr {
+			continue
+		}
+
+		if m.Indexed() != v.indexed {
+			t.Errorf("%s case %d: Indexed() = %v, want %v", t.Name(), i, m.Indexed(), v.indexed)
+		}
+		if !reflect.DeepEqual(m.Triangles(), v.triangles) {
+			t.Errorf("%s case %d: Triangles() = %v, want %v", t.Name(), i, m.Triangles(), v.triangles)
+		}
+		if m.Uploads() != 1 {
+			t.Errorf("%s case %d: Uploads() = %d, want 1", t.Name(), i, m.Uploads())
+		}
+	}
+}
+
+func TestMesh_Layout(t *testing.T) {
+	m := &Mesh{}
+	m.SetVertices([]mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}})
+	m.SetNormals([]mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}})
+	m.SetUVs([]mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}})
+
+	if m.Layout() != gfx.DefaultVertexLayout {
+		t.Errorf("Layout() = %s, want %s", m.Layout(), gfx.DefaultVertexLayout)
+	}
+
+	m.SetColors([]mgl32.Vec4{{1, 0, 0, 1}, {0, 1, 0, 1}, {0, 0, 1, 1}})
+	m.SetWeights([]mgl32.Vec4{{1, 0, 0, 0}})
+
+	want := gfx.DefaultVertexLayout | gfx.NewVertexLayout(gfx.AttributeColor, gfx.AttributeWeights)
+	if m.Layout() != want {
+		t.Errorf("Layout() = %s, want %s", m.Layout(), want)
+	}
+	if err := m.Upload(); err == nil {
+		t.Error("expected error for asymmetric weights")
+	}
+
+	m.SetWeights(nil)
+	if err := m.Upload(); err != nil {
+		t.Error(err)
+	}
+
+	// Morph targets stay on the CPU and do not change the layout.
+	want = m.Layout()
+	m.SetMorphTargets([]geometry.MorphTarget{{Name: "smile", Positions: make([]mgl32.Vec3, 3)}})
+	if m.Layout() != want {
+		t.Errorf("Layout() with morph targets = %s, want %s", m.Layout(), want)
+	}
+
+	m.Clear()
+	if m.MorphTargets() != nil {
+		t.Error("Clear() kept morph targets")
+	}
+}
@@ -41,6 +41,11 @@ func (r *Renderer) Dealloc(gfx.Allocater)   {}
 func (r *Renderer) Init(*glfw.Window) error { return nil }
 func (r *Renderer) Destroy()                {}
 
+// Headless reports true, as the renderer does not draw.
+func (r *Renderer) Headless() bool {
+	return true
+}
+
 func NewRenderer() *Renderer {
 	return &Renderer{}
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/image"
 	"github.com/haakenlabs/ember/pkg/math"
 )
 
@@ -34,6 +35,12 @@ var _ gfx.Texture = &Texture{}
 type Texture struct {
 	size        math.IVec2
 	textureType gfx.TextureType
+	format      gfx.TextureFormat
+	layers      int32
+	levels      uint32
+
+	// data holds the data set for each layer of each mip level.
+	data [][][]uint8
 }
 
 func (t *Texture) Bind() {}
@@ -109,38 +116,132 @@ func (t *Texture) SetWrapT(int32) {}
 func (t *Texture) Activate(uint32) {}
 
 func (t *Texture) MipLevels() uint32 {
-	return 0
+	return t.levels
 }
 
-func (t *Texture) GenerateMipmaps() {}
+// GenerateMipmaps generates the mip chain of each layer of 8-bit RGBA
+// textures on the CPU, filtering sRGB color in linear space. Other textures only record the number of levels.
+func (t *Texture) GenerateMipmaps() {
+	w, h := int(t.size.X()), int(t.size.Y())
+	if w < 1 || h < 1 {
+		return
+	}
+
+	levels := uint32(1)
+	for s := w | h; s > 1; s >>= 1 {
+		levels++
+	}
+	t.levels = levels
+
+	switch t.format {
+	case gfx.TextureFormatDefaultColor, gfx.TextureFormatRGBA8, gfx.TextureFormatSRGBA8:
+	default:
+		return
+	}
+	if len(t.data) == 0 {
+		return
+	}
+
+	for layer, data := range t.data[0] {
+		if len(data) == 0 {
+			continue
+		}
+
+		mips, err := image.MipmapsRGBA8(data, w, h, image.MipOptions{
+			SRGB: t.format.ColorSpace() == gfx.ColorSpaceSRGB,
+		})
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		for level := 1; level < len(mips); level++ {
+			t.SetLayerMipData(mips[level], int32(layer), int32(level))
+		}
+	}
+}
 
 func (t *Texture) Layers() int32 {
-	return 0
+	return t.layers
 }
 
-func (t *Texture) SetLayers(int32) {}
+func (t *Texture) SetLayers(layers int32) {
+	t.layers = layers
+}
 
 func (t *Texture) ID() int32 {
 	return 1
 }
 
-func (t *Texture) SetFormat(gfx.TextureFormat) {}
+func (t *Texture) SetFormat(format gfx.TextureFormat) {
+	t.format = format
+}
 
-func (t *Texture) SetData([]uint8) {}
+func (t *Texture) SetData(data []uint8) {
+	t.SetLayerMipData(data, 0, 0)
+}
 
-func (t *Texture) SetLayerData([]uint8, int32) {}
+func (t *Texture) SetLayerData(data []uint8, layer int32) {
+	t.SetLayerMipData(data, layer, 0)
+}
 
 func (t *Texture) SetHDRData([]float32) {}
 
 func (t *Texture) SetHDRLayerData([]float32, int32) {}
 
+func (t *Texture) SetMipData(data []uint8, level int32) {
+	t.SetLayerMipData(data, 0, level)
+}
+
+// SetLayerMipData stores the data of a layer of a mip level, and records
+// the number of mip levels given data.
+func (t *Texture) SetLayerMipData(data []uint8, layer, level int32) {
+	if layer < 0 || level < 0 {
+		return
+	}
+
+	for int32(len(t.data)) <= level {
+		t.data = append(t.data, nil)
+	}
+	for int32(len(t.data[level])) <= layer {
+		t.data[level] = append(t.data[level], nil)
+	}
+	t.data[level][layer] = data
+
+	if uint32(level) >= t.levels {
+		t.levels = uint32(level + 1)
+	}
+}
+
+// MipData returns the data of a layer of a mip level, or nil if none was
+// set or generated.
+func (t *Texture) MipData(layer, level int32) []uint8 {
+	if level < 0 || int(level) >= len(t.data) {
+		return nil
+	}
+	if layer < 0 || int(layer) >= len(t.data[level]) {
+		return nil
+	}
+
+	return t.data[level][layer]
+}
+
 func (t *Texture) Format() gfx.TextureFormat {
-	return gfx.TextureFormatDefaultColor
+	return t.format
 }
 
 func (r *Renderer) MakeTexture(cfg *gfx.TextureConfig) gfx.Texture {
 	return &Texture{
 		size:        cfg.Size,
 		textureType: cfg.Type,
+		format:      cfg.TextureFormat(),
+		layers:      cfg.Layers,
+		levels:      1,
 	}
 }
+
+// SupportsTextureFormat reports every format but the block compressed ones
+// as supported, so that loaders take their CPU decoding path.
+func (r *Renderer) SupportsTextureFormat(format gfx.TextureFormat) bool {
+	return !format.Compressed()
+}
@@ -0,0 +1,78 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mock
+
+import (
+	"testing"
+
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/math"
+)
+
+func TestTexture_GenerateMipmaps(t *testing.T) {
+	r := &Renderer{}
+	texture := r.MakeTexture(&gfx.TextureConfig{
+		Type:   gfx.Texture2D,
+		Format: gfx.TextureFormatRGBA8,
+		Size:   math.IVec2{4, 2},
+	}).(*Texture)
+
+	data := make([]uint8, 4*2*4)
+	for i := range data {
+		data[i] = 200
+	}
+	texture.SetData(data)
+	texture.GenerateMipmaps()
+
+	if texture.MipLevels() != 3 {
+		t.Fatalf("MipLevels() = %d, want 3", texture.MipLevels())
+	}
+
+	for level, size := range []int{32, 8, 4} {
+		mip := texture.MipData(0, int32(level))
+		if len(mip) != size {
+			t.Errorf("level %d: len = %d, want %d", level, len(mip), size)
+			continue
+		}
+		for i := range mip {
+			if mip[i] != 200 {
+				t.Errorf("level %d: data = %v, want 200", level, mip)
+				break
+			}
+		}
+	}
+
+	hdr := r.MakeTexture(&gfx.TextureConfig{
+		Type:   gfx.Texture2D,
+		Format: gfx.TextureFormatRGBA16,
+		Size:   math.IVec2{8, 8},
+	}).(*Texture)
+	hdr.GenerateMipmaps()
+
+	if hdr.MipLevels() != 4 {
+		t.Errorf("MipLevels() = %d, want 4", hdr.MipLevels())
+	}
+	if hdr.MipData(0, 1) != nil {
+		t.Error("generated data for a 16-bit texture")
+	}
+}
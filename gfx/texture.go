@@ -22,7 +22,13 @@ SOFTWARE.
 
 package gfx
 
-import "github.com/haakenlabs/ember/pkg/math"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/haakenlabs/ember/pkg/math"
+)
 
 type TextureType uint8
 
@@ -32,6 +38,7 @@ const (
 	TextureCubemap
 	TextureFont
 	TextureColor
+	Texture2DArray
 )
 
 func (t TextureType) String() string {
@@ -46,6 +53,8 @@ func (t TextureType) String() string {
 		return "TextureFont"
 	case TextureColor:
 		return "TextureColor"
+	case Texture2DArray:
+		return "Texture2DArray"
 	default:
 		return "Unknown Texture Type"
 	}
@@ -76,6 +85,159 @@ const (
 	TextureFormatDepth24
 	TextureFormatDepth24Stencil8
 	TextureFormatStencil8
+	TextureFormatBC1
+	TextureFormatBC2
+	TextureFormatBC3
+	TextureFormatBC4
+	TextureFormatBC5
+	TextureFormatBC6H
+	TextureFormatBC7
+	TextureFormatETC2RGB8
+	TextureFormatETC2RGB8A1
+	TextureFormatETC2RGBA8
+	TextureFormatSRGB8
+	TextureFormatSRGBA8
+	TextureFormatBC1SRGB
+	TextureFormatBC2SRGB
+	TextureFormatBC3SRGB
+	TextureFormatBC7SRGB
+	TextureFormatETC2SRGB8
+	TextureFormatETC2SRGB8A1
+	TextureFormatETC2SRGBA8
+)
+
+// srgbFormats maps formats to their sRGB variants.
+var srgbFormats = map[TextureFormat]TextureFormat{
+	TextureFormatDefaultColor: TextureFormatSRGBA8,
+	TextureFormatRGB8:         TextureFormatSRGB8,
+	TextureFormatRGBA8:        TextureFormatSRGBA8,
+	TextureFormatBC1:          TextureFormatBC1SRGB,
+	TextureFormatBC2:          TextureFormatBC2SRGB,
+	TextureFormatBC3:          TextureFormatBC3SRGB,
+	TextureFormatBC7:          TextureFormatBC7SRGB,
+	TextureFormatETC2RGB8:     TextureFormatETC2SRGB8,
+	TextureFormatETC2RGB8A1:   TextureFormatETC2SRGB8A1,
+	TextureFormatETC2RGBA8:    TextureFormatETC2SRGBA8,
+}
+
+// linearFormats maps sRGB formats to their linear variants.
+var linearFormats = map[TextureFormat]TextureFormat{
+	TextureFormatSRGB8:       TextureFormatRGB8,
+	TextureFormatSRGBA8:      TextureFormatRGBA8,
+	TextureFormatBC1SRGB:     TextureFormatBC1,
+	TextureFormatBC2SRGB:     TextureFormatBC2,
+	TextureFormatBC3SRGB:     TextureFormatBC3,
+	TextureFormatBC7SRGB:     TextureFormatBC7,
+	TextureFormatETC2SRGB8:   TextureFormatETC2RGB8,
+	TextureFormatETC2SRGB8A1: TextureFormatETC2RGB8A1,
+	TextureFormatETC2SRGBA8:  TextureFormatETC2RGBA8,
+}
+
+// Compressed reports whether the format is block compressed. Data of block
+// compressed textures is uploaded as it is stored, and they cannot be
+// rendered to.
+func (f TextureFormat) Compressed() bool {
+	switch {
+	case f >= TextureFormatBC1 && f <= TextureFormatETC2RGBA8:
+		return true
+	case f >= TextureFormatBC1SRGB && f <= TextureFormatETC2SRGBA8:
+		return true
+	}
+
+	return false
+}
+
+// ColorSpace returns the color space of the data of the format. Only sRGB
+// formats are decoded to linear when sampled.
+func (f TextureFormat) ColorSpace() ColorSpace {
+	if _, ok := linearFormats[f]; ok {
+		return ColorSpaceSRGB
+	}
+
+	return ColorSpaceLinear
+}
+
+// InColorSpace returns the variant of the format for data in a color space.
+// Formats without an sRGB variant, like float formats, are returned as they
+// are.
+func (f TextureFormat) InColorSpace(space ColorSpace) TextureFormat {
+	switch space {
+	case ColorSpaceSRGB:
+		if v, ok := srgbFormats[f]; ok {
+			return v
+		}
+	case ColorSpaceLinear:
+		if v, ok := linearFormats[f]; ok {
+			return v
+		}
+	}
+
+	return f
+}
+
+// ColorSpace is the encoding of the color held by a texture. Color textures
+// like albedo maps are usually sRGB; data textures like normal maps, and
+// HDR images, are linear.
+type ColorSpace uint8
+
+const (
+	ColorSpaceLinear ColorSpace = iota
+	ColorSpaceSRGB
+)
+
+func (c ColorSpace) String() string {
+	switch c {
+	case ColorSpaceLinear:
+		return "linear"
+	case ColorSpaceSRGB:
+		return "sRGB"
+	default:
+		return "Unknown Color Space"
+	}
+}
+
+// MarshalJSON encodes the color space as "linear" or "srgb".
+func (c ColorSpace) MarshalJSON() ([]byte, error) {
+	switch c {
+	case ColorSpaceLinear, ColorSpaceSRGB:
+		return []byte(strconv.Quote(strings.ToLower(c.String()))), nil
+	}
+
+	return nil, fmt.Errorf("invalid color space: %d", c)
+}
+
+// UnmarshalJSON decodes a color space from "linear" or "srgb", in any case.
+func (c *ColorSpace) UnmarshalJSON(data []byte) error {
+	switch strings.ToLower(strings.Trim(string(data), "\"")) {
+	case "linear":
+		*c = ColorSpaceLinear
+	case "srgb":
+		*c = ColorSpaceSRGB
+	default:
+		return fmt.Errorf("invalid color space: %s", data)
+	}
+
+	return nil
+}
+
+// Texture filters, as taken by SetMagFilter and SetMinFilter. The values
+// are those of the OpenGL enumerants, which formats like glTF share.
+const (
+	FilterNearest              int32 = 0x2600
+	FilterLinear               int32 = 0x2601
+	FilterNearestMipmapNearest int32 = 0x2700
+	FilterLinearMipmapNearest  int32 = 0x2701
+	FilterNearestMipmapLinear  int32 = 0x2702
+	FilterLinearMipmapLinear   int32 = 0x2703
+)
+
+// Texture wrap modes, as taken by SetWrapS, SetWrapT and SetWrapR, with the
+// values of the OpenGL enumerants.
+const (
+	WrapRepeat         int32 = 0x2901
+	WrapClampToBorder  int32 = 0x812d
+	WrapClampToEdge    int32 = 0x812f
+	WrapMirroredRepeat int32 = 0x8370
 )
 
 type Texture interface {
@@ -107,6 +269,8 @@ type Texture interface {
 	SetLayerData([]uint8, int32)
 	SetHDRData([]float32)
 	SetHDRLayerData([]float32, int32)
+	SetMipData([]uint8, int32)
+	SetLayerMipData([]uint8, int32, int32)
 }
 
 type TextureConfig struct {
@@ -114,4 +278,18 @@ type TextureConfig struct {
 	Format TextureFormat
 	Layers int32
 	Size   math.IVec2
+
+	// ColorSpace sRGB selects the sRGB variant of Format, so that sampling
+	// returns linear color. Formats without one hold linear data.
+	ColorSpace ColorSpace
+}
+
+// TextureFormat returns the format of textures made from the config, with
+// the color space applied.
+func (c *TextureConfig) TextureFormat() TextureFormat {
+	if c.ColorSpace == ColorSpaceSRGB {
+		return c.Format.InColorSpace(ColorSpaceSRGB)
+	}
+
+	return c.Format
 }
@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gfx
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTextureFormat_InColorSpace(t *testing.T) {
+	var tests = []struct {
+		format TextureFormat
+		space  ColorSpace
+		want   TextureFormat
+	}{
+		{TextureFormatRGBA8, ColorSpaceSRGB, TextureFormatSRGBA8},
+		{TextureFormatDefaultColor, ColorSpaceSRGB, TextureFormatSRGBA8},
+		{TextureFormatBC7, ColorSpaceSRGB, TextureFormatBC7SRGB},
+		{TextureFormatBC4, ColorSpaceSRGB, TextureFormatBC4},
+		{TextureFormatRGBA16, ColorSpaceSRGB, TextureFormatRGBA16},
+		{TextureFormatSRGBA8, ColorSpaceSRGB, TextureFormatSRGBA8},
+		{TextureFormatSRGBA8, ColorSpaceLinear, TextureFormatRGBA8},
+		{TextureFormatETC2SRGB8A1, ColorSpaceLinear, TextureFormatETC2RGB8A1},
+		{TextureFormatR8, ColorSpaceLinear, TextureFormatR8},
+	}
+
+	for i, v := range tests {
+		got := v.format.InColorSpace(v.space)
+		if got != v.want {
+			t.Errorf("%s case %d: got %d, want %d", t.Name(), i, got, v.want)
+		}
+
+		if got.Compressed() != v.format.Compressed() {
+			t.Errorf("%s case %d: Compressed() changed", t.Name(), i)
+		}
+		if _, ok := srgbFormats[v.format]; ok && got.ColorSpace() != v.space {
+			t.Errorf("%s case %d: ColorSpace() = %s, want %s", t.Name(), i, got.ColorSpace(), v.space)
+		}
+	}
+
+	cfg := &TextureConfig{Format: TextureFormatRGBA8, ColorSpace: ColorSpaceSRGB}
+	if cfg.TextureFormat() != TextureFormatSRGBA8 {
+		t.Errorf("TextureFormat() = %d, want %d", cfg.TextureFormat(), TextureFormatSRGBA8)
+	}
+	cfg = &TextureConfig{Format: TextureFormatSRGBA8}
+	if cfg.TextureFormat() != TextureFormatSRGBA8 {
+		t.Errorf("TextureFormat() of explicit sRGB format = %d, want %d", cfg.TextureFormat(), TextureFormatSRGBA8)
+	}
+}
+
+func TestColorSpace_UnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		in      string
+		want    ColorSpace
+		wantErr bool
+	}{
+		{in: `"linear"`, want: ColorSpaceLinear},
+		{in: `"sRGB"`, want: ColorSpaceSRGB},
+		{in: `"gamma"`, wantErr: true},
+	}
+
+	for i, v := range tests {
+		var c ColorSpace
+
+		err := json.Unmarshal([]byte(v.in), &c)
+		if (err != nil) != v.wantErr {
+			t.Errorf("%s failed test case %d. err: %v wantErr: %v", t.Name(), i, err, v.wantErr)
+			continue
+		}
+		if v.wantErr {
+			continue
+		}
+		if c != v.want {
+			t.Errorf("%s case %d: got %s, want %s", t.Name(), i, c, v.want)
+		}
+
+		data, err := json.Marshal(c)
+		if err != nil {
+			t.Error(err)
+		} else if err := json.Unmarshal(data, &c); err != nil || c != v.want {
+			t.Errorf("%s case %d: round trip of %s = %s", t.Name(), i, data, c)
+		}
+	}
+}
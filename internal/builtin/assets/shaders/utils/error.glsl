@@ -0,0 +1,25 @@
+#ifdef _VERTEX_
+layout(location = 0) in vec3 vertex;
+layout(location = 1) in vec3 normal;
+layout(location = 2) in vec2 uv;
+
+uniform mat4 v_projection_matrix;
+uniform mat4 v_view_matrix;
+uniform mat4 v_model_matrix;
+
+void main()
+{
+    gl_Position = v_projection_matrix * v_view_matrix * v_model_matrix * vec4(vertex, 1.0);
+}
+
+#endif
+
+#ifdef _FRAGMENT_
+out vec4 fo_color;
+
+void main()
+{
+    fo_color = vec4(1.0, 0.0, 1.0, 1.0);
+}
+
+#endif
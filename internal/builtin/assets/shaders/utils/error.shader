@@ -0,0 +1,6 @@
+{
+    "name": "utils/error",
+    "files": [
+        "error.glsl"
+    ]
+}
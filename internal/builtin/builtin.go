@@ -22,4 +22,57 @@ SOFTWARE.
 
 package builtin
 
-//go:generate go-bindata -o builtin_gen.go -prefix assets -pkg builtin assets/...
+import (
+	"embed"
+	"io/fs"
+	"sort"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// FS is the file system of builtin assets, rooted at the assets directory.
+var FS fs.FS
+
+func init() {
+	var err error
+
+	if FS, err = fs.Sub(assets, "assets"); err != nil {
+		panic(err)
+	}
+}
+
+// Asset returns the contents of the builtin asset with the given name.
+func Asset(name string) ([]byte, error) {
+	return fs.ReadFile(FS, name)
+}
+
+// MustAsset is like Asset, but panics if an error occurs.
+func MustAsset(name string) []byte {
+	data, err := Asset(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+// AssetNames returns the sorted names of all builtin assets.
+func AssetNames() []string {
+	var names []string
+
+	fs.WalkDir(FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			names = append(names, name)
+		}
+
+		return nil
+	})
+
+	sort.Strings(names)
+
+	return names
+}
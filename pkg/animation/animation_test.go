@@ -0,0 +1,376 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package animation
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/pkg/geometry"
+)
+
+const epsilon = 1e-4
+
+// near reports whether every component of a is within epsilon of b.
+func near(a, b mgl32.Vec3) bool {
+	for i := range a {
+		if abs(a[i]-b[i]) > epsilon {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestChannel_Sample(t *testing.T) {
+	linear := Channel{
+		Path:   PathTranslation,
+		Times:  []float32{1, 2},
+		Values: []float32{0, 0, 0, 2, 4, 6},
+	}
+	step := linear
+	step.Interpolation = InterpolationStep
+
+	// A cubic spline with zero tangents eases between the values.
+	cubic := Channel{
+		Path:          PathTranslation,
+		Interpolation: InterpolationCubicSpline,
+		Times:         []float32{1, 2},
+		Values:        []float32{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 4, 6, 0, 0, 0},
+	}
+
+	var tests = []struct {
+		channel Channel
+		t       float32
+		want    mgl32.Vec3
+	}{
+		{channel: linear, t: 0, want: mgl32.Vec3{0, 0, 0}},
+		{channel: linear, t: 1.5, want: mgl32.Vec3{1, 2, 3}},
+		{channel: linear, t: 1.25, want: mgl32.Vec3{0.5, 1, 1.5}},
+		{channel: linear, t: 3, want: mgl32.Vec3{2, 4, 6}},
+		{channel: step, t: 1.9, want: mgl32.Vec3{0, 0, 0}},
+		{channel: step, t: 2, want: mgl32.Vec3{2, 4, 6}},
+		{channel: cubic, t: 1.5, want: mgl32.Vec3{1, 2, 3}},
+		{channel: cubic, t: 1.25, want: mgl32.Vec3{0.3125, 0.625, 0.9375}},
+		{channel: cubic, t: 2, want: mgl32.Vec3{2, 4, 6}},
+	}
+
+	for i, v := range tests {
+		if err := v.channel.Validate(); err != nil {
+			t.Fatalf("%s case %d: %v", t.Name(), i, err)
+		}
+
+		tr := Identity()
+		v.channel.Sample(v.t, &tr)
+
+		if !near(tr.Translation, v.want) {
+			t.Errorf("%s case %d: Sample(%v) = %v, want %v", t.Name(), i, v.t, tr.Translation, v.want)
+		}
+	}
+
+	invalid := []Channel{
+		{Path: PathTranslation},
+		{Path: PathTranslation, Times: []float32{1, 0}, Values: make([]float32, 6)},
+		{Path: PathRotation, Times: []float32{0, 1}, Values: make([]float32, 6)},
+		{Path: PathScale, Interpolation: InterpolationCubicSpline, Times: []float32{0}, Values: make([]float32, 3)},
+	}
+	for i, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s invalid case %d: expected error", t.Name(), i)
+		}
+	}
+}
+
+func TestChannel_SampleRotation(t *testing.T) {
+	// The second keyframe is the negation of a quarter turn about y, so the
+	// shortest path is still a quarter turn.
+	q := mgl32.QuatRotate(math.Pi/2, mgl32.Vec3{0, 1, 0})
+	c := Channel{
+		Path:   PathRotation,
+		Times:  []float32{0, 1},
+		Values: []float32{0, 0, 0, 1, -q.V[0], -q.V[1], -q.V[2], -q.W},
+	}
+
+	tr := Identity()
+	c.Sample(0.5, &tr)
+
+	want := mgl32.QuatRotate(math.Pi/4, mgl32.Vec3{0, 1, 0})
+	if abs(tr.Rotation.Dot(want)) < 1-epsilon {
+		t.Errorf("Sample(0.5) = %v, want %v", tr.Rotation, want)
+	}
+	if abs(tr.Rotation.Len()-1) > epsilon {
+		t.Errorf("Sample(0.5) has length %v", tr.Rotation.Len())
+	}
+}
+
+func TestClip_Sample(t *testing.T) {
+	c := Clip{
+		Duration: 2,
+		Channels: []Channel{
+			{Target: 1, Path: PathScale, Times: []float32{0, 2}, Values: []float32{1, 1, 1, 3, 3, 3}},
+			{Target: 5, Path: PathScale, Times: []float32{0}, Values: []float32{0, 0, 0}},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	pose := NewPose(2)
+	c.Sample(c.Wrap(3, true), pose)
+
+	if pose[0] != Identity() {
+		t.Errorf("untargeted transform changed to %v", pose[0])
+	}
+	if !near(pose[1].Scale, mgl32.Vec3{2, 2, 2}) {
+		t.Errorf("scale = %v, want 2", pose[1].Scale)
+	}
+
+	var wraps = []struct {
+		t    float32
+		loop bool
+		want float32
+	}{
+		{t: 0.5, loop: true, want: 0.5},
+		{t: 4.5, loop: true, want: 0.5},
+		{t: -0.5, loop: true, want: 1.5},
+		{t: 4.5, loop: false, want: 2},
+		{t: -1, loop: false, want: 0},
+	}
+	for i, v := range wraps {
+		if got := c.Wrap(v.t, v.loop); abs(got-v.want) > epsilon {
+			t.Errorf("%s case %d: Wrap(%v, %v) = %v, want %v", t.Name(), i, v.t, v.loop, got, v.want)
+		}
+	}
+}
+
+func TestClip_JSON(t *testing.T) {
+	in := `{"name": "bob", "duration": 1, "channels": [
+		{"target": 2, "path": "scale", "interpolation": "step", "times": [0], "values": [1, 2, 3]}
+	]}`
+
+	var c Clip
+	if err := json.Unmarshal([]byte(in), &c); err != nil {
+		t.Fatal(err)
+	}
+	if ch := c.Channels[0]; ch.Target != 2 || ch.Path != PathScale || ch.Interpolation != InterpolationStep {
+		t.Errorf("channel = %+v", ch)
+	}
+
+	out, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c2 Clip
+	if err := json.Unmarshal(out, &c2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, c2) {
+		t.Errorf("round trip = %+v, want %+v", c2, c)
+	}
+
+	if err := json.Unmarshal([]byte(`{"channels": [{"path": "color"}]}`), &c); err == nil {
+		t.Error("expected error for unknown path")
+	}
+}
+
+func TestPose_Blend(t *testing.T) {
+	a := NewPose(1)
+	b := NewPose(1)
+	b[0] = Transform{
+		Translation: mgl32.Vec3{2, 0, 0},
+		Rotation:    mgl32.QuatRotate(math.Pi/2, mgl32.Vec3{0, 0, 1}),
+		Scale:       mgl32.Vec3{3, 3, 3},
+	}
+
+	p := NewPose(1)
+	p.Blend(a, b, 0.5)
+
+	if !near(p[0].Translation, mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("translation = %v", p[0].Translation)
+	}
+	if !near(p[0].Scale, mgl32.Vec3{2, 2, 2}) {
+		t.Errorf("scale = %v", p[0].Scale)
+	}
+
+	want := mgl32.QuatRotate(math.Pi/4, mgl32.Vec3{0, 0, 1})
+	if abs(p[0].Rotation.Dot(want)) < 1-epsilon {
+		t.Errorf("rotation = %v, want %v", p[0].Rotation, want)
+	}
+}
+
+// arm returns a skeleton of two joints one unit apart along x, bound in the
+// rest pose.
+func arm() *Skeleton {
+	s := &Skeleton{
+		Joints: []Joint{
+			{Parent: -1, Rest: Identity()},
+			{Parent: 0, Rest: Transform{Translation: mgl32.Vec3{1, 0, 0}, Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{1, 1, 1}}},
+		},
+	}
+
+	world := make([]mgl32.Mat4, len(s.Joints))
+	s.WorldMatrices(s.RestPose(), world)
+	for i := range s.Joints {
+		s.Joints[i].InverseBind = world[i].Inv()
+	}
+
+	return s
+}
+
+func TestSkeleton_WorldMatrices(t *testing.T) {
+	s := arm()
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	pose := s.RestPose()
+	pose[0].Rotation = mgl32.QuatRotate(math.Pi/2, mgl32.Vec3{0, 0, 1})
+
+	world := make([]mgl32.Mat4, len(s.Joints))
+	s.WorldMatrices(pose, world)
+
+	got := world[1].Mul4x1(mgl32.Vec4{0, 0, 0, 1}).Vec3()
+	if !near(got, mgl32.Vec3{0, 1, 0}) {
+		t.Errorf("joint 1 at %v, want {0 1 0}", got)
+	}
+
+	s.Joints[0].Parent = 1
+	if err := s.Validate(); err == nil {
+		t.Error("expected error for parent after child")
+	}
+}
+
+func TestSkin(t *testing.T) {
+	s := arm()
+
+	pose := s.RestPose()
+	pose[1].Rotation = mgl32.QuatRotate(math.Pi/2, mgl32.Vec3{0, 0, 1})
+
+	matrices := make([]mgl32.Mat4, len(s.Joints))
+	s.SkinMatrices(pose, matrices)
+
+	positions := []mgl32.Vec3{{0.5, 0, 0}, {2, 0, 0}, {1.5, 0, 0}, {3, 0, 0}}
+	normals := []mgl32.Vec3{{0, 1, 0}, {0, 1, 0}, {0, 1, 0}, {0, 1, 0}}
+	joints := [][4]uint16{{0}, {1}, {0, 1}, {}}
+	weights := []mgl32.Vec4{{1}, {1}, {0.5, 0.5}, {}}
+
+	dstPositions := make([]mgl32.Vec3, len(positions))
+	dstNormals := make([]mgl32.Vec3, len(normals))
+	Skin(positions, normals, joints, weights, matrices, dstPositions, dstNormals)
+
+	wantPositions := []mgl32.Vec3{{0.5, 0, 0}, {1, 1, 0}, {1.25, 0.25, 0}, {3, 0, 0}}
+	wantNormals := []mgl32.Vec3{{0, 1, 0}, {-1, 0, 0}, {-float32(math.Sqrt2) / 2, float32(math.Sqrt2) / 2, 0}, {0, 1, 0}}
+
+	for i := range positions {
+		if !near(dstPositions[i], wantPositions[i]) {
+			t.Errorf("position %d = %v, want %v", i, dstPositions[i], wantPositions[i])
+		}
+		if !near(dstNormals[i], wantNormals[i]) {
+			t.Errorf("normal %d = %v, want %v", i, dstNormals[i], wantNormals[i])
+		}
+	}
+}
+
+func TestChannel_SampleWeights(t *testing.T) {
+	c := Channel{
+		Target: 3,
+		Path:   PathWeights,
+		Times:  []float32{0, 1},
+		Values: []float32{0, 1, 0, 1, 0, 0.5},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Width() != 3 {
+		t.Fatalf("Width() = %d, want 3", c.Width())
+	}
+
+	got := make([]float32, c.Width())
+	c.SampleWeights(0.5, got)
+
+	if want := []float32{0.5, 0.5, 0.25}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SampleWeights(0.5) = %v, want %v", got, want)
+	}
+
+	// Weight channels leave poses alone.
+	pose := NewPose(4)
+	(&Clip{Channels: []Channel{c}}).Sample(0.5, pose)
+	if pose[3] != Identity() {
+		t.Errorf("weight channel changed the pose to %v", pose[3])
+	}
+
+	c.Values = c.Values[:5]
+	if err := c.Validate(); err == nil {
+		t.Error("expected error for uneven weights")
+	}
+}
+
+func TestMorph(t *testing.T) {
+	positions := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}}
+	normals := []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}}
+	targets := []geometry.MorphTarget{
+		{
+			Name:      "raise",
+			Positions: []mgl32.Vec3{{0, 0, 2}, {0, 0, 0}},
+			Normals:   []mgl32.Vec3{{1, 0, -1}, {0, 0, 0}},
+		},
+		{
+			Name:      "stretch",
+			Positions: []mgl32.Vec3{{0, 0, 0}, {4, 0, 0}},
+		},
+	}
+
+	dstPositions := make([]mgl32.Vec3, len(positions))
+	dstNormals := make([]mgl32.Vec3, len(normals))
+	Morph(positions, normals, targets, []float32{0.5, 0.25}, dstPositions, dstNormals)
+
+	wantPositions := []mgl32.Vec3{{0, 0, 1}, {2, 0, 0}}
+	wantNormals := []mgl32.Vec3{{float32(math.Sqrt2) / 2, 0, float32(math.Sqrt2) / 2}, {0, 0, 1}}
+
+	for i := range positions {
+		if !near(dstPositions[i], wantPositions[i]) {
+			t.Errorf("position %d = %v, want %v", i, dstPositions[i], wantPositions[i])
+		}
+		if !near(dstNormals[i], wantNormals[i]) {
+			t.Errorf("normal %d = %v, want %v", i, dstNormals[i], wantNormals[i])
+		}
+	}
+
+	// Zero weights leave the mesh in its base shape.
+	Morph(positions, nil, targets, []float32{0}, dstPositions, nil)
+	if !reflect.DeepEqual(dstPositions, positions) {
+		t.Errorf("unweighted positions = %v, want %v", dstPositions, positions)
+	}
+}
+
+func abs(a float32) float32 {
+	if a < 0 {
+		return -a
+	}
+
+	return a
+}
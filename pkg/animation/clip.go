@@ -0,0 +1,348 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package animation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Path is the property of a target animated by a channel.
+type Path uint8
+
+// Animated properties.
+const (
+	PathTranslation Path = iota
+	PathRotation
+	PathScale
+
+	// PathWeights animates the morph target weights of a target, with one
+	// component per morph target.
+	PathWeights
+)
+
+var pathNames = [...]string{"translation", "rotation", "scale", "weights"}
+
+// String returns the name of the path.
+func (p Path) String() string {
+	if int(p) < len(pathNames) {
+		return pathNames[p]
+	}
+
+	return fmt.Sprintf("Path(%d)", p)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p Path) MarshalText() ([]byte, error) {
+	if int(p) >= len(pathNames) {
+		return nil, fmt.Errorf("animation: invalid path %d", p)
+	}
+
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Path) UnmarshalText(text []byte) error {
+	for i, name := range pathNames {
+		if string(text) == name {
+			*p = Path(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("animation: unknown path %q", text)
+}
+
+// components returns the number of components of the values of the path,
+// or zero for weights, which have as many as the target has morph targets.
+func (p Path) components() int {
+	switch p {
+	case PathRotation:
+		return 4
+	case PathWeights:
+		return 0
+	}
+
+	return 3
+}
+
+// Interpolation is the interpolation between the keyframes of a channel.
+type Interpolation uint8
+
+// Interpolation modes.
+const (
+	InterpolationLinear Interpolation = iota
+	InterpolationStep
+	InterpolationCubicSpline
+)
+
+var interpolationNames = [...]string{"linear", "step", "cubic_spline"}
+
+// String returns the name of the interpolation.
+func (i Interpolation) String() string {
+	if int(i) < len(interpolationNames) {
+		return interpolationNames[i]
+	}
+
+	return fmt.Sprintf("Interpolation(%d)", i)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (i Interpolation) MarshalText() ([]byte, error) {
+	if int(i) >= len(interpolationNames) {
+		return nil, fmt.Errorf("animation: invalid interpolation %d", i)
+	}
+
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (i *Interpolation) UnmarshalText(text []byte) error {
+	for j, name := range interpolationNames {
+		if string(text) == name {
+			*i = Interpolation(j)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("animation: unknown interpolation %q", text)
+}
+
+// Channel animates one property of a target. Rotations are stored as x, y,
+// z, w. Cubic spline channels store an in tangent, a value and an out
+// tangent for each keyframe.
+type Channel struct {
+	Target        int           `json:"target"`
+	Path          Path          `json:"path"`
+	Interpolation Interpolation `json:"interpolation"`
+	Times         []float32     `json:"times"`
+	Values        []float32     `json:"values"`
+}
+
+// Validate checks that the channel has ordered keyframes with a value each.
+func (c *Channel) Validate() error {
+	if len(c.Times) == 0 {
+		return fmt.Errorf("animation: channel has no keyframes")
+	}
+
+	for i := 1; i < len(c.Times); i++ {
+		if c.Times[i] < c.Times[i-1] {
+			return fmt.Errorf("animation: keyframe times are not increasing")
+		}
+	}
+
+	if int(c.Path) >= len(pathNames) {
+		return fmt.Errorf("animation: invalid path %d", c.Path)
+	}
+	if int(c.Interpolation) >= len(interpolationNames) {
+		return fmt.Errorf("animation: invalid interpolation %d", c.Interpolation)
+	}
+
+	n := len(c.Times) * c.Width()
+	if c.Interpolation == InterpolationCubicSpline {
+		n *= 3
+	}
+	if n == 0 || len(c.Values) != n {
+		return fmt.Errorf("animation: %d values for %d keyframes", len(c.Values), len(c.Times))
+	}
+
+	return nil
+}
+
+// Sample sets the property of the transform to the value of the channel at
+// time t. Times outside of the keyframes are clamped. Weight channels do not
+// change the transform.
+func (c *Channel) Sample(t float32, dst *Transform) {
+	var v [4]float32
+
+	if c.Path == PathWeights {
+		return
+	}
+
+	c.sample(t, v[:c.Path.components()])
+
+	switch c.Path {
+	case PathTranslation:
+		dst.Translation = mgl32.Vec3{v[0], v[1], v[2]}
+	case PathRotation:
+		dst.Rotation = quat(v).Normalize()
+	case PathScale:
+		dst.Scale = mgl32.Vec3{v[0], v[1], v[2]}
+	}
+}
+
+// SampleWeights sets dst to the morph target weights of a weight channel at
+// time t. dst must have Width elements.
+func (c *Channel) SampleWeights(t float32, dst []float32) {
+	if c.Path == PathWeights {
+		c.sample(t, dst)
+	}
+}
+
+// Width returns the number of components of each value of the channel.
+func (c *Channel) Width() int {
+	if c.Path != PathWeights {
+		return c.Path.components()
+	}
+	if len(c.Times) == 0 {
+		return 0
+	}
+
+	n := len(c.Values) / len(c.Times)
+	if c.Interpolation == InterpolationCubicSpline {
+		n /= 3
+	}
+
+	return n
+}
+
+// sample sets v to the value of the channel at time t.
+func (c *Channel) sample(t float32, v []float32) {
+	n := len(v)
+	count := len(c.Times)
+
+	// i is the keyframe at or before t, and w the position between it and
+	// the next keyframe.
+	i := sort.Search(count, func(k int) bool { return c.Times[k] > t }) - 1
+
+	switch {
+	case i < 0:
+		copy(v, c.value(0, n))
+	case i >= count-1:
+		copy(v, c.value(count-1, n))
+	case c.Interpolation == InterpolationStep:
+		copy(v, c.value(i, n))
+	default:
+		dt := c.Times[i+1] - c.Times[i]
+		w := (t - c.Times[i]) / dt
+
+		a, b := c.value(i, n), c.value(i+1, n)
+
+		switch {
+		case c.Interpolation == InterpolationCubicSpline:
+			c.hermite(i, n, w, dt, v)
+		case c.Path == PathRotation:
+			q := slerp(quat([4]float32{a[0], a[1], a[2], a[3]}), quat([4]float32{b[0], b[1], b[2], b[3]}), w)
+			v[0], v[1], v[2], v[3] = q.V[0], q.V[1], q.V[2], q.W
+		default:
+			for k := range v {
+				v[k] = a[k] + (b[k]-a[k])*w
+			}
+		}
+	}
+}
+
+// value returns the value of keyframe i.
+func (c *Channel) value(i, n int) []float32 {
+	if c.Interpolation == InterpolationCubicSpline {
+		return c.Values[(i*3+1)*n : (i*3+2)*n]
+	}
+
+	return c.Values[i*n : (i+1)*n]
+}
+
+// hermite evaluates the cubic spline between keyframes i and i+1.
+func (c *Channel) hermite(i, n int, w, dt float32, dst []float32) {
+	w2 := w * w
+	w3 := w2 * w
+
+	v0 := c.Values[(i*3+1)*n:]
+	b0 := c.Values[(i*3+2)*n:]
+	a1 := c.Values[(i*3+3)*n:]
+	v1 := c.Values[(i*3+4)*n:]
+
+	for k := 0; k < n; k++ {
+		dst[k] = (2*w3-3*w2+1)*v0[k] +
+			(w3-2*w2+w)*dt*b0[k] +
+			(-2*w3+3*w2)*v1[k] +
+			(w3-w2)*dt*a1[k]
+	}
+}
+
+// Clip is a named set of channels.
+type Clip struct {
+	Name     string    `json:"name"`
+	Duration float32   `json:"duration"`
+	Channels []Channel `json:"channels"`
+}
+
+// Validate checks the channels of the clip.
+func (c *Clip) Validate() error {
+	for i := range c.Channels {
+		if c.Channels[i].Target < 0 {
+			return fmt.Errorf("animation: channel %d has a negative target", i)
+		}
+		if err := c.Channels[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sample sets the animated properties of the pose to their values at time
+// t. Channels targeting transforms outside of the pose, and weight channels,
+// are ignored.
+func (c *Clip) Sample(t float32, pose Pose) {
+	for i := range c.Channels {
+		ch := &c.Channels[i]
+		if ch.Target < len(pose) && ch.Path != PathWeights {
+			ch.Sample(t, &pose[ch.Target])
+		}
+	}
+}
+
+// Wrap maps a time in to the duration of the clip, repeating the clip when
+// loop is set and clamping otherwise.
+func (c *Clip) Wrap(t float32, loop bool) float32 {
+	if c.Duration <= 0 {
+		return 0
+	}
+
+	if loop {
+		t = float32(mod(float64(t), float64(c.Duration)))
+	} else if t > c.Duration {
+		t = c.Duration
+	}
+	if t < 0 {
+		t = 0
+	}
+
+	return t
+}
+
+func quat(v [4]float32) mgl32.Quat {
+	return mgl32.Quat{W: v[3], V: mgl32.Vec3{v[0], v[1], v[2]}}
+}
+
+func mod(a, b float64) float64 {
+	m := math.Mod(a, b)
+	if m < 0 {
+		m += b
+	}
+
+	return m
+}
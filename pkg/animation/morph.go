@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package animation
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/pkg/geometry"
+)
+
+// Morph adds the deltas of the morph targets, scaled by their weights, to
+// positions and normals, writing the results to dstPositions and
+// dstNormals. normals and dstNormals may be nil. Targets without a weight
+// are ignored, and morphed normals are renormalized.
+func Morph(positions, normals []mgl32.Vec3, targets []geometry.MorphTarget, weights []float32, dstPositions, dstNormals []mgl32.Vec3) {
+	copy(dstPositions, positions)
+
+	morphNormals := normals != nil && dstNormals != nil
+	if morphNormals {
+		copy(dstNormals, normals)
+	}
+
+	for i := range targets {
+		if i >= len(weights) || weights[i] == 0 {
+			continue
+		}
+
+		w := weights[i]
+		t := &targets[i]
+
+		for j := range dstPositions {
+			dstPositions[j] = dstPositions[j].Add(t.Positions[j].Mul(w))
+		}
+
+		if morphNormals && t.Normals != nil {
+			for j := range dstNormals {
+				dstNormals[j] = dstNormals[j].Add(t.Normals[j].Mul(w))
+			}
+		}
+	}
+
+	if morphNormals {
+		for j, n := range dstNormals {
+			if l := n.Len(); l != 0 {
+				dstNormals[j] = n.Mul(1 / l)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package animation
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Joint is a joint of a skeleton.
+type Joint struct {
+	Name string `json:"name"`
+
+	// Parent is the index of the parent joint, or -1 for a root joint.
+	Parent int `json:"parent"`
+
+	// InverseBind transforms from model space to the space of the joint in
+	// the bind pose of the skinned mesh.
+	InverseBind mgl32.Mat4 `json:"inverse_bind"`
+
+	// Rest is the local transform of the joint when it is not animated.
+	Rest Transform `json:"rest"`
+}
+
+// Skeleton is a hierarchy of joints. Parents precede their children.
+type Skeleton struct {
+	Name   string  `json:"name"`
+	Joints []Joint `json:"joints"`
+}
+
+// Validate checks that every parent precedes its children.
+func (s *Skeleton) Validate() error {
+	for i := range s.Joints {
+		p := s.Joints[i].Parent
+		if p < -1 || p >= i {
+			return fmt.Errorf("animation: joint %d has invalid parent %d", i, p)
+		}
+	}
+
+	return nil
+}
+
+// RestPose returns the rest transforms of the joints.
+func (s *Skeleton) RestPose() Pose {
+	p := make(Pose, len(s.Joints))
+	for i := range s.Joints {
+		p[i] = s.Joints[i].Rest
+	}
+
+	return p
+}
+
+// WorldMatrices sets out to the model space matrices of the joints in the
+// pose. out must have one element per joint.
+func (s *Skeleton) WorldMatrices(pose Pose, out []mgl32.Mat4) {
+	for i := range s.Joints {
+		m := pose[i].Matrix()
+		if p := s.Joints[i].Parent; p >= 0 {
+			m = out[p].Mul4(m)
+		}
+		out[i] = m
+	}
+}
+
+// SkinMatrices sets out to the matrices which move the vertices of the bind
+// pose in to the pose. out must have one element per joint.
+func (s *Skeleton) SkinMatrices(pose Pose, out []mgl32.Mat4) {
+	s.WorldMatrices(pose, out)
+
+	for i := range s.Joints {
+		out[i] = out[i].Mul4(s.Joints[i].InverseBind)
+	}
+}
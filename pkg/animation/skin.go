@@ -0,0 +1,63 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package animation
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// Skin transforms positions and normals by the weighted sum of the skin
+// matrices of their joints, writing the results to dstPositions and
+// dstNormals. normals and dstNormals may be nil. Weights are expected to sum
+// to one; vertices without weight are left untransformed.
+func Skin(positions, normals []mgl32.Vec3, joints [][4]uint16, weights []mgl32.Vec4, matrices []mgl32.Mat4, dstPositions, dstNormals []mgl32.Vec3) {
+	for i := range positions {
+		var m mgl32.Mat4
+		var total float32
+
+		for k := 0; k < 4; k++ {
+			w := weights[i][k]
+			j := int(joints[i][k])
+			if w == 0 || j >= len(matrices) {
+				continue
+			}
+
+			for e := range m {
+				m[e] += matrices[j][e] * w
+			}
+			total += w
+		}
+
+		if total == 0 {
+			m = mgl32.Ident4()
+		}
+
+		dstPositions[i] = m.Mul4x1(positions[i].Vec4(1)).Vec3()
+
+		if normals != nil && dstNormals != nil {
+			n := m.Mat3().Mul3x1(normals[i])
+			if l := n.Len(); l != 0 {
+				n = n.Mul(1 / l)
+			}
+			dstNormals[i] = n
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package animation
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// Transform is a translation, rotation and scale, applied in reverse order.
+type Transform struct {
+	Translation mgl32.Vec3 `json:"translation"`
+	Rotation    mgl32.Quat `json:"rotation"`
+	Scale       mgl32.Vec3 `json:"scale"`
+}
+
+// Identity returns the identity transform.
+func Identity() Transform {
+	return Transform{Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{1, 1, 1}}
+}
+
+// Matrix returns the matrix of the transform.
+func (t Transform) Matrix() mgl32.Mat4 {
+	tr := mgl32.Translate3D(t.Translation.X(), t.Translation.Y(), t.Translation.Z())
+	sc := mgl32.Scale3D(t.Scale.X(), t.Scale.Y(), t.Scale.Z())
+
+	return tr.Mul4(t.Rotation.Mat4()).Mul4(sc)
+}
+
+// Lerp interpolates between two transforms, spherically for the rotation
+// along the shortest path.
+func Lerp(a, b Transform, w float32) Transform {
+	return Transform{
+		Translation: lerp3(a.Translation, b.Translation, w),
+		Rotation:    slerp(a.Rotation, b.Rotation, w),
+		Scale:       lerp3(a.Scale, b.Scale, w),
+	}
+}
+
+// Pose holds a transform for each target of an animation, such as the
+// joints of a skeleton.
+type Pose []Transform
+
+// NewPose returns a pose of n identity transforms.
+func NewPose(n int) Pose {
+	p := make(Pose, n)
+	for i := range p {
+		p[i] = Identity()
+	}
+
+	return p
+}
+
+// Blend sets the pose to the interpolation between the poses a and b with
+// the weight of b. All poses must have the same length.
+func (p Pose) Blend(a, b Pose, w float32) {
+	for i := range p {
+		p[i] = Lerp(a[i], b[i], w)
+	}
+}
+
+func lerp3(a, b mgl32.Vec3, w float32) mgl32.Vec3 {
+	return a.Add(b.Sub(a).Mul(w))
+}
+
+// slerp interpolates spherically between two rotations along the shortest
+// path.
+func slerp(a, b mgl32.Quat, w float32) mgl32.Quat {
+	if a.Dot(b) < 0 {
+		b = b.Scale(-1)
+	}
+
+	return mgl32.QuatSlerp(a, b, w).Normalize()
+}
@@ -0,0 +1,291 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package atlas
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Algorithm is a rectangle packing algorithm.
+type Algorithm uint8
+
+const (
+	// Skyline places rectangles bottom-left against the outline of the
+	// placed ones. It is fast, and packs rectangles of similar heights
+	// such as glyphs well.
+	Skyline Algorithm = iota
+
+	// MaxRects tracks the maximal free rectangles of each page, placing
+	// rectangles by best short side fit. It is slower, and packs
+	// rectangles of varied sizes more tightly.
+	MaxRects
+)
+
+// ErrTooLarge is returned for rectangles which do not fit on a page.
+var ErrTooLarge = errors.New("atlas: rectangle larger than page")
+
+// errFull is returned when the pages of a packing are used up.
+var errFull = errors.New("atlas: pages full")
+
+// Options configures packing.
+type Options struct {
+	Algorithm Algorithm
+
+	// Width and Height are the maximum size of the pages. Rectangles which
+	// do not fit on a page are packed on a new one. A zero size packs all
+	// rectangles on a single square page, as small as found.
+	Width  int
+	Height int
+
+	// Padding is the space kept between rectangles, and between rectangles
+	// and the edges of their page.
+	Padding int
+
+	// PowerOfTwo rounds the size of pages up to powers of two. Width and
+	// Height should be powers of two as well.
+	PowerOfTwo bool
+}
+
+// Placement is the location of a packed rectangle.
+type Placement struct {
+	Page int
+	Rect image.Rectangle
+}
+
+// Result is the outcome of a packing.
+type Result struct {
+	// Pages holds the size of each page, trimmed to the rectangles on it.
+	Pages []image.Point
+
+	// Placements holds the location of each rectangle, in the order the
+	// sizes were given.
+	Placements []Placement
+}
+
+// UV returns the texture coordinates of a placed rectangle on its page, as
+// the minimum U and V followed by the maximum U and V.
+func (r *Result) UV(i int) mgl32.Vec4 {
+	p := r.Placements[i]
+	size := r.Pages[p.Page]
+	w, h := float32(size.X), float32(size.Y)
+
+	return mgl32.Vec4{
+		float32(p.Rect.Min.X) / w,
+		float32(p.Rect.Min.Y) / h,
+		float32(p.Rect.Max.X) / w,
+		float32(p.Rect.Max.Y) / h,
+	}
+}
+
+// bin is a page being packed.
+type bin interface {
+	// insert places a rectangle of the given size, returning its position.
+	insert(w, h int) (image.Point, bool)
+}
+
+func newBin(algorithm Algorithm, width, height int) bin {
+	if algorithm == MaxRects {
+		return newMaxRects(width, height)
+	}
+
+	return newSkyline(width, height)
+}
+
+// Pack packs rectangles of the given sizes. Rectangles are not rotated.
+func Pack(sizes []image.Point, opts Options) (*Result, error) {
+	for _, s := range sizes {
+		if s.X < 0 || s.Y < 0 {
+			return nil, errors.New("atlas: negative rectangle size")
+		}
+	}
+	if opts.Padding < 0 {
+		return nil, errors.New("atlas: negative padding")
+	}
+
+	order := packingOrder(sizes, opts.Algorithm)
+
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return packSquare(sizes, order, opts)
+	}
+
+	return packPages(sizes, order, opts, opts.Width, opts.Height, 0)
+}
+
+// packingOrder returns the order in which rectangles are packed: by
+// decreasing height for the skyline, and by decreasing longer side for
+// maximal rectangles. Placing large rectangles first packs more tightly.
+func packingOrder(sizes []image.Point, algorithm Algorithm) []int {
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := sizes[order[i]], sizes[order[j]]
+
+		if algorithm == MaxRects {
+			al, as := a.X, a.Y
+			if as > al {
+				al, as = as, al
+			}
+			bl, bs := b.X, b.Y
+			if bs > bl {
+				bl, bs = bs, bl
+			}
+			if al != bl {
+				return al > bl
+			}
+			return as > bs
+		}
+
+		if a.Y != b.Y {
+			return a.Y > b.Y
+		}
+		return a.X > b.X
+	})
+
+	return order
+}
+
+// packSquare packs all rectangles on a single square page, growing the page
+// from the total area of the rectangles until they fit.
+func packSquare(sizes []image.Point, order []int, opts Options) (*Result, error) {
+	pad := opts.Padding
+
+	side, area := 1, 0
+	for _, s := range sizes {
+		area += (s.X + pad) * (s.Y + pad)
+		if s.X+2*pad > side {
+			side = s.X + 2*pad
+		}
+		if s.Y+2*pad > side {
+			side = s.Y + 2*pad
+		}
+	}
+	if a := int(math.Ceil(math.Sqrt(float64(area)))) + pad; a > side {
+		side = a
+	}
+
+	for {
+		if opts.PowerOfTwo {
+			side = nextPowerOfTwo(side)
+		}
+
+		r, err := packPages(sizes, order, opts, side, side, 1)
+		if err != errFull {
+			return r, err
+		}
+
+		if opts.PowerOfTwo {
+			side *= 2
+		} else {
+			side += side/16 + 1
+		}
+	}
+}
+
+// packPages packs rectangles on pages of the given size, opening pages as
+// needed up to the given count. A zero count opens any number of pages.
+func packPages(sizes []image.Point, order []int, opts Options, width, height, count int) (*Result, error) {
+	pad := opts.Padding
+
+	r := &Result{Placements: make([]Placement, len(sizes))}
+
+	// Each rectangle is padded on its right and bottom, in a bin inset by
+	// the padding on the left and top.
+	var bins []bin
+
+	for _, i := range order {
+		w, h := sizes[i].X+pad, sizes[i].Y+pad
+		if w > width-pad || h > height-pad {
+			return nil, ErrTooLarge
+		}
+
+		page := -1
+		var p image.Point
+
+		for j, b := range bins {
+			if pos, ok := b.insert(w, h); ok {
+				page, p = j, pos
+				break
+			}
+		}
+
+		if page < 0 {
+			if count > 0 && len(bins) == count {
+				return nil, errFull
+			}
+
+			b := newBin(opts.Algorithm, width-pad, height-pad)
+			pos, ok := b.insert(w, h)
+			if !ok {
+				return nil, ErrTooLarge
+			}
+
+			bins = append(bins, b)
+			page, p = len(bins)-1, pos
+		}
+
+		origin := p.Add(image.Pt(pad, pad))
+		r.Placements[i] = Placement{
+			Page: page,
+			Rect: image.Rectangle{Min: origin, Max: origin.Add(sizes[i])},
+		}
+	}
+
+	r.Pages = make([]image.Point, len(bins))
+	for i := range r.Pages {
+		r.Pages[i] = image.Pt(1, 1)
+	}
+	for _, p := range r.Placements {
+		size := &r.Pages[p.Page]
+		if p.Rect.Max.X+pad > size.X {
+			size.X = p.Rect.Max.X + pad
+		}
+		if p.Rect.Max.Y+pad > size.Y {
+			size.Y = p.Rect.Max.Y + pad
+		}
+	}
+	if opts.PowerOfTwo {
+		for i, size := range r.Pages {
+			r.Pages[i] = image.Pt(nextPowerOfTwo(size.X), nextPowerOfTwo(size.Y))
+		}
+	}
+
+	return r, nil
+}
+
+// nextPowerOfTwo returns the smallest power of two not less than n.
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+
+	return p
+}
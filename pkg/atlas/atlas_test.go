@@ -0,0 +1,160 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package atlas
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func randomSizes(n, lo, hi int) []image.Point {
+	rnd := rand.New(rand.NewSource(1))
+
+	sizes := make([]image.Point, n)
+	for i := range sizes {
+		sizes[i] = image.Pt(lo+rnd.Intn(hi-lo+1), lo+rnd.Intn(hi-lo+1))
+	}
+
+	return sizes
+}
+
+// checkPacking checks that every rectangle keeps its size, lies on its page
+// and is padded from the page edges and the other rectangles.
+func checkPacking(t *testing.T, name string, sizes []image.Point, r *Result, pad int) {
+	if len(r.Placements) != len(sizes) {
+		t.Fatalf("%s: %d placements, want %d", name, len(r.Placements), len(sizes))
+	}
+
+	for i, p := range r.Placements {
+		if p.Page < 0 || p.Page >= len(r.Pages) {
+			t.Fatalf("%s: rect %d on page %d of %d", name, i, p.Page, len(r.Pages))
+		}
+		if p.Rect.Size() != sizes[i] {
+			t.Errorf("%s: rect %d has size %v, want %v", name, i, p.Rect.Size(), sizes[i])
+		}
+
+		page := image.Rect(pad, pad, r.Pages[p.Page].X-pad, r.Pages[p.Page].Y-pad)
+		if !p.Rect.In(page) {
+			t.Errorf("%s: rect %d at %v outside page %v", name, i, p.Rect, page)
+		}
+
+		for j := i + 1; j < len(r.Placements); j++ {
+			q := r.Placements[j]
+			if q.Page == p.Page && p.Rect.Inset(-pad).Overlaps(q.Rect) {
+				t.Errorf("%s: rects %d %v and %d %v closer than %d", name, i, p.Rect, j, q.Rect, pad)
+			}
+		}
+	}
+}
+
+func TestPack(t *testing.T) {
+	sizes := randomSizes(200, 4, 48)
+
+	for _, algorithm := range []Algorithm{Skyline, MaxRects} {
+		r, err := Pack(sizes, Options{
+			Algorithm: algorithm,
+			Width:     256,
+			Height:    256,
+			Padding:   2,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkPacking(t, "pages", sizes, r, 2)
+
+		if len(r.Pages) < 2 {
+			t.Errorf("algorithm %d: %d pages, want several", algorithm, len(r.Pages))
+		}
+		for i, p := range r.Pages {
+			if p.X > 256 || p.Y > 256 {
+				t.Errorf("algorithm %d: page %d of size %v", algorithm, i, p)
+			}
+		}
+	}
+}
+
+func TestPack_Square(t *testing.T) {
+	sizes := randomSizes(100, 4, 32)
+
+	var area int
+	for _, s := range sizes {
+		area += s.X * s.Y
+	}
+
+	for _, algorithm := range []Algorithm{Skyline, MaxRects} {
+		r, err := Pack(sizes, Options{Algorithm: algorithm, Padding: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkPacking(t, "square", sizes, r, 1)
+
+		if len(r.Pages) != 1 {
+			t.Fatalf("algorithm %d: %d pages, want 1", algorithm, len(r.Pages))
+		}
+		if used := float64(area) / float64(r.Pages[0].X*r.Pages[0].Y); used < 0.6 {
+			t.Errorf("algorithm %d: %.2f of page %v used", algorithm, used, r.Pages[0])
+		}
+	}
+}
+
+func TestPack_PowerOfTwo(t *testing.T) {
+	sizes := randomSizes(50, 3, 40)
+
+	r, err := Pack(sizes, Options{Algorithm: MaxRects, PowerOfTwo: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkPacking(t, "pot", sizes, r, 0)
+
+	for _, p := range r.Pages {
+		if p.X&(p.X-1) != 0 || p.Y&(p.Y-1) != 0 {
+			t.Errorf("page size %v is not a power of two", p)
+		}
+	}
+
+	uv := r.UV(0)
+	rect, page := r.Placements[0].Rect, r.Pages[r.Placements[0].Page]
+	if uv[0] != float32(rect.Min.X)/float32(page.X) || uv[3] != float32(rect.Max.Y)/float32(page.Y) {
+		t.Errorf("uv %v of rect %v on page %v", uv, rect, page)
+	}
+}
+
+func TestPack_TooLarge(t *testing.T) {
+	_, err := Pack([]image.Point{{10, 10}, {64, 10}}, Options{Width: 64, Height: 64, Padding: 1})
+	if err != ErrTooLarge {
+		t.Errorf("error %v, want %v", err, ErrTooLarge)
+	}
+
+	// Empty rectangles take no space.
+	r, err := Pack([]image.Point{{0, 0}, {8, 8}}, Options{Width: 8, Height: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Pages) != 1 {
+		t.Errorf("%d pages, want 1", len(r.Pages))
+	}
+}
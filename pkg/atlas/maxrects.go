@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package atlas
+
+import "image"
+
+// maxRects is a bin packed with the maximal rectangles algorithm. The free
+// space is kept as the set of the largest free rectangles, which overlap.
+type maxRects struct {
+	free []image.Rectangle
+}
+
+func newMaxRects(width, height int) *maxRects {
+	return &maxRects{
+		free: []image.Rectangle{image.Rect(0, 0, width, height)},
+	}
+}
+
+func (m *maxRects) insert(w, h int) (image.Point, bool) {
+	if w == 0 || h == 0 {
+		return image.Point{}, true
+	}
+
+	best := -1
+	var bestShort, bestLong int
+
+	// Place the rectangle in the free rectangle it fills best along its
+	// shorter leftover side.
+	for i, f := range m.free {
+		if w > f.Dx() || h > f.Dy() {
+			continue
+		}
+
+		short, long := f.Dx()-w, f.Dy()-h
+		if short > long {
+			short, long = long, short
+		}
+
+		if best < 0 || short < bestShort || (short == bestShort && long < bestLong) {
+			best, bestShort, bestLong = i, short, long
+		}
+	}
+
+	if best < 0 {
+		return image.Point{}, false
+	}
+
+	p := m.free[best].Min
+	m.split(image.Rectangle{Min: p, Max: p.Add(image.Pt(w, h))})
+	m.prune()
+
+	return p, true
+}
+
+// split replaces the free rectangles overlapping a placed rectangle with
+// the free space left around it.
+func (m *maxRects) split(used image.Rectangle) {
+	var free []image.Rectangle
+
+	for _, f := range m.free {
+		if !f.Overlaps(used) {
+			free = append(free, f)
+			continue
+		}
+
+		if used.Min.X > f.Min.X {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, used.Min.X, f.Max.Y))
+		}
+		if used.Max.X < f.Max.X {
+			free = append(free, image.Rect(used.Max.X, f.Min.Y, f.Max.X, f.Max.Y))
+		}
+		if used.Min.Y > f.Min.Y {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, f.Max.X, used.Min.Y))
+		}
+		if used.Max.Y < f.Max.Y {
+			free = append(free, image.Rect(f.Min.X, used.Max.Y, f.Max.X, f.Max.Y))
+		}
+	}
+
+	m.free = free
+}
+
+// prune removes the free rectangles contained in others.
+func (m *maxRects) prune() {
+	var free []image.Rectangle
+
+	for i, a := range m.free {
+		contained := false
+		for j, b := range m.free {
+			// Of identical rectangles, the first is kept.
+			if i != j && a.In(b) && (a != b || j < i) {
+				contained = true
+				break
+			}
+		}
+
+		if !contained {
+			free = append(free, a)
+		}
+	}
+
+	m.free = free
+}
@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package atlas
+
+import "image"
+
+// skylineNode is a segment of the skyline: the top edge of the placed
+// rectangles over a span of the page.
+type skylineNode struct {
+	x     int
+	y     int
+	width int
+}
+
+// skyline is a bin packed with the bottom-left skyline algorithm. Space
+// below the skyline is never reused.
+type skyline struct {
+	width  int
+	height int
+	nodes  []skylineNode
+}
+
+func newSkyline(width, height int) *skyline {
+	return &skyline{
+		width:  width,
+		height: height,
+		nodes:  []skylineNode{{width: width}},
+	}
+}
+
+func (s *skyline) insert(w, h int) (image.Point, bool) {
+	if w == 0 || h == 0 {
+		return image.Point{}, true
+	}
+
+	best := -1
+	var bestY, bestWidth int
+
+	// Place the rectangle where its top is lowest, then on the narrowest
+	// segment.
+	for i := range s.nodes {
+		y, ok := s.fit(i, w, h)
+		if !ok {
+			continue
+		}
+
+		top := y + h
+		if best < 0 || top < bestY+h || (top == bestY+h && s.nodes[i].width < bestWidth) {
+			best, bestY, bestWidth = i, y, s.nodes[i].width
+		}
+	}
+
+	if best < 0 {
+		return image.Point{}, false
+	}
+
+	p := image.Pt(s.nodes[best].x, bestY)
+	s.add(best, p, w, h)
+
+	return p, true
+}
+
+// fit returns the height at which a rectangle rests when its left edge is
+// at the start of the given segment.
+func (s *skyline) fit(i, w, h int) (int, bool) {
+	if s.nodes[i].x+w > s.width {
+		return 0, false
+	}
+
+	y := 0
+	for left := w; left > 0; i++ {
+		if i >= len(s.nodes) {
+			return 0, false
+		}
+		if s.nodes[i].y > y {
+			y = s.nodes[i].y
+		}
+		if y+h > s.height {
+			return 0, false
+		}
+		left -= s.nodes[i].width
+	}
+
+	return y, true
+}
+
+// add raises the skyline over a placed rectangle.
+func (s *skyline) add(i int, p image.Point, w, h int) {
+	s.nodes = append(s.nodes, skylineNode{})
+	copy(s.nodes[i+1:], s.nodes[i:])
+	s.nodes[i] = skylineNode{x: p.X, y: p.Y + h, width: w}
+
+	// Shrink or remove the segments covered by the new one.
+	for j := i + 1; j < len(s.nodes); {
+		prev, n := s.nodes[j-1], &s.nodes[j]
+		if n.x >= prev.x+prev.width {
+			break
+		}
+
+		shrink := prev.x + prev.width - n.x
+		n.x += shrink
+		n.width -= shrink
+		if n.width > 0 {
+			break
+		}
+
+		s.nodes = append(s.nodes[:j], s.nodes[j+1:]...)
+	}
+
+	// Merge neighbouring segments of equal height.
+	for j := 0; j < len(s.nodes)-1; {
+		if s.nodes[j].y == s.nodes[j+1].y {
+			s.nodes[j].width += s.nodes[j+1].width
+			s.nodes = append(s.nodes[:j+1], s.nodes[j+2:]...)
+		} else {
+			j++
+		}
+	}
+}
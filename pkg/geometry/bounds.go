@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// AABB is an axis-aligned bounding box.
+type AABB struct {
+	Min mgl32.Vec3
+	Max mgl32.Vec3
+}
+
+// Center returns the center of the box.
+func (b AABB) Center() mgl32.Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+// Size returns the size of the box along each axis.
+func (b AABB) Size() mgl32.Vec3 {
+	return b.Max.Sub(b.Min)
+}
+
+// Extents returns half the size of the box.
+func (b AABB) Extents() mgl32.Vec3 {
+	return b.Size().Mul(0.5)
+}
+
+// Contains reports whether the point is inside the box.
+func (b AABB) Contains(p mgl32.Vec3) bool {
+	for i := 0; i < 3; i++ {
+		if p[i] < b.Min[i] || p[i] > b.Max[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Union returns the box enclosing both boxes.
+func (b AABB) Union(o AABB) AABB {
+	for i := 0; i < 3; i++ {
+		if o.Min[i] < b.Min[i] {
+			b.Min[i] = o.Min[i]
+		}
+		if o.Max[i] > b.Max[i] {
+			b.Max[i] = o.Max[i]
+		}
+	}
+
+	return b
+}
+
+// Sphere is a bounding sphere.
+type Sphere struct {
+	Center mgl32.Vec3
+	Radius float32
+}
+
+// Contains reports whether the point is inside the sphere.
+func (s Sphere) Contains(p mgl32.Vec3) bool {
+	return p.Sub(s.Center).Len() <= s.Radius
+}
+
+// Bounds returns the bounding box of the points. The box of no points is
+// empty and centered at the origin.
+func Bounds(points []mgl32.Vec3) AABB {
+	if len(points) == 0 {
+		return AABB{}
+	}
+
+	b := AABB{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		b = b.Union(AABB{Min: p, Max: p})
+	}
+
+	return b
+}
+
+// BoundingSphere returns a sphere enclosing the points, found with Ritter's
+// algorithm. The sphere is close to, but not always, the smallest one.
+func BoundingSphere(points []mgl32.Vec3) Sphere {
+	if len(points) == 0 {
+		return Sphere{}
+	}
+
+	// Start from the two points farthest apart along a greedy search.
+	y := farthest(points, points[0])
+	z := farthest(points, y)
+
+	s := Sphere{
+		Center: y.Add(z).Mul(0.5),
+		Radius: z.Sub(y).Len() / 2,
+	}
+
+	// Grow the sphere to enclose the remaining points.
+	for _, p := range points {
+		d := p.Sub(s.Center).Len()
+		if d <= s.Radius {
+			continue
+		}
+
+		r := (s.Radius + d) / 2
+		s.Center = s.Center.Add(p.Sub(s.Center).Mul((r - s.Radius) / d))
+		s.Radius = r
+	}
+
+	return s
+}
+
+// Bounds returns the bounding box of the mesh.
+func (m *Mesh) Bounds() AABB {
+	return Bounds(m.Positions)
+}
+
+// BoundingSphere returns a bounding sphere of the mesh.
+func (m *Mesh) BoundingSphere() Sphere {
+	return BoundingSphere(m.Positions)
+}
+
+func farthest(points []mgl32.Vec3, from mgl32.Vec3) mgl32.Vec3 {
+	var best mgl32.Vec3
+	var dist float32 = -1
+
+	for _, p := range points {
+		if d := p.Sub(from).Len(); d > dist {
+			best, dist = p, d
+		}
+	}
+
+	return best
+}
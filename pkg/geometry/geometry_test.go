@@ -0,0 +1,289 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const epsilon = 1e-5
+
+// cube returns an unindexed unit cube centered at the origin, with face
+// normals and uvs spanning each face.
+func cube() *Mesh {
+	m := &Mesh{}
+
+	faces := [6][3]mgl32.Vec3{
+		{{1, 0, 0}, {0, 0, -1}, {0, 1, 0}},
+		{{-1, 0, 0}, {0, 0, 1}, {0, 1, 0}},
+		{{0, 1, 0}, {1, 0, 0}, {0, 0, -1}},
+		{{0, -1, 0}, {1, 0, 0}, {0, 0, 1}},
+		{{0, 0, 1}, {1, 0, 0}, {0, 1, 0}},
+		{{0, 0, -1}, {-1, 0, 0}, {0, 1, 0}},
+	}
+	corners := [6]mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 0}, {1, 1}, {0, 1}}
+
+	for _, f := range faces {
+		for _, c := range corners {
+			p := f[0].Mul(0.5).
+				Add(f[1].Mul(c.X() - 0.5)).
+				Add(f[2].Mul(c.Y() - 0.5))
+
+			m.Positions = append(m.Positions, p)
+			m.Normals = append(m.Normals, f[0])
+			m.UVs = append(m.UVs, c)
+		}
+	}
+
+	return m
+}
+
+// quad returns an indexed quad in the XY plane facing +Z, with the u axis
+// optionally mirrored.
+func quad(mirror bool) *Mesh {
+	m := &Mesh{
+		Positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+		Normals:   []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+		UVs:       []mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+		Indices:   []uint32{0, 1, 2, 0, 2, 3},
+	}
+
+	if mirror {
+		for i := range m.UVs {
+			m.UVs[i][0] = 1 - m.UVs[i][0]
+		}
+	}
+
+	return m
+}
+
+func TestMesh_Weld(t *testing.T) {
+	m := cube()
+
+	removed, err := m.Weld(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 12 || len(m.Positions) != 24 || len(m.Indices) != 36 {
+		t.Errorf("exact weld: removed %d, %d vertices, %d indices", removed, len(m.Positions), len(m.Indices))
+	}
+	if err := m.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	// Dropping the normals and uvs leaves the eight corners.
+	m = cube()
+	m.Normals, m.UVs = nil, nil
+	for i := range m.Positions {
+		m.Positions[i][0] += float32(i%3) * 1e-4
+	}
+
+	if _, err := m.Weld(1e-3); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Positions) != 8 {
+		t.Errorf("epsilon weld: %d vertices, want 8", len(m.Positions))
+	}
+
+	// Vertices bound to different joints are kept apart.
+	m = quad(false)
+	m.Unweld()
+	m.Joints = make([][4]uint16, len(m.Positions))
+	m.Weights = make([]mgl32.Vec4, len(m.Positions))
+	for i := range m.Joints {
+		m.Joints[i] = [4]uint16{uint16(i / 3)}
+		m.Weights[i] = mgl32.Vec4{1, 0, 0, 0}
+	}
+
+	if _, err := m.Weld(1e-3); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Positions) != 6 || len(m.Joints) != 6 || len(m.Weights) != 6 {
+		t.Errorf("skinned weld: %d vertices, want 6", len(m.Positions))
+	}
+
+	// Vertices are kept apart when their morph target deltas differ.
+	for _, v := range []struct {
+		split bool
+		want  int
+	}{
+		{split: false, want: 4},
+		{split: true, want: 6},
+	} {
+		m = quad(false)
+		m.Unweld()
+
+		target := MorphTarget{Name: "bulge", Positions: make([]mgl32.Vec3, len(m.Positions))}
+		for i := range target.Positions {
+			target.Positions[i] = mgl32.Vec3{0, 0, 1}
+			if v.split && i >= 3 {
+				target.Positions[i] = mgl32.Vec3{0, 0, 2}
+			}
+		}
+		m.Targets = []MorphTarget{target}
+
+		if _, err := m.Weld(0); err != nil {
+			t.Fatal(err)
+		}
+		if len(m.Positions) != v.want || len(m.Targets[0].Positions) != v.want {
+			t.Errorf("morph weld (split %v): %d vertices, want %d", v.split, len(m.Positions), v.want)
+		}
+	}
+
+	if _, err := (&Mesh{Positions: make([]mgl32.Vec3, 3), Targets: []MorphTarget{{Positions: make([]mgl32.Vec3, 2)}}}).Weld(0); err == nil {
+		t.Error("expected error for short morph target")
+	}
+	if _, err := (&Mesh{Positions: make([]mgl32.Vec3, 3), Colors: make([]mgl32.Vec4, 2)}).Weld(0); err == nil {
+		t.Error("expected error for missing colors")
+	}
+	if _, err := (&Mesh{Positions: make([]mgl32.Vec3, 4)}).Weld(0); err == nil {
+		t.Error("expected error for partial triangle")
+	}
+}
+
+func TestMesh_SmoothNormals(t *testing.T) {
+	m := cube()
+	if _, err := m.Weld(0); err != nil {
+		t.Fatal(err)
+	}
+
+	m.SmoothNormals()
+
+	for i, p := range m.Positions {
+		want := p.Normalize()
+		if !m.Normals[i].ApproxEqualThreshold(want, epsilon) {
+			t.Errorf("normal %d at %v = %v, want %v", i, p, m.Normals[i], want)
+		}
+	}
+}
+
+func TestMesh_FlatNormals(t *testing.T) {
+	m := cube()
+	want := append([]mgl32.Vec3(nil), m.Normals...)
+
+	if _, err := m.Weld(0); err != nil {
+		t.Fatal(err)
+	}
+
+	m.SmoothNormals()
+	m.FlatNormals()
+
+	if m.Indexed() || len(m.Normals) != len(want) {
+		t.Fatalf("flat normals left %d indices and %d normals", len(m.Indices), len(m.Normals))
+	}
+	for i := range want {
+		if !m.Normals[i].ApproxEqualThreshold(want[i], epsilon) {
+			t.Errorf("normal %d = %v, want %v", i, m.Normals[i], want[i])
+		}
+	}
+}
+
+func TestMesh_GenerateTangents(t *testing.T) {
+	var tests = []struct {
+		mirror bool
+		want   mgl32.Vec4
+	}{
+		{mirror: false, want: mgl32.Vec4{1, 0, 0, 1}},
+		{mirror: true, want: mgl32.Vec4{-1, 0, 0, -1}},
+	}
+
+	for i, v := range tests {
+		m := quad(v.mirror)
+		if err := m.GenerateTangents(); err != nil {
+			t.Fatal(err)
+		}
+
+		for j, tan := range m.Tangents {
+			if !tan.Vec3().ApproxEqualThreshold(v.want.Vec3(), epsilon) || tan[3] != v.want[3] {
+				t.Errorf("%s case %d: tangent %d = %v, want %v", t.Name(), i, j, tan, v.want)
+			}
+		}
+	}
+
+	// Tangents are orthogonal to the normals of a cube.
+	m := cube()
+	if err := m.GenerateTangents(); err != nil {
+		t.Fatal(err)
+	}
+	for i, tan := range m.Tangents {
+		if d := tan.Vec3().Dot(m.Normals[i]); abs(d) > epsilon || abs(tan.Vec3().Len()-1) > epsilon {
+			t.Errorf("tangent %d = %v is not a unit vector orthogonal to %v", i, tan, m.Normals[i])
+		}
+	}
+
+	if err := (&Mesh{Positions: make([]mgl32.Vec3, 3)}).GenerateTangents(); err != ErrMissingNormals {
+		t.Errorf("err = %v, want %v", err, ErrMissingNormals)
+	}
+}
+
+func TestMesh_FlipWinding(t *testing.T) {
+	for _, m := range []*Mesh{cube(), quad(false)} {
+		before := make([]mgl32.Vec3, m.TriangleCount())
+		for i := range before {
+			a, b, c := m.Triangle(i)
+			before[i], _ = faceNormal(m.Positions[a], m.Positions[b], m.Positions[c])
+		}
+
+		m.FlipWinding()
+
+		for i := range before {
+			a, b, c := m.Triangle(i)
+			n, _ := faceNormal(m.Positions[a], m.Positions[b], m.Positions[c])
+			if !n.ApproxEqualThreshold(before[i].Mul(-1), epsilon) {
+				t.Errorf("triangle %d normal = %v, want %v", i, n, before[i].Mul(-1))
+			}
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	m := cube()
+
+	b := m.Bounds()
+	if b.Min != (mgl32.Vec3{-0.5, -0.5, -0.5}) || b.Max != (mgl32.Vec3{0.5, 0.5, 0.5}) {
+		t.Errorf("bounds = %v", b)
+	}
+	if b.Center() != (mgl32.Vec3{}) || b.Extents() != (mgl32.Vec3{0.5, 0.5, 0.5}) {
+		t.Errorf("center = %v, extents = %v", b.Center(), b.Extents())
+	}
+
+	s := m.BoundingSphere()
+	if !s.Center.ApproxEqualThreshold(mgl32.Vec3{}, epsilon) || abs(s.Radius-mgl32.Vec3{0.5, 0.5, 0.5}.Len()) > epsilon {
+		t.Errorf("sphere = %v", s)
+	}
+
+	// Every point is enclosed, including those of a lopsided cloud.
+	points := append(m.Positions, mgl32.Vec3{3, 0, 0}, mgl32.Vec3{0, 2, 1})
+	s = BoundingSphere(points)
+	for _, p := range points {
+		if p.Sub(s.Center).Len() > s.Radius+epsilon {
+			t.Errorf("point %v outside of sphere %v", p, s)
+		}
+	}
+
+	if (Bounds(nil) != AABB{}) || (BoundingSphere(nil) != Sphere{}) {
+		t.Error("bounds of no points are not empty")
+	}
+}
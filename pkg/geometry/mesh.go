@@ -0,0 +1,259 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Geometry errors.
+var (
+	ErrMissingNormals = errors.New("geometry: mesh has no normals")
+	ErrMissingUVs     = errors.New("geometry: mesh has no uvs")
+)
+
+// Mesh is triangle geometry. Every attribute which is present has one
+// element per vertex. Triangles are given by Indices, or by consecutive
+// vertices when Indices is empty.
+type Mesh struct {
+	Positions []mgl32.Vec3
+	Normals   []mgl32.Vec3
+	UVs       []mgl32.Vec2
+
+	// Tangents hold the tangent in xyz and the bitangent sign in w, such
+	// that the bitangent is w * cross(normal, tangent).
+	Tangents []mgl32.Vec4
+
+	Colors []mgl32.Vec4
+	UV2s   []mgl32.Vec2
+
+	// Joints and Weights bind each vertex to up to four joints of a
+	// skeleton.
+	Joints  [][4]uint16
+	Weights []mgl32.Vec4
+
+	// Targets are morph targets, blended on to the vertices by weight.
+	Targets []MorphTarget
+
+	Indices []uint32
+}
+
+// MorphTarget is a named shape of a mesh, given by position and normal
+// deltas for every vertex. Normals may be nil.
+type MorphTarget struct {
+	Name      string
+	Positions []mgl32.Vec3
+	Normals   []mgl32.Vec3
+}
+
+// Indexed reports whether the mesh has indices.
+func (m *Mesh) Indexed() bool {
+	return len(m.Indices) != 0
+}
+
+// TriangleCount returns the number of triangles of the mesh.
+func (m *Mesh) TriangleCount() int {
+	if m.Indexed() {
+		return len(m.Indices) / 3
+	}
+
+	return len(m.Positions) / 3
+}
+
+// Triangle returns the vertex indices of the i-th triangle.
+func (m *Mesh) Triangle(i int) (uint32, uint32, uint32) {
+	if m.Indexed() {
+		return m.Indices[i*3], m.Indices[i*3+1], m.Indices[i*3+2]
+	}
+
+	j := uint32(i * 3)
+
+	return j, j + 1, j + 2
+}
+
+// Validate checks that the attributes have matching lengths and that the
+// indices form whole triangles of existing vertices.
+func (m *Mesh) Validate() error {
+	n := len(m.Positions)
+
+	if m.Normals != nil && len(m.Normals) != n {
+		return fmt.Errorf("geometry: %d normals for %d vertices", len(m.Normals), n)
+	}
+	if m.UVs != nil && len(m.UVs) != n {
+		return fmt.Errorf("geometry: %d uvs for %d vertices", len(m.UVs), n)
+	}
+	if m.Tangents != nil && len(m.Tangents) != n {
+		return fmt.Errorf("geometry: %d tangents for %d vertices", len(m.Tangents), n)
+	}
+	if m.Colors != nil && len(m.Colors) != n {
+		return fmt.Errorf("geometry: %d colors for %d vertices", len(m.Colors), n)
+	}
+	if m.UV2s != nil && len(m.UV2s) != n {
+		return fmt.Errorf("geometry: %d secondary uvs for %d vertices", len(m.UV2s), n)
+	}
+	if m.Joints != nil && len(m.Joints) != n {
+		return fmt.Errorf("geometry: %d joints for %d vertices", len(m.Joints), n)
+	}
+	if m.Weights != nil && len(m.Weights) != n {
+		return fmt.Errorf("geometry: %d weights for %d vertices", len(m.Weights), n)
+	}
+	for _, t := range m.Targets {
+		if len(t.Positions) != n || (t.Normals != nil && len(t.Normals) != n) {
+			return fmt.Errorf("geometry: morph target %q does not match %d vertices", t.Name, n)
+		}
+	}
+
+	if !m.Indexed() {
+		if n%3 != 0 {
+			return fmt.Errorf("geometry: %d vertices do not form triangles", n)
+		}
+		return nil
+	}
+
+	if len(m.Indices)%3 != 0 {
+		return fmt.Errorf("geometry: %d indices do not form triangles", len(m.Indices))
+	}
+
+	for _, i := range m.Indices {
+		if int(i) >= n {
+			return fmt.Errorf("geometry: index %d out of range", i)
+		}
+	}
+
+	return nil
+}
+
+// Unweld gives every triangle its own vertices, removing the indices.
+func (m *Mesh) Unweld() {
+	if !m.Indexed() {
+		return
+	}
+
+	order := make([]int, len(m.Indices))
+	for i, idx := range m.Indices {
+		order[i] = int(idx)
+	}
+
+	m.reorder(order)
+	m.Indices = nil
+}
+
+// reorder replaces every attribute with its elements at the given vertices.
+func (m *Mesh) reorder(order []int) {
+	m.Positions = gather3(m.Positions, order)
+	m.Normals = gather3(m.Normals, order)
+	m.UVs = gather2(m.UVs, order)
+	m.Tangents = gather4(m.Tangents, order)
+	m.Colors = gather4(m.Colors, order)
+	m.UV2s = gather2(m.UV2s, order)
+	m.Weights = gather4(m.Weights, order)
+
+	if m.Joints != nil {
+		joints := make([][4]uint16, len(order))
+		for i, o := range order {
+			joints[i] = m.Joints[o]
+		}
+		m.Joints = joints
+	}
+
+	for i := range m.Targets {
+		m.Targets[i].Positions = gather3(m.Targets[i].Positions, order)
+		m.Targets[i].Normals = gather3(m.Targets[i].Normals, order)
+	}
+}
+
+// FlipWinding reverses the order of the vertices of every triangle, turning
+// front faces in to back faces. Normals are left unchanged.
+func (m *Mesh) FlipWinding() {
+	if m.Indexed() {
+		for i := 0; i+2 < len(m.Indices); i += 3 {
+			m.Indices[i+1], m.Indices[i+2] = m.Indices[i+2], m.Indices[i+1]
+		}
+		return
+	}
+
+	order := make([]int, len(m.Positions))
+	for i := 0; i+2 < len(order); i += 3 {
+		order[i], order[i+1], order[i+2] = i, i+2, i+1
+	}
+
+	m.reorder(order)
+}
+
+// FlipNormals negates the normals, and the tangents and morph target normal
+// deltas along with them.
+func (m *Mesh) FlipNormals() {
+	for i := range m.Normals {
+		m.Normals[i] = m.Normals[i].Mul(-1)
+	}
+	for _, t := range m.Targets {
+		for i := range t.Normals {
+			t.Normals[i] = t.Normals[i].Mul(-1)
+		}
+	}
+	for i := range m.Tangents {
+		m.Tangents[i] = mgl32.Vec4{-m.Tangents[i][0], -m.Tangents[i][1], -m.Tangents[i][2], m.Tangents[i][3]}
+	}
+}
+
+func gather2(s []mgl32.Vec2, order []int) []mgl32.Vec2 {
+	if s == nil {
+		return nil
+	}
+
+	r := make([]mgl32.Vec2, len(order))
+	for i, o := range order {
+		r[i] = s[o]
+	}
+
+	return r
+}
+
+func gather3(s []mgl32.Vec3, order []int) []mgl32.Vec3 {
+	if s == nil {
+		return nil
+	}
+
+	r := make([]mgl32.Vec3, len(order))
+	for i, o := range order {
+		r[i] = s[o]
+	}
+
+	return r
+}
+
+func gather4(s []mgl32.Vec4, order []int) []mgl32.Vec4 {
+	if s == nil {
+		return nil
+	}
+
+	r := make([]mgl32.Vec4, len(order))
+	for i, o := range order {
+		r[i] = s[o]
+	}
+
+	return r
+}
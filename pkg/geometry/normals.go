@@ -0,0 +1,116 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// SmoothNormals generates normals shared by all triangles around a position.
+// Each triangle contributes its normal weighted by its angle at the vertex,
+// and vertices at the same position share the result, so seams in the uvs
+// do not show in the shading.
+func (m *Mesh) SmoothNormals() {
+	sums := make(map[mgl32.Vec3]mgl32.Vec3)
+
+	for i := 0; i < m.TriangleCount(); i++ {
+		a, b, c := m.Triangle(i)
+		n, ok := faceNormal(m.Positions[a], m.Positions[b], m.Positions[c])
+		if !ok {
+			continue
+		}
+
+		for j, w := range cornerAngles(m.Positions[a], m.Positions[b], m.Positions[c]) {
+			p := m.Positions[[3]uint32{a, b, c}[j]]
+			sums[p] = sums[p].Add(n.Mul(w))
+		}
+	}
+
+	m.Normals = make([]mgl32.Vec3, len(m.Positions))
+	for i, p := range m.Positions {
+		m.Normals[i] = normalize(sums[p])
+	}
+}
+
+// FlatNormals gives every triangle its own vertices with the normal of the
+// triangle.
+func (m *Mesh) FlatNormals() {
+	m.Unweld()
+
+	m.Normals = make([]mgl32.Vec3, len(m.Positions))
+
+	for i := 0; i+2 < len(m.Positions); i += 3 {
+		n, _ := faceNormal(m.Positions[i], m.Positions[i+1], m.Positions[i+2])
+		m.Normals[i], m.Normals[i+1], m.Normals[i+2] = n, n, n
+	}
+}
+
+// faceNormal returns the unit normal of a counter-clockwise triangle. It
+// reports false for degenerate triangles.
+func faceNormal(a, b, c mgl32.Vec3) (mgl32.Vec3, bool) {
+	n := b.Sub(a).Cross(c.Sub(a))
+
+	l := n.Len()
+	if l == 0 {
+		return mgl32.Vec3{}, false
+	}
+
+	return n.Mul(1 / l), true
+}
+
+// cornerAngles returns the interior angles of a triangle at each vertex.
+func cornerAngles(a, b, c mgl32.Vec3) [3]float32 {
+	return [3]float32{
+		angle(b.Sub(a), c.Sub(a)),
+		angle(c.Sub(b), a.Sub(b)),
+		angle(a.Sub(c), b.Sub(c)),
+	}
+}
+
+// angle returns the angle between two vectors.
+func angle(u, v mgl32.Vec3) float32 {
+	l := u.Len() * v.Len()
+	if l == 0 {
+		return 0
+	}
+
+	cos := u.Dot(v) / l
+	if cos > 1 {
+		cos = 1
+	} else if cos < -1 {
+		cos = -1
+	}
+
+	return float32(math.Acos(float64(cos)))
+}
+
+func normalize(v mgl32.Vec3) mgl32.Vec3 {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+
+	return v.Mul(1 / l)
+}
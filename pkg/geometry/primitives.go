@@ -0,0 +1,514 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Primitives are centered at the origin, wound counter-clockwise when seen
+// from outside and have uvs with the origin in the bottom left corner.
+// Round shapes are built around the Y axis, with their uv seam facing +Z.
+
+// profilePoint is a point of a profile curve swept around the Y axis by
+// lathe. The normal is given in the same radius, height plane.
+type profilePoint struct {
+	radius float32
+	y      float32
+	normal mgl32.Vec2
+	v      float32
+}
+
+// Quad returns a quad in the XY plane, facing +Z.
+func Quad(width, height float32) *Mesh {
+	m := &Mesh{}
+
+	m.grid(1, 1, func(u, v float32) (mgl32.Vec3, mgl32.Vec3) {
+		return mgl32.Vec3{(u - 0.5) * width, (v - 0.5) * height, 0}, mgl32.Vec3{0, 0, 1}
+	})
+
+	return m
+}
+
+// Plane returns a plane in the XZ plane, facing +Y. Each side is split in to
+// the given number of subdivisions.
+func Plane(width, depth float32, subdivisions int) *Mesh {
+	m := &Mesh{}
+
+	subdivisions = atLeast(subdivisions, 1)
+
+	m.grid(subdivisions, subdivisions, func(u, v float32) (mgl32.Vec3, mgl32.Vec3) {
+		return mgl32.Vec3{(u - 0.5) * width, 0, (0.5 - v) * depth}, mgl32.Vec3{0, 1, 0}
+	})
+
+	return m
+}
+
+// Cube returns a cube with the given edge length. Every face has its own
+// vertices and spans the whole uv space.
+func Cube(size float32) *Mesh {
+	m := &Mesh{}
+
+	// Each face is given by its normal and its u and v axes.
+	faces := [6][3]mgl32.Vec3{
+		{{1, 0, 0}, {0, 0, -1}, {0, 1, 0}},
+		{{-1, 0, 0}, {0, 0, 1}, {0, 1, 0}},
+		{{0, 1, 0}, {1, 0, 0}, {0, 0, -1}},
+		{{0, -1, 0}, {1, 0, 0}, {0, 0, 1}},
+		{{0, 0, 1}, {1, 0, 0}, {0, 1, 0}},
+		{{0, 0, -1}, {-1, 0, 0}, {0, 1, 0}},
+	}
+
+	for _, f := range faces {
+		f := f
+		m.grid(1, 1, func(u, v float32) (mgl32.Vec3, mgl32.Vec3) {
+			p := f[0].Mul(0.5).
+				Add(f[1].Mul(u - 0.5)).
+				Add(f[2].Mul(v - 0.5))
+
+			return p.Mul(size), f[0]
+		})
+	}
+
+	return m
+}
+
+// UVSphere returns a sphere made of the given number of segments around the
+// Y axis and rings from pole to pole.
+func UVSphere(radius float32, segments, rings int) *Mesh {
+	m := &Mesh{}
+
+	rings = atLeast(rings, 2)
+
+	profile := make([]profilePoint, rings+1)
+	for j := range profile {
+		s, c := sincos(math.Pi * float64(j) / float64(rings))
+		if j == 0 || j == rings {
+			s = 0
+		}
+
+		profile[j] = profilePoint{
+			radius: radius * s,
+			y:      -radius * c,
+			normal: mgl32.Vec2{s, -c},
+			v:      float32(j) / float32(rings),
+		}
+	}
+
+	m.lathe(profile, segments)
+
+	return m
+}
+
+// Icosphere returns a sphere made by repeatedly splitting the faces of an
+// icosahedron in four. Vertices are shared, except along the uv seam and at
+// the poles.
+func Icosphere(radius float32, subdivisions int) *Mesh {
+	t := float32((1 + math.Sqrt(5)) / 2)
+
+	m := &Mesh{
+		Positions: []mgl32.Vec3{
+			{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+			{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+			{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+		},
+		Indices: []uint32{
+			0, 11, 5, 0, 5, 1, 0, 1, 7, 0, 7, 10, 0, 10, 11,
+			1, 5, 9, 5, 11, 4, 11, 10, 2, 10, 7, 6, 7, 1, 8,
+			3, 9, 4, 3, 4, 2, 3, 2, 6, 3, 6, 8, 3, 8, 9,
+			4, 9, 5, 2, 4, 11, 6, 2, 10, 8, 6, 7, 9, 8, 1,
+		},
+	}
+
+	for i := range m.Positions {
+		m.Positions[i] = m.Positions[i].Normalize()
+	}
+
+	for i := 0; i < subdivisions; i++ {
+		m.subdivide()
+	}
+
+	m.Normals = make([]mgl32.Vec3, len(m.Positions))
+	m.UVs = make([]mgl32.Vec2, len(m.Positions))
+
+	for i, p := range m.Positions {
+		m.Normals[i] = p
+		m.UVs[i] = sphereUV(p)
+		m.Positions[i] = p.Mul(radius)
+	}
+
+	m.fixSeam()
+
+	return m
+}
+
+// Cylinder returns a capped cylinder made of the given number of segments
+// around the Y axis.
+func Cylinder(radius, height float32, segments int) *Mesh {
+	m := &Mesh{}
+
+	m.lathe([]profilePoint{
+		{radius: radius, y: -height / 2, normal: mgl32.Vec2{1, 0}, v: 0},
+		{radius: radius, y: height / 2, normal: mgl32.Vec2{1, 0}, v: 1},
+	}, segments)
+
+	m.disc(mgl32.Vec3{0, height / 2, 0}, mgl32.Vec3{0, 0, 1}, mgl32.Vec3{1, 0, 0}, radius, segments)
+	m.disc(mgl32.Vec3{0, -height / 2, 0}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, 1}, radius, segments)
+
+	return m
+}
+
+// Cone returns a cone with its base at the bottom and its apex at the top,
+// made of the given number of segments around the Y axis.
+func Cone(radius, height float32, segments int) *Mesh {
+	m := &Mesh{}
+
+	n := mgl32.Vec2{height, radius}.Normalize()
+
+	m.lathe([]profilePoint{
+		{radius: radius, y: -height / 2, normal: n, v: 0},
+		{radius: 0, y: height / 2, normal: n, v: 1},
+	}, segments)
+
+	m.disc(mgl32.Vec3{0, -height / 2, 0}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 0, 1}, radius, segments)
+
+	return m
+}
+
+// Capsule returns a cylinder with hemispherical ends, made of the given
+// number of segments around the Y axis and rings per hemisphere. The height
+// includes both ends, and is at least twice the radius.
+func Capsule(radius, height float32, segments, rings int) *Mesh {
+	m := &Mesh{}
+
+	rings = atLeast(rings, 1)
+
+	half := float32(math.Max(float64(height/2-radius), 0))
+	length := math.Pi*radius + 2*half
+
+	var profile []profilePoint
+
+	for j := 0; j <= 2*rings; j++ {
+		theta := math.Pi / 2 * float64(j) / float64(rings)
+
+		s, c := sincos(theta)
+		switch j {
+		case 0:
+			s = 0
+		case rings:
+			c = 0
+		case 2 * rings:
+			s = 0
+		}
+
+		arc := radius * float32(theta)
+		if j >= rings {
+			arc += 2 * half
+		}
+
+		p := profilePoint{
+			radius: radius * s,
+			y:      -radius * c,
+			normal: mgl32.Vec2{s, -c},
+		}
+
+		if j < rings {
+			p.y -= half
+		} else if j > rings {
+			p.y += half
+		}
+
+		// The equator is split in to the two ends of the cylinder.
+		if j == rings && half > 0 {
+			bottom := p
+			bottom.y -= half
+			bottom.v = (arc - 2*half) / length
+			profile = append(profile, bottom)
+
+			p.y += half
+		}
+
+		p.v = arc / length
+		profile = append(profile, p)
+	}
+
+	m.lathe(profile, segments)
+
+	return m
+}
+
+// Torus returns a torus around the Y axis, with the given distance from the
+// center to the center of the tube and the radius of the tube. The tube is
+// made of the given number of segments around the Y axis and sides around
+// the tube.
+func Torus(radius, tube float32, segments, sides int) *Mesh {
+	m := &Mesh{}
+
+	sides = atLeast(sides, 3)
+
+	profile := make([]profilePoint, sides+1)
+	for j := range profile {
+		s, c := sincos(2 * math.Pi * float64(j) / float64(sides))
+
+		profile[j] = profilePoint{
+			radius: radius + tube*c,
+			y:      tube * s,
+			normal: mgl32.Vec2{c, s},
+			v:      float32(j) / float32(sides),
+		}
+	}
+
+	m.lathe(profile, segments)
+
+	return m
+}
+
+// grid adds a grid of vertices with the given number of columns and rows of
+// quads. The surface function returns the position and normal at the uv
+// coordinates, which must be such that the cross product of the u and v
+// directions points to the front. Triangles with coincident vertices, such
+// as those at poles, are skipped.
+func (m *Mesh) grid(cols, rows int, surface func(u, v float32) (mgl32.Vec3, mgl32.Vec3)) {
+	base := uint32(len(m.Positions))
+
+	for r := 0; r <= rows; r++ {
+		for c := 0; c <= cols; c++ {
+			u := float32(c) / float32(cols)
+			v := float32(r) / float32(rows)
+
+			p, n := surface(u, v)
+
+			m.Positions = append(m.Positions, p)
+			m.Normals = append(m.Normals, n)
+			m.UVs = append(m.UVs, mgl32.Vec2{u, v})
+		}
+	}
+
+	stride := uint32(cols + 1)
+
+	for r := uint32(0); r < uint32(rows); r++ {
+		for c := uint32(0); c < uint32(cols); c++ {
+			a := base + r*stride + c
+			b := a + 1
+			d := a + stride + 1
+			e := a + stride
+
+			m.triangle(a, b, d)
+			m.triangle(a, d, e)
+		}
+	}
+}
+
+// triangle adds a triangle unless two of its vertices coincide.
+func (m *Mesh) triangle(a, b, c uint32) {
+	pa, pb, pc := m.Positions[a], m.Positions[b], m.Positions[c]
+	if pa == pb || pb == pc || pc == pa {
+		return
+	}
+
+	m.Indices = append(m.Indices, a, b, c)
+}
+
+// lathe adds the surface swept by a profile, ordered from bottom to top,
+// around the Y axis in the given number of segments.
+func (m *Mesh) lathe(profile []profilePoint, segments int) {
+	segments = atLeast(segments, 3)
+
+	m.grid(segments, len(profile)-1, func(u, v float32) (mgl32.Vec3, mgl32.Vec3) {
+		p := profile[int(v*float32(len(profile)-1)+0.5)]
+
+		s, c := sincos(2 * math.Pi * float64(u))
+		if u == 1 {
+			s, c = 0, 1
+		}
+
+		return mgl32.Vec3{p.radius * s, p.y, p.radius * c},
+			normalize(mgl32.Vec3{p.normal[0] * s, p.normal[1], p.normal[0] * c})
+	})
+
+	// The grid spaces rows evenly; use the profile's own v coordinates.
+	base := len(m.UVs) - len(profile)*(segments+1)
+	for j, p := range profile {
+		for i := 0; i <= segments; i++ {
+			m.UVs[base+j*(segments+1)+i][1] = p.v
+		}
+	}
+}
+
+// disc adds a disc facing the cross product of the axes a and b, made of the
+// given number of segments. Its uvs map the disc in to the unit square.
+func (m *Mesh) disc(center, a, b mgl32.Vec3, radius float32, segments int) {
+	segments = atLeast(segments, 3)
+
+	n := a.Cross(b)
+	base := uint32(len(m.Positions))
+
+	m.Positions = append(m.Positions, center)
+	m.Normals = append(m.Normals, n)
+	m.UVs = append(m.UVs, mgl32.Vec2{0.5, 0.5})
+
+	for i := 0; i <= segments; i++ {
+		s, c := sincos(2 * math.Pi * float64(i) / float64(segments))
+
+		m.Positions = append(m.Positions, center.Add(a.Mul(c*radius)).Add(b.Mul(s*radius)))
+		m.Normals = append(m.Normals, n)
+		m.UVs = append(m.UVs, mgl32.Vec2{0.5 + c/2, 0.5 + s/2})
+	}
+
+	for i := uint32(1); i <= uint32(segments); i++ {
+		m.Indices = append(m.Indices, base, base+i, base+i+1)
+	}
+}
+
+// subdivide splits every triangle in to four, placing the new vertices on
+// the unit sphere.
+func (m *Mesh) subdivide() {
+	midpoints := make(map[[2]uint32]uint32)
+
+	midpoint := func(a, b uint32) uint32 {
+		k := [2]uint32{a, b}
+		if a > b {
+			k = [2]uint32{b, a}
+		}
+
+		if i, ok := midpoints[k]; ok {
+			return i
+		}
+
+		i := uint32(len(m.Positions))
+		m.Positions = append(m.Positions, m.Positions[a].Add(m.Positions[b]).Normalize())
+		midpoints[k] = i
+
+		return i
+	}
+
+	indices := make([]uint32, 0, len(m.Indices)*4)
+
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := m.Indices[i], m.Indices[i+1], m.Indices[i+2]
+		ab, bc, ca := midpoint(a, b), midpoint(b, c), midpoint(c, a)
+
+		indices = append(indices,
+			a, ab, ca,
+			b, bc, ab,
+			c, ca, bc,
+			ab, bc, ca,
+		)
+	}
+
+	m.Indices = indices
+}
+
+// fixSeam duplicates the vertices of triangles crossing the uv seam, moving
+// them by one in u, and gives pole vertices the u coordinate of the rest of
+// their triangle.
+func (m *Mesh) fixSeam() {
+	wrapped := make(map[uint32]uint32)
+
+	duplicate := func(i uint32, uv mgl32.Vec2) uint32 {
+		j := uint32(len(m.Positions))
+
+		m.Positions = append(m.Positions, m.Positions[i])
+		m.Normals = append(m.Normals, m.Normals[i])
+		m.UVs = append(m.UVs, uv)
+
+		return j
+	}
+
+	for t := 0; t+2 < len(m.Indices); t += 3 {
+		tri := m.Indices[t : t+3]
+
+		var lo, hi float32 = 1, 0
+		for _, i := range tri {
+			if isPole(m.Positions[i]) {
+				continue
+			}
+			lo = float32(math.Min(float64(lo), float64(m.UVs[i][0])))
+			hi = float32(math.Max(float64(hi), float64(m.UVs[i][0])))
+		}
+
+		if hi-lo > 0.5 {
+			for k, i := range tri {
+				if isPole(m.Positions[i]) || m.UVs[i][0] >= 0.5 {
+					continue
+				}
+
+				j, ok := wrapped[i]
+				if !ok {
+					j = duplicate(i, mgl32.Vec2{m.UVs[i][0] + 1, m.UVs[i][1]})
+					wrapped[i] = j
+				}
+				tri[k] = j
+			}
+		}
+
+		for k, i := range tri {
+			if !isPole(m.Positions[i]) {
+				continue
+			}
+
+			var u float32
+			for l, o := range tri {
+				if l != k {
+					u += m.UVs[o][0] / 2
+				}
+			}
+
+			tri[k] = duplicate(i, mgl32.Vec2{u, m.UVs[i][1]})
+		}
+	}
+}
+
+// sphereUV returns the uv coordinates of a point on the unit sphere, matching
+// those of UVSphere.
+func sphereUV(p mgl32.Vec3) mgl32.Vec2 {
+	u := math.Atan2(float64(p.X()), float64(p.Z())) / (2 * math.Pi)
+	if u < 0 {
+		u++
+	}
+
+	y := math.Max(-1, math.Min(1, float64(p.Y())))
+	v := 0.5 + math.Asin(y)/math.Pi
+
+	return mgl32.Vec2{float32(u), float32(v)}
+}
+
+func isPole(p mgl32.Vec3) bool {
+	return p.X() == 0 && p.Z() == 0
+}
+
+func sincos(a float64) (float32, float32) {
+	s, c := math.Sincos(a)
+
+	return float32(s), float32(c)
+}
+
+func atLeast(n, min int) int {
+	if n < min {
+		return min
+	}
+
+	return n
+}
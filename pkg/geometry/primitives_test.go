@@ -0,0 +1,197 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestPrimitives(t *testing.T) {
+	var tests = []struct {
+		name   string
+		mesh   *Mesh
+		bounds AABB
+		closed bool
+	}{
+		{
+			name:   "quad",
+			mesh:   Quad(2, 1),
+			bounds: AABB{Min: mgl32.Vec3{-1, -0.5, 0}, Max: mgl32.Vec3{1, 0.5, 0}},
+		},
+		{
+			name:   "plane",
+			mesh:   Plane(2, 4, 3),
+			bounds: AABB{Min: mgl32.Vec3{-1, 0, -2}, Max: mgl32.Vec3{1, 0, 2}},
+		},
+		{
+			name:   "cube",
+			mesh:   Cube(2),
+			bounds: AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}},
+			closed: true,
+		},
+		{
+			name:   "uv sphere",
+			mesh:   UVSphere(1, 16, 8),
+			bounds: AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}},
+			closed: true,
+		},
+		{
+			name:   "icosphere",
+			mesh:   Icosphere(1, 2),
+			bounds: AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}},
+			closed: true,
+		},
+		{
+			name:   "cylinder",
+			mesh:   Cylinder(1, 2, 16),
+			bounds: AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}},
+			closed: true,
+		},
+		{
+			name:   "cone",
+			mesh:   Cone(1, 2, 16),
+			bounds: AABB{Min: mgl32.Vec3{-1, -1, -1}, Max: mgl32.Vec3{1, 1, 1}},
+			closed: true,
+		},
+		{
+			name:   "capsule",
+			mesh:   Capsule(0.5, 3, 16, 4),
+			bounds: AABB{Min: mgl32.Vec3{-0.5, -1.5, -0.5}, Max: mgl32.Vec3{0.5, 1.5, 0.5}},
+			closed: true,
+		},
+		{
+			name:   "torus",
+			mesh:   Torus(1, 0.25, 24, 12),
+			bounds: AABB{Min: mgl32.Vec3{-1.25, -0.25, -1.25}, Max: mgl32.Vec3{1.25, 0.25, 1.25}},
+		},
+	}
+
+	for _, v := range tests {
+		m := v.mesh
+
+		if err := m.Validate(); err != nil || !m.Indexed() {
+			t.Errorf("%s: invalid mesh: %v", v.name, err)
+			continue
+		}
+		if len(m.Normals) == 0 || len(m.UVs) == 0 {
+			t.Errorf("%s: missing normals or uvs", v.name)
+			continue
+		}
+
+		b := m.Bounds()
+		if !b.Min.ApproxEqualThreshold(v.bounds.Min, 1e-4) || !b.Max.ApproxEqualThreshold(v.bounds.Max, 1e-4) {
+			t.Errorf("%s: bounds = %v, want %v", v.name, b, v.bounds)
+		}
+
+		for i, n := range m.Normals {
+			if abs(n.Len()-1) > epsilon {
+				t.Errorf("%s: normal %d = %v is not a unit vector", v.name, i, n)
+			}
+		}
+
+		for i, uv := range m.UVs {
+			if uv[0] < 0 || uv[0] > 1.5 || uv[1] < 0 || uv[1] > 1 {
+				t.Errorf("%s: uv %d = %v out of range", v.name, i, uv)
+			}
+		}
+
+		// Triangles face the same way as their vertex normals and, for
+		// closed shapes around the origin, away from the origin.
+		for i := 0; i < m.TriangleCount(); i++ {
+			a, b, c := m.Triangle(i)
+
+			fn, ok := faceNormal(m.Positions[a], m.Positions[b], m.Positions[c])
+			if !ok {
+				t.Errorf("%s: triangle %d is degenerate", v.name, i)
+				continue
+			}
+
+			vn := m.Normals[a].Add(m.Normals[b]).Add(m.Normals[c])
+			if fn.Dot(vn) <= 0 {
+				t.Errorf("%s: triangle %d faces %v, against its normals %v", v.name, i, fn, vn)
+			}
+
+			center := m.Positions[a].Add(m.Positions[b]).Add(m.Positions[c])
+			if v.closed && fn.Dot(center) <= 0 {
+				t.Errorf("%s: triangle %d faces %v, inwards", v.name, i, fn)
+			}
+		}
+
+		if err := m.GenerateTangents(); err != nil {
+			t.Errorf("%s: %v", v.name, err)
+		}
+	}
+}
+
+func TestPrimitives_Area(t *testing.T) {
+	var tests = []struct {
+		name string
+		mesh *Mesh
+		want float64
+	}{
+		{name: "plane", mesh: Plane(2, 3, 4), want: 6},
+		{name: "cube", mesh: Cube(2), want: 24},
+		{name: "uv sphere", mesh: UVSphere(1, 64, 32), want: 4 * math.Pi},
+		{name: "icosphere", mesh: Icosphere(1, 4), want: 4 * math.Pi},
+		{name: "cylinder", mesh: Cylinder(1, 2, 128), want: 6 * math.Pi},
+		{name: "capsule", mesh: Capsule(1, 4, 64, 16), want: 8 * math.Pi},
+		{name: "torus", mesh: Torus(2, 0.5, 128, 64), want: 4 * math.Pi * math.Pi * 2 * 0.5},
+	}
+
+	for _, v := range tests {
+		var area float64
+
+		for i := 0; i < v.mesh.TriangleCount(); i++ {
+			a, b, c := v.mesh.Triangle(i)
+			p := v.mesh.Positions
+			area += float64(p[b].Sub(p[a]).Cross(p[c].Sub(p[a])).Len()) / 2
+		}
+
+		if math.Abs(area-v.want)/v.want > 0.01 {
+			t.Errorf("%s: area = %f, want %f", v.name, area, v.want)
+		}
+	}
+}
+
+func TestIcosphere_Shared(t *testing.T) {
+	m := Icosphere(1, 1)
+
+	// 42 distinct points, with a few duplicates along the seam and poles.
+	if m.TriangleCount() != 80 || len(m.Positions) < 42 || len(m.Positions) > 60 {
+		t.Errorf("%d triangles and %d vertices", m.TriangleCount(), len(m.Positions))
+	}
+
+	// Every triangle spans less than half of the uv space.
+	for i := 0; i < m.TriangleCount(); i++ {
+		a, b, c := m.Triangle(i)
+		lo := math.Min(float64(m.UVs[a][0]), math.Min(float64(m.UVs[b][0]), float64(m.UVs[c][0])))
+		hi := math.Max(float64(m.UVs[a][0]), math.Max(float64(m.UVs[b][0]), float64(m.UVs[c][0])))
+
+		if hi-lo > 0.5 {
+			t.Errorf("triangle %d spans u from %f to %f", i, lo, hi)
+		}
+	}
+}
@@ -0,0 +1,546 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// borderWeight scales the planes which hold border edges in place, relative
+// to the planes of the triangles.
+const borderWeight = 10
+
+// Simplify returns a copy of the mesh reduced to at most target triangles,
+// or as close to it as the constraints allow, by collapsing edges in order
+// of their quadric error. Collapses costing more than maxError are not made
+// unless maxError is zero. Vertices keep their attributes: a collapse moves
+// all triangles of one vertex on to a neighbouring vertex.
+//
+// Vertices on UV or other attribute seams are never removed, and vertices on
+// the border of the mesh are only collapsed along the border, so seams and
+// outlines are preserved.
+func (m *Mesh) Simplify(target int, maxError float32) (*Mesh, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	out := m.clone()
+	if !out.Indexed() {
+		if _, err := out.Weld(0); err != nil {
+			return nil, err
+		}
+	}
+
+	if target < 0 {
+		target = 0
+	}
+	if out.TriangleCount() <= target {
+		return out, nil
+	}
+
+	s := newSimplifier(out)
+	s.run(target, float64(maxError))
+	s.finish()
+
+	return out, nil
+}
+
+// LODs returns a chain of simplified meshes with the given fractions of the
+// triangles of the mesh. Each level is simplified from the one before it, so
+// ratios should be decreasing.
+func (m *Mesh) LODs(ratios []float32, maxError float32) ([]*Mesh, error) {
+	count := m.TriangleCount()
+	lods := make([]*Mesh, 0, len(ratios))
+
+	src := m
+	for _, r := range ratios {
+		if r <= 0 || r > 1 {
+			return nil, fmt.Errorf("geometry: invalid lod ratio %v", r)
+		}
+
+		lod, err := src.Simplify(int(float32(count)*r), maxError)
+		if err != nil {
+			return nil, err
+		}
+
+		lods = append(lods, lod)
+		src = lod
+	}
+
+	return lods, nil
+}
+
+// clone returns a deep copy of the mesh.
+func (m *Mesh) clone() *Mesh {
+	order := make([]int, len(m.Positions))
+	for i := range order {
+		order[i] = i
+	}
+
+	c := *m
+	c.Targets = append([]MorphTarget(nil), m.Targets...)
+	c.reorder(order)
+	c.Indices = append([]uint32(nil), m.Indices...)
+	if len(c.Indices) == 0 {
+		c.Indices = nil
+	}
+
+	return &c
+}
+
+// quadric is a symmetric 4x4 matrix measuring the squared distance to a set
+// of planes.
+type quadric [10]float64
+
+func planeQuadric(n mgl32.Vec3, p mgl32.Vec3, w float64) quadric {
+	a, b, c := float64(n[0]), float64(n[1]), float64(n[2])
+	d := -(a*float64(p[0]) + b*float64(p[1]) + c*float64(p[2]))
+
+	return quadric{
+		a * a * w, a * b * w, a * c * w, a * d * w,
+		b * b * w, b * c * w, b * d * w,
+		c * c * w, c * d * w,
+		d * d * w,
+	}
+}
+
+func (q *quadric) add(o quadric) {
+	for i := range q {
+		q[i] += o[i]
+	}
+}
+
+// eval returns the error of the quadric at p.
+func (q *quadric) eval(p mgl32.Vec3) float64 {
+	x, y, z := float64(p[0]), float64(p[1]), float64(p[2])
+
+	e := q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+
+	return math.Max(e, 0)
+}
+
+// vertexKind constrains the collapses of a vertex.
+type vertexKind uint8
+
+const (
+	kindInterior vertexKind = iota
+	kindBorder
+	kindLocked
+)
+
+// collapse is a candidate collapse of vertex u on to vertex v.
+type collapse struct {
+	cost    float64
+	u, v    uint32
+	version uint32
+}
+
+type collapseHeap []collapse
+
+func (h collapseHeap) Len() int            { return len(h) }
+func (h collapseHeap) Less(i, j int) bool  { return h[i].cost < h[j].cost }
+func (h collapseHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *collapseHeap) Push(x interface{}) { *h = append(*h, x.(collapse)) }
+func (h *collapseHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// edgeKey is an undirected edge between two positions.
+type edgeKey [2]uint32
+
+func makeEdgeKey(a, b uint32) edgeKey {
+	if a > b {
+		a, b = b, a
+	}
+
+	return edgeKey{a, b}
+}
+
+// simplifier holds the state of a simplification.
+type simplifier struct {
+	m *Mesh
+
+	// group maps each vertex to the first vertex at the same position.
+	group []uint32
+	kind  []vertexKind
+
+	// border holds the edges between positions used by one triangle.
+	border map[edgeKey]bool
+
+	quadrics []quadric
+	version  []uint32
+	removed  []bool
+
+	tris  [][3]uint32
+	dead  []bool
+	alive int
+
+	// vtris lists the triangles around each vertex. Entries become stale as
+	// triangles die or move to other vertices.
+	vtris [][]int
+
+	queue collapseHeap
+}
+
+func newSimplifier(m *Mesh) *simplifier {
+	n := len(m.Positions)
+
+	s := &simplifier{
+		m:        m,
+		group:    make([]uint32, n),
+		kind:     make([]vertexKind, n),
+		border:   make(map[edgeKey]bool),
+		quadrics: make([]quadric, n),
+		version:  make([]uint32, n),
+		removed:  make([]bool, n),
+		vtris:    make([][]int, n),
+	}
+
+	// Vertices at the same position but with different attributes lie on
+	// a seam.
+	first := make(map[mgl32.Vec3]uint32, n)
+	for i, p := range m.Positions {
+		g, ok := first[p]
+		if !ok {
+			g = uint32(i)
+			first[p] = g
+		} else {
+			s.kind[g] = kindLocked
+			s.kind[i] = kindLocked
+		}
+		s.group[i] = g
+	}
+
+	edges := make(map[edgeKey]int)
+
+	for i := 0; i < m.TriangleCount(); i++ {
+		a, b, c := m.Triangle(i)
+		t := [3]uint32{a, b, c}
+
+		s.tris = append(s.tris, t)
+		s.dead = append(s.dead, false)
+		for _, v := range t {
+			s.vtris[v] = append(s.vtris[v], i)
+		}
+
+		for k := 0; k < 3; k++ {
+			edges[makeEdgeKey(s.group[t[k]], s.group[t[(k+1)%3]])]++
+		}
+
+		pa, pb, pc := m.Positions[a], m.Positions[b], m.Positions[c]
+		cross := pb.Sub(pa).Cross(pc.Sub(pa))
+		area := float64(cross.Len()) / 2
+		if area == 0 {
+			continue
+		}
+
+		q := planeQuadric(cross.Normalize(), pa, area)
+		for _, v := range t {
+			s.quadrics[v].add(q)
+		}
+	}
+	s.alive = len(s.tris)
+
+	for e, count := range edges {
+		if count == 1 {
+			s.border[e] = true
+		}
+	}
+
+	// Border edges are held in place by planes perpendicular to their
+	// triangles.
+	for i, t := range s.tris {
+		pa, pb, pc := m.Positions[t[0]], m.Positions[t[1]], m.Positions[t[2]]
+		n, ok := faceNormal(pa, pb, pc)
+		if !ok {
+			continue
+		}
+
+		for k := 0; k < 3; k++ {
+			u, v := t[k], t[(k+1)%3]
+			if !s.border[makeEdgeKey(s.group[u], s.group[v])] {
+				continue
+			}
+
+			pu, pv := m.Positions[u], m.Positions[v]
+			edge := pv.Sub(pu)
+			pn := edge.Cross(n)
+			if pn.Len() == 0 {
+				continue
+			}
+
+			q := planeQuadric(pn.Normalize(), pu, float64(edge.Dot(edge))*borderWeight)
+			s.quadrics[u].add(q)
+			s.quadrics[v].add(q)
+		}
+
+		for _, v := range t {
+			if s.kind[v] == kindInterior && s.onBorder(v, i) {
+				s.kind[v] = kindBorder
+			}
+		}
+	}
+
+	// Border vertices where the border turns or meets another border are
+	// corners of the outline, and stay in place.
+	ends := make(map[uint32][]uint32)
+	for e := range s.border {
+		ends[e[0]] = append(ends[e[0]], e[1])
+		ends[e[1]] = append(ends[e[1]], e[0])
+	}
+	for v, kind := range s.kind {
+		if kind != kindBorder {
+			continue
+		}
+
+		g := s.group[v]
+		e := ends[g]
+		if len(e) != 2 || !straight(m.Positions[e[0]], m.Positions[g], m.Positions[e[1]]) {
+			s.kind[v] = kindLocked
+		}
+	}
+
+	for v := range s.vtris {
+		s.push(uint32(v))
+	}
+
+	return s
+}
+
+// straight reports whether the path from a through p to b runs on in a
+// straight line at p.
+func straight(a, p, b mgl32.Vec3) bool {
+	da, db := p.Sub(a), b.Sub(p)
+
+	return da.Dot(db) > 0 && da.Cross(db).Len() <= 1e-6*da.Len()*db.Len()
+}
+
+// onBorder reports whether an edge of triangle i at vertex v is a border
+// edge.
+func (s *simplifier) onBorder(v uint32, i int) bool {
+	t := s.tris[i]
+
+	for k := 0; k < 3; k++ {
+		if t[k] == v || t[(k+1)%3] == v {
+			if s.border[makeEdgeKey(s.group[t[k]], s.group[t[(k+1)%3]])] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// push queues the collapses of vertex u on to its neighbours.
+func (s *simplifier) push(u uint32) {
+	if s.removed[u] || s.kind[u] == kindLocked {
+		return
+	}
+
+	seen := make(map[uint32]bool)
+
+	for _, i := range s.vtris[u] {
+		if s.dead[i] || !s.has(i, u) {
+			continue
+		}
+
+		for _, v := range s.tris[i] {
+			if v == u || seen[v] {
+				continue
+			}
+			seen[v] = true
+
+			if !s.allowed(u, v) {
+				continue
+			}
+
+			q := s.quadrics[u]
+			q.add(s.quadrics[v])
+
+			heap.Push(&s.queue, collapse{
+				cost:    q.eval(s.m.Positions[v]),
+				u:       u,
+				v:       v,
+				version: s.version[u],
+			})
+		}
+	}
+}
+
+// allowed reports whether u may be collapsed on to v. Border vertices only
+// move along the border.
+func (s *simplifier) allowed(u, v uint32) bool {
+	if s.group[u] == s.group[v] {
+		return false
+	}
+	if s.kind[u] == kindBorder {
+		return s.border[makeEdgeKey(s.group[u], s.group[v])]
+	}
+
+	return s.kind[u] == kindInterior
+}
+
+func (s *simplifier) has(i int, v uint32) bool {
+	t := s.tris[i]
+
+	return t[0] == v || t[1] == v || t[2] == v
+}
+
+// run collapses edges until the mesh has at most target triangles.
+func (s *simplifier) run(target int, maxError float64) {
+	for s.alive > target && s.queue.Len() > 0 {
+		c := heap.Pop(&s.queue).(collapse)
+
+		if s.removed[c.u] || s.removed[c.v] || c.version != s.version[c.u] {
+			continue
+		}
+		if maxError > 0 && c.cost > maxError {
+			break
+		}
+
+		if !s.valid(c.u, c.v) {
+			continue
+		}
+
+		s.collapse(c.u, c.v)
+	}
+}
+
+// valid reports whether collapsing u on to v keeps the orientation of the
+// remaining triangles around u.
+func (s *simplifier) valid(u, v uint32) bool {
+	p := s.m.Positions[v]
+
+	for _, i := range s.vtris[u] {
+		if s.dead[i] || !s.has(i, u) || s.has(i, v) {
+			continue
+		}
+
+		t := s.tris[i]
+		var before, after [3]mgl32.Vec3
+		for k, w := range t {
+			before[k] = s.m.Positions[w]
+			after[k] = before[k]
+			if w == u {
+				after[k] = p
+			}
+		}
+
+		n0, ok0 := faceNormal(before[0], before[1], before[2])
+		n1, ok1 := faceNormal(after[0], after[1], after[2])
+		if ok0 && (!ok1 || n0.Dot(n1) <= 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// collapse moves the triangles of u on to v, removing those which become
+// degenerate.
+func (s *simplifier) collapse(u, v uint32) {
+	for _, i := range s.vtris[u] {
+		if s.dead[i] || !s.has(i, u) {
+			continue
+		}
+
+		t := &s.tris[i]
+		for k := range t {
+			if t[k] == u {
+				t[k] = v
+			}
+		}
+
+		ga, gb, gc := s.group[t[0]], s.group[t[1]], s.group[t[2]]
+		if ga == gb || gb == gc || ga == gc {
+			s.dead[i] = true
+			s.alive--
+			continue
+		}
+
+		s.vtris[v] = append(s.vtris[v], i)
+	}
+
+	s.removed[u] = true
+	s.vtris[u] = nil
+	s.quadrics[v].add(s.quadrics[u])
+
+	// The costs of the collapses around v have changed. They are queued in
+	// the order of the triangles, so that ties are broken the same way on
+	// every run.
+	neighbours := []uint32{v}
+	seen := map[uint32]bool{v: true}
+	for _, i := range s.vtris[v] {
+		if s.dead[i] || !s.has(i, v) {
+			continue
+		}
+		for _, w := range s.tris[i] {
+			if !seen[w] {
+				seen[w] = true
+				neighbours = append(neighbours, w)
+			}
+		}
+	}
+
+	for _, w := range neighbours {
+		s.version[w]++
+		s.push(w)
+	}
+}
+
+// finish writes the remaining triangles to the mesh, dropping unused
+// vertices.
+func (s *simplifier) finish() {
+	remap := make(map[uint32]uint32)
+	var order []int
+	var indices []uint32
+
+	for i, t := range s.tris {
+		if s.dead[i] {
+			continue
+		}
+
+		for _, v := range t {
+			j, ok := remap[v]
+			if !ok {
+				j = uint32(len(order))
+				remap[v] = j
+				order = append(order, int(v))
+			}
+			indices = append(indices, j)
+		}
+	}
+
+	s.m.reorder(order)
+	s.m.Indices = indices
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func area(m *Mesh) float64 {
+	var a float64
+
+	for i := 0; i < m.TriangleCount(); i++ {
+		x, y, z := m.Triangle(i)
+		p := m.Positions
+		a += float64(p[y].Sub(p[x]).Cross(p[z].Sub(p[x])).Len()) / 2
+	}
+
+	return a
+}
+
+func TestMesh_Simplify(t *testing.T) {
+	// A flat grid collapses to a handful of triangles without losing its
+	// outline.
+	m := Plane(2, 2, 15)
+	count, vertices := m.TriangleCount(), len(m.Positions)
+	target := count / 10
+
+	s, err := m.Simplify(target, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if s.TriangleCount() > target {
+		t.Errorf("plane: %d triangles, want at most %d", s.TriangleCount(), target)
+	}
+	if a := area(s); math.Abs(a-4) > 1e-3 {
+		t.Errorf("plane: area = %f, want 4", a)
+	}
+	if s.Bounds() != m.Bounds() {
+		t.Errorf("plane: bounds = %v, want %v", s.Bounds(), m.Bounds())
+	}
+	if m.TriangleCount() != count || len(m.Positions) != vertices {
+		t.Error("plane: source mesh was modified")
+	}
+
+	// Vertices of a curved surface stay on the surface.
+	m = UVSphere(1, 32, 16)
+	target = m.TriangleCount() / 2
+
+	s, err = m.Simplify(target, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.TriangleCount() > target {
+		t.Errorf("sphere: %d triangles, want at most %d", s.TriangleCount(), target)
+	}
+	for i, p := range s.Positions {
+		if math.Abs(float64(p.Len())-1) > 1e-4 {
+			t.Errorf("sphere: vertex %d off the surface at %v", i, p)
+		}
+	}
+	if a := area(s); math.Abs(a-4*math.Pi)/(4*math.Pi) > 0.1 {
+		t.Errorf("sphere: area = %f, want about %f", a, 4*math.Pi)
+	}
+
+	// A small error bound stops the collapse of curved regions.
+	bounded, err := m.Simplify(0, 1e-6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounded.TriangleCount() < target {
+		t.Errorf("sphere: %d triangles with a small error bound", bounded.TriangleCount())
+	}
+
+	// Every vertex of a cube lies on a normal seam.
+	m = Cube(1)
+	if s, err = m.Simplify(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if s.TriangleCount() != 12 {
+		t.Errorf("cube: %d triangles, want 12", s.TriangleCount())
+	}
+}
+
+func TestMesh_SimplifyCorners(t *testing.T) {
+	// Simplifying as far as possible keeps the corners of the outline.
+	m := Plane(2, 2, 9)
+
+	s, err := m.Simplify(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []mgl32.Vec3{{-1, 0, -1}, {1, 0, -1}, {-1, 0, 1}, {1, 0, 1}} {
+		found := false
+		for _, p := range s.Positions {
+			found = found || p.ApproxEqual(c)
+		}
+		if !found {
+			t.Errorf("corner %v removed", c)
+		}
+	}
+	if a := area(s); math.Abs(a-4) > 1e-3 {
+		t.Errorf("area = %f, want 4", a)
+	}
+}
+
+func TestMesh_SimplifySeams(t *testing.T) {
+	// Split the uvs of a grid along x = 0, making a seam across it.
+	m := Plane(2, 2, 15)
+	m.Unweld()
+	for i := 0; i < len(m.Positions); i += 3 {
+		c := m.Positions[i].Add(m.Positions[i+1]).Add(m.Positions[i+2])
+		if c.X() > 0 {
+			for k := i; k < i+3; k++ {
+				m.UVs[k] = m.UVs[k].Add(mgl32.Vec2{10, 0})
+			}
+		}
+	}
+
+	seam := func(m *Mesh) map[mgl32.Vec3]int {
+		points := make(map[mgl32.Vec3]int)
+		for _, p := range m.Positions {
+			if p.X() == 0 {
+				points[p]++
+			}
+		}
+		return points
+	}
+	want := seam(m)
+
+	s, err := m.Simplify(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := seam(s)
+	if len(got) != len(want) {
+		t.Errorf("%d points on the seam, want %d", len(got), len(want))
+	}
+	for p, n := range got {
+		if n != 2 && p.Z() != 1 && p.Z() != -1 {
+			t.Errorf("seam point %v has %d vertices, want 2", p, n)
+		}
+	}
+	if a := area(s); math.Abs(a-4) > 1e-3 {
+		t.Errorf("area = %f, want 4", a)
+	}
+	if s.TriangleCount() >= m.TriangleCount()/4 {
+		t.Errorf("%d triangles left of %d", s.TriangleCount(), m.TriangleCount())
+	}
+}
+
+func TestMesh_LODs(t *testing.T) {
+	m := Icosphere(1, 3)
+
+	lods, err := m.LODs([]float32{0.5, 0.25, 0.1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lods) != 3 {
+		t.Fatalf("%d lods, want 3", len(lods))
+	}
+
+	prev := m.TriangleCount()
+	for i, lod := range lods {
+		if err := lod.Validate(); err != nil {
+			t.Errorf("lod %d: %v", i, err)
+		}
+		if lod.TriangleCount() >= prev {
+			t.Errorf("lod %d: %d triangles, previous level has %d", i, lod.TriangleCount(), prev)
+		}
+		prev = lod.TriangleCount()
+	}
+
+	if _, err := m.LODs([]float32{0}, 0); err == nil {
+		t.Error("expected error for zero ratio")
+	}
+}
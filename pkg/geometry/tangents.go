@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// GenerateTangents generates tangents from the normals and uvs of the mesh,
+// following the conventions of MikkTSpace: the tangent points along
+// increasing u, contributions of each triangle are weighted by its angle at
+// the vertex and projected on to the tangent plane, the result is
+// orthogonalized against the normal, and w holds the sign of the bitangent.
+// Shaders reconstruct the bitangent as w * cross(normal, tangent).
+func (m *Mesh) GenerateTangents() error {
+	if len(m.Normals) != len(m.Positions) {
+		return ErrMissingNormals
+	}
+	if len(m.UVs) != len(m.Positions) {
+		return ErrMissingUVs
+	}
+
+	tan := make([]mgl32.Vec3, len(m.Positions))
+	bit := make([]mgl32.Vec3, len(m.Positions))
+
+	for i := 0; i < m.TriangleCount(); i++ {
+		a, b, c := m.Triangle(i)
+		idx := [3]uint32{a, b, c}
+
+		p0, p1, p2 := m.Positions[a], m.Positions[b], m.Positions[c]
+		t0, t1, t2 := m.UVs[a], m.UVs[b], m.UVs[c]
+
+		e1, e2 := p1.Sub(p0), p2.Sub(p0)
+		d1, d2 := t1.Sub(t0), t2.Sub(t0)
+
+		r := d1[0]*d2[1] - d2[0]*d1[1]
+		if r == 0 {
+			continue
+		}
+
+		s := e1.Mul(d2[1]).Sub(e2.Mul(d1[1])).Mul(1 / r)
+		t := e2.Mul(d1[0]).Sub(e1.Mul(d2[0])).Mul(1 / r)
+
+		for j, w := range cornerAngles(p0, p1, p2) {
+			v := idx[j]
+			n := m.Normals[v]
+
+			tan[v] = tan[v].Add(normalize(project(s, n)).Mul(w))
+			bit[v] = bit[v].Add(normalize(project(t, n)).Mul(w))
+		}
+	}
+
+	m.Tangents = make([]mgl32.Vec4, len(m.Positions))
+
+	for i, n := range m.Normals {
+		t := normalize(project(tan[i], n))
+		if t.Len() == 0 {
+			t = perpendicular(n)
+		}
+
+		w := float32(1)
+		if n.Cross(t).Dot(bit[i]) < 0 {
+			w = -1
+		}
+
+		m.Tangents[i] = mgl32.Vec4{t[0], t[1], t[2], w}
+	}
+
+	return nil
+}
+
+// project removes the component of v along the unit vector n.
+func project(v, n mgl32.Vec3) mgl32.Vec3 {
+	return v.Sub(n.Mul(n.Dot(v)))
+}
+
+// perpendicular returns a unit vector perpendicular to n, used where the
+// uvs do not define a tangent.
+func perpendicular(n mgl32.Vec3) mgl32.Vec3 {
+	axis := mgl32.Vec3{1, 0, 0}
+	if abs(n[0]) > 0.9 {
+		axis = mgl32.Vec3{0, 1, 0}
+	}
+
+	return normalize(project(axis, n))
+}
+
+func abs(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+
+	return f
+}
@@ -0,0 +1,210 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package geometry
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// cell is a cell of the grid used to find nearby vertices.
+type cell [3]int64
+
+// Weld merges vertices whose attributes all differ by no more than epsilon,
+// making the mesh indexed. With an epsilon of zero only identical vertices
+// are merged. Vertices are only merged if their morph target deltas match as
+// well. It returns the number of vertices removed.
+func (m *Mesh) Weld(epsilon float32) (int, error) {
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
+
+	count := len(m.Positions)
+
+	remap := make([]uint32, count)
+	var keep []int
+
+	if epsilon <= 0 {
+		seen := make(map[vertex][]uint32, count)
+
+		for i := 0; i < count; i++ {
+			v := m.vertex(i)
+			if j, ok := m.findMorph(seen[v], keep, i, 0); ok {
+				remap[i] = j
+				continue
+			}
+
+			remap[i] = uint32(len(keep))
+			seen[v] = append(seen[v], remap[i])
+			keep = append(keep, i)
+		}
+	} else {
+		grid := make(map[cell][]uint32)
+
+		for i := 0; i < count; i++ {
+			c := cellOf(m.Positions[i], epsilon)
+
+			if j, ok := m.findNear(grid, keep, c, i, epsilon); ok {
+				remap[i] = j
+				continue
+			}
+
+			remap[i] = uint32(len(keep))
+			grid[c] = append(grid[c], remap[i])
+			keep = append(keep, i)
+		}
+	}
+
+	indices := m.Indices
+	if !m.Indexed() {
+		indices = make([]uint32, count)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+
+	m.Indices = make([]uint32, len(indices))
+	for i, idx := range indices {
+		m.Indices[i] = remap[idx]
+	}
+
+	m.reorder(keep)
+
+	return count - len(keep), nil
+}
+
+// vertex holds all attributes of a vertex.
+type vertex struct {
+	p  mgl32.Vec3
+	n  mgl32.Vec3
+	t  mgl32.Vec2
+	g  mgl32.Vec4
+	c  mgl32.Vec4
+	t2 mgl32.Vec2
+	j  [4]uint16
+	w  mgl32.Vec4
+}
+
+func (m *Mesh) vertex(i int) vertex {
+	v := vertex{p: m.Positions[i]}
+	if m.Normals != nil {
+		v.n = m.Normals[i]
+	}
+	if m.UVs != nil {
+		v.t = m.UVs[i]
+	}
+	if m.Tangents != nil {
+		v.g = m.Tangents[i]
+	}
+	if m.Colors != nil {
+		v.c = m.Colors[i]
+	}
+	if m.UV2s != nil {
+		v.t2 = m.UV2s[i]
+	}
+	if m.Joints != nil {
+		v.j = m.Joints[i]
+	}
+	if m.Weights != nil {
+		v.w = m.Weights[i]
+	}
+
+	return v
+}
+
+// findNear finds a kept vertex close to vertex i in the cells around c.
+func (m *Mesh) findNear(grid map[cell][]uint32, keep []int, c cell, i int, epsilon float32) (uint32, bool) {
+	v := m.vertex(i)
+
+	for x := c[0] - 1; x <= c[0]+1; x++ {
+		for y := c[1] - 1; y <= c[1]+1; y++ {
+			for z := c[2] - 1; z <= c[2]+1; z++ {
+				for _, j := range grid[cell{x, y, z}] {
+					if near(v, m.vertex(keep[j]), epsilon) && m.morphNear(i, keep[j], epsilon) {
+						return j, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// findMorph finds a kept vertex among the candidates whose morph target
+// deltas are close to those of vertex i.
+func (m *Mesh) findMorph(candidates []uint32, keep []int, i int, epsilon float32) (uint32, bool) {
+	for _, j := range candidates {
+		if m.morphNear(i, keep[j], epsilon) {
+			return j, true
+		}
+	}
+
+	return 0, false
+}
+
+// morphNear reports whether the morph target deltas of two vertices differ
+// by no more than epsilon.
+func (m *Mesh) morphNear(a, b int, epsilon float32) bool {
+	for _, t := range m.Targets {
+		for i := 0; i < 3; i++ {
+			if abs(t.Positions[a][i]-t.Positions[b][i]) > epsilon {
+				return false
+			}
+			if t.Normals != nil && abs(t.Normals[a][i]-t.Normals[b][i]) > epsilon {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func cellOf(p mgl32.Vec3, size float32) cell {
+	return cell{
+		int64(math.Floor(float64(p[0] / size))),
+		int64(math.Floor(float64(p[1] / size))),
+		int64(math.Floor(float64(p[2] / size))),
+	}
+}
+
+func near(a, b vertex, epsilon float32) bool {
+	for i := 0; i < 3; i++ {
+		if abs(a.p[i]-b.p[i]) > epsilon || abs(a.n[i]-b.n[i]) > epsilon {
+			return false
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if abs(a.t[i]-b.t[i]) > epsilon || abs(a.t2[i]-b.t2[i]) > epsilon {
+			return false
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if abs(a.g[i]-b.g[i]) > epsilon || abs(a.c[i]-b.c[i]) > epsilon || abs(a.w[i]-b.w[i]) > epsilon {
+			return false
+		}
+	}
+
+	return a.j == b.j
+}
@@ -0,0 +1,387 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gltf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// maxZeroComponents limits the components of accessors without a buffer
+// view, which a document declares without holding their data.
+const maxZeroComponents = 1 << 24
+
+// Components returns the number of components of an accessor type.
+func Components(accessorType string) int {
+	switch accessorType {
+	case "SCALAR":
+		return 1
+	case "VEC2":
+		return 2
+	case "VEC3":
+		return 3
+	case "VEC4", "MAT2":
+		return 4
+	case "MAT3":
+		return 9
+	case "MAT4":
+		return 16
+	}
+
+	return 0
+}
+
+// componentSize returns the size in bytes of a component type.
+func componentSize(componentType int) int {
+	switch componentType {
+	case ComponentByte, ComponentUnsignedByte:
+		return 1
+	case ComponentShort, ComponentUnsignedShort:
+		return 2
+	case ComponentUnsignedInt, ComponentFloat:
+		return 4
+	}
+
+	return 0
+}
+
+// Floats reads the elements of an accessor as floats, returning the flat
+// component data and the number of components per element. Normalized
+// integer components are mapped to [0, 1] or [-1, 1].
+func (d *Document) Floats(i int) ([]float32, int, error) {
+	if i < 0 || i >= len(d.Accessors) {
+		return nil, 0, fmt.Errorf("gltf: accessor %d out of range", i)
+	}
+
+	a := d.Accessors[i]
+
+	n := Components(a.Type)
+	size := componentSize(a.ComponentType)
+	if n == 0 || size == 0 {
+		return nil, 0, fmt.Errorf("gltf: accessor %d has invalid type %s/%d", i, a.Type, a.ComponentType)
+	}
+
+	var data []byte
+	var stride int
+
+	if a.BufferView != nil {
+		var err error
+		if data, stride, err = d.elements(a, *a.BufferView, n*size); err != nil {
+			return nil, 0, err
+		}
+	} else if a.Count < 0 || a.Count > maxZeroComponents/n {
+		return nil, 0, fmt.Errorf("gltf: accessor %d has invalid count %d", i, a.Count)
+	}
+
+	out := make([]float32, a.Count*n)
+
+	if a.BufferView != nil {
+		for e := 0; e < a.Count; e++ {
+			for c := 0; c < n; c++ {
+				out[e*n+c] = component(data[e*stride+c*size:], a.ComponentType, a.Normalized)
+			}
+		}
+	}
+
+	if a.Sparse != nil {
+		if err := d.applySparse(a, out, n, size); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return out, n, nil
+}
+
+// Indices reads an accessor of unsigned integers, such as the indices of a
+// primitive or the joint indices of a skin.
+func (d *Document) Indices(i int) ([]uint32, error) {
+	if i < 0 || i >= len(d.Accessors) {
+		return nil, fmt.Errorf("gltf: accessor %d out of range", i)
+	}
+
+	a := d.Accessors[i]
+
+	switch a.ComponentType {
+	case ComponentUnsignedByte, ComponentUnsignedShort, ComponentUnsignedInt:
+	default:
+		return nil, fmt.Errorf("gltf: accessor %d is not unsigned", i)
+	}
+
+	// Sparse values are applied as floats, which are exact below 2^24.
+	if a.Sparse != nil || a.BufferView == nil {
+		f, _, err := d.Floats(i)
+		if err != nil {
+			return nil, err
+		}
+
+		out := make([]uint32, len(f))
+		for j := range f {
+			out[j] = uint32(f[j])
+		}
+
+		return out, nil
+	}
+
+	n := Components(a.Type)
+	if n == 0 {
+		return nil, fmt.Errorf("gltf: accessor %d has invalid type %s", i, a.Type)
+	}
+
+	size := componentSize(a.ComponentType)
+
+	data, stride, err := d.elements(a, *a.BufferView, n*size)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]uint32, a.Count*n)
+
+	for e := 0; e < a.Count; e++ {
+		for c := 0; c < n; c++ {
+			b := data[e*stride+c*size:]
+
+			switch a.ComponentType {
+			case ComponentUnsignedByte:
+				out[e*n+c] = uint32(b[0])
+			case ComponentUnsignedShort:
+				out[e*n+c] = uint32(binary.LittleEndian.Uint16(b))
+			case ComponentUnsignedInt:
+				out[e*n+c] = binary.LittleEndian.Uint32(b)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// Vec2s reads an accessor of VEC2 elements.
+func (d *Document) Vec2s(i int) ([]mgl32.Vec2, error) {
+	f, err := d.typed(i, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]mgl32.Vec2, len(f)/2)
+	for j := range out {
+		out[j] = mgl32.Vec2{f[j*2], f[j*2+1]}
+	}
+
+	return out, nil
+}
+
+// Vec3s reads an accessor of VEC3 elements.
+func (d *Document) Vec3s(i int) ([]mgl32.Vec3, error) {
+	f, err := d.typed(i, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]mgl32.Vec3, len(f)/3)
+	for j := range out {
+		out[j] = mgl32.Vec3{f[j*3], f[j*3+1], f[j*3+2]}
+	}
+
+	return out, nil
+}
+
+// Vec4s reads an accessor of VEC4 elements.
+func (d *Document) Vec4s(i int) ([]mgl32.Vec4, error) {
+	f, err := d.typed(i, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]mgl32.Vec4, len(f)/4)
+	for j := range out {
+		out[j] = mgl32.Vec4{f[j*4], f[j*4+1], f[j*4+2], f[j*4+3]}
+	}
+
+	return out, nil
+}
+
+// Mat4s reads an accessor of column-major MAT4 elements.
+func (d *Document) Mat4s(i int) ([]mgl32.Mat4, error) {
+	f, err := d.typed(i, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]mgl32.Mat4, len(f)/16)
+	for j := range out {
+		copy(out[j][:], f[j*16:(j+1)*16])
+	}
+
+	return out, nil
+}
+
+func (d *Document) typed(i, components int) ([]float32, error) {
+	f, n, err := d.Floats(i)
+	if err != nil {
+		return nil, err
+	}
+	if n != components {
+		return nil, fmt.Errorf("gltf: accessor %d has %d components, expected %d", i, n, components)
+	}
+
+	return f, nil
+}
+
+// elements returns the data of an accessor and the stride between its
+// elements.
+func (d *Document) elements(a Accessor, view, elemSize int) ([]byte, int, error) {
+	data, err := d.view(view)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stride := d.BufferViews[view].ByteStride
+	if stride == 0 {
+		stride = elemSize
+	}
+
+	if a.Count < 0 || a.ByteOffset < 0 || stride < 0 {
+		return nil, 0, fmt.Errorf("gltf: accessor of buffer view %d has negative count, offset or stride", view)
+	}
+
+	// The last element must end within the view.
+	if a.ByteOffset > len(data) {
+		return nil, 0, fmt.Errorf("gltf: accessor exceeds buffer view %d", view)
+	}
+	data = data[a.ByteOffset:]
+	if a.Count > 0 && (elemSize > len(data) || a.Count-1 > (len(data)-elemSize)/stride) {
+		return nil, 0, fmt.Errorf("gltf: accessor exceeds buffer view %d", view)
+	}
+
+	return data, stride, nil
+}
+
+func (d *Document) applySparse(a Accessor, out []float32, n, size int) error {
+	s := a.Sparse
+
+	idxSize := componentSize(s.Indices.ComponentType)
+	if idxSize == 0 {
+		return fmt.Errorf("gltf: invalid sparse index type %d", s.Indices.ComponentType)
+	}
+
+	idx, err := d.view(s.Indices.BufferView)
+	if err != nil {
+		return err
+	}
+	val, err := d.view(s.Values.BufferView)
+	if err != nil {
+		return err
+	}
+
+	if s.Count < 0 || s.Count > a.Count {
+		return fmt.Errorf("gltf: invalid sparse count %d", s.Count)
+	}
+	if s.Indices.ByteOffset < 0 || s.Indices.ByteOffset > len(idx) || s.Values.ByteOffset < 0 || s.Values.ByteOffset > len(val) {
+		return fmt.Errorf("gltf: sparse data exceeds buffer view")
+	}
+
+	idx = idx[s.Indices.ByteOffset:]
+	val = val[s.Values.ByteOffset:]
+
+	if s.Count > len(idx)/idxSize || s.Count > len(val)/(n*size) {
+		return fmt.Errorf("gltf: sparse data exceeds buffer view")
+	}
+
+	for e := 0; e < s.Count; e++ {
+		target := int(component(idx[e*idxSize:], s.Indices.ComponentType, false))
+		if target >= a.Count {
+			return fmt.Errorf("gltf: sparse index %d out of range", target)
+		}
+
+		for c := 0; c < n; c++ {
+			out[target*n+c] = component(val[(e*n+c)*size:], a.ComponentType, a.Normalized)
+		}
+	}
+
+	return nil
+}
+
+func component(b []byte, componentType int, normalized bool) float32 {
+	switch componentType {
+	case ComponentByte:
+		v := float32(int8(b[0]))
+		if normalized {
+			return float32(math.Max(float64(v/127), -1))
+		}
+		return v
+	case ComponentUnsignedByte:
+		v := float32(b[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case ComponentShort:
+		v := float32(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return float32(math.Max(float64(v/32767), -1))
+		}
+		return v
+	case ComponentUnsignedShort:
+		v := float32(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case ComponentUnsignedInt:
+		return float32(binary.LittleEndian.Uint32(b))
+	case ComponentFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+
+	return 0
+}
+
+// Triangulate converts the indices of a triangle strip or fan in to a
+// triangle list. Triangle lists are returned as is.
+func Triangulate(mode int, indices []uint32) ([]uint32, error) {
+	switch mode {
+	case ModeTriangles:
+		return indices, nil
+	case ModeTriangleStrip:
+		var out []uint32
+		for i := 2; i < len(indices); i++ {
+			// Every other triangle is flipped to keep the winding.
+			if i%2 == 0 {
+				out = append(out, indices[i-2], indices[i-1], indices[i])
+			} else {
+				out = append(out, indices[i-1], indices[i-2], indices[i])
+			}
+		}
+		return out, nil
+	case ModeTriangleFan:
+		var out []uint32
+		for i := 2; i < len(indices); i++ {
+			out = append(out, indices[0], indices[i-1], indices[i])
+		}
+		return out, nil
+	}
+
+	return nil, fmt.Errorf("gltf: mode %d is not a triangle mode", mode)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	glbMagic      = 0x46546C67 // "glTF"
+	glbVersion    = 2
+	glbHeaderLen  = 12
+	glbChunkJSON  = 0x4E4F534A // "JSON"
+	glbChunkBIN   = 0x004E4942 // "BIN\x00"
+	dataURIPrefix = "data:"
+)
+
+// Decoding errors.
+var (
+	ErrInvalidGLB         = errors.New("gltf: invalid glb container")
+	ErrUnsupportedVersion = errors.New("gltf: unsupported version")
+	ErrBufferNotResolved  = errors.New("gltf: buffer not resolved")
+)
+
+// Decode decodes a glTF document, given either as JSON or as a binary .glb
+// container. Buffers must be resolved before accessors can be read.
+func Decode(data []byte) (*Document, error) {
+	d := &Document{}
+
+	if IsGLB(data) {
+		var err error
+		if data, d.bin, err = splitGLB(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(d.Asset.Version, "2.") {
+		return nil, ErrUnsupportedVersion
+	}
+
+	return d, nil
+}
+
+// IsGLB reports whether data starts with the header of a .glb container.
+func IsGLB(data []byte) bool {
+	return len(data) >= glbHeaderLen && binary.LittleEndian.Uint32(data) == glbMagic
+}
+
+// splitGLB returns the JSON and binary chunks of a .glb container.
+func splitGLB(data []byte) (jsonChunk, binChunk []byte, err error) {
+	if binary.LittleEndian.Uint32(data[4:]) != glbVersion {
+		return nil, nil, ErrUnsupportedVersion
+	}
+
+	length := binary.LittleEndian.Uint32(data[8:])
+	if length < glbHeaderLen || uint64(length) > uint64(len(data)) {
+		return nil, nil, ErrInvalidGLB
+	}
+
+	data = data[glbHeaderLen:length]
+
+	for len(data) >= 8 {
+		chunkLen := binary.LittleEndian.Uint32(data)
+		chunkType := binary.LittleEndian.Uint32(data[4:])
+
+		if uint64(chunkLen) > uint64(len(data)-8) {
+			return nil, nil, ErrInvalidGLB
+		}
+
+		chunk := data[8 : 8+chunkLen]
+
+		switch chunkType {
+		case glbChunkJSON:
+			jsonChunk = chunk
+		case glbChunkBIN:
+			if binChunk == nil {
+				binChunk = chunk
+			}
+		}
+
+		data = data[8+chunkLen:]
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, ErrInvalidGLB
+	}
+
+	return jsonChunk, binChunk, nil
+}
+
+// ResolveBuffers loads the contents of every buffer. Data URIs are decoded,
+// buffers without a URI refer to the binary chunk of a .glb container, and
+// any other URI is passed to open, which is usually relative to the document.
+func (d *Document) ResolveBuffers(open func(uri string) ([]byte, error)) error {
+	d.data = make([][]byte, len(d.Buffers))
+
+	for i, b := range d.Buffers {
+		var data []byte
+		var err error
+
+		switch {
+		case b.URI == "":
+			if d.bin == nil {
+				return fmt.Errorf("gltf: buffer %d has no data", i)
+			}
+			data = d.bin
+		case IsDataURI(b.URI):
+			data, err = DecodeDataURI(b.URI)
+		default:
+			var uri string
+			if uri, err = url.PathUnescape(b.URI); err == nil {
+				data, err = open(uri)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("gltf: buffer %d is %d bytes, expected %d", i, len(data), b.ByteLength)
+		}
+
+		d.data[i] = data
+	}
+
+	return nil
+}
+
+// ImageData returns the encoded data of an image stored in a buffer view or
+// data URI. Images referring to external files return a nil slice.
+func (d *Document) ImageData(i int) ([]byte, error) {
+	if i < 0 || i >= len(d.Images) {
+		return nil, fmt.Errorf("gltf: image %d out of range", i)
+	}
+
+	img := d.Images[i]
+
+	switch {
+	case img.BufferView != nil:
+		return d.view(*img.BufferView)
+	case IsDataURI(img.URI):
+		return DecodeDataURI(img.URI)
+	}
+
+	return nil, nil
+}
+
+// IsDataURI reports whether the URI embeds its data.
+func IsDataURI(uri string) bool {
+	return strings.HasPrefix(uri, dataURIPrefix)
+}
+
+// DecodeDataURI decodes the data of a base64 encoded data URI.
+func DecodeDataURI(uri string) ([]byte, error) {
+	i := strings.IndexByte(uri, ',')
+	if !IsDataURI(uri) || i < 0 || !strings.HasSuffix(uri[:i], ";base64") {
+		return nil, fmt.Errorf("gltf: unsupported data uri")
+	}
+
+	return base64.StdEncoding.DecodeString(uri[i+1:])
+}
+
+// view returns the bytes of a buffer view.
+func (d *Document) view(i int) ([]byte, error) {
+	if i < 0 || i >= len(d.BufferViews) {
+		return nil, fmt.Errorf("gltf: buffer view %d out of range", i)
+	}
+
+	v := d.BufferViews[i]
+
+	if v.Buffer < 0 || v.Buffer >= len(d.data) || d.data[v.Buffer] == nil {
+		return nil, ErrBufferNotResolved
+	}
+
+	data := d.data[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset > len(data) || v.ByteLength > len(data)-v.ByteOffset {
+		return nil, fmt.Errorf("gltf: buffer view %d exceeds buffer %d", i, v.Buffer)
+	}
+
+	return data[v.ByteOffset : v.ByteOffset+v.ByteLength], nil
+}
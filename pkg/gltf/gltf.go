@@ -0,0 +1,406 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gltf
+
+import (
+	"encoding/json"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Primitive modes.
+const (
+	ModePoints = iota
+	ModeLines
+	ModeLineLoop
+	ModeLineStrip
+	ModeTriangles
+	ModeTriangleStrip
+	ModeTriangleFan
+)
+
+// Accessor component types.
+const (
+	ComponentByte          = 5120
+	ComponentUnsignedByte  = 5121
+	ComponentShort         = 5122
+	ComponentUnsignedShort = 5123
+	ComponentUnsignedInt   = 5125
+	ComponentFloat         = 5126
+)
+
+// Well known attribute semantics.
+const (
+	AttributePosition  = "POSITION"
+	AttributeNormal    = "NORMAL"
+	AttributeTangent   = "TANGENT"
+	AttributeTexCoord0 = "TEXCOORD_0"
+	AttributeTexCoord1 = "TEXCOORD_1"
+	AttributeColor0    = "COLOR_0"
+	AttributeJoints0   = "JOINTS_0"
+	AttributeWeights0  = "WEIGHTS_0"
+)
+
+// Animation channel paths.
+const (
+	PathTranslation = "translation"
+	PathRotation    = "rotation"
+	PathScale       = "scale"
+	PathWeights     = "weights"
+)
+
+// Animation sampler interpolations.
+const (
+	InterpolationLinear      = "LINEAR"
+	InterpolationStep        = "STEP"
+	InterpolationCubicSpline = "CUBICSPLINE"
+)
+
+// Document is a glTF 2.0 asset.
+type Document struct {
+	Asset              Asset        `json:"asset"`
+	Scene              *int         `json:"scene"`
+	Scenes             []Scene      `json:"scenes"`
+	Nodes              []Node       `json:"nodes"`
+	Meshes             []Mesh       `json:"meshes"`
+	Skins              []Skin       `json:"skins"`
+	Animations         []Animation  `json:"animations"`
+	Materials          []Material   `json:"materials"`
+	Textures           []Texture    `json:"textures"`
+	Images             []Image      `json:"images"`
+	Samplers           []Sampler    `json:"samplers"`
+	Accessors          []Accessor   `json:"accessors"`
+	BufferViews        []BufferView `json:"bufferViews"`
+	Buffers            []Buffer     `json:"buffers"`
+	ExtensionsUsed     []string     `json:"extensionsUsed"`
+	ExtensionsRequired []string     `json:"extensionsRequired"`
+
+	// bin is the binary chunk of a .glb container.
+	bin []byte
+
+	// data holds the contents of each buffer once resolved.
+	data [][]byte
+}
+
+// Asset holds the metadata of a document.
+type Asset struct {
+	Version    string `json:"version"`
+	MinVersion string `json:"minVersion"`
+	Generator  string `json:"generator"`
+	Copyright  string `json:"copyright"`
+}
+
+// Scene is a set of root nodes.
+type Scene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+// Node is an element of the node hierarchy. Its transform is given either by
+// a matrix or by translation, rotation and scale.
+type Node struct {
+	Name        string       `json:"name"`
+	Children    []int        `json:"children"`
+	Mesh        *int         `json:"mesh"`
+	Skin        *int         `json:"skin"`
+	Camera      *int         `json:"camera"`
+	Matrix      *[16]float32 `json:"matrix"`
+	Translation *[3]float32  `json:"translation"`
+	Rotation    *[4]float32  `json:"rotation"`
+	Scale       *[3]float32  `json:"scale"`
+	Weights     []float32    `json:"weights"`
+}
+
+// Mesh is a set of primitives. Weights are the default weights of the morph
+// targets of the primitives.
+type Mesh struct {
+	Name       string          `json:"name"`
+	Primitives []Primitive     `json:"primitives"`
+	Weights    []float32       `json:"weights"`
+	Extras     json.RawMessage `json:"extras"`
+}
+
+// Primitive is geometry drawn with a single material. Attributes, indices
+// and morph targets refer to accessors.
+type Primitive struct {
+	Attributes map[string]int   `json:"attributes"`
+	Indices    *int             `json:"indices"`
+	Material   *int             `json:"material"`
+	Mode       *int             `json:"mode"`
+	Targets    []map[string]int `json:"targets"`
+}
+
+// Skin binds the vertices of meshes to a hierarchy of joint nodes. The
+// inverse bind matrices refer to an accessor of one matrix per joint.
+type Skin struct {
+	Name                string `json:"name"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices"`
+	Skeleton            *int   `json:"skeleton"`
+	Joints              []int  `json:"joints"`
+}
+
+// Animation is a set of channels animating the properties of nodes.
+type Animation struct {
+	Name     string             `json:"name"`
+	Channels []AnimationChannel `json:"channels"`
+	Samplers []AnimationSampler `json:"samplers"`
+}
+
+// AnimationChannel animates the property of a node with a sampler.
+type AnimationChannel struct {
+	Sampler int `json:"sampler"`
+	Target  struct {
+		Node *int   `json:"node"`
+		Path string `json:"path"`
+	} `json:"target"`
+}
+
+// AnimationSampler holds the keyframe times and values of a channel in the
+// input and output accessors.
+type AnimationSampler struct {
+	Input         int    `json:"input"`
+	Interpolation string `json:"interpolation"`
+	Output        int    `json:"output"`
+}
+
+// Material is a metallic-roughness PBR material.
+type Material struct {
+	Name                 string                `json:"name"`
+	PBRMetallicRoughness *PBRMetallicRoughness `json:"pbrMetallicRoughness"`
+	NormalTexture        *TextureInfo          `json:"normalTexture"`
+	OcclusionTexture     *TextureInfo          `json:"occlusionTexture"`
+	EmissiveTexture      *TextureInfo          `json:"emissiveTexture"`
+	EmissiveFactor       [3]float32            `json:"emissiveFactor"`
+	AlphaMode            string                `json:"alphaMode"`
+	AlphaCutoff          *float32              `json:"alphaCutoff"`
+	DoubleSided          bool                  `json:"doubleSided"`
+}
+
+// PBRMetallicRoughness holds the parameters of the metallic-roughness model.
+type PBRMetallicRoughness struct {
+	BaseColorFactor          *[4]float32  `json:"baseColorFactor"`
+	BaseColorTexture         *TextureInfo `json:"baseColorTexture"`
+	MetallicFactor           *float32     `json:"metallicFactor"`
+	RoughnessFactor          *float32     `json:"roughnessFactor"`
+	MetallicRoughnessTexture *TextureInfo `json:"metallicRoughnessTexture"`
+}
+
+// TextureInfo refers to a texture used by a material. Scale applies to
+// normal textures, strength to occlusion textures.
+type TextureInfo struct {
+	Index    int      `json:"index"`
+	TexCoord int      `json:"texCoord"`
+	Scale    *float32 `json:"scale"`
+	Strength *float32 `json:"strength"`
+}
+
+// Texture combines an image with a sampler.
+type Texture struct {
+	Name    string `json:"name"`
+	Sampler *int   `json:"sampler"`
+	Source  *int   `json:"source"`
+}
+
+// Image is image data given by a URI or a buffer view.
+type Image struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+
+// Sampler holds the filtering and wrapping modes of a texture.
+type Sampler struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+	WrapS     int `json:"wrapS"`
+	WrapT     int `json:"wrapT"`
+}
+
+// Accessor describes a typed view in to a buffer view.
+type Accessor struct {
+	BufferView    *int      `json:"bufferView"`
+	ByteOffset    int       `json:"byteOffset"`
+	ComponentType int       `json:"componentType"`
+	Normalized    bool      `json:"normalized"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min"`
+	Max           []float32 `json:"max"`
+	Sparse        *Sparse   `json:"sparse"`
+}
+
+// Sparse holds the elements of an accessor which deviate from its buffer
+// view, or from zero when it has none.
+type Sparse struct {
+	Count   int `json:"count"`
+	Indices struct {
+		BufferView    int `json:"bufferView"`
+		ByteOffset    int `json:"byteOffset"`
+		ComponentType int `json:"componentType"`
+	} `json:"indices"`
+	Values struct {
+		BufferView int `json:"bufferView"`
+		ByteOffset int `json:"byteOffset"`
+	} `json:"values"`
+}
+
+// BufferView is a slice of a buffer.
+type BufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+	Target     int `json:"target"`
+}
+
+// Buffer is binary data given by a URI, or by the binary chunk of a .glb
+// container when the URI is empty.
+type Buffer struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// DefaultScene returns the index of the scene to display, or -1 if the
+// document has no scenes.
+func (d *Document) DefaultScene() int {
+	if d.Scene != nil {
+		return *d.Scene
+	}
+	if len(d.Scenes) > 0 {
+		return 0
+	}
+
+	return -1
+}
+
+// PrimitiveMode returns the mode of the primitive.
+func (p *Primitive) PrimitiveMode() int {
+	if p.Mode != nil {
+		return *p.Mode
+	}
+
+	return ModeTriangles
+}
+
+// TargetNames returns the names of the morph targets of the mesh, which are
+// given by the targetNames extra by convention. It returns nil if the mesh
+// has no such names.
+func (m *Mesh) TargetNames() []string {
+	var extras struct {
+		TargetNames []string `json:"targetNames"`
+	}
+
+	if len(m.Extras) == 0 || json.Unmarshal(m.Extras, &extras) != nil {
+		return nil
+	}
+
+	return extras.TargetNames
+}
+
+// InterpolationMode returns the interpolation of the sampler.
+func (s *AnimationSampler) InterpolationMode() string {
+	if s.Interpolation != "" {
+		return s.Interpolation
+	}
+
+	return InterpolationLinear
+}
+
+// BaseColor returns the base color factor of the material.
+func (m *Material) BaseColor() mgl32.Vec4 {
+	if m.PBRMetallicRoughness != nil && m.PBRMetallicRoughness.BaseColorFactor != nil {
+		return mgl32.Vec4(*m.PBRMetallicRoughness.BaseColorFactor)
+	}
+
+	return mgl32.Vec4{1, 1, 1, 1}
+}
+
+// Metallic returns the metallic factor of the material.
+func (m *Material) Metallic() float32 {
+	if m.PBRMetallicRoughness != nil && m.PBRMetallicRoughness.MetallicFactor != nil {
+		return *m.PBRMetallicRoughness.MetallicFactor
+	}
+
+	return 1
+}
+
+// Roughness returns the roughness factor of the material.
+func (m *Material) Roughness() float32 {
+	if m.PBRMetallicRoughness != nil && m.PBRMetallicRoughness.RoughnessFactor != nil {
+		return *m.PBRMetallicRoughness.RoughnessFactor
+	}
+
+	return 1
+}
+
+// TRS returns the translation, rotation and scale of the node. A matrix is
+// decomposed, which assumes it has no shear.
+func (n *Node) TRS() (mgl32.Vec3, mgl32.Quat, mgl32.Vec3) {
+	if n.Matrix != nil {
+		return decompose(mgl32.Mat4(*n.Matrix))
+	}
+
+	t := mgl32.Vec3{}
+	r := mgl32.QuatIdent()
+	s := mgl32.Vec3{1, 1, 1}
+
+	if n.Translation != nil {
+		t = mgl32.Vec3(*n.Translation)
+	}
+	if n.Rotation != nil {
+		r = mgl32.Quat{W: n.Rotation[3], V: mgl32.Vec3{n.Rotation[0], n.Rotation[1], n.Rotation[2]}}
+	}
+	if n.Scale != nil {
+		s = mgl32.Vec3(*n.Scale)
+	}
+
+	return t, r, s
+}
+
+func decompose(m mgl32.Mat4) (mgl32.Vec3, mgl32.Quat, mgl32.Vec3) {
+	t := mgl32.Vec3{m[12], m[13], m[14]}
+
+	s := mgl32.Vec3{
+		m.Col(0).Vec3().Len(),
+		m.Col(1).Vec3().Len(),
+		m.Col(2).Vec3().Len(),
+	}
+
+	// A negative determinant mirrors the basis.
+	if m.Mat3().Det() < 0 {
+		s[0] = -s[0]
+	}
+
+	for i := 0; i < 3; i++ {
+		if s[i] == 0 {
+			return t, mgl32.QuatIdent(), s
+		}
+		for j := 0; j < 3; j++ {
+			m[i*4+j] /= s[i]
+		}
+	}
+
+	return t, mgl32.Mat4ToQuat(m).Normalize(), s
+}
@@ -0,0 +1,398 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// triangle is a document with one indexed triangle. Buffer 0 holds three
+// float positions followed by three unsigned short indices.
+const triangle = `{
+	"asset": {"version": "2.0"},
+	"scenes": [{"nodes": [0]}],
+	"nodes": [{"name": "tri", "mesh": 0, "translation": [1, 2, 3]}],
+	"meshes": [{"primitives": [{"attributes": {"POSITION": 0}, "indices": 1}]}],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+		{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"}
+	],
+	"bufferViews": [
+		{"buffer": 0, "byteOffset": 0, "byteLength": 36},
+		{"buffer": 0, "byteOffset": 36, "byteLength": 6}
+	],
+	"buffers": [{"byteLength": 42%s}]
+}`
+
+func triangleData() []byte {
+	buf := &bytes.Buffer{}
+
+	binary.Write(buf, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	binary.Write(buf, binary.LittleEndian, []uint16{0, 1, 2})
+
+	return buf.Bytes()
+}
+
+func makeGLB(js, bin []byte) []byte {
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	buf := &bytes.Buffer{}
+	w := func(v ...uint32) {
+		binary.Write(buf, binary.LittleEndian, v)
+	}
+
+	w(glbMagic, glbVersion, uint32(glbHeaderLen+16+len(js)+len(bin)))
+	w(uint32(len(js)), glbChunkJSON)
+	buf.Write(js)
+	w(uint32(len(bin)), glbChunkBIN)
+	buf.Write(bin)
+
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	data := triangleData()
+	uri := `, "uri": "data:application/octet-stream;base64,` + base64.StdEncoding.EncodeToString(data) + `"`
+
+	var tests = []struct {
+		name  string
+		in    []byte
+		files map[string][]byte
+	}{
+		{name: "glb", in: makeGLB([]byte(fmt.Sprintf(triangle, "")), data)},
+		{name: "data uri", in: []byte(fmt.Sprintf(triangle, uri))},
+		{name: "external", in: []byte(fmt.Sprintf(triangle, `, "uri": "tri%20angle.bin"`)), files: map[string][]byte{"tri angle.bin": data}},
+	}
+
+	for _, v := range tests {
+		d, err := Decode(v.in)
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+
+		err = d.ResolveBuffers(func(uri string) ([]byte, error) {
+			return v.files[uri], nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+
+		pos, err := d.Vec3s(d.Meshes[0].Primitives[0].Attributes[AttributePosition])
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+		if want := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}; !reflect.DeepEqual(pos, want) {
+			t.Errorf("%s: positions = %v, want %v", v.name, pos, want)
+		}
+
+		idx, err := d.Indices(*d.Meshes[0].Primitives[0].Indices)
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+		if want := []uint32{0, 1, 2}; !reflect.DeepEqual(idx, want) {
+			t.Errorf("%s: indices = %v, want %v", v.name, idx, want)
+		}
+	}
+}
+
+// corruptGLB returns a .glb container with the word at the offset replaced.
+func corruptGLB(offset int, v uint32) []byte {
+	data := makeGLB([]byte(`{"asset": {"version": "2.0"}}`), nil)
+	binary.LittleEndian.PutUint32(data[offset:], v)
+
+	return data
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	var tests = [][]byte{
+		[]byte(`{"asset": {"version": "1.0"}}`),
+		[]byte(`not json`),
+		makeGLB(nil, nil)[:20],
+		[]byte("glTF\x02\x00\x00\x00\x02\x00\x00\x00"),
+		[]byte("glTF\x02\x00\x00\x00\x0b\x00\x00\x00"),
+		corruptGLB(12, 0xffffffff),
+		corruptGLB(12, 0x7ffffffc),
+		corruptGLB(8, 0xffffffff),
+	}
+
+	for i, v := range tests {
+		if _, err := Decode(v); err == nil {
+			t.Errorf("%s case %d: expected error", t.Name(), i)
+		}
+	}
+}
+
+func TestFloats_Normalized(t *testing.T) {
+	d := &Document{
+		Accessors: []Accessor{
+			{BufferView: intPtr(0), ComponentType: ComponentUnsignedByte, Normalized: true, Count: 2, Type: "VEC2"},
+			{BufferView: intPtr(0), ComponentType: ComponentByte, Normalized: true, Count: 2, Type: "VEC2"},
+		},
+		BufferViews: []BufferView{{Buffer: 0, ByteLength: 4}},
+		data:        [][]byte{{0, 255, 127, 128}},
+	}
+
+	got, _, err := d.Floats(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float32{0, 1, 127.0 / 255, 128.0 / 255}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unsigned = %v, want %v", got, want)
+	}
+
+	got, _, err = d.Floats(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float32{0, -1.0 / 127, 1, -1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("signed = %v, want %v", got, want)
+	}
+}
+
+func TestFloats_Sparse(t *testing.T) {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, []uint16{2, 0})
+	binary.Write(buf, binary.LittleEndian, []float32{5, 7})
+
+	sparse := &Sparse{Count: 2}
+	sparse.Indices.BufferView = 0
+	sparse.Indices.ComponentType = ComponentUnsignedShort
+	sparse.Values.BufferView = 0
+	sparse.Values.ByteOffset = 4
+
+	d := &Document{
+		Accessors:   []Accessor{{ComponentType: ComponentFloat, Count: 4, Type: "SCALAR", Sparse: sparse}},
+		BufferViews: []BufferView{{Buffer: 0, ByteLength: 12}},
+		data:        [][]byte{buf.Bytes()},
+	}
+
+	got, _, err := d.Floats(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float32{7, 0, 5, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sparse = %v, want %v", got, want)
+	}
+}
+
+func TestFloats_Invalid(t *testing.T) {
+	sparse := func(count, indexOffset, valueOffset int) *Sparse {
+		s := &Sparse{Count: count}
+		s.Indices.BufferView = 0
+		s.Indices.ByteOffset = indexOffset
+		s.Indices.ComponentType = ComponentUnsignedShort
+		s.Values.BufferView = 0
+		s.Values.ByteOffset = valueOffset
+		return s
+	}
+
+	var tests = []struct {
+		accessor Accessor
+		view     BufferView
+	}{
+		{Accessor{BufferView: intPtr(0), Count: -1}, BufferView{ByteLength: 12}},
+		{Accessor{Count: -1}, BufferView{}},
+		{Accessor{Count: 1 << 30}, BufferView{}},
+		{Accessor{BufferView: intPtr(0), Count: 1, ByteOffset: -4}, BufferView{ByteLength: 12}},
+		{Accessor{BufferView: intPtr(0), Count: 1, ByteOffset: 16}, BufferView{ByteLength: 12}},
+		{Accessor{BufferView: intPtr(0), Count: 2}, BufferView{ByteLength: 12, ByteStride: -4}},
+		{Accessor{BufferView: intPtr(0), Count: 1 << 62}, BufferView{ByteLength: 12, ByteStride: 4}},
+		{Accessor{BufferView: intPtr(0), Count: 4}, BufferView{ByteLength: 12}},
+		{Accessor{BufferView: intPtr(0), Count: 1}, BufferView{ByteOffset: -4, ByteLength: 12}},
+		{Accessor{BufferView: intPtr(0), Count: 1}, BufferView{ByteLength: -4}},
+		{Accessor{BufferView: intPtr(0), Count: 1}, BufferView{ByteOffset: 4, ByteLength: 1<<63 - 1}},
+		{Accessor{Count: 2, Sparse: sparse(-1, 0, 4)}, BufferView{ByteLength: 12}},
+		{Accessor{Count: 2, Sparse: sparse(3, 0, 4)}, BufferView{ByteLength: 12}},
+		{Accessor{Count: 2, Sparse: sparse(1, -2, 4)}, BufferView{ByteLength: 12}},
+		{Accessor{Count: 2, Sparse: sparse(1, 0, -4)}, BufferView{ByteLength: 12}},
+		{Accessor{Count: 2, Sparse: sparse(1, 0, 16)}, BufferView{ByteLength: 12}},
+		{Accessor{Count: 2, Sparse: sparse(2, 0, 8)}, BufferView{ByteLength: 12}},
+	}
+
+	for i, v := range tests {
+		v.accessor.ComponentType = ComponentFloat
+		v.accessor.Type = "SCALAR"
+
+		d := &Document{
+			Accessors:   []Accessor{v.accessor},
+			BufferViews: []BufferView{v.view},
+			data:        [][]byte{make([]byte, 12)},
+		}
+
+		if _, _, err := d.Floats(0); err == nil {
+			t.Errorf("%s case %d: expected error", t.Name(), i)
+		}
+
+		v.accessor.ComponentType = ComponentUnsignedInt
+		d.Accessors[0] = v.accessor
+		if _, err := d.Indices(0); err == nil {
+			t.Errorf("%s case %d: expected error for indices", t.Name(), i)
+		}
+	}
+}
+
+func TestNode_TRS(t *testing.T) {
+	r := mgl32.QuatRotate(mgl32.DegToRad(90), mgl32.Vec3{0, 1, 0})
+	m := mgl32.Translate3D(1, 2, 3).Mul4(r.Mat4()).Mul4(mgl32.Scale3D(2, 3, 4))
+
+	n := &Node{Matrix: (*[16]float32)(&m)}
+
+	pos, rot, scale := n.TRS()
+	if !pos.ApproxEqualThreshold(mgl32.Vec3{1, 2, 3}, 1e-5) {
+		t.Errorf("translation = %v", pos)
+	}
+	if !rot.ApproxEqualThreshold(r, 1e-5) {
+		t.Errorf("rotation = %v, want %v", rot, r)
+	}
+	if !scale.ApproxEqualThreshold(mgl32.Vec3{2, 3, 4}, 1e-5) {
+		t.Errorf("scale = %v", scale)
+	}
+
+	n = &Node{Rotation: &[4]float32{0, 0, 0, 1}}
+	if _, rot, scale := n.TRS(); rot != mgl32.QuatIdent() || scale != (mgl32.Vec3{1, 1, 1}) {
+		t.Errorf("defaults = %v %v", rot, scale)
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	var tests = []struct {
+		mode int
+		in   []uint32
+		want []uint32
+	}{
+		{mode: ModeTriangles, in: []uint32{0, 1, 2}, want: []uint32{0, 1, 2}},
+		{mode: ModeTriangleStrip, in: []uint32{0, 1, 2, 3}, want: []uint32{0, 1, 2, 2, 1, 3}},
+		{mode: ModeTriangleFan, in: []uint32{0, 1, 2, 3}, want: []uint32{0, 1, 2, 0, 2, 3}},
+	}
+
+	for i, v := range tests {
+		got, err := Triangulate(v.mode, v.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, v.want) {
+			t.Errorf("%s case %d value mismatch. want: %v got: %v", t.Name(), i, v.want, got)
+		}
+	}
+
+	if _, err := Triangulate(ModeLines, nil); err == nil {
+		t.Errorf("%s: expected error for lines", t.Name())
+	}
+}
+
+func TestDecode_Animation(t *testing.T) {
+	js := `{
+		"asset": {"version": "2.0"},
+		"nodes": [{"children": [1]}, {}],
+		"skins": [{"inverseBindMatrices": 0, "joints": [0, 1]}],
+		"animations": [{
+			"name": "wave",
+			"channels": [{"sampler": 0, "target": {"node": 1, "path": "rotation"}}],
+			"samplers": [{"input": 1, "output": 2}]
+		}],
+		"accessors": [
+			{"bufferView": 0, "componentType": 5126, "count": 2, "type": "MAT4"},
+			{"bufferView": 0, "componentType": 5126, "count": 2, "type": "SCALAR"},
+			{"bufferView": 0, "componentType": 5126, "count": 2, "type": "VEC4"}
+		],
+		"bufferViews": [{"buffer": 0, "byteLength": 128}],
+		"buffers": [{"byteLength": 128}]
+	}`
+
+	m := mgl32.Translate3D(1, 2, 3)
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, mgl32.Ident4())
+	binary.Write(buf, binary.LittleEndian, m)
+
+	d, err := Decode(makeGLB([]byte(js), buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ResolveBuffers(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Skins) != 1 || !reflect.DeepEqual(d.Skins[0].Joints, []int{0, 1}) {
+		t.Fatalf("skins = %+v", d.Skins)
+	}
+	mats, err := d.Mat4s(*d.Skins[0].InverseBindMatrices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []mgl32.Mat4{mgl32.Ident4(), m}; !reflect.DeepEqual(mats, want) {
+		t.Errorf("inverse bind matrices = %v, want %v", mats, want)
+	}
+
+	if len(d.Animations) != 1 {
+		t.Fatalf("animations = %+v", d.Animations)
+	}
+	a := d.Animations[0]
+	if a.Name != "wave" || *a.Channels[0].Target.Node != 1 || a.Channels[0].Target.Path != PathRotation {
+		t.Errorf("channel = %+v", a.Channels[0])
+	}
+	if a.Samplers[0].InterpolationMode() != InterpolationLinear {
+		t.Errorf("interpolation = %q, want %q", a.Samplers[0].InterpolationMode(), InterpolationLinear)
+	}
+	if _, err := d.Mat4s(a.Samplers[0].Output); err == nil {
+		t.Error("expected error for VEC4 accessor")
+	}
+}
+
+func TestMesh_TargetNames(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want []string
+	}{
+		{in: `{"primitives": []}`, want: nil},
+		{in: `{"extras": {"targetNames": ["smile", "blink"]}}`, want: []string{"smile", "blink"}},
+		{in: `{"extras": "free text"}`, want: nil},
+	}
+
+	for i, v := range tests {
+		var m Mesh
+		if err := json.Unmarshal([]byte(v.in), &m); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := m.TargetNames(); !reflect.DeepEqual(got, v.want) {
+			t.Errorf("%s case %d: TargetNames() = %v, want %v", t.Name(), i, got, v.want)
+		}
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package cube
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// identity returns an identity table of the given size in the .cube format.
+func identity(n int) string {
+	var b strings.Builder
+
+	b.WriteString("# Created by hand\nTITLE \"Identity\"\n")
+	fmt.Fprintf(&b, "LUT_3D_SIZE %d\n\nDOMAIN_MIN 0 0 0\nDOMAIN_MAX 1 1 1\n", n)
+
+	d := float64(n - 1)
+	for z := 0; z < n; z++ {
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				fmt.Fprintf(&b, "%g %g %g\n", float64(x)/d, float64(y)/d, float64(z)/d)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func TestDecode(t *testing.T) {
+	l, err := Decode(strings.NewReader(identity(4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.Title != "Identity" {
+		t.Errorf("title %q, want %q", l.Title, "Identity")
+	}
+	if l.Size != 4 || len(l.Data) != 4*4*4*3 {
+		t.Fatalf("size %d with %d values", l.Size, len(l.Data))
+	}
+	if l.DomainMax != [3]float32{1, 1, 1} {
+		t.Errorf("domain max %v", l.DomainMax)
+	}
+
+	// The entry of texel (1, 2, 3) maps its own color.
+	i := ((3*4+2)*4 + 1) * 3
+	if got, want := l.Data[i:i+3], []float32{1.0 / 3, 2.0 / 3, 1}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("entry %v, want %v", got, want)
+	}
+	if s := l.Slice(3); len(s) != 4*4*3 || s[2] != 1 {
+		t.Errorf("slice of %d values, blue %v", len(s), s[2])
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"missing size", "0 0 0\n"},
+		{"short", "LUT_3D_SIZE 2\n0 0 0\n1 1 1\n"},
+		{"long", identity(2) + "1 1 1\n"},
+		{"values", "LUT_3D_SIZE 2\n0 0\n"},
+		{"size", "LUT_3D_SIZE 1\n"},
+		{"1d", "LUT_1D_SIZE 16\n"},
+	}
+
+	for _, test := range tests {
+		if _, err := Decode(strings.NewReader(test.data)); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package cube
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxSize is the largest supported table size.
+const maxSize = 256
+
+// Cube errors.
+var (
+	ErrUnsupported = errors.New("cube: 1D tables are not supported")
+)
+
+// LUT is a 3D color lookup table.
+type LUT struct {
+	Title string
+
+	// Size is the number of entries along each axis.
+	Size int
+
+	// DomainMin and DomainMax are the range of the input colors of the
+	// table. They default to [0, 1].
+	DomainMin [3]float32
+	DomainMax [3]float32
+
+	// Data holds Size³ RGB entries, with red varying fastest, then green,
+	// then blue: the entries of a volume texture indexed by input color.
+	Data []float32
+}
+
+// Slice returns the entries of a slice of the table of constant blue, as
+// the data of a layer of a volume texture.
+func (l *LUT) Slice(b int) []float32 {
+	n := l.Size * l.Size * 3
+
+	return l.Data[b*n : (b+1)*n]
+}
+
+// Decode reads a 3D lookup table.
+func Decode(r io.Reader) (*LUT, error) {
+	l := &LUT{DomainMax: [3]float32{1, 1, 1}}
+
+	s := bufio.NewScanner(r)
+
+	var lineNo int
+
+	for s.Scan() {
+		lineNo++
+
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if err := l.parseLine(line); err != nil {
+			return nil, fmt.Errorf("cube: line %d: %v", lineNo, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if l.Size == 0 {
+		return nil, errors.New("cube: missing LUT_3D_SIZE")
+	}
+	if want := l.Size * l.Size * l.Size * 3; len(l.Data) != want {
+		return nil, fmt.Errorf("cube: %d entries, want %d", len(l.Data)/3, want/3)
+	}
+
+	return l, nil
+}
+
+func (l *LUT) parseLine(line string) error {
+	fields := strings.Fields(line)
+
+	switch fields[0] {
+	case "TITLE":
+		l.Title = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "TITLE")), `"`)
+	case "LUT_1D_SIZE":
+		return ErrUnsupported
+	case "LUT_3D_SIZE":
+		if len(fields) != 2 {
+			return errors.New("invalid LUT_3D_SIZE")
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 2 || n > maxSize {
+			return fmt.Errorf("invalid size: %s", fields[1])
+		}
+		if l.Size != 0 {
+			return errors.New("duplicate LUT_3D_SIZE")
+		}
+		l.Size = n
+		l.Data = make([]float32, 0, n*n*n*3)
+	case "DOMAIN_MIN", "DOMAIN_MAX":
+		v, err := parseFloats(fields[1:], 3)
+		if err != nil {
+			return err
+		}
+		if fields[0] == "DOMAIN_MIN" {
+			copy(l.DomainMin[:], v)
+		} else {
+			copy(l.DomainMax[:], v)
+		}
+	case "LUT_3D_INPUT_RANGE":
+		v, err := parseFloats(fields[1:], 2)
+		if err != nil {
+			return err
+		}
+		l.DomainMin = [3]float32{v[0], v[0], v[0]}
+		l.DomainMax = [3]float32{v[1], v[1], v[1]}
+	default:
+		// Other keywords, such as those of other applications, are
+		// skipped.
+		if c := fields[0][0]; c >= 'A' && c <= 'Z' {
+			return nil
+		}
+
+		if l.Size == 0 {
+			return errors.New("entry before LUT_3D_SIZE")
+		}
+		if len(l.Data) == cap(l.Data) {
+			return errors.New("too many entries")
+		}
+
+		v, err := parseFloats(fields, 3)
+		if err != nil {
+			return err
+		}
+		l.Data = append(l.Data, v...)
+	}
+
+	return nil
+}
+
+func parseFloats(fields []string, n int) ([]float32, error) {
+	if len(fields) != n {
+		return nil, fmt.Errorf("expected %d values, got %d", n, len(fields))
+	}
+
+	v := make([]float32, n)
+	for i, f := range fields {
+		x, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = float32(x)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,248 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package image
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// ErrCubeLayout is returned for images of a size which no cubemap layout
+// has.
+var ErrCubeLayout = errors.New("image: image size matches no cubemap layout")
+
+// CubeLayout is the arrangement of the faces of a cubemap in an image.
+// Faces are in the order of the cubemap layers, +X, -X, +Y, -Y, +Z and -Z,
+// and oriented as they are stored, with their first row at the top.
+type CubeLayout uint8
+
+const (
+	// CubeEquirect is an equirectangular panorama, twice as wide as it is
+	// high, with up at the top. Longitude runs from -X at the left edge
+	// through -Z, +X and +Z.
+	CubeEquirect CubeLayout = iota
+
+	// CubeHorizontalCross has the faces -X, +Z, +X and -Z in its middle
+	// row, with +Y above and -Y below +Z, in a 4x3 grid.
+	CubeHorizontalCross
+
+	// CubeVerticalCross has the faces -X, +Z and +X in its second row, with
+	// +Y above and -Y and -Z below +Z, in a 3x4 grid. -Z is upside down.
+	CubeVerticalCross
+
+	// CubeHorizontalStrip has the faces side by side, in a 6x1 grid.
+	CubeHorizontalStrip
+
+	// CubeVerticalStrip has the faces one below the other, in a 1x6 grid.
+	CubeVerticalStrip
+)
+
+func (l CubeLayout) String() string {
+	switch l {
+	case CubeEquirect:
+		return "equirect"
+	case CubeHorizontalCross:
+		return "horizontal cross"
+	case CubeVerticalCross:
+		return "vertical cross"
+	case CubeHorizontalStrip:
+		return "horizontal strip"
+	case CubeVerticalStrip:
+		return "vertical strip"
+	default:
+		return "Unknown Cube Layout"
+	}
+}
+
+// crossCells holds the cells of the faces in the horizontal and vertical
+// crosses.
+var crossCells = map[CubeLayout][6]image.Point{
+	CubeHorizontalCross: {{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {3, 1}},
+	CubeVerticalCross:   {{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {1, 3}},
+}
+
+// FaceSize returns the size of the faces held by an image of the layout
+// and the given size, or 0 if the image is not of the proportions of the
+// layout. Equirectangular images have faces of half their height.
+func (l CubeLayout) FaceSize(width, height int) int {
+	switch l {
+	case CubeEquirect:
+		if width == 2*height {
+			return height / 2
+		}
+	case CubeHorizontalCross:
+		if width%4 == 0 && width/4*3 == height {
+			return width / 4
+		}
+	case CubeVerticalCross:
+		if width%3 == 0 && width/3*4 == height {
+			return width / 3
+		}
+	case CubeHorizontalStrip:
+		if width == 6*height {
+			return height
+		}
+	case CubeVerticalStrip:
+		if height == 6*width {
+			return width
+		}
+	}
+
+	return 0
+}
+
+// cell returns the region of the image holding a face of the given size.
+func (l CubeLayout) cell(face, size int) image.Rectangle {
+	var p image.Point
+
+	switch l {
+	case CubeHorizontalStrip:
+		p = image.Point{face, 0}
+	case CubeVerticalStrip:
+		p = image.Point{0, face}
+	default:
+		p = crossCells[l][face]
+	}
+
+	return image.Rect(p.X*size, p.Y*size, (p.X+1)*size, (p.Y+1)*size)
+}
+
+// DetectCubeLayout returns the cubemap layout of images of the given size,
+// which the proportions of each layout tell apart.
+func DetectCubeLayout(width, height int) (CubeLayout, error) {
+	for l := CubeEquirect; l <= CubeVerticalStrip; l++ {
+		if l.FaceSize(width, height) > 0 {
+			return l, nil
+		}
+	}
+
+	return 0, ErrCubeLayout
+}
+
+// CubeFaces returns the six faces of a cubemap held by an image of the
+// layout, resampled bilinearly to the given size. A size of 0 keeps the
+// size of the faces of the layout.
+func CubeFaces(f *Float, layout CubeLayout, size int) ([6]*Float, error) {
+	var faces [6]*Float
+
+	n := layout.FaceSize(f.Width, f.Height)
+	if n < 1 {
+		return faces, ErrCubeLayout
+	}
+	if size < 1 {
+		size = n
+	}
+
+	whole := image.Rect(0, 0, f.Width, f.Height)
+	scale := float64(n) / float64(size)
+
+	for i := range faces {
+		face := NewFloat(size, size)
+		cell := layout.cell(i, n)
+
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				var c [4]float32
+
+				if layout == CubeEquirect {
+					s := 2*(float64(x)+0.5)/float64(size) - 1
+					t := 2*(float64(y)+0.5)/float64(size) - 1
+					u, v := equirectUV(cubeDirection(i, s, t))
+					c = f.bilinear(whole, u*float64(f.Width)-0.5, v*float64(f.Height)-0.5, EdgeWrap)
+				} else {
+					px := (float64(x)+0.5)*scale - 0.5
+					py := (float64(y)+0.5)*scale - 0.5
+					if layout == CubeVerticalCross && i == 5 {
+						px, py = float64(n-1)-px, float64(n-1)-py
+					}
+					c = f.bilinear(cell, px, py, EdgeClamp)
+				}
+
+				copy(face.Pix[(y*size+x)*4:], c[:])
+			}
+		}
+
+		faces[i] = face
+	}
+
+	return faces, nil
+}
+
+// cubeDirection returns the direction of a point of a cubemap face, of s
+// running right and t down across the face from -1 to 1, as sampled by
+// the graphics API.
+func cubeDirection(face int, s, t float64) (x, y, z float64) {
+	switch face {
+	case 0:
+		return 1, -t, -s
+	case 1:
+		return -1, -t, s
+	case 2:
+		return s, 1, t
+	case 3:
+		return s, -1, -t
+	case 4:
+		return s, -t, 1
+	default:
+		return -s, -t, -1
+	}
+}
+
+// equirectUV returns the coordinates in an equirectangular panorama of a
+// direction, with v = 0 at the top. Longitude is measured as the cubemap
+// conversion shader does.
+func equirectUV(x, y, z float64) (u, v float64) {
+	l := math.Sqrt(x*x + y*y + z*z)
+
+	u = math.Atan2(z, x)/(2*math.Pi) + 0.5
+	v = 0.5 - math.Asin(y/l)/math.Pi
+
+	return u, v
+}
+
+// bilinear samples the region r of the image at a position relative to it,
+// of pixel centers at integers. Positions outside the region are handled
+// as edge tells horizontally, and are clamped vertically.
+func (f *Float) bilinear(r image.Rectangle, x, y float64, edge Edge) [4]float32 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := float32(x-x0), float32(y-y0)
+
+	xs := [2]int{edge.index(int(x0), r.Dx()), edge.index(int(x0)+1, r.Dx())}
+	ys := [2]int{EdgeClamp.index(int(y0), r.Dy()), EdgeClamp.index(int(y0)+1, r.Dy())}
+	wx := [2]float32{1 - fx, fx}
+	wy := [2]float32{1 - fy, fy}
+
+	var c [4]float32
+	for j := range ys {
+		for i := range xs {
+			p := ((r.Min.Y+ys[j])*f.Width + r.Min.X + xs[i]) * 4
+			w := wx[i] * wy[j]
+			for k := range c {
+				c[k] += f.Pix[p+k] * w
+			}
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,271 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package image
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestDetectCubeLayout(t *testing.T) {
+	var tests = []struct {
+		width, height int
+		layout        CubeLayout
+		size          int
+	}{
+		{512, 256, CubeEquirect, 128},
+		{256, 192, CubeHorizontalCross, 64},
+		{192, 256, CubeVerticalCross, 64},
+		{384, 64, CubeHorizontalStrip, 64},
+		{64, 384, CubeVerticalStrip, 64},
+	}
+
+	for _, test := range tests {
+		l, err := DetectCubeLayout(test.width, test.height)
+		if err != nil || l != test.layout {
+			t.Errorf("%dx%d: layout %v, %v, want %v", test.width, test.height, l, err, test.layout)
+			continue
+		}
+		if n := l.FaceSize(test.width, test.height); n != test.size {
+			t.Errorf("%dx%d: face size %d, want %d", test.width, test.height, n, test.size)
+		}
+	}
+
+	for _, size := range [][2]int{{0, 0}, {100, 100}, {2, 1}, {10, 7}} {
+		if _, err := DetectCubeLayout(size[0], size[1]); err != ErrCubeLayout {
+			t.Errorf("%v: error %v, want ErrCubeLayout", size, err)
+		}
+	}
+}
+
+// testFace returns a face whose pixels tell the face and position apart.
+func testFace(face, size int) *Float {
+	f := NewFloat(size, size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			i := (y*size + x) * 4
+			f.Pix[i+0] = float32(face)
+			f.Pix[i+1] = float32(x)
+			f.Pix[i+2] = float32(y)
+			f.Pix[i+3] = 1
+		}
+	}
+	return f
+}
+
+// assemble places faces in the cells of a layout.
+func assemble(faces [6]*Float, layout CubeLayout, width, height int) *Float {
+	n := faces[0].Width
+	f := NewFloat(width, height)
+
+	for i, face := range faces {
+		cell := layout.cell(i, n)
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				sx, sy := x, y
+				if layout == CubeVerticalCross && i == 5 {
+					sx, sy = n-1-x, n-1-y
+				}
+				copy(f.Pix[((cell.Min.Y+y)*width+cell.Min.X+x)*4:][:4], face.Pix[(sy*n+sx)*4:][:4])
+			}
+		}
+	}
+
+	return f
+}
+
+func TestCubeFaces_Layouts(t *testing.T) {
+	const n = 8
+
+	var faces [6]*Float
+	for i := range faces {
+		faces[i] = testFace(i, n)
+	}
+
+	var tests = []struct {
+		layout        CubeLayout
+		width, height int
+	}{
+		{CubeHorizontalCross, 4 * n, 3 * n},
+		{CubeVerticalCross, 3 * n, 4 * n},
+		{CubeHorizontalStrip, 6 * n, n},
+		{CubeVerticalStrip, n, 6 * n},
+	}
+
+	for _, test := range tests {
+		src := assemble(faces, test.layout, test.width, test.height)
+
+		out, err := CubeFaces(src, test.layout, 0)
+		if err != nil {
+			t.Fatalf("%v: %v", test.layout, err)
+		}
+
+		for i := range out {
+			if out[i].Width != n || out[i].Height != n {
+				t.Fatalf("%v: face %d is %dx%d", test.layout, i, out[i].Width, out[i].Height)
+			}
+			for j, v := range out[i].Pix {
+				if v != faces[i].Pix[j] {
+					t.Fatalf("%v: face %d Pix[%d] = %v, want %v", test.layout, i, j, v, faces[i].Pix[j])
+				}
+			}
+		}
+	}
+}
+
+func TestCubeFaces_Orientation(t *testing.T) {
+	const n = 2
+
+	// The image coordinates of the top left and top right pixels of each
+	// face, in the order +X, -X, +Y, -Y, +Z and -Z.
+	var tests = []struct {
+		width, height int
+		corners       [6][2]image.Point
+	}{
+		{4 * n, 3 * n, [6][2]image.Point{
+			{{4, 2}, {5, 2}}, {{0, 2}, {1, 2}}, {{2, 0}, {3, 0}},
+			{{2, 4}, {3, 4}}, {{2, 2}, {3, 2}}, {{6, 2}, {7, 2}},
+		}},
+		{3 * n, 4 * n, [6][2]image.Point{
+			{{4, 2}, {5, 2}}, {{0, 2}, {1, 2}}, {{2, 0}, {3, 0}},
+			{{2, 4}, {3, 4}}, {{2, 2}, {3, 2}}, {{3, 7}, {2, 7}},
+		}},
+		{6 * n, n, [6][2]image.Point{
+			{{0, 0}, {1, 0}}, {{2, 0}, {3, 0}}, {{4, 0}, {5, 0}},
+			{{6, 0}, {7, 0}}, {{8, 0}, {9, 0}}, {{10, 0}, {11, 0}},
+		}},
+		{n, 6 * n, [6][2]image.Point{
+			{{0, 0}, {1, 0}}, {{0, 2}, {1, 2}}, {{0, 4}, {1, 4}},
+			{{0, 6}, {1, 6}}, {{0, 8}, {1, 8}}, {{0, 10}, {1, 10}},
+		}},
+	}
+
+	for i, v := range tests {
+		// Each pixel holds its coordinates.
+		src := NewFloat(v.width, v.height)
+		for y := 0; y < v.height; y++ {
+			for x := 0; x < v.width; x++ {
+				src.Pix[(y*v.width+x)*4+0] = float32(x)
+				src.Pix[(y*v.width+x)*4+1] = float32(y)
+			}
+		}
+
+		layout, err := DetectCubeLayout(v.width, v.height)
+		if err != nil {
+			t.Fatalf("%s case %d: %v", t.Name(), i, err)
+		}
+
+		faces, err := CubeFaces(src, layout, 0)
+		if err != nil {
+			t.Fatalf("%s case %d: %v", t.Name(), i, err)
+		}
+
+		for j, face := range faces {
+			for k, want := range v.corners[j] {
+				p := face.Pix[k*(n-1)*4:]
+				if got := (image.Point{int(p[0]), int(p[1])}); got != want {
+					t.Errorf("%s case %d: %v face %d corner %d is %v, want %v", t.Name(), i, layout, j, k, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestCubeFaces_Resample(t *testing.T) {
+	src := NewFloat(6*4, 4)
+	for i := range src.Pix {
+		src.Pix[i] = float32(i / 4 % 24 / 4)
+	}
+
+	out, err := CubeFaces(src, CubeHorizontalStrip, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Faces are sampled on their own, without bleeding into neighbours.
+	for i, face := range out {
+		if face.Width != 7 {
+			t.Fatalf("face %d is %dx%d", i, face.Width, face.Height)
+		}
+		for j, v := range face.Pix {
+			if math.Abs(float64(v)-float64(i)) > 1e-5 {
+				t.Fatalf("face %d Pix[%d] = %v, want %d", i, j, v, i)
+			}
+		}
+	}
+}
+
+func TestCubeFaces_Equirect(t *testing.T) {
+	const w, h = 512, 256
+
+	// Each pixel holds its direction.
+	src := NewFloat(w, h)
+	for y := 0; y < h; y++ {
+		lat := (0.5 - (float64(y)+0.5)/h) * math.Pi
+		for x := 0; x < w; x++ {
+			lon := ((float64(x)+0.5)/w - 0.5) * 2 * math.Pi
+			i := (y*w + x) * 4
+			src.Pix[i+0] = float32(math.Cos(lat) * math.Cos(lon))
+			src.Pix[i+1] = float32(math.Sin(lat))
+			src.Pix[i+2] = float32(math.Cos(lat) * math.Sin(lon))
+			src.Pix[i+3] = 1
+		}
+	}
+
+	const n = 32
+
+	faces, err := CubeFaces(src, CubeEquirect, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, face := range faces {
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				s := 2*(float64(x)+0.5)/n - 1
+				tc := 2*(float64(y)+0.5)/n - 1
+				dx, dy, dz := cubeDirection(i, s, tc)
+				l := math.Sqrt(dx*dx + dy*dy + dz*dz)
+
+				p := face.Pix[(y*n+x)*4:]
+				d := math.Abs(float64(p[0])-dx/l) + math.Abs(float64(p[1])-dy/l) + math.Abs(float64(p[2])-dz/l)
+				if d > 0.02 {
+					t.Fatalf("face %d pixel (%d, %d) is %v, want direction (%.3f, %.3f, %.3f)",
+						i, x, y, p[:3], dx/l, dy/l, dz/l)
+				}
+			}
+		}
+	}
+
+	// Up is at the top of the side faces.
+	if p := faces[4].Pix; p[1] < 0.5 || p[len(p)-3] > -0.5 {
+		t.Errorf("+Z face is upside down")
+	}
+}
+
+func TestCubeFaces_Errors(t *testing.T) {
+	if _, err := CubeFaces(NewFloat(10, 10), CubeEquirect, 4); err != ErrCubeLayout {
+		t.Errorf("error %v, want ErrCubeLayout", err)
+	}
+}
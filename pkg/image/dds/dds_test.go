@@ -0,0 +1,203 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package dds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/haakenlabs/ember/pkg/image/surface"
+)
+
+// header returns a DDS header for an image of the size, mip levels and pixel
+// format.
+func header(w, h, levels uint32, pf pixelFormat, caps2 uint32) []byte {
+	var buf bytes.Buffer
+
+	put := func(v ...uint32) {
+		for _, x := range v {
+			binary.Write(&buf, binary.LittleEndian, x)
+		}
+	}
+
+	buf.WriteString(Magic)
+	put(headerSize, 0x1007|flagMipMapCount, h, w, 0, 0, levels)
+	put(make([]uint32, 11)...)
+	put(pixelFormatSize, pf.flags, pf.fourCC, pf.bitCount)
+	put(pf.masks[:]...)
+	put(0x1000, caps2, 0, 0, 0)
+
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	var tests = []struct {
+		pf      pixelFormat
+		caps2   uint32
+		dx10    []uint32
+		w, h    uint32
+		levels  uint32
+		format  surface.Format
+		layers  int
+		cubemap bool
+		srgb    bool
+	}{
+		{
+			pf:     pixelFormat{flags: pfFourCC, fourCC: fourCC("DXT1")},
+			w:      8,
+			h:      4,
+			levels: 4,
+			format: surface.FormatBC1,
+			layers: 1,
+		},
+		{
+			pf:     pixelFormat{flags: pfRGB | pfAlphaPixels, bitCount: 32, masks: [4]uint32{0xff0000, 0xff00, 0xff, 0xff000000}},
+			w:      4,
+			h:      4,
+			levels: 1,
+			format: surface.FormatBGRA8,
+			layers: 1,
+		},
+		{
+			pf:      pixelFormat{flags: pfFourCC, fourCC: fourCC("DXT5")},
+			caps2:   caps2Cubemap | caps2AllFaces,
+			w:       4,
+			h:       4,
+			levels:  3,
+			format:  surface.FormatBC3,
+			layers:  1,
+			cubemap: true,
+		},
+		{
+			pf:     pixelFormat{flags: pfFourCC, fourCC: fourCC("DX10")},
+			dx10:   []uint32{99, 3, 0, 3, 0},
+			w:      16,
+			h:      16,
+			levels: 5,
+			format: surface.FormatBC7,
+			layers: 3,
+			srgb:   true,
+		},
+		{
+			pf:      pixelFormat{flags: pfFourCC, fourCC: fourCC("DX10")},
+			dx10:    []uint32{10, 3, miscTextureCube, 2, 0},
+			w:       2,
+			h:       2,
+			levels:  2,
+			format:  surface.FormatRGBA16F,
+			layers:  2,
+			cubemap: true,
+		},
+	}
+
+	for i, v := range tests {
+		data := header(v.w, v.h, v.levels, v.pf, v.caps2)
+		for _, x := range v.dx10 {
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], x)
+			data = append(data, b[:]...)
+		}
+
+		faces := 1
+		if v.cubemap {
+			faces = 6
+		}
+
+		// Every image is filled with the index of its layer and face.
+		for j := 0; j < v.layers*faces; j++ {
+			for l := 0; l < int(v.levels); l++ {
+				w, h := int(v.w)>>uint(l), int(v.h)>>uint(l)
+				if w < 1 {
+					w = 1
+				}
+				if h < 1 {
+					h = 1
+				}
+				data = append(data, bytes.Repeat([]byte{byte(j)}, v.format.Size(w, h))...)
+			}
+		}
+
+		img, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s case %d: %v", t.Name(), i, err)
+			continue
+		}
+
+		if img.Format != v.format || img.Layers != v.layers || img.Cubemap != v.cubemap || img.SRGB != v.srgb {
+			t.Errorf("%s case %d: %s, %d layers, cubemap %v, srgb %v", t.Name(), i, img.Format, img.Layers, img.Cubemap, img.SRGB)
+		}
+		if img.Width != int(v.w) || img.Height != int(v.h) || len(img.Levels) != int(v.levels) {
+			t.Errorf("%s case %d: %dx%d with %d levels", t.Name(), i, img.Width, img.Height, len(img.Levels))
+		}
+
+		for j := 0; j < v.layers*faces; j++ {
+			last := img.Data(len(img.Levels)-1, j/faces, j%faces)
+			if last[0] != byte(j) {
+				t.Errorf("%s case %d: image %d holds %d", t.Name(), i, j, last[0])
+			}
+		}
+	}
+}
+
+// dx10 returns a DDS file of a 4x4 BC1 image with a DX10 header of the
+// dimension, misc flags and array size, followed by data for one image.
+func dx10(dim, misc, arraySize uint32) []byte {
+	data := header(4, 4, 1, pixelFormat{flags: pfFourCC, fourCC: fourCC("DX10")}, 0)
+	for _, x := range []uint32{71, dim, misc, arraySize, 0} {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], x)
+		data = append(data, b[:]...)
+	}
+
+	return append(data, make([]byte, 8)...)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	dxt1 := pixelFormat{flags: pfFourCC, fourCC: fourCC("DXT1")}
+	block := make([]byte, 8)
+
+	var tests = [][]byte{
+		[]byte("DDS"),
+		append([]byte("XXXX"), header(4, 4, 1, dxt1, 0)[4:]...),
+		header(4, 4, 1, dxt1, 0),
+		header(4, 4, 4, dxt1, 0),
+		header(4, 4, 1, pixelFormat{flags: pfFourCC, fourCC: fourCC("ETC1")}, 0),
+		header(4, 4, 1, dxt1, caps2Volume),
+		header(4, 4, 1, dxt1, caps2Cubemap|0x400),
+		append(header(0, 4, 1, dxt1, 0), block...),
+		append(header(0xffffffff, 0xffffffff, 1, dxt1, 0), block...),
+		append(header(surface.MaxDimension*2, 4, 1, dxt1, 0), block...),
+		append(header(4, 4, 0xffffffff, dxt1, 0), block...),
+		dx10(3, 0, 0xffffffff),
+		dx10(3, 0, surface.MaxLayers+1),
+		dx10(3, miscTextureCube, 2),
+		dx10(dimTexture3D, 0, 1),
+	}
+
+	for i, data := range tests {
+		if _, err := Decode(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s case %d: expected error", t.Name(), i)
+		}
+	}
+}
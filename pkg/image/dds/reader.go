@@ -0,0 +1,283 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package dds
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/haakenlabs/ember/pkg/image/surface"
+)
+
+// Magic is the signature at the start of DDS files.
+const Magic = "DDS "
+
+const (
+	headerSize      = 124
+	dx10HeaderSize  = 20
+	pixelFormatSize = 32
+)
+
+// Header flags.
+const (
+	flagMipMapCount = 0x20000
+)
+
+// Pixel format flags.
+const (
+	pfAlphaPixels = 0x1
+	pfFourCC      = 0x4
+	pfRGB         = 0x40
+	pfLuminance   = 0x20000
+)
+
+// Caps2 flags.
+const (
+	caps2Cubemap    = 0x200
+	caps2AllFaces   = 0xfc00
+	caps2Volume     = 0x200000
+	miscTextureCube = 0x4
+	dimTexture3D    = 4
+)
+
+// DDS errors.
+var (
+	ErrFormat = errors.New("dds: invalid format")
+)
+
+// dxgiFormats maps DXGI formats to surface formats. sRGB formats are marked
+// by the second value.
+var dxgiFormats = map[uint32]struct {
+	format surface.Format
+	srgb   bool
+}{
+	2:  {surface.FormatRGBA32F, false},
+	10: {surface.FormatRGBA16F, false},
+	16: {surface.FormatRG32F, false},
+	28: {surface.FormatRGBA8, false},
+	29: {surface.FormatRGBA8, true},
+	34: {surface.FormatRG16F, false},
+	41: {surface.FormatR32F, false},
+	49: {surface.FormatRG8, false},
+	54: {surface.FormatR16F, false},
+	61: {surface.FormatR8, false},
+	71: {surface.FormatBC1, false},
+	72: {surface.FormatBC1, true},
+	74: {surface.FormatBC2, false},
+	75: {surface.FormatBC2, true},
+	77: {surface.FormatBC3, false},
+	78: {surface.FormatBC3, true},
+	80: {surface.FormatBC4, false},
+	83: {surface.FormatBC5, false},
+	87: {surface.FormatBGRA8, false},
+	91: {surface.FormatBGRA8, true},
+	95: {surface.FormatBC6H, false},
+	98: {surface.FormatBC7, false},
+	99: {surface.FormatBC7, true},
+}
+
+// fourCCFormats maps the four character codes of legacy files, and the
+// Direct3D 9 format numbers stored in their place, to surface formats.
+var fourCCFormats = map[uint32]surface.Format{
+	fourCC("DXT1"): surface.FormatBC1,
+	fourCC("DXT2"): surface.FormatBC2,
+	fourCC("DXT3"): surface.FormatBC2,
+	fourCC("DXT4"): surface.FormatBC3,
+	fourCC("DXT5"): surface.FormatBC3,
+	fourCC("ATI1"): surface.FormatBC4,
+	fourCC("BC4U"): surface.FormatBC4,
+	fourCC("ATI2"): surface.FormatBC5,
+	fourCC("BC5U"): surface.FormatBC5,
+	111:            surface.FormatR16F,
+	112:            surface.FormatRG16F,
+	113:            surface.FormatRGBA16F,
+	114:            surface.FormatR32F,
+	115:            surface.FormatRG32F,
+	116:            surface.FormatRGBA32F,
+}
+
+type pixelFormat struct {
+	flags    uint32
+	fourCC   uint32
+	bitCount uint32
+	masks    [4]uint32
+}
+
+// Decode reads a DDS file. Volume textures are not supported.
+func Decode(r io.Reader) (*surface.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 4+headerSize || string(data[:4]) != Magic {
+		return nil, ErrFormat
+	}
+
+	h := data[4:]
+	u32 := func(off int) uint32 { return binary.LittleEndian.Uint32(h[off:]) }
+
+	if u32(0) != headerSize || u32(72) != pixelFormatSize {
+		return nil, ErrFormat
+	}
+
+	flags := u32(4)
+	img := &surface.Image{
+		Height: int(u32(8)),
+		Width:  int(u32(12)),
+		Layers: 1,
+	}
+
+	levels := 1
+	if flags&flagMipMapCount != 0 && u32(24) > 1 {
+		levels = int(u32(24))
+	}
+
+	pf := pixelFormat{
+		flags:    u32(76),
+		fourCC:   u32(80),
+		bitCount: u32(84),
+		masks:    [4]uint32{u32(88), u32(92), u32(96), u32(100)},
+	}
+	caps2 := u32(108)
+
+	offset := 4 + headerSize
+	opaque := false
+
+	if pf.flags&pfFourCC != 0 && pf.fourCC == fourCC("DX10") {
+		if len(data) < offset+dx10HeaderSize {
+			return nil, ErrFormat
+		}
+
+		x := data[offset:]
+		dxgi := binary.LittleEndian.Uint32(x[0:])
+		dim := binary.LittleEndian.Uint32(x[4:])
+		misc := binary.LittleEndian.Uint32(x[8:])
+		arraySize := binary.LittleEndian.Uint32(x[12:])
+
+		f, ok := dxgiFormats[dxgi]
+		if !ok {
+			return nil, fmt.Errorf("dds: unsupported DXGI format %d", dxgi)
+		}
+		if dim == dimTexture3D {
+			return nil, fmt.Errorf("dds: volume textures are not supported")
+		}
+
+		img.Format, img.SRGB = f.format, f.srgb
+		img.Cubemap = misc&miscTextureCube != 0
+		if arraySize > 1 {
+			img.Layers = int(arraySize)
+		}
+
+		offset += dx10HeaderSize
+	} else {
+		if img.Format, err = legacyFormat(pf); err != nil {
+			return nil, err
+		}
+		opaque = pf.flags&pfRGB != 0 && pf.masks[3] == 0
+
+		if caps2&caps2Volume != 0 {
+			return nil, fmt.Errorf("dds: volume textures are not supported")
+		}
+		if caps2&caps2Cubemap != 0 {
+			if caps2&caps2AllFaces != caps2AllFaces {
+				return nil, fmt.Errorf("dds: cubemaps without all faces are not supported")
+			}
+			img.Cubemap = true
+		}
+	}
+
+	size, err := img.DataSize(levels)
+	if err != nil {
+		return nil, fmt.Errorf("dds: %v", err)
+	}
+	if size > int64(len(data)-offset) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	// Images are stored by layer and face, each with its mip chain.
+	n := img.Layers * img.Faces()
+
+	img.Levels = make([][][]byte, levels)
+	for l := range img.Levels {
+		img.Levels[l] = make([][]byte, n)
+	}
+
+	for i := 0; i < n; i++ {
+		for l := 0; l < levels; l++ {
+			size := img.Format.Size(img.LevelSize(l))
+
+			img.Levels[l][i] = data[offset : offset+size]
+			offset += size
+
+			// Colors without alpha leave its byte undefined.
+			if opaque {
+				for j := 3; j < size; j += 4 {
+					img.Levels[l][i][j] = 0xff
+				}
+			}
+		}
+	}
+
+	return img, img.Validate()
+}
+
+// legacyFormat returns the format of a file without a DX10 header.
+func legacyFormat(pf pixelFormat) (surface.Format, error) {
+	if pf.flags&pfFourCC != 0 {
+		if f, ok := fourCCFormats[pf.fourCC]; ok {
+			return f, nil
+		}
+		return surface.FormatUnknown, fmt.Errorf("dds: unsupported four character code %q", fourCCString(pf.fourCC))
+	}
+
+	switch {
+	case pf.flags&pfRGB != 0 && pf.bitCount == 32:
+		if pf.masks[3] != 0 && (pf.masks[3] != 0xff000000 || pf.flags&pfAlphaPixels == 0) {
+			break
+		}
+		switch {
+		case pf.masks == [4]uint32{0xff, 0xff00, 0xff0000, pf.masks[3]}:
+			return surface.FormatRGBA8, nil
+		case pf.masks == [4]uint32{0xff0000, 0xff00, 0xff, pf.masks[3]}:
+			return surface.FormatBGRA8, nil
+		}
+	case pf.flags&pfLuminance != 0 && pf.bitCount == 8:
+		return surface.FormatR8, nil
+	}
+
+	return surface.FormatUnknown, fmt.Errorf("dds: unsupported pixel format of %d bits", pf.bitCount)
+}
+
+func fourCC(s string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(s))
+}
+
+func fourCCString(c uint32) string {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], c)
+
+	return string(b[:])
+}
@@ -1,5 +1,5 @@
 /*
-Copyright (c) 2018 HaakenLabs
+Copyright (c) 2017 HaakenLabs
 
 Permission is hereby granted, free of charge, to any person obtaining a copy
 of this software and associated documentation files (the "Software"), to deal
@@ -20,4 +20,10 @@ OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 SOFTWARE.
 */
 
+// Package image processes texture images on the CPU: mip chain generation
+// with gamma-correct filtering, resizing with a choice of filters, alpha
+// premultiplication, normal map renormalization and channel swizzling.
+//
+// Images are converted to Float, which holds linear float RGBA pixels, and
+// converted back to 8-bit data for texture uploads once processed.
 package image
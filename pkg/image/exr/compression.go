@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package exr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+)
+
+// rleDecompress decompresses RLE compressed data of the given
+// uncompressed size. Each run starts with a signed count, negative for a
+// run of literal bytes and otherwise one less than the number of copies of
+// the following byte.
+func rleDecompress(src []byte, size int) ([]byte, error) {
+	out := make([]byte, 0, size)
+
+	for i := 0; i < len(src); {
+		n := int(int8(src[i]))
+		i++
+
+		if n < 0 {
+			n = -n
+			if len(src)-i < n || size-len(out) < n {
+				return nil, FormatError("invalid RLE data")
+			}
+			out = append(out, src[i:i+n]...)
+			i += n
+			continue
+		}
+
+		if i == len(src) || size-len(out) < n+1 {
+			return nil, FormatError("invalid RLE data")
+		}
+		for ; n >= 0; n-- {
+			out = append(out, src[i])
+		}
+		i++
+	}
+
+	if len(out) != size {
+		return nil, FormatError("invalid RLE data")
+	}
+
+	return unpredict(out), nil
+}
+
+// zipDecompress decompresses zlib compressed data of the given
+// uncompressed size.
+func zipDecompress(src []byte, size int) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	out := make([]byte, size)
+	if _, err := io.ReadFull(zr, out); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = FormatError("short ZIP data")
+		}
+		return nil, err
+	}
+
+	return unpredict(out), nil
+}
+
+// unpredict undoes the preprocessing of RLE and ZIP compressed data, which
+// stores the differences between successive bytes, and the bytes of even
+// offsets before those of odd offsets.
+func unpredict(b []byte) []byte {
+	for i := 1; i < len(b); i++ {
+		b[i] = byte(int(b[i-1]) + int(b[i]) - 128)
+	}
+
+	out := make([]byte, len(b))
+	half := (len(b) + 1) / 2
+
+	for i := range out {
+		if i%2 == 0 {
+			out[i] = b[i/2]
+		} else {
+			out[i] = b[half+i/2]
+		}
+	}
+
+	return out
+}
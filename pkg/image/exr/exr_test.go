@@ -0,0 +1,863 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package exr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"container/heap"
+	"encoding/binary"
+	"image"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/haakenlabs/ember/pkg/image/hdr"
+)
+
+// testFile describes a file written by encode.
+type testFile struct {
+	channels    []Channel
+	compression Compression
+	window      image.Rectangle
+	tiles       *Tiles
+	order       LineOrder
+	version     uint32
+}
+
+// testSamples returns samples of each channel over the window, which
+// halves represent exactly.
+func testSamples(f *testFile) map[string][]float32 {
+	rnd := rand.New(rand.NewSource(1))
+	w, h := f.window.Dx(), f.window.Dy()
+	samples := make(map[string][]float32)
+
+	for ci, c := range f.channels {
+		s := make([]float32, w*h)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				var v float32
+				switch {
+				case c.Type == PixelUint:
+					v = float32(rnd.Intn(1 << 20))
+				case x < w/2:
+					v = float32((x*7+y*3+ci)%40)/8 - 1
+				case c.Type == PixelHalf:
+					v = float32(rnd.Intn(2000)) / 64
+				default:
+					v = rnd.Float32() * 100
+				}
+				s[y*w+x] = v
+			}
+		}
+		samples[c.Name] = s
+	}
+
+	return samples
+}
+
+// floatToHalf converts a float32 which a half represents exactly.
+func floatToHalf(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	if f == 0 {
+		return sign
+	}
+
+	return sign | uint16(int(b>>23&0xff)-127+15)<<10 | uint16(b>>13&0x3ff)
+}
+
+func putAttribute(b *bytes.Buffer, name, typ string, value []byte) {
+	b.WriteString(name + "\x00" + typ + "\x00")
+	binary.Write(b, binary.LittleEndian, int32(len(value)))
+	b.Write(value)
+}
+
+func box(r image.Rectangle) []byte {
+	b := make([]byte, 16)
+	for i, v := range []int{r.Min.X, r.Min.Y, r.Max.X - 1, r.Max.Y - 1} {
+		binary.LittleEndian.PutUint32(b[i*4:], uint32(int32(v)))
+	}
+	return b
+}
+
+// encode writes a file of the samples.
+func encode(t *testing.T, f *testFile, samples map[string][]float32) []byte {
+	var b bytes.Buffer
+
+	version := uint32(2) | f.version
+	if f.tiles != nil {
+		version |= flagTiled
+	}
+	b.WriteString(Magic)
+	binary.Write(&b, binary.LittleEndian, version)
+
+	var chlist bytes.Buffer
+	for _, c := range f.channels {
+		chlist.WriteString(c.Name + "\x00")
+		xs, ys := c.XSampling, c.YSampling
+		if xs == 0 {
+			xs, ys = 1, 1
+		}
+		binary.Write(&chlist, binary.LittleEndian, []int32{int32(c.Type), 0, int32(xs), int32(ys)})
+	}
+	chlist.WriteByte(0)
+
+	putAttribute(&b, "channels", "chlist", chlist.Bytes())
+	putAttribute(&b, "compression", "compression", []byte{byte(f.compression)})
+	putAttribute(&b, "dataWindow", "box2i", box(f.window))
+	putAttribute(&b, "displayWindow", "box2i", box(f.window))
+	putAttribute(&b, "lineOrder", "lineOrder", []byte{byte(f.order)})
+	putAttribute(&b, "pixelAspectRatio", "float", []byte{0, 0, 0x80, 0x3f})
+	putAttribute(&b, "screenWindowWidth", "float", []byte{0, 0, 0x80, 0x3f})
+	if f.tiles != nil {
+		tiles := make([]byte, 9)
+		binary.LittleEndian.PutUint32(tiles[0:], uint32(f.tiles.Width))
+		binary.LittleEndian.PutUint32(tiles[4:], uint32(f.tiles.Height))
+		tiles[8] = byte(f.tiles.Mode)
+		putAttribute(&b, "tiles", "tiledesc", tiles)
+	}
+	b.WriteByte(0)
+
+	// Chunks are regions of the window, with their chunk header.
+	type chunk struct {
+		header     []int32
+		x, y, w, h int
+	}
+	var chunks []chunk
+
+	w, h := f.window.Dx(), f.window.Dy()
+	if f.tiles != nil {
+		for ty := 0; ty*f.tiles.Height < h; ty++ {
+			for tx := 0; tx*f.tiles.Width < w; tx++ {
+				c := chunk{header: []int32{int32(tx), int32(ty), 0, 0}, x: tx * f.tiles.Width, y: ty * f.tiles.Height}
+				c.w, c.h = f.tiles.Width, f.tiles.Height
+				if w-c.x < c.w {
+					c.w = w - c.x
+				}
+				if h-c.y < c.h {
+					c.h = h - c.y
+				}
+				chunks = append(chunks, c)
+			}
+		}
+	} else {
+		lines := f.compression.lines()
+		for y := 0; y < h; y += lines {
+			c := chunk{header: []int32{int32(f.window.Min.Y + y)}, y: y, w: w, h: lines}
+			if h-y < lines {
+				c.h = h - y
+			}
+			chunks = append(chunks, c)
+		}
+	}
+
+	table := b.Len()
+	b.Write(make([]byte, 8*len(chunks)))
+
+	order := make([]int, len(chunks))
+	for i := range order {
+		order[i] = i
+		if f.order == DecreasingY {
+			order[i] = len(chunks) - 1 - i
+		}
+	}
+
+	for _, i := range order {
+		c := chunks[i]
+
+		var raw bytes.Buffer
+		for y := c.y; y < c.y+c.h; y++ {
+			for _, ch := range f.channels {
+				for _, v := range samples[ch.Name][y*w+c.x : y*w+c.x+c.w] {
+					switch ch.Type {
+					case PixelHalf:
+						binary.Write(&raw, binary.LittleEndian, floatToHalf(v))
+					case PixelFloat:
+						binary.Write(&raw, binary.LittleEndian, v)
+					case PixelUint:
+						binary.Write(&raw, binary.LittleEndian, uint32(v))
+					}
+				}
+			}
+		}
+
+		data := compress(t, raw.Bytes(), f, c.w, c.h)
+
+		binary.LittleEndian.PutUint64(b.Bytes()[table+i*8:], uint64(b.Len()))
+		binary.Write(&b, binary.LittleEndian, c.header)
+		binary.Write(&b, binary.LittleEndian, int32(len(data)))
+		b.Write(data)
+	}
+
+	return b.Bytes()
+}
+
+// compress compresses the data of a chunk. Unlike in OpenEXR, data is
+// only stored uncompressed if compression does not change its size, so
+// that decompression is always exercised.
+func compress(t *testing.T, raw []byte, f *testFile, width, lines int) []byte {
+	var data []byte
+
+	switch f.compression {
+	case CompressionNone:
+		return raw
+	case CompressionRLE:
+		data = rleCompress(predict(raw))
+	case CompressionZIPS, CompressionZIP:
+		var b bytes.Buffer
+		zw := zlib.NewWriter(&b)
+		zw.Write(predict(raw))
+		zw.Close()
+		data = b.Bytes()
+	case CompressionPIZ:
+		data = pizCompress(raw, f.channels, width, lines)
+	default:
+		t.Fatalf("cannot compress %v", f.compression)
+	}
+
+	if len(data) == len(raw) {
+		return raw
+	}
+
+	return data
+}
+
+// predict is the inverse of unpredict.
+func predict(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i += 2 {
+		out = append(out, b[i])
+	}
+	for i := 1; i < len(b); i += 2 {
+		out = append(out, b[i])
+	}
+
+	for i := len(out) - 1; i > 0; i-- {
+		out[i] = byte(int(out[i]) - int(out[i-1]) + 128)
+	}
+
+	return out
+}
+
+func rleCompress(b []byte) []byte {
+	var out []byte
+
+	for i := 0; i < len(b); {
+		n := 1
+		for i+n < len(b) && n < 128 && b[i+n] == b[i] {
+			n++
+		}
+		if n >= 3 {
+			out = append(out, byte(n-1), b[i])
+			i += n
+			continue
+		}
+
+		j := i
+		for j < len(b) && j-i < 127 {
+			if j+2 < len(b) && b[j] == b[j+1] && b[j] == b[j+2] {
+				break
+			}
+			j++
+		}
+		out = append(out, byte(int8(-(j - i))))
+		out = append(out, b[i:j]...)
+		i = j
+	}
+
+	return out
+}
+
+func pizCompress(raw []byte, channels []Channel, width, lines int) []byte {
+	planes := make([][]uint16, len(channels))
+	for p, y := 0, 0; y < lines; y++ {
+		for i, c := range channels {
+			for n := width * c.Type.size() / 2; n > 0; n-- {
+				planes[i] = append(planes[i], binary.LittleEndian.Uint16(raw[p:]))
+				p += 2
+			}
+		}
+	}
+
+	var words []uint16
+	for _, p := range planes {
+		words = append(words, p...)
+	}
+
+	bitmap := make([]byte, bitmapSize)
+	for _, v := range words {
+		bitmap[v>>3] |= 1 << (v & 7)
+	}
+	bitmap[0] &^= 1
+
+	minNonZero, maxNonZero := bitmapSize-1, 0
+	for i, v := range bitmap {
+		if v != 0 {
+			if i < minNonZero {
+				minNonZero = i
+			}
+			if i > maxNonZero {
+				maxNonZero = i
+			}
+		}
+	}
+
+	lut := make([]uint16, ushortRange)
+	k := 0
+	for i := range lut {
+		if i == 0 || bitmap[i>>3]&(1<<uint(i&7)) != 0 {
+			lut[i] = uint16(k)
+			k++
+		}
+	}
+	for i, v := range words {
+		words[i] = lut[v]
+	}
+
+	start := 0
+	for _, c := range channels {
+		n := c.Type.size() / 2
+		for j := 0; j < n; j++ {
+			wav2Encode(words[start+j:], width, n, lines, width*n, uint16(k-1))
+		}
+		start += width * lines * n
+	}
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, []uint16{uint16(minNonZero), uint16(maxNonZero)})
+	if minNonZero <= maxNonZero {
+		b.Write(bitmap[minNonZero : maxNonZero+1])
+	}
+	huf := hufCompress(words)
+	binary.Write(&b, binary.LittleEndian, int32(len(huf)))
+	b.Write(huf)
+
+	return b.Bytes()
+}
+
+func wav2Encode(in []uint16, nx, ox, ny, oy int, mx uint16) {
+	enc := wenc16
+	if mx < 1<<14 {
+		enc = wenc14
+	}
+
+	n := nx
+	if ny < n {
+		n = ny
+	}
+
+	for p, p2 := 1, 2; p2 <= n; p, p2 = p2, p2<<1 {
+		py := 0
+		ey := oy * (ny - p2)
+		oy1, oy2 := oy*p, oy*p2
+		ox1, ox2 := ox*p, ox*p2
+
+		for ; py <= ey; py += oy2 {
+			px := py
+			ex := py + ox*(nx-p2)
+
+			for ; px <= ex; px += ox2 {
+				p01 := px + ox1
+				p10 := px + oy1
+				p11 := p10 + ox1
+
+				i00, i01 := enc(in[px], in[p01])
+				i10, i11 := enc(in[p10], in[p11])
+				in[px], in[p10] = enc(i00, i10)
+				in[p01], in[p11] = enc(i01, i11)
+			}
+
+			if nx&p != 0 {
+				p10 := px + oy1
+				in[px], in[p10] = enc(in[px], in[p10])
+			}
+		}
+
+		if ny&p != 0 {
+			px := py
+			ex := py + ox*(nx-p2)
+
+			for ; px <= ex; px += ox2 {
+				p01 := px + ox1
+				in[px], in[p01] = enc(in[px], in[p01])
+			}
+		}
+	}
+}
+
+func wenc14(a, b uint16) (uint16, uint16) {
+	as, bs := int(int16(a)), int(int16(b))
+
+	return uint16(int16((as + bs) >> 1)), uint16(int16(as - bs))
+}
+
+func wenc16(a, b uint16) (uint16, uint16) {
+	ao := (int(a) + 0x8000) & 0xffff
+	m := (ao + int(b)) >> 1
+	d := ao - int(b)
+	if d < 0 {
+		m = (m + 0x8000) & 0xffff
+	}
+
+	return uint16(m), uint16(d & 0xffff)
+}
+
+// bitWriter writes bits, most significant first.
+type bitWriter struct {
+	out   []byte
+	c     uint64
+	lc    uint
+	nBits int
+}
+
+func (w *bitWriter) write(v uint64, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		w.c = w.c<<1 | v>>uint(i)&1
+		w.lc++
+		w.nBits++
+		if w.lc == 8 {
+			w.out = append(w.out, byte(w.c))
+			w.c, w.lc = 0, 0
+		}
+	}
+}
+
+func (w *bitWriter) flush() {
+	if w.lc > 0 {
+		w.out = append(w.out, byte(w.c<<(8-w.lc)))
+		w.c, w.lc = 0, 0
+	}
+}
+
+type node struct {
+	freq   int
+	sym    int
+	kids   [2]*node
+	serial int
+}
+
+type nodeHeap []*node
+
+func (h nodeHeap) Len() int { return len(h) }
+func (h nodeHeap) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].serial < h[j].serial
+}
+func (h nodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(*node)) }
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// hufCompress Huffman codes words as hufDecompress reads them. Runs of
+// more than three repeats are coded with the run length symbol.
+func hufCompress(words []uint16) []byte {
+	freq := make([]int, hufEncSize)
+	for _, v := range words {
+		freq[v]++
+	}
+
+	im, iM := hufEncSize, 0
+	for i, f := range freq {
+		if f > 0 {
+			if i < im {
+				im = i
+			}
+			iM = i
+		}
+	}
+	iM++
+	freq[iM] = 1
+
+	var h nodeHeap
+	for i := im; i <= iM; i++ {
+		if freq[i] > 0 {
+			h = append(h, &node{freq: freq[i], sym: i, serial: len(h)})
+		}
+	}
+	heap.Init(&h)
+	for serial := len(h); h.Len() > 1; serial++ {
+		a := heap.Pop(&h).(*node)
+		b := heap.Pop(&h).(*node)
+		heap.Push(&h, &node{freq: a.freq + b.freq, kids: [2]*node{a, b}, serial: serial})
+	}
+
+	hcode := make([]uint64, hufEncSize)
+	var walk func(n *node, depth uint64)
+	walk = func(n *node, depth uint64) {
+		if n.kids[0] == nil {
+			hcode[n.sym] = depth
+			return
+		}
+		walk(n.kids[0], depth+1)
+		walk(n.kids[1], depth+1)
+	}
+	walk(h[0], 0)
+
+	// Pack the code lengths, with runs of unused symbols.
+	var tw bitWriter
+	for i := im; i <= iM; i++ {
+		if hcode[i] != 0 {
+			tw.write(hcode[i], 6)
+			continue
+		}
+
+		run := 1
+		for i+run <= iM && hcode[i+run] == 0 && run < 255+shortestLongRun {
+			run++
+		}
+		switch {
+		case run >= shortestLongRun:
+			tw.write(longZerocodeRun, 6)
+			tw.write(uint64(run-shortestLongRun), 8)
+		case run >= 2:
+			tw.write(uint64(shortZerocodeRun+run-2), 6)
+		default:
+			tw.write(0, 6)
+		}
+		i += run - 1
+	}
+	tw.flush()
+
+	hufCanonicalCodeTable(hcode)
+
+	code := func(w *bitWriter, sym int) {
+		w.write(hcode[sym]>>6, uint(hcode[sym]&63))
+	}
+
+	var dw bitWriter
+	for i := 0; i < len(words); {
+		code(&dw, int(words[i]))
+
+		run := 0
+		for i+1+run < len(words) && words[i+1+run] == words[i] && run < 255 {
+			run++
+		}
+		if run > 3 {
+			code(&dw, iM)
+			dw.write(uint64(run), 8)
+			i += run + 1
+		} else {
+			i++
+		}
+	}
+	nBits := dw.nBits
+	dw.flush()
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, []uint32{uint32(im), uint32(iM), uint32(len(tw.out)), uint32(nBits), 0})
+	b.Write(tw.out)
+	b.Write(dw.out)
+
+	return b.Bytes()
+}
+
+var (
+	rgbHalf = []Channel{
+		{Name: "B", Type: PixelHalf, XSampling: 1, YSampling: 1},
+		{Name: "G", Type: PixelHalf, XSampling: 1, YSampling: 1},
+		{Name: "R", Type: PixelHalf, XSampling: 1, YSampling: 1},
+	}
+	rgbaFloat = []Channel{
+		{Name: "A", Type: PixelFloat, XSampling: 1, YSampling: 1},
+		{Name: "B", Type: PixelFloat, XSampling: 1, YSampling: 1},
+		{Name: "G", Type: PixelFloat, XSampling: 1, YSampling: 1},
+		{Name: "R", Type: PixelFloat, XSampling: 1, YSampling: 1},
+	}
+	mixed = []Channel{
+		{Name: "Y", Type: PixelHalf, XSampling: 1, YSampling: 1},
+		{Name: "id", Type: PixelUint, XSampling: 1, YSampling: 1},
+		{Name: "z", Type: PixelFloat, XSampling: 1, YSampling: 1},
+	}
+)
+
+func TestDecodeImage(t *testing.T) {
+	compressions := []Compression{
+		CompressionNone,
+		CompressionRLE,
+		CompressionZIPS,
+		CompressionZIP,
+		CompressionPIZ,
+	}
+	windows := []image.Rectangle{
+		image.Rect(0, 0, 40, 70),
+		image.Rect(-3, 5, 16, 18),
+		image.Rect(2, 2, 3, 3),
+	}
+	tiles := []*Tiles{
+		nil,
+		{Width: 8, Height: 8},
+		{Width: 16, Height: 5, Mode: MipmapLevels},
+	}
+
+	for _, c := range compressions {
+		for _, win := range windows {
+			for _, tile := range tiles {
+				for _, channels := range [][]Channel{rgbHalf, rgbaFloat, mixed} {
+					for _, order := range []LineOrder{IncreasingY, DecreasingY} {
+						f := &testFile{
+							channels:    channels,
+							compression: c,
+							window:      win,
+							tiles:       tile,
+							order:       order,
+						}
+						testDecodeImage(t, f)
+					}
+				}
+			}
+		}
+	}
+}
+
+func testDecodeImage(t *testing.T, f *testFile) {
+	samples := testSamples(f)
+
+	m, err := DecodeImage(bytes.NewReader(encode(t, f, samples)))
+	if err != nil {
+		t.Fatalf("%v %v tiles %v: %v", f.compression, f.window, f.tiles, err)
+	}
+
+	if m.Width != f.window.Dx() || m.Height != f.window.Dy() {
+		t.Fatalf("size is %dx%d, want %v", m.Width, m.Height, f.window.Size())
+	}
+	if m.Header.DataWindow != f.window || m.Header.Compression != f.compression || len(m.Header.Channels) != len(f.channels) {
+		t.Fatalf("header is %+v", m.Header)
+	}
+
+	for _, c := range f.channels {
+		got := m.Channels[c.Name]
+		for i, v := range samples[c.Name] {
+			if got[i] != v {
+				t.Fatalf("%v %v tiles %v: channel %s sample %d is %v, want %v",
+					f.compression, f.window, f.tiles, c.Name, i, got[i], v)
+			}
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	f := &testFile{channels: rgbaFloat, compression: CompressionPIZ, window: image.Rect(10, 20, 42, 36)}
+	samples := testSamples(f)
+	data := encode(t, f, samples)
+
+	img, name, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "exr" {
+		t.Fatalf("format is %q", name)
+	}
+
+	rgb, ok := img.(*hdr.RGB96)
+	if !ok {
+		t.Fatalf("image is %T", img)
+	}
+	if rgb.Bounds() != image.Rect(0, 0, 32, 16) {
+		t.Fatalf("bounds are %v", rgb.Bounds())
+	}
+
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			i := y*32 + x
+			want := hdr.RGB96Color{R: samples["R"][i], G: samples["G"][i], B: samples["B"][i]}
+			if c := rgb.RGB96At(x, y); c != want {
+				t.Fatalf("pixel (%d, %d) is %v, want %v", x, y, c, want)
+			}
+		}
+	}
+
+	cfg, name, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "exr" || cfg.Width != 32 || cfg.Height != 16 || cfg.ColorModel != hdr.RGB96Model {
+		t.Fatalf("config is %v %+v", name, cfg)
+	}
+}
+
+func TestDecode_Luminance(t *testing.T) {
+	f := &testFile{channels: mixed, compression: CompressionZIP, window: image.Rect(0, 0, 8, 8)}
+	samples := testSamples(f)
+
+	img, err := Decode(bytes.NewReader(encode(t, f, samples)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rgb := img.(*hdr.RGB96)
+	for i, v := range samples["Y"] {
+		if c := rgb.RGB96At(i%8, i/8); c != (hdr.RGB96Color{R: v, G: v, B: v}) {
+			t.Fatalf("pixel %d is %v, want %v", i, c, v)
+		}
+	}
+}
+
+func TestHalfToFloat(t *testing.T) {
+	var tests = []struct {
+		h uint16
+		f float32
+	}{
+		{0x0000, 0},
+		{0x3c00, 1},
+		{0xc000, -2},
+		{0x3555, 0.333251953125},
+		{0x7bff, 65504},
+		{0x0400, 1.0 / (1 << 14)},
+		{0x0001, 1.0 / (1 << 24)},
+		{0x83ff, -1023.0 / (1 << 24)},
+		{0x7c00, float32(math.Inf(1))},
+		{0xfc00, float32(math.Inf(-1))},
+	}
+
+	for _, test := range tests {
+		if f := halfToFloat(test.h); f != test.f {
+			t.Errorf("halfToFloat(%#04x) = %v, want %v", test.h, f, test.f)
+		}
+	}
+
+	if f := halfToFloat(0x8000); f != 0 || !math.Signbit(float64(f)) {
+		t.Errorf("halfToFloat(0x8000) = %v, want -0", f)
+	}
+	if f := halfToFloat(0x7e00); !math.IsNaN(float64(f)) {
+		t.Errorf("halfToFloat(0x7e00) = %v, want NaN", f)
+	}
+}
+
+func TestWavelet(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, mx := range []uint16{1<<14 - 1, 0xffff} {
+		for _, size := range []image.Point{{1, 1}, {7, 3}, {16, 16}, {33, 9}} {
+			words := make([]uint16, size.X*size.Y*2)
+			for i := range words {
+				words[i] = uint16(rnd.Intn(int(mx) + 1))
+			}
+			want := append([]uint16(nil), words...)
+
+			// Two interleaved components, as of float channels.
+			for j := 0; j < 2; j++ {
+				wav2Encode(words[j:], size.X, 2, size.Y, size.X*2, mx)
+			}
+			for j := 0; j < 2; j++ {
+				wav2Decode(words[j:], size.X, 2, size.Y, size.X*2, mx)
+			}
+
+			for i := range words {
+				if words[i] != want[i] {
+					t.Fatalf("max %d size %v: word %d is %d, want %d", mx, size, i, words[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestHuffman(t *testing.T) {
+	// Frequencies of powers of two give codes longer than the decoding
+	// table index.
+	var words []uint16
+	for sym := 0; sym < 18; sym++ {
+		for i := 0; i < 1<<uint(sym); i++ {
+			words = append(words, uint16(sym*37))
+		}
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(words), func(i, j int) {
+		words[i], words[j] = words[j], words[i]
+	})
+	sort.Slice(words[:1000], func(i, j int) bool { return words[i] < words[j] })
+	words = append(words, 40000, 65535)
+
+	data := hufCompress(words)
+
+	got := make([]uint16, len(words))
+	if err := hufDecompress(data, got); err != nil {
+		t.Fatal(err)
+	}
+	for i := range words {
+		if got[i] != words[i] {
+			t.Fatalf("word %d is %d, want %d", i, got[i], words[i])
+		}
+	}
+
+	if err := hufDecompress(data, make([]uint16, len(words)+1)); err == nil {
+		t.Error("decoded into too large a buffer")
+	}
+	if err := hufDecompress(data[:len(data)-100], got); err == nil {
+		t.Error("decoded truncated data")
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	f := &testFile{channels: rgbHalf, compression: CompressionZIP, window: image.Rect(0, 0, 16, 16)}
+	valid := encode(t, f, testSamples(f))
+
+	multipart := append([]byte(nil), valid...)
+	multipart[5] |= flagMultipart >> 8
+
+	subsampled := &testFile{
+		channels:    []Channel{{Name: "R", Type: PixelHalf, XSampling: 2, YSampling: 2}},
+		compression: CompressionNone,
+		window:      image.Rect(0, 0, 4, 4),
+	}
+	b44 := &testFile{channels: rgbHalf, compression: CompressionNone, window: image.Rect(0, 0, 4, 4)}
+	b44Data := bytes.Replace(encode(t, b44, testSamples(b44)),
+		[]byte("compression\x00compression\x00\x01\x00\x00\x00\x00"),
+		[]byte("compression\x00compression\x00\x01\x00\x00\x00\x06"), 1)
+	noColor := &testFile{channels: mixed[1:], compression: CompressionNone, window: image.Rect(0, 0, 4, 4)}
+
+	var noChannels bytes.Buffer
+	noChannels.WriteString(Magic + "\x02\x00\x00\x00")
+	putAttribute(&noChannels, "compression", "compression", []byte{0})
+	noChannels.WriteByte(0)
+
+	var tests = []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"magic", []byte("\x76\x2f\x31\x02\x02\x00\x00\x00"), FormatError("not an EXR file")},
+		{"version", []byte(Magic + "\x01\x00\x00\x00"), UnsupportedError("version 1")},
+		{"multipart", multipart, UnsupportedError("multipart files")},
+		{"attributes", noChannels.Bytes(), FormatError("missing attribute channels")},
+		{"subsampled", encode(t, subsampled, map[string][]float32{"R": make([]float32, 16)}), UnsupportedError("subsampled channel R")},
+		{"compression", b44Data, UnsupportedError("B44 compression")},
+		{"color", encode(t, noColor, testSamples(noColor)), UnsupportedError("no color channels")},
+		{"truncated", valid[:len(valid)-10], io.ErrUnexpectedEOF},
+		{"header", valid[:40], io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		_, err := Decode(bytes.NewReader(test.data))
+		if err == nil || (test.err != nil && err != test.err) {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,447 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package exr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// PixelType is the type of the samples of a channel.
+type PixelType uint32
+
+const (
+	PixelUint PixelType = iota
+	PixelHalf
+	PixelFloat
+)
+
+func (t PixelType) String() string {
+	switch t {
+	case PixelUint:
+		return "uint"
+	case PixelHalf:
+		return "half"
+	case PixelFloat:
+		return "float"
+	default:
+		return "Unknown Pixel Type"
+	}
+}
+
+// size returns the size of a sample in bytes.
+func (t PixelType) size() int {
+	if t == PixelHalf {
+		return 2
+	}
+
+	return 4
+}
+
+// Compression is the compression method of the pixel data of an image.
+type Compression uint8
+
+const (
+	CompressionNone Compression = iota
+	CompressionRLE
+	CompressionZIPS
+	CompressionZIP
+	CompressionPIZ
+	CompressionPXR24
+	CompressionB44
+	CompressionB44A
+	CompressionDWAA
+	CompressionDWAB
+)
+
+var compressionNames = map[Compression]string{
+	CompressionNone:  "none",
+	CompressionRLE:   "RLE",
+	CompressionZIPS:  "ZIPS",
+	CompressionZIP:   "ZIP",
+	CompressionPIZ:   "PIZ",
+	CompressionPXR24: "PXR24",
+	CompressionB44:   "B44",
+	CompressionB44A:  "B44A",
+	CompressionDWAA:  "DWAA",
+	CompressionDWAB:  "DWAB",
+}
+
+func (c Compression) String() string {
+	if s, ok := compressionNames[c]; ok {
+		return s
+	}
+
+	return "Unknown Compression"
+}
+
+// lines returns the number of scanlines compressed together in a block of
+// a scanline image.
+func (c Compression) lines() int {
+	switch c {
+	case CompressionZIP, CompressionPXR24:
+		return 16
+	case CompressionPIZ, CompressionB44, CompressionB44A, CompressionDWAA:
+		return 32
+	case CompressionDWAB:
+		return 256
+	}
+
+	return 1
+}
+
+// LineOrder is the order in which the blocks of an image are stored.
+// Blocks are placed by their coordinates, so it does not matter to
+// decoding.
+type LineOrder uint8
+
+const (
+	IncreasingY LineOrder = iota
+	DecreasingY
+	RandomY
+)
+
+// LevelMode is the set of resolution levels of a tiled image.
+type LevelMode uint8
+
+const (
+	OneLevel LevelMode = iota
+	MipmapLevels
+	RipmapLevels
+)
+
+// Channel is a channel of an image.
+type Channel struct {
+	Name      string
+	Type      PixelType
+	PLinear   bool
+	XSampling int
+	YSampling int
+}
+
+// Tiles describes the tiles of a tiled image.
+type Tiles struct {
+	Width  int
+	Height int
+	Mode   LevelMode
+}
+
+// Attribute is a header attribute, as stored in the file.
+type Attribute struct {
+	Type  string
+	Value []byte
+}
+
+// Header is the header of an EXR image. Windows are exclusive of their
+// maximum, unlike in the file.
+type Header struct {
+	Channels      []Channel
+	Compression   Compression
+	DataWindow    image.Rectangle
+	DisplayWindow image.Rectangle
+	LineOrder     LineOrder
+	PixelAspect   float32
+
+	// Tiles is nil for scanline images.
+	Tiles *Tiles
+
+	// Attributes holds every attribute of the header, including those
+	// parsed into the fields above.
+	Attributes map[string]Attribute
+}
+
+// Channel returns the channel of the given name, or nil if the image has
+// none.
+func (h *Header) Channel(name string) *Channel {
+	for i := range h.Channels {
+		if h.Channels[i].Name == name {
+			return &h.Channels[i]
+		}
+	}
+
+	return nil
+}
+
+// pixelSize returns the size in bytes of the samples of a pixel over every
+// channel.
+func (h *Header) pixelSize() int {
+	n := 0
+	for _, c := range h.Channels {
+		n += c.Type.size()
+	}
+
+	return n
+}
+
+// byteReader is a reader of the header.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// readString reads a null terminated string of at most maxNameLength bytes.
+func readString(r byteReader) (string, error) {
+	var s []byte
+
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if c == 0 {
+			return string(s), nil
+		}
+		if len(s) == maxNameLength {
+			return "", FormatError("name too long")
+		}
+		s = append(s, c)
+	}
+}
+
+// readHeader reads the magic number, version and header of a file. Only
+// single part images are accepted.
+func readHeader(r byteReader) (*Header, error) {
+	var pre [8]byte
+	if _, err := io.ReadFull(r, pre[:]); err != nil {
+		return nil, err
+	}
+	if string(pre[:4]) != Magic {
+		return nil, FormatError("not an EXR file")
+	}
+
+	version := binary.LittleEndian.Uint32(pre[4:])
+	if version&0xff != 2 {
+		return nil, UnsupportedError(fmt.Sprintf("version %d", version&0xff))
+	}
+	if version&flagNonImage != 0 {
+		return nil, UnsupportedError("deep data")
+	}
+	if version&flagMultipart != 0 {
+		return nil, UnsupportedError("multipart files")
+	}
+
+	h := &Header{
+		PixelAspect: 1,
+		Attributes:  make(map[string]Attribute),
+	}
+
+	for {
+		name, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			break
+		}
+
+		typ, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+
+		var size int32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return nil, err
+		}
+		if size < 0 || size > maxAttributeSize {
+			return nil, FormatError("invalid size of attribute " + name)
+		}
+
+		value := make([]byte, size)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+
+		h.Attributes[name] = Attribute{Type: typ, Value: value}
+	}
+
+	if err := h.parseAttributes(version&flagTiled != 0); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// parseAttributes sets the fields of the header from its attributes.
+func (h *Header) parseAttributes(tiled bool) error {
+	for _, name := range []string{"channels", "compression", "dataWindow"} {
+		if _, ok := h.Attributes[name]; !ok {
+			return FormatError("missing attribute " + name)
+		}
+	}
+
+	for name, a := range h.Attributes {
+		var err error
+
+		switch name {
+		case "channels":
+			err = a.check("chlist", -1)
+			if err == nil {
+				h.Channels, err = parseChannels(a.Value)
+			}
+		case "compression":
+			err = a.check("compression", 1)
+			if err == nil {
+				h.Compression = Compression(a.Value[0])
+			}
+		case "dataWindow":
+			err = a.check("box2i", 16)
+			if err == nil {
+				h.DataWindow = parseBox(a.Value)
+			}
+		case "displayWindow":
+			err = a.check("box2i", 16)
+			if err == nil {
+				h.DisplayWindow = parseBox(a.Value)
+			}
+		case "lineOrder":
+			err = a.check("lineOrder", 1)
+			if err == nil {
+				h.LineOrder = LineOrder(a.Value[0])
+			}
+		case "pixelAspectRatio":
+			err = a.check("float", 4)
+			if err == nil {
+				h.PixelAspect = math.Float32frombits(binary.LittleEndian.Uint32(a.Value))
+			}
+		case "tiles":
+			err = a.check("tiledesc", 9)
+			if err == nil {
+				h.Tiles = &Tiles{
+					Width:  int(binary.LittleEndian.Uint32(a.Value[0:])),
+					Height: int(binary.LittleEndian.Uint32(a.Value[4:])),
+					Mode:   LevelMode(a.Value[8] & 0xf),
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if h.DataWindow.Empty() {
+		return FormatError("empty data window")
+	}
+	if h.DisplayWindow.Empty() {
+		h.DisplayWindow = h.DataWindow
+	}
+	if len(h.Channels) == 0 {
+		return FormatError("no channels")
+	}
+
+	for _, c := range h.Channels {
+		if c.Type > PixelFloat {
+			return FormatError("invalid pixel type of channel " + c.Name)
+		}
+		if c.XSampling != 1 || c.YSampling != 1 {
+			return UnsupportedError("subsampled channel " + c.Name)
+		}
+	}
+
+	if tiled {
+		if h.Tiles == nil {
+			return FormatError("missing attribute tiles")
+		}
+		if h.Tiles.Width < 1 || h.Tiles.Height < 1 || h.Tiles.Width*h.Tiles.Height > maxPixels {
+			return FormatError("invalid tile size")
+		}
+		if h.Tiles.Mode > RipmapLevels {
+			return FormatError("invalid level mode")
+		}
+	} else {
+		h.Tiles = nil
+	}
+
+	if h.Compression > CompressionDWAB {
+		return FormatError("invalid compression")
+	}
+
+	w, ht := h.DataWindow.Dx(), h.DataWindow.Dy()
+	if w > maxPixels/ht || w*ht > maxPixels/len(h.Channels) {
+		return UnsupportedError("image too large")
+	}
+
+	return nil
+}
+
+// check returns an error unless the attribute is of the given type and,
+// unless size is negative, size.
+func (a Attribute) check(typ string, size int) error {
+	if a.Type != typ {
+		return FormatError("attribute type " + a.Type + " is not " + typ)
+	}
+	if size >= 0 && len(a.Value) != size {
+		return FormatError("invalid size of " + typ + " attribute")
+	}
+
+	return nil
+}
+
+// parseChannels parses a chlist attribute.
+func parseChannels(b []byte) ([]Channel, error) {
+	var channels []Channel
+
+	for {
+		i := 0
+		for i < len(b) && b[i] != 0 {
+			i++
+		}
+		if i == len(b) {
+			return nil, FormatError("unterminated channel list")
+		}
+		if i == 0 {
+			return channels, nil
+		}
+
+		name := string(b[:i])
+		b = b[i+1:]
+		if len(b) < 16 {
+			return nil, FormatError("short channel list")
+		}
+
+		channels = append(channels, Channel{
+			Name:      name,
+			Type:      PixelType(binary.LittleEndian.Uint32(b[0:])),
+			PLinear:   b[4] != 0,
+			XSampling: int(int32(binary.LittleEndian.Uint32(b[8:]))),
+			YSampling: int(int32(binary.LittleEndian.Uint32(b[12:]))),
+		})
+		b = b[16:]
+	}
+}
+
+// parseBox parses a box2i attribute, of inclusive maximum, to a rectangle.
+// Inverted boxes are kept, and are empty.
+func parseBox(b []byte) image.Rectangle {
+	v := func(i int) int {
+		return int(int32(binary.LittleEndian.Uint32(b[i*4:])))
+	}
+
+	return image.Rectangle{
+		Min: image.Point{v(0), v(1)},
+		Max: image.Point{v(2) + 1, v(3) + 1},
+	}
+}
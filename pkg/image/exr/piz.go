@@ -0,0 +1,521 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package exr
+
+import "encoding/binary"
+
+// PIZ compression applies a lookup table and a wavelet transform to the
+// 16-bit words of the pixel data, and Huffman codes them.
+
+const (
+	bitmapSize  = 1 << 13
+	ushortRange = 1 << 16
+
+	hufEncBits = 16
+	hufDecBits = 14
+	hufEncSize = 1<<hufEncBits + 1
+	hufDecSize = 1 << hufDecBits
+	hufDecMask = hufDecSize - 1
+
+	// Code lengths of runs of symbols without a code, in the packed
+	// encoding table.
+	shortZerocodeRun = 59
+	longZerocodeRun  = 63
+	shortestLongRun  = 2 + longZerocodeRun - shortZerocodeRun
+)
+
+// errHuffman reports invalid Huffman coded data.
+var errHuffman = FormatError("invalid Huffman data")
+
+// pizDecompress decompresses PIZ compressed pixel data of a region of the
+// given size.
+func pizDecompress(src []byte, channels []Channel, width, lines, size int) ([]byte, error) {
+	if len(src) < 4 {
+		return nil, FormatError("short PIZ data")
+	}
+
+	minNonZero := int(binary.LittleEndian.Uint16(src[0:]))
+	maxNonZero := int(binary.LittleEndian.Uint16(src[2:]))
+	src = src[4:]
+
+	if maxNonZero >= bitmapSize {
+		return nil, FormatError("invalid PIZ bitmap")
+	}
+
+	bitmap := make([]byte, bitmapSize)
+	if minNonZero <= maxNonZero {
+		n := maxNonZero - minNonZero + 1
+		if len(src) < n {
+			return nil, FormatError("short PIZ data")
+		}
+		copy(bitmap[minNonZero:], src[:n])
+		src = src[n:]
+	}
+
+	lut, maxValue := reverseLUT(bitmap)
+
+	if len(src) < 4 {
+		return nil, FormatError("short PIZ data")
+	}
+	length := int(int32(binary.LittleEndian.Uint32(src)))
+	src = src[4:]
+	if length < 0 || length > len(src) {
+		return nil, FormatError("short PIZ data")
+	}
+
+	words := make([]uint16, size/2)
+	if err := hufDecompress(src[:length], words); err != nil {
+		return nil, err
+	}
+
+	// Each channel is transformed on its own, of one word per half
+	// sample and two per float or uint sample.
+	start := 0
+	for _, c := range channels {
+		n := c.Type.size() / 2
+		for j := 0; j < n; j++ {
+			wav2Decode(words[start+j:], width, n, lines, width*n, maxValue)
+		}
+		start += width * lines * n
+	}
+
+	for i, v := range words {
+		words[i] = lut[v]
+	}
+
+	// The words of each channel are together; interleave them by line.
+	out := make([]byte, 0, size)
+	starts := make([]int, len(channels))
+	start = 0
+	for i, c := range channels {
+		starts[i] = start
+		start += width * lines * c.Type.size() / 2
+	}
+
+	for j := 0; j < lines; j++ {
+		for i, c := range channels {
+			n := width * c.Type.size() / 2
+			for _, v := range words[starts[i] : starts[i]+n] {
+				out = append(out, byte(v), byte(v>>8))
+			}
+			starts[i] += n
+		}
+	}
+
+	return out, nil
+}
+
+// reverseLUT returns the table mapping the indices of the values set in
+// the bitmap back to the values, along with the largest index. Zero is
+// always included.
+func reverseLUT(bitmap []byte) ([]uint16, uint16) {
+	lut := make([]uint16, ushortRange)
+
+	k := 0
+	for i := 0; i < ushortRange; i++ {
+		if i == 0 || bitmap[i>>3]&(1<<uint(i&7)) != 0 {
+			lut[k] = uint16(i)
+			k++
+		}
+	}
+
+	return lut, uint16(k - 1)
+}
+
+// wav2Decode undoes the 2D wavelet transform of n values, of x stride ox
+// and y stride oy. Values below 1<<14 use a transform without modular
+// arithmetic.
+func wav2Decode(in []uint16, nx, ox, ny, oy int, mx uint16) {
+	w14 := mx < 1<<14
+
+	dec := wdec16
+	if w14 {
+		dec = wdec14
+	}
+
+	n := nx
+	if ny < n {
+		n = ny
+	}
+
+	p := 1
+	for p <= n {
+		p <<= 1
+	}
+	p >>= 1
+	p2 := p
+	p >>= 1
+
+	// Decode each level, from the coarsest.
+	for p >= 1 {
+		py := 0
+		ey := oy * (ny - p2)
+		oy1, oy2 := oy*p, oy*p2
+		ox1, ox2 := ox*p, ox*p2
+
+		for ; py <= ey; py += oy2 {
+			px := py
+			ex := py + ox*(nx-p2)
+
+			for ; px <= ex; px += ox2 {
+				p01 := px + ox1
+				p10 := px + oy1
+				p11 := p10 + ox1
+
+				i00, i10 := dec(in[px], in[p10])
+				i01, i11 := dec(in[p01], in[p11])
+				in[px], in[p01] = dec(i00, i01)
+				in[p10], in[p11] = dec(i10, i11)
+			}
+
+			// Odd column.
+			if nx&p != 0 {
+				p10 := px + oy1
+				in[px], in[p10] = dec(in[px], in[p10])
+			}
+		}
+
+		// Odd line.
+		if ny&p != 0 {
+			px := py
+			ex := py + ox*(nx-p2)
+
+			for ; px <= ex; px += ox2 {
+				p01 := px + ox1
+				in[px], in[p01] = dec(in[px], in[p01])
+			}
+		}
+
+		p2 = p
+		p >>= 1
+	}
+}
+
+// wdec14 decodes the low and high parts of a pair of 14-bit values.
+func wdec14(l, h uint16) (uint16, uint16) {
+	hi := int(int16(h))
+	ai := int(int16(l)) + (hi & 1) + (hi >> 1)
+
+	return uint16(int16(ai)), uint16(int16(ai - hi))
+}
+
+// wdec16 decodes the low and high parts of a pair of 16-bit values, in
+// modular arithmetic.
+func wdec16(l, h uint16) (uint16, uint16) {
+	m, d := int(l), int(h)
+	b := (m - (d >> 1)) & 0xffff
+	a := (d + b - 0x8000) & 0xffff
+
+	return uint16(a), uint16(b)
+}
+
+// bitReader reads the bits of Huffman coded data, most significant first.
+type bitReader struct {
+	in  []byte
+	pos int
+
+	c  uint64
+	lc int
+}
+
+// fill reads a byte into the buffer.
+func (b *bitReader) fill() error {
+	if b.pos >= len(b.in) {
+		return errHuffman
+	}
+
+	b.c = b.c<<8 | uint64(b.in[b.pos])
+	b.pos++
+	b.lc += 8
+
+	return nil
+}
+
+// bits reads n bits.
+func (b *bitReader) bits(n int) (uint64, error) {
+	for b.lc < n {
+		if err := b.fill(); err != nil {
+			return 0, err
+		}
+	}
+	b.lc -= n
+
+	return (b.c >> uint(b.lc)) & (1<<uint(n) - 1), nil
+}
+
+// hufDec is an entry of the decoding table, indexed by the first
+// hufDecBits bits of codes. Codes no longer than that have entries of
+// their length and symbol; longer codes are listed by the entries of
+// their prefix.
+type hufDec struct {
+	len  int
+	lit  int
+	long []int
+}
+
+// hufDecompress decodes Huffman coded words into out, which must be
+// filled exactly.
+func hufDecompress(src []byte, out []uint16) error {
+	if len(src) == 0 {
+		if len(out) != 0 {
+			return errHuffman
+		}
+		return nil
+	}
+	if len(src) < 20 {
+		return errHuffman
+	}
+
+	im := int(binary.LittleEndian.Uint32(src[0:]))
+	iM := int(binary.LittleEndian.Uint32(src[4:]))
+	nBits := int(binary.LittleEndian.Uint32(src[12:]))
+
+	if im < 0 || im >= hufEncSize || iM < 0 || iM >= hufEncSize || im > iM {
+		return errHuffman
+	}
+
+	br := &bitReader{in: src[20:]}
+
+	hcode := make([]uint64, hufEncSize)
+	if err := hufUnpackEncTable(br, im, iM, hcode); err != nil {
+		return err
+	}
+
+	data := src[20+br.pos:]
+	if nBits < 0 || nBits > 8*len(data) {
+		return errHuffman
+	}
+
+	hdec, err := hufBuildDecTable(hcode, im, iM)
+	if err != nil {
+		return err
+	}
+
+	return hufDecode(hcode, hdec, data[:(nBits+7)/8], nBits, iM, out)
+}
+
+// hufUnpackEncTable reads the packed code lengths of the symbols im to iM,
+// and builds their canonical codes.
+func hufUnpackEncTable(br *bitReader, im, iM int, hcode []uint64) error {
+	for ; im <= iM; im++ {
+		l, err := br.bits(6)
+		if err != nil {
+			return err
+		}
+		hcode[im] = l
+
+		var run int
+		switch {
+		case l == longZerocodeRun:
+			n, err := br.bits(8)
+			if err != nil {
+				return err
+			}
+			run = int(n) + shortestLongRun
+		case l >= shortZerocodeRun:
+			run = int(l) - shortZerocodeRun + 2
+		default:
+			continue
+		}
+
+		if im+run > iM+1 {
+			return errHuffman
+		}
+		for ; run > 0; run-- {
+			hcode[im] = 0
+			im++
+		}
+		im--
+	}
+
+	hufCanonicalCodeTable(hcode)
+
+	return nil
+}
+
+// hufCanonicalCodeTable assigns canonical codes to the code lengths of the
+// table, which then hold the code above the low 6 bits of the length.
+// Longer codes are assigned lower values.
+func hufCanonicalCodeTable(hcode []uint64) {
+	var n [59]uint64
+
+	for _, l := range hcode {
+		n[l]++
+	}
+
+	var c uint64
+	for i := 58; i > 0; i-- {
+		nc := (c + n[i]) >> 1
+		n[i] = c
+		c = nc
+	}
+
+	for i, l := range hcode {
+		if l > 0 {
+			hcode[i] = l | n[l]<<6
+			n[l]++
+		}
+	}
+}
+
+// hufBuildDecTable builds the decoding table of the codes of the symbols
+// im to iM.
+func hufBuildDecTable(hcode []uint64, im, iM int) ([]hufDec, error) {
+	hdec := make([]hufDec, hufDecSize)
+
+	for ; im <= iM; im++ {
+		c := hcode[im] >> 6
+		l := int(hcode[im] & 63)
+
+		if c>>uint(l) != 0 {
+			return nil, errHuffman
+		}
+
+		if l > hufDecBits {
+			pl := &hdec[c>>uint(l-hufDecBits)]
+			if pl.len != 0 {
+				return nil, errHuffman
+			}
+			pl.lit++
+			pl.long = append(pl.long, im)
+		} else if l != 0 {
+			i := int(c << uint(hufDecBits-l))
+			for n := 1 << uint(hufDecBits-l); n > 0; n-- {
+				pl := &hdec[i]
+				if pl.len != 0 || pl.long != nil {
+					return nil, errHuffman
+				}
+				pl.len = l
+				pl.lit = im
+				i++
+			}
+		}
+	}
+
+	return hdec, nil
+}
+
+// hufDecode decodes nBits bits of codes into out. The symbol rlc is
+// followed by a count of repeats of the previous word.
+func hufDecode(hcode []uint64, hdec []hufDec, in []byte, nBits, rlc int, out []uint16) error {
+	br := &bitReader{in: in}
+	n := 0
+
+	emit := func(sym int) error {
+		if sym != rlc {
+			if n == len(out) {
+				return errHuffman
+			}
+			out[n] = uint16(sym)
+			n++
+			return nil
+		}
+
+		if br.lc < 8 {
+			if err := br.fill(); err != nil {
+				return err
+			}
+		}
+		br.lc -= 8
+		cs := int(br.c>>uint(br.lc)) & 0xff
+
+		if n == 0 || len(out)-n < cs {
+			return errHuffman
+		}
+		for s := out[n-1]; cs > 0; cs-- {
+			out[n] = s
+			n++
+		}
+
+		return nil
+	}
+
+	for br.pos < len(in) {
+		if err := br.fill(); err != nil {
+			return err
+		}
+
+		for br.lc >= hufDecBits {
+			pl := &hdec[(br.c>>uint(br.lc-hufDecBits))&hufDecMask]
+
+			if pl.len != 0 {
+				br.lc -= pl.len
+				if err := emit(pl.lit); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if pl.long == nil {
+				return errHuffman
+			}
+
+			j := 0
+			for ; j < pl.lit; j++ {
+				sym := pl.long[j]
+				l := int(hcode[sym] & 63)
+
+				for br.lc < l && br.pos < len(in) {
+					if err := br.fill(); err != nil {
+						return err
+					}
+				}
+
+				if br.lc >= l && hcode[sym]>>6 == (br.c>>uint(br.lc-l))&(1<<uint(l)-1) {
+					br.lc -= l
+					if err := emit(sym); err != nil {
+						return err
+					}
+					break
+				}
+			}
+			if j == pl.lit {
+				return errHuffman
+			}
+		}
+	}
+
+	// Decode the codes left in the buffer, less the padding of the last
+	// byte.
+	i := (8 - nBits) & 7
+	br.c >>= uint(i)
+	br.lc -= i
+
+	for br.lc > 0 {
+		pl := &hdec[(br.c<<uint(hufDecBits-br.lc))&hufDecMask]
+		if pl.len == 0 || pl.len > br.lc {
+			return errHuffman
+		}
+
+		br.lc -= pl.len
+		if err := emit(pl.lit); err != nil {
+			return err
+		}
+	}
+
+	if n != len(out) {
+		return errHuffman
+	}
+
+	return nil
+}
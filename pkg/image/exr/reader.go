@@ -0,0 +1,383 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package exr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/haakenlabs/ember/pkg/image/hdr"
+)
+
+// Magic is the magic number EXR files start with.
+const Magic = "\x76\x2f\x31\x01"
+
+// Flags of the version field.
+const (
+	flagTiled     = 0x200
+	flagNonImage  = 0x800
+	flagMultipart = 0x1000
+)
+
+const (
+	maxNameLength    = 255
+	maxAttributeSize = 1 << 26
+
+	// maxPixels limits the size of decoded images.
+	maxPixels = 1 << 28
+)
+
+// FormatError reports that the input is not a valid EXR image.
+type FormatError string
+
+func (e FormatError) Error() string {
+	return "exr: invalid format: " + string(e)
+}
+
+// UnsupportedError reports that the input uses a valid but unimplemented EXR feature.
+type UnsupportedError string
+
+func (e UnsupportedError) Error() string {
+	return "exr: unsupported feature: " + string(e)
+}
+
+func init() {
+	image.RegisterFormat("exr", Magic, Decode, DecodeConfig)
+}
+
+// Image is a decoded EXR image. Its samples cover the data window of its
+// header, with the origin at the top left corner of the window.
+type Image struct {
+	Header *Header
+	Width  int
+	Height int
+
+	// Channels maps the name of each channel to its samples, row by row.
+	Channels map[string][]float32
+}
+
+// RGB96 returns the color of the image, taken from its R, G and B channels,
+// or from its Y channel if it is a luminance image. Missing channels are
+// zero, and any other channel is dropped.
+func (m *Image) RGB96() *hdr.RGB96 {
+	img := hdr.NewRGB96(image.Rect(0, 0, m.Width, m.Height))
+
+	r, g, b := m.Channels["R"], m.Channels["G"], m.Channels["B"]
+	if r == nil && g == nil && b == nil {
+		r = m.Channels["Y"]
+		g, b = r, r
+	}
+
+	sample := func(c []float32, i int) float32 {
+		if c == nil {
+			return 0
+		}
+		return c[i]
+	}
+
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			i := y*m.Width + x
+			img.SetRGB96(x, y, hdr.RGB96Color{
+				R: sample(r, i),
+				G: sample(g, i),
+				B: sample(b, i),
+			})
+		}
+	}
+
+	return img
+}
+
+// hasColor reports whether the image has any channel read by RGB96.
+func (m *Image) hasColor() bool {
+	for _, name := range []string{"R", "G", "B", "Y"} {
+		if _, ok := m.Channels[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decoder reads the pixel data of a file held in memory.
+type decoder struct {
+	h    *Header
+	data []byte
+	img  *Image
+
+	// table is the offset of the chunk offset table.
+	table int
+}
+
+func (d *decoder) decode() (*Image, error) {
+	switch d.h.Compression {
+	case CompressionNone, CompressionRLE, CompressionZIPS, CompressionZIP, CompressionPIZ:
+	default:
+		return nil, UnsupportedError(d.h.Compression.String() + " compression")
+	}
+
+	w, h := d.h.DataWindow.Dx(), d.h.DataWindow.Dy()
+
+	d.img = &Image{
+		Header:   d.h,
+		Width:    w,
+		Height:   h,
+		Channels: make(map[string][]float32, len(d.h.Channels)),
+	}
+	for _, c := range d.h.Channels {
+		d.img.Channels[c.Name] = make([]float32, w*h)
+	}
+
+	// Only the full resolution level of tiled images is read, whose tiles
+	// come first.
+	var chunks int
+	if t := d.h.Tiles; t != nil {
+		chunks = ((w + t.Width - 1) / t.Width) * ((h + t.Height - 1) / t.Height)
+	} else {
+		lines := d.h.Compression.lines()
+		chunks = (h + lines - 1) / lines
+	}
+
+	for i := 0; i < chunks; i++ {
+		p := d.table + i*8
+		if p+8 > len(d.data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		off := binary.LittleEndian.Uint64(d.data[p:])
+		if off < uint64(d.table) || off >= uint64(len(d.data)) {
+			return nil, FormatError("invalid chunk offset")
+		}
+
+		var err error
+		if d.h.Tiles != nil {
+			err = d.readTile(d.data[off:])
+		} else {
+			err = d.readBlock(d.data[off:])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d.img, nil
+}
+
+// readBlock reads a block of scanlines.
+func (d *decoder) readBlock(b []byte) error {
+	if len(b) < 8 {
+		return io.ErrUnexpectedEOF
+	}
+
+	win := d.h.DataWindow
+	lines := d.h.Compression.lines()
+
+	y := int(int32(binary.LittleEndian.Uint32(b[0:])))
+	if y < win.Min.Y || y >= win.Max.Y || (y-win.Min.Y)%lines != 0 {
+		return FormatError("invalid scanline block")
+	}
+	if win.Max.Y-y < lines {
+		lines = win.Max.Y - y
+	}
+
+	return d.readChunk(b[4:], win.Min.X, y, win.Dx(), lines)
+}
+
+// readTile reads a tile of the full resolution level.
+func (d *decoder) readTile(b []byte) error {
+	if len(b) < 16 {
+		return io.ErrUnexpectedEOF
+	}
+
+	var v [4]int
+	for i := range v {
+		v[i] = int(int32(binary.LittleEndian.Uint32(b[i*4:])))
+	}
+	if v[2] != 0 || v[3] != 0 {
+		return FormatError("invalid tile level")
+	}
+
+	t := d.h.Tiles
+	x, y := v[0]*t.Width, v[1]*t.Height
+	if v[0] < 0 || v[1] < 0 || x >= d.img.Width || y >= d.img.Height {
+		return FormatError("invalid tile")
+	}
+
+	width, lines := t.Width, t.Height
+	if d.img.Width-x < width {
+		width = d.img.Width - x
+	}
+	if d.img.Height-y < lines {
+		lines = d.img.Height - y
+	}
+
+	win := d.h.DataWindow
+
+	return d.readChunk(b[16:], win.Min.X+x, win.Min.Y+y, width, lines)
+}
+
+// readChunk reads the size prefixed pixel data of a chunk, of a region of
+// the data window.
+func (d *decoder) readChunk(b []byte, x, y, width, lines int) error {
+	if len(b) < 4 {
+		return io.ErrUnexpectedEOF
+	}
+
+	size := int(int32(binary.LittleEndian.Uint32(b)))
+	if size < 0 {
+		return FormatError("invalid chunk size")
+	}
+	if size > len(b)-4 {
+		return io.ErrUnexpectedEOF
+	}
+
+	raw, err := d.decompress(b[4:4+size], width, lines)
+	if err != nil {
+		return err
+	}
+
+	// Lines hold the samples of each channel in turn.
+	win := d.h.DataWindow
+	p := 0
+
+	for j := 0; j < lines; j++ {
+		row := (y-win.Min.Y+j)*d.img.Width + x - win.Min.X
+
+		for _, c := range d.h.Channels {
+			dst := d.img.Channels[c.Name][row : row+width]
+
+			switch c.Type {
+			case PixelHalf:
+				for i := range dst {
+					dst[i] = halfToFloat(binary.LittleEndian.Uint16(raw[p:]))
+					p += 2
+				}
+			case PixelFloat:
+				for i := range dst {
+					dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[p:]))
+					p += 4
+				}
+			case PixelUint:
+				for i := range dst {
+					dst[i] = float32(binary.LittleEndian.Uint32(raw[p:]))
+					p += 4
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// decompress returns the uncompressed pixel data of a chunk.
+func (d *decoder) decompress(src []byte, width, lines int) ([]byte, error) {
+	size := width * lines * d.h.pixelSize()
+
+	// Chunks which compression would not shrink are stored as they are.
+	if len(src) == size {
+		return src, nil
+	}
+
+	switch d.h.Compression {
+	case CompressionRLE:
+		return rleDecompress(src, size)
+	case CompressionZIPS, CompressionZIP:
+		return zipDecompress(src, size)
+	case CompressionPIZ:
+		return pizDecompress(src, d.h.Channels, width, lines, size)
+	}
+
+	return nil, FormatError("invalid size of uncompressed chunk")
+}
+
+// ReadHeader reads the header of an EXR image.
+func ReadHeader(r io.Reader) (*Header, error) {
+	h, err := readHeader(bufio.NewReader(r))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return h, err
+}
+
+// DecodeImage reads an EXR image from r, with the samples of every
+// channel.
+func DecodeImage(r io.Reader) (*Image, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bytes.NewReader(data)
+
+	h, err := readHeader(br)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	d := &decoder{
+		h:     h,
+		data:  data,
+		table: len(data) - br.Len(),
+	}
+
+	return d.decode()
+}
+
+// Decode reads an EXR image from r and returns its color as an
+// *hdr.RGB96.
+func Decode(r io.Reader) (image.Image, error) {
+	m, err := DecodeImage(r)
+	if err != nil {
+		return nil, err
+	}
+	if !m.hasColor() {
+		return nil, UnsupportedError("no color channels")
+	}
+
+	return m.RGB96(), nil
+}
+
+// DecodeConfig returns the color model and dimensions of an EXR image
+// without decoding the entire image.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{
+		ColorModel: hdr.RGB96Model,
+		Width:      h.DataWindow.Dx(),
+		Height:     h.DataWindow.Dy(),
+	}, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package image
+
+import "math"
+
+// Filter is a resampling filter. Its kernel weighs a source pixel by its
+// distance from the sampled point, in destination pixels, and is zero at
+// distances beyond the support.
+type Filter struct {
+	Support float64
+	Kernel  func(x float64) float64
+}
+
+// Resampling filters.
+var (
+	// Box averages the source pixels covered by each destination pixel.
+	Box = Filter{Support: 0.5, Kernel: box}
+
+	// Triangle interpolates linearly between source pixels.
+	Triangle = Filter{Support: 1, Kernel: triangle}
+
+	// CatmullRom is a sharp cubic filter.
+	CatmullRom = Filter{Support: 2, Kernel: cubic(0, 0.5)}
+
+	// MitchellNetravali is a cubic filter balancing sharpness and ringing.
+	MitchellNetravali = Filter{Support: 2, Kernel: cubic(1.0/3, 1.0/3)}
+
+	// Lanczos3 is a sinc filter windowed by a sinc three pixels wide.
+	Lanczos3 = Filter{Support: 3, Kernel: lanczos(3)}
+
+	// Kaiser is a sinc filter windowed by a Kaiser window three pixels wide.
+	// It keeps mip levels sharper than Box does.
+	Kaiser = Filter{Support: 3, Kernel: kaiser(3, 4)}
+)
+
+func box(x float64) float64 {
+	if x >= -0.5 && x < 0.5 {
+		return 1
+	}
+
+	return 0
+}
+
+func triangle(x float64) float64 {
+	x = math.Abs(x)
+	if x < 1 {
+		return 1 - x
+	}
+
+	return 0
+}
+
+// cubic returns the kernel of the Mitchell-Netravali family of cubic filters
+// with parameters b and c.
+func cubic(b, c float64) func(float64) float64 {
+	return func(x float64) float64 {
+		x = math.Abs(x)
+
+		switch {
+		case x < 1:
+			return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+		case x < 2:
+			return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+		}
+
+		return 0
+	}
+}
+
+func lanczos(width float64) func(float64) float64 {
+	return func(x float64) float64 {
+		if math.Abs(x) >= width {
+			return 0
+		}
+
+		return sinc(x) * sinc(x/width)
+	}
+}
+
+// kaiser returns the kernel of a sinc filter windowed by a Kaiser window of
+// the given width and shape alpha.
+func kaiser(width, alpha float64) func(float64) float64 {
+	scale := 1 / bessel0(alpha)
+
+	return func(x float64) float64 {
+		t := x / width
+		if t <= -1 || t >= 1 {
+			return 0
+		}
+
+		return sinc(x) * bessel0(alpha*math.Sqrt(1-t*t)) * scale
+	}
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+
+	x *= math.Pi
+
+	return math.Sin(x) / x
+}
+
+// bessel0 is the zeroth order modified Bessel function of the first kind.
+func bessel0(x float64) float64 {
+	sum, term := 1.0, 1.0
+
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x * x / 4) / float64(k*k)
+		sum += term
+	}
+
+	return sum
+}
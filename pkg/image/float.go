@@ -0,0 +1,281 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package image
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/haakenlabs/ember/pkg/image/hdr"
+	emath "github.com/haakenlabs/ember/pkg/math"
+)
+
+// ErrShortData is returned for pixel data smaller than its image.
+var ErrShortData = errors.New("image: pixel data too short")
+
+// Float is an image of float RGBA pixels, the working format of the
+// processing functions. Color is linear and alpha is straight unless the
+// image was premultiplied with Premultiply.
+type Float struct {
+	Width  int
+	Height int
+
+	// Pix holds the pixels in R, G, B, A order, row by row.
+	Pix []float32
+}
+
+// NewFloat returns a transparent black image of the given size.
+func NewFloat(width, height int) *Float {
+	return &Float{
+		Width:  width,
+		Height: height,
+		Pix:    make([]float32, width*height*4),
+	}
+}
+
+// FromRGBA8 converts 8-bit RGBA data to a float image. The color of sRGB
+// data is converted to linear.
+func FromRGBA8(data []byte, width, height int, srgb bool) (*Float, error) {
+	f := NewFloat(width, height)
+	if len(data) < len(f.Pix) {
+		return nil, ErrShortData
+	}
+
+	for i := range f.Pix {
+		if srgb && i%4 != 3 {
+			f.Pix[i] = srgbDecode[data[i]]
+		} else {
+			f.Pix[i] = float32(data[i]) / 255
+		}
+	}
+
+	return f, nil
+}
+
+// FromImage converts an image to a float image. The color of images of sRGB
+// color is converted to linear. HDR images are linear and opaque, whatever
+// srgb is.
+func FromImage(img image.Image, srgb bool) *Float {
+	b := img.Bounds()
+	f := NewFloat(b.Dx(), b.Dy())
+
+	if rgb, ok := img.(*hdr.RGB96); ok {
+		for y := 0; y < f.Height; y++ {
+			for x := 0; x < f.Width; x++ {
+				c := rgb.RGB96At(b.Min.X+x, b.Min.Y+y)
+				i := (y*f.Width + x) * 4
+				f.Pix[i+0] = c.R
+				f.Pix[i+1] = c.G
+				f.Pix[i+2] = c.B
+				f.Pix[i+3] = 1
+			}
+		}
+
+		return f
+	}
+
+	// Straight alpha is kept exactly, rather than through premultiplied
+	// color.
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		for y := 0; y < f.Height; y++ {
+			i := nrgba.PixOffset(b.Min.X, b.Min.Y+y)
+			data, _ := FromRGBA8(nrgba.Pix[i:i+f.Width*4], f.Width, 1, srgb)
+			copy(f.Pix[y*f.Width*4:], data.Pix)
+		}
+
+		return f
+	}
+
+	for y := 0; y < f.Height; y++ {
+		for x := 0; x < f.Width; x++ {
+			c := color.NRGBA64Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA64)
+			i := (y*f.Width + x) * 4
+			f.Pix[i+0] = float32(c.R) / 0xffff
+			f.Pix[i+1] = float32(c.G) / 0xffff
+			f.Pix[i+2] = float32(c.B) / 0xffff
+			f.Pix[i+3] = float32(c.A) / 0xffff
+
+			if srgb {
+				for j := i; j < i+3; j++ {
+					f.Pix[j] = emath.SRGBToLinear(f.Pix[j])
+				}
+			}
+		}
+	}
+
+	return f
+}
+
+// RGBA8 converts the image to 8-bit RGBA data, as taken by
+// gfx.Texture.SetData. Color is encoded as sRGB if srgb is true. Values are
+// clamped to [0, 1].
+func (f *Float) RGBA8(srgb bool) []byte {
+	data := make([]byte, len(f.Pix))
+
+	for i, v := range f.Pix {
+		if srgb && i%4 != 3 {
+			v = emath.LinearToSRGB(v)
+		}
+		data[i] = quantize(v)
+	}
+
+	return data
+}
+
+// RGB32 converts the image to float RGB data, as taken by
+// gfx.Texture.SetHDRData. Alpha is dropped and values are not clamped.
+func (f *Float) RGB32() []float32 {
+	data := make([]float32, 0, f.Width*f.Height*3)
+
+	for i := 0; i < len(f.Pix); i += 4 {
+		data = append(data, f.Pix[i], f.Pix[i+1], f.Pix[i+2])
+	}
+
+	return data
+}
+
+// NRGBA converts the image to an image.NRGBA, with color encoded as sRGB if
+// srgb is true.
+func (f *Float) NRGBA(srgb bool) *image.NRGBA {
+	return &image.NRGBA{
+		Pix:    f.RGBA8(srgb),
+		Stride: f.Width * 4,
+		Rect:   image.Rect(0, 0, f.Width, f.Height),
+	}
+}
+
+// Premultiply multiplies the color of each pixel by its alpha.
+func (f *Float) Premultiply() {
+	for i := 0; i < len(f.Pix); i += 4 {
+		a := f.Pix[i+3]
+		f.Pix[i+0] *= a
+		f.Pix[i+1] *= a
+		f.Pix[i+2] *= a
+	}
+}
+
+// Unpremultiply divides the color of each pixel by its alpha. The color of
+// transparent pixels is left as it is.
+func (f *Float) Unpremultiply() {
+	for i := 0; i < len(f.Pix); i += 4 {
+		a := f.Pix[i+3]
+		if a == 0 {
+			continue
+		}
+		f.Pix[i+0] /= a
+		f.Pix[i+1] /= a
+		f.Pix[i+2] /= a
+	}
+}
+
+// RenormalizeNormals restores unit length to the normals of a normal map,
+// which filtering shortens. Normals are encoded in RGB as n*0.5+0.5. Normals
+// of zero length are replaced by +Z.
+func (f *Float) RenormalizeNormals() {
+	for i := 0; i < len(f.Pix); i += 4 {
+		x := float64(f.Pix[i+0])*2 - 1
+		y := float64(f.Pix[i+1])*2 - 1
+		z := float64(f.Pix[i+2])*2 - 1
+
+		l := math.Sqrt(x*x + y*y + z*z)
+		if l == 0 {
+			x, y, z, l = 0, 0, 1, 1
+		}
+
+		f.Pix[i+0] = float32(x/l*0.5 + 0.5)
+		f.Pix[i+1] = float32(y/l*0.5 + 0.5)
+		f.Pix[i+2] = float32(z/l*0.5 + 0.5)
+	}
+}
+
+// Swizzle rearranges the channels of each pixel. The pattern names the
+// source of each of the four channels with r, g, b or a, or sets it to a
+// constant with 0 or 1: "bgra" swaps red and blue, and "rrr1" spreads red
+// over an opaque gray image.
+func (f *Float) Swizzle(pattern string) error {
+	if len(pattern) != 4 {
+		return fmt.Errorf("image: invalid swizzle pattern %q", pattern)
+	}
+
+	var src [4]int
+	for i := 0; i < 4; i++ {
+		switch pattern[i] {
+		case 'r', 'R':
+			src[i] = 0
+		case 'g', 'G':
+			src[i] = 1
+		case 'b', 'B':
+			src[i] = 2
+		case 'a', 'A':
+			src[i] = 3
+		case '0':
+			src[i] = -1
+		case '1':
+			src[i] = -2
+		default:
+			return fmt.Errorf("image: invalid swizzle pattern %q", pattern)
+		}
+	}
+
+	for i := 0; i < len(f.Pix); i += 4 {
+		var p [4]float32
+		copy(p[:], f.Pix[i:i+4])
+
+		for j, s := range src {
+			switch s {
+			case -1:
+				f.Pix[i+j] = 0
+			case -2:
+				f.Pix[i+j] = 1
+			default:
+				f.Pix[i+j] = p[s]
+			}
+		}
+	}
+
+	return nil
+}
+
+// srgbDecode holds the linear values of 8-bit sRGB channels.
+var srgbDecode = func() (t [256]float32) {
+	for i := range t {
+		t[i] = emath.SRGBToLinear(float32(i) / 255)
+	}
+
+	return
+}()
+
+// quantize converts a value to 8 bits, rounding to nearest.
+func quantize(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+
+	return uint8(v*255 + 0.5)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package image
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/haakenlabs/ember/pkg/image/hdr"
+)
+
+func TestFromRGBA8(t *testing.T) {
+	data := make([]byte, 256*4)
+	for i := range data {
+		data[i] = uint8(i / 4)
+	}
+
+	for _, srgb := range []bool{false, true} {
+		f, err := FromRGBA8(data, 16, 16, srgb)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := f.RGBA8(srgb)
+		for i := range data {
+			if got[i] != data[i] {
+				t.Fatalf("srgb %v: round trip of %d = %d", srgb, data[i], got[i])
+			}
+		}
+	}
+
+	f, _ := FromRGBA8([]byte{188, 188, 188, 188}, 1, 1, true)
+	if math.Abs(float64(f.Pix[0])-0.5029) > 1e-3 {
+		t.Errorf("linear value of sRGB 188 = %v, want 0.5029", f.Pix[0])
+	}
+	if f.Pix[3] != 188.0/255 {
+		t.Errorf("alpha = %v, want %v", f.Pix[3], 188.0/255)
+	}
+
+	if _, err := FromRGBA8(data, 17, 16, false); err != ErrShortData {
+		t.Errorf("err = %v, want %v", err, ErrShortData)
+	}
+}
+
+func TestFromImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(2, 3, 4, 4))
+	img.SetNRGBA(3, 3, color.NRGBA{255, 0, 51, 102})
+
+	f := FromImage(img, false)
+	if f.Width != 2 || f.Height != 1 {
+		t.Fatalf("size = %dx%d, want 2x1", f.Width, f.Height)
+	}
+	want := []float32{0, 0, 0, 0, 1, 0, 0.2, 0.4}
+	for i := range want {
+		if math.Abs(float64(f.Pix[i]-want[i])) > 1e-6 {
+			t.Errorf("Pix[%d] = %v, want %v", i, f.Pix[i], want[i])
+		}
+	}
+
+	rgb := hdr.NewRGB96(image.Rect(0, 0, 1, 1))
+	rgb.SetRGB96(0, 0, hdr.RGB96Color{R: 4, G: 0.5, B: 0})
+
+	f = FromImage(rgb, true)
+	want = []float32{4, 0.5, 0, 1}
+	for i := range want {
+		if f.Pix[i] != want[i] {
+			t.Errorf("HDR Pix[%d] = %v, want %v", i, f.Pix[i], want[i])
+		}
+	}
+}
+
+func TestFloat_RGB32(t *testing.T) {
+	f := &Float{Width: 2, Height: 1, Pix: []float32{4, 0.5, 0, 1, -1, 2, 3, 0}}
+
+	data := f.RGB32()
+	want := []float32{4, 0.5, 0, -1, 2, 3}
+	if len(data) != len(want) {
+		t.Fatalf("len = %d, want %d", len(data), len(want))
+	}
+	for i := range want {
+		if data[i] != want[i] {
+			t.Errorf("data[%d] = %v, want %v", i, data[i], want[i])
+		}
+	}
+}
+
+func TestFloat_Premultiply(t *testing.T) {
+	f := &Float{Width: 2, Height: 1, Pix: []float32{1, 0.5, 0.25, 0.5, 1, 1, 1, 0}}
+
+	f.Premultiply()
+	want := []float32{0.5, 0.25, 0.125, 0.5, 0, 0, 0, 0}
+	for i := range want {
+		if f.Pix[i] != want[i] {
+			t.Errorf("premultiplied Pix[%d] = %v, want %v", i, f.Pix[i], want[i])
+		}
+	}
+
+	f.Unpremultiply()
+	want = []float32{1, 0.5, 0.25, 0.5, 0, 0, 0, 0}
+	for i := range want {
+		if f.Pix[i] != want[i] {
+			t.Errorf("unpremultiplied Pix[%d] = %v, want %v", i, f.Pix[i], want[i])
+		}
+	}
+}
+
+func TestFloat_RenormalizeNormals(t *testing.T) {
+	f := &Float{Width: 3, Height: 1, Pix: []float32{
+		1, 0.5, 0.5, 1,
+		0.75, 0.5, 0.5, 1,
+		0.5, 0.5, 0.5, 1,
+	}}
+
+	f.RenormalizeNormals()
+
+	want := []float32{
+		1, 0.5, 0.5, 1,
+		1, 0.5, 0.5, 1,
+		0.5, 0.5, 1, 1,
+	}
+	for i := range want {
+		if math.Abs(float64(f.Pix[i]-want[i])) > 1e-6 {
+			t.Errorf("Pix[%d] = %v, want %v", i, f.Pix[i], want[i])
+		}
+	}
+}
+
+func TestFloat_Swizzle(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		want    []float32
+		wantErr bool
+	}{
+		{pattern: "rgba", want: []float32{0.1, 0.2, 0.3, 0.4}},
+		{pattern: "BGRA", want: []float32{0.3, 0.2, 0.1, 0.4}},
+		{pattern: "rrr1", want: []float32{0.1, 0.1, 0.1, 1}},
+		{pattern: "a000", want: []float32{0.4, 0, 0, 0}},
+		{pattern: "rgb", wantErr: true},
+		{pattern: "rgbx", wantErr: true},
+	}
+
+	for i, v := range tests {
+		f := &Float{Width: 1, Height: 1, Pix: []float32{0.1, 0.2, 0.3, 0.4}}
+
+		err := f.Swizzle(v.pattern)
+		if (err != nil) != v.wantErr {
+			t.Errorf("%s failed test case %d. err: %v wantErr: %v", t.Name(), i, err, v.wantErr)
+			continue
+		}
+		if v.wantErr {
+			continue
+		}
+
+		for j := range v.want {
+			if f.Pix[j] != v.want[j] {
+				t.Errorf("%s case %d: Pix = %v, want %v", t.Name(), i, f.Pix, v.want)
+				break
+			}
+		}
+	}
+}
@@ -20,6 +20,7 @@ OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 SOFTWARE.
 */
 
-// Package hdr implements an image.Image-compliant reader for the Radiance HDR
-// image format.
+// Package hdr implements an image.Image-compliant reader and a writer for
+// the Radiance HDR image format, in every orientation and with both run
+// length encoding schemes.
 package hdr
@@ -0,0 +1,274 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package hdr
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// testImage returns an image of gradients, constant areas and noise, to
+// exercise both literal and repeated runs.
+func testImage(w, h int) *RGB96 {
+	rnd := rand.New(rand.NewSource(1))
+	img := NewRGB96(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var c RGB96Color
+			switch {
+			case x < w/3:
+				c = RGB96Color{float32(x) / 4, float32(y) * 8, 0.01}
+			case x < 2*w/3:
+				c = RGB96Color{0.5, 0.25, 2}
+			default:
+				c = RGB96Color{rnd.Float32() * 1000, rnd.Float32(), rnd.Float32() * 0.001}
+			}
+			img.SetRGB96(x, y, c)
+		}
+	}
+
+	return img
+}
+
+// compare checks that the images match, within the precision of the shared
+// exponent of RGBE pixels.
+func compare(t *testing.T, name string, got, want *RGB96) {
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("%s: bounds %v, want %v", name, got.Bounds(), want.Bounds())
+	}
+
+	b := want.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			g, w := got.RGB96At(x, y), want.RGB96At(x, y)
+			tol := float64(math.Max(float64(w.R), math.Max(float64(w.G), float64(w.B)))) / 100
+
+			if math.Abs(float64(g.R-w.R)) > tol || math.Abs(float64(g.G-w.G)) > tol || math.Abs(float64(g.B-w.B)) > tol {
+				t.Fatalf("%s: pixel (%d, %d) is %v, want %v", name, x, y, g, w)
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	img := testImage(37, 23)
+
+	var orientations []Orientation
+	for _, major := range []Axis{MinusY, PlusY, MinusX, PlusX} {
+		for _, minor := range []Axis{MinusY, PlusY, MinusX, PlusX} {
+			if o := (Orientation{major, minor}); o.Valid() {
+				orientations = append(orientations, o)
+			}
+		}
+	}
+	if len(orientations) != 8 {
+		t.Fatalf("%d orientations", len(orientations))
+	}
+
+	for _, o := range orientations {
+		for _, flat := range []bool{false, true} {
+			var buf bytes.Buffer
+			if err := Encode(&buf, img, &Options{Orientation: o, Flat: flat}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, h, err := DecodeRGB96(&buf)
+			if err != nil {
+				t.Fatalf("%v flat %v: %v", o, flat, err)
+			}
+			if h.Orientation != o {
+				t.Errorf("orientation %v, want %v", h.Orientation, o)
+			}
+
+			compare(t, h.resolution(), got, img)
+		}
+	}
+}
+
+func TestEncode_Compression(t *testing.T) {
+	img := testImage(300, 4)
+
+	var rle, flat bytes.Buffer
+	if err := Encode(&rle, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Encode(&flat, img, &Options{Flat: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rle.Len() >= flat.Len() {
+		t.Errorf("run length encoded size %d, flat size %d", rle.Len(), flat.Len())
+	}
+
+	got, err := Decode(&rle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compare(t, "rle", got.(*RGB96), img)
+}
+
+func TestEncode_Exposure(t *testing.T) {
+	img := testImage(16, 16)
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, &Options{Exposure: 0.25, Software: "ember"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, h, err := DecodeRGB96(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h.Exposure != 0.25 || h.Software != "ember" || h.Vars["EXPOSURE"] != "0.25" {
+		t.Errorf("exposure %v, software %q, vars %v", h.Exposure, h.Software, h.Vars)
+	}
+
+	compare(t, "exposure", got, img)
+}
+
+func TestEncode_SRGB(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{255, 128, 0, 255})
+	img.SetNRGBA(1, 0, color.NRGBA{255, 255, 255, 0})
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := NewRGB96(img.Bounds())
+	want.SetRGB96(0, 0, RGB96Color{1, 0.2158605, 0})
+	compare(t, "srgb", got.(*RGB96), want)
+}
+
+func TestDecode_Header(t *testing.T) {
+	data := "#?RGBE\n" +
+		"# A comment\n" +
+		"pfilt -x /2 -y /2\n" +
+		"EXPOSURE=2\n" +
+		"EXPOSURE= 0.5e1\n" +
+		"COLORCORR=1 2 4\n" +
+		"PIXASPECT=1.5\n" +
+		"PRIMARIES=0.64 0.33 0.3 0.6 0.15 0.06 0.3127 0.329\n" +
+		"VIEW=-vtv -vp 0 0 0\n" +
+		"FORMAT=32-bit_rle_rgbe\n" +
+		"\n" +
+		"+X 2 -Y 1\n" +
+		"\x80\x80\x80\x81\x80\x80\x80\x80"
+
+	img, h, err := DecodeRGB96(bytes.NewReader([]byte(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h.Width != 2 || h.Height != 1 || h.Orientation != (Orientation{PlusX, MinusY}) {
+		t.Errorf("size %dx%d, orientation %v", h.Width, h.Height, h.Orientation)
+	}
+	if h.Exposure != 10 || h.ColorCorr != [3]float64{1, 2, 4} || h.PixelAspect != 1.5 {
+		t.Errorf("exposure %v, color correction %v, pixel aspect %v", h.Exposure, h.ColorCorr, h.PixelAspect)
+	}
+	if h.Primaries[6] != 0.3127 || h.Vars["VIEW"] != "-vtv -vp 0 0 0" {
+		t.Errorf("primaries %v, vars %v", h.Primaries, h.Vars)
+	}
+
+	// Pixels are divided by the exposure and color correction.
+	if c := img.RGB96At(0, 0); c != (RGB96Color{0.1, 0.05, 0.025}) {
+		t.Errorf("pixel %v", c)
+	}
+	if c := img.RGB96At(1, 0); c != (RGB96Color{0.05, 0.025, 0.0125}) {
+		t.Errorf("pixel %v", c)
+	}
+
+	// The format is registered for both signatures.
+	if _, name, err := image.Decode(bytes.NewReader([]byte(data))); err != nil || name != "hdr" {
+		t.Errorf("image.Decode: %q, %v", name, err)
+	}
+}
+
+func TestDecode_OldRLE(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("#?RADIANCE\n\n-Y 2 +X 300\n")
+
+	// A pixel repeated 43 + 1<<8 times, then a row of flat pixels.
+	buf.Write([]byte{128, 64, 32, 129, 1, 1, 1, 43, 1, 1, 1, 1})
+	for i := 0; i < 300; i++ {
+		buf.Write([]byte{byte(i), 0, 0, 136})
+	}
+
+	img, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rgb := img.(*RGB96)
+	for x := 0; x < 300; x++ {
+		if c := rgb.RGB96At(x, 0); c != (RGB96Color{1, 0.5, 0.25}) {
+			t.Fatalf("pixel (%d, 0) is %v", x, c)
+		}
+		if c := rgb.RGB96At(x, 1); c != (RGB96Color{float32(byte(x)), 0, 0}) {
+			t.Fatalf("pixel (%d, 1) is %v", x, c)
+		}
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	var valid bytes.Buffer
+	if err := Encode(&valid, testImage(32, 4), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name string
+		data string
+		err  error
+	}{
+		{"magic", "P6\n", FormatError("not an HDR file")},
+		{"format", "#?RADIANCE\nFORMAT=32-bit_rle_rgba\n\n-Y 1 +X 1\n", UnsupportedError("format 32-bit_rle_rgba")},
+		{"resolution", "#?RADIANCE\n\n-Y 1 -Y 1\n", FormatError("invalid resolution string")},
+		{"dimension", "#?RADIANCE\n\n-Y 4294967296 +X 4294967296\n", UnsupportedError("image too large")},
+		{"width", "#?RADIANCE\n\n-Y 1 +X 65537\n", UnsupportedError("image too large")},
+		{"pixels", "#?RADIANCE\n\n-Y 65536 +X 65536\n", UnsupportedError("image too large")},
+		{"exposure", "#?RADIANCE\nEXPOSURE=0\n\n-Y 1 +X 1\n", nil},
+		{"repeat", "#?RADIANCE\n\n-Y 1 +X 2\n\x01\x01\x01\x01", FormatError("repeat of no pixel")},
+		{"truncated", valid.String()[:valid.Len()-10], io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		_, err := Decode(bytes.NewReader([]byte(test.data)))
+		if err == nil || (test.err != nil && err != test.err) {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package hdr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pixel formats of the FORMAT header variable.
+const (
+	FormatRGBE = "32-bit_rle_rgbe"
+	FormatXYZE = "32-bit_rle_xyze"
+)
+
+// Axis is a signed axis of the resolution string of a file. Y points up, so
+// that -Y runs from the top of the image to the bottom.
+type Axis uint8
+
+const (
+	MinusY Axis = iota
+	PlusY
+	MinusX
+	PlusX
+)
+
+var axisNames = map[Axis]string{
+	MinusY: "-Y",
+	PlusY:  "+Y",
+	MinusX: "-X",
+	PlusX:  "+X",
+}
+
+func (a Axis) String() string {
+	return axisNames[a]
+}
+
+// isX reports whether the axis is horizontal.
+func (a Axis) isX() bool {
+	return a == MinusX || a == PlusX
+}
+
+// index returns the image coordinate of the k-th pixel along the axis, of n
+// pixels.
+func (a Axis) index(k, n int) int {
+	if a == PlusY || a == MinusX {
+		return n - 1 - k
+	}
+
+	return k
+}
+
+func parseAxis(s string) (Axis, bool) {
+	for a, name := range axisNames {
+		if s == name {
+			return a, true
+		}
+	}
+
+	return 0, false
+}
+
+// Orientation is the order of the pixels of a file: scanlines follow each
+// other along the Major axis, and the pixels of a scanline run along the
+// Minor axis.
+type Orientation struct {
+	Major Axis
+	Minor Axis
+}
+
+// Standard is the usual orientation, of rows from top to bottom with pixels
+// from left to right.
+var Standard = Orientation{MinusY, PlusX}
+
+// Valid reports whether the orientation has a vertical and a horizontal
+// axis.
+func (o Orientation) Valid() bool {
+	return o.Major.isX() != o.Minor.isX()
+}
+
+// Header holds the header of a Radiance HDR file.
+type Header struct {
+	Width  int
+	Height int
+
+	Orientation Orientation
+
+	// Format is FormatRGBE or FormatXYZE.
+	Format string
+
+	// Exposure is the multiplier applied to the pixels of the file, the
+	// product of the EXPOSURE variables. It is 1 if there are none.
+	Exposure float64
+
+	// ColorCorr is the multiplier applied to each channel of the pixels of
+	// the file, the product of the COLORCORR variables.
+	ColorCorr [3]float64
+
+	// PixelAspect is the height over width ratio of the pixels, the product
+	// of the PIXASPECT variables.
+	PixelAspect float64
+
+	// Primaries holds the CIE xy chromaticities of the red, green and blue
+	// primaries and of the white point, or zeros if unset.
+	Primaries [8]float64
+
+	// Software is the program which wrote the file.
+	Software string
+
+	// Vars holds the value of every header variable by name, the last one
+	// for repeated variables.
+	Vars map[string]string
+}
+
+func newHeader() *Header {
+	return &Header{
+		Orientation: Standard,
+		Format:      FormatRGBE,
+		Exposure:    1,
+		ColorCorr:   [3]float64{1, 1, 1},
+		PixelAspect: 1,
+		Vars:        make(map[string]string),
+	}
+}
+
+// parseVar parses a header line of the form NAME=value.
+func (h *Header) parseVar(line string) error {
+	i := strings.IndexByte(line, '=')
+	if i < 0 {
+		// Lines of commands which processed the file are kept by some
+		// writers.
+		return nil
+	}
+
+	name, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+	h.Vars[name] = value
+
+	switch name {
+	case "FORMAT":
+		if value != FormatRGBE && value != FormatXYZE {
+			return UnsupportedError("format " + value)
+		}
+		h.Format = value
+	case "EXPOSURE", "PIXASPECT":
+		v, err := parseFloats(value, 1)
+		if err != nil {
+			return FormatError(name + ": " + err.Error())
+		}
+		if name == "EXPOSURE" {
+			h.Exposure *= v[0]
+		} else {
+			h.PixelAspect *= v[0]
+		}
+	case "COLORCORR":
+		v, err := parseFloats(value, 3)
+		if err != nil {
+			return FormatError(name + ": " + err.Error())
+		}
+		for i := range h.ColorCorr {
+			h.ColorCorr[i] *= v[i]
+		}
+	case "PRIMARIES":
+		v, err := parseFloats(value, 8)
+		if err != nil {
+			return FormatError(name + ": " + err.Error())
+		}
+		copy(h.Primaries[:], v)
+	case "SOFTWARE":
+		h.Software = value
+	}
+
+	return nil
+}
+
+// parseResolution parses the resolution string, such as "-Y 512 +X 768".
+func (h *Header) parseResolution(line string) error {
+	f := strings.Fields(line)
+	if len(f) != 4 {
+		return FormatError("invalid resolution string")
+	}
+
+	major, ok1 := parseAxis(f[0])
+	minor, ok2 := parseAxis(f[2])
+	n1, err1 := strconv.Atoi(f[1])
+	n2, err2 := strconv.Atoi(f[3])
+
+	o := Orientation{major, minor}
+	if !ok1 || !ok2 || err1 != nil || err2 != nil || !o.Valid() || n1 <= 0 || n2 <= 0 {
+		return FormatError("invalid resolution string")
+	}
+	if n1 > maxDimension || n2 > maxDimension {
+		return UnsupportedError("image too large")
+	}
+
+	h.Orientation = o
+	if major.isX() {
+		h.Width, h.Height = n1, n2
+	} else {
+		h.Width, h.Height = n2, n1
+	}
+
+	return nil
+}
+
+// resolution returns the resolution string of the header.
+func (h *Header) resolution() string {
+	n1, n2 := h.Height, h.Width
+	if h.Orientation.Major.isX() {
+		n1, n2 = n2, n1
+	}
+
+	return fmt.Sprintf("%s %d %s %d", h.Orientation.Major, n1, h.Orientation.Minor, n2)
+}
+
+// parseFloats parses n positive numbers.
+func parseFloats(s string, n int) ([]float64, error) {
+	f := strings.Fields(s)
+	if len(f) != n {
+		return nil, fmt.Errorf("expected %d values, got %d", n, len(f))
+	}
+
+	v := make([]float64, n)
+	for i := range f {
+		x, err := strconv.ParseFloat(f[i], 64)
+		if err != nil {
+			return nil, err
+		}
+		if x <= 0 {
+			return nil, fmt.Errorf("invalid value %s", f[i])
+		}
+		v[i] = x
+	}
+
+	return v, nil
+}
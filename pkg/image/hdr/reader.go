@@ -24,25 +24,27 @@ package hdr
 
 import (
 	"bufio"
-	"fmt"
 	"image"
 	"io"
 	"math"
+	"strings"
 )
 
 const (
-	radianceHeader = "#?RADIANCE\n"
+	radianceHeader = "#?RADIANCE"
+	rgbeHeader     = "#?RGBE"
+
+	// Scanlines of other lengths are never run length encoded per
+	// component.
+	minRLELength = 8
+	maxRLELength = 0x7fff
+
+	// maxDimension limits the width and height of decoded images, and
+	// maxPixels their size.
+	maxDimension = 1 << 16
+	maxPixels    = 1 << 28
 )
 
-type decoder struct {
-	r             io.Reader
-	img           image.Image
-	width, height int
-	depth         int
-	headerSize    int
-	tmp           [3 * 256]byte
-}
-
 // FormatError reports that the input is not a valid HDR image.
 type FormatError string
 
@@ -59,115 +61,152 @@ func (e UnsupportedError) Error() string {
 
 func init() {
 	image.RegisterFormat("hdr", radianceHeader, Decode, DecodeConfig)
+	image.RegisterFormat("hdr", rgbeHeader, Decode, DecodeConfig)
 }
 
-func (d *decoder) parseHeader(b *bufio.Reader) error {
-	var x, y string
-	var w, h int
+// readHeader reads the header of a file, up to and including its resolution
+// string.
+func readHeader(b *bufio.Reader) (*Header, error) {
+	line, err := b.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "#?") {
+		return nil, FormatError("not an HDR file")
+	}
+
+	h := newHeader()
 
 	for {
 		line, err := b.ReadString('\n')
-
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if len(line) == 1 {
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
 			break
 		}
-	}
+		if line[0] == '#' {
+			continue
+		}
 
-	line, err := b.ReadString('\n')
+		if err := h.parseVar(line); err != nil {
+			return nil, err
+		}
+	}
 
-	_, err = fmt.Sscanf(line, "%s %d %s %d", &y, &h, &x, &w)
+	line, err = b.ReadString('\n')
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := h.parseResolution(line); err != nil {
+		return nil, err
+	}
+	if h.Width > maxPixels/h.Height {
+		return nil, UnsupportedError("image too large")
 	}
 
-	// TODO: Handle different X, Y scenarios
+	return h, nil
+}
 
-	d.width = w
-	d.height = h
+// readPixels reads the scanlines of a file in to an image, in the order of
+// its orientation.
+func readPixels(b *bufio.Reader, h *Header) (*RGB96, error) {
+	img := NewRGB96(image.Rect(0, 0, h.Width, h.Height))
 
-	return nil
-}
+	o := h.Orientation
+	scanlines, length := h.Height, h.Width
+	if o.Major.isX() {
+		scanlines, length = length, scanlines
+	}
 
-func (d *decoder) parseData(b *bufio.Reader) error {
-	line := make([]byte, d.width*4)
+	line := make([]byte, length*4)
 
-	for y := 0; y < d.height; y++ {
-		if err := readLine(b, line); err != nil {
-			return err
+	for s := 0; s < scanlines; s++ {
+		if err := readScanline(b, line); err != nil {
+			return nil, err
 		}
 
-		for x := 0; x < d.width; x++ {
-			i := x * 4
+		for i := 0; i < length; i++ {
+			c := rgbeColor(line[i*4 : i*4+4])
+			if h.Format == FormatXYZE {
+				c = xyzToRGB(c)
+			}
 
-			r := ldexp(line[i+3], line[i+0])
-			g := ldexp(line[i+3], line[i+1])
-			b := ldexp(line[i+3], line[i+2])
+			x, y := o.Minor.index(i, length), o.Major.index(s, scanlines)
+			if o.Major.isX() {
+				x, y = o.Major.index(s, scanlines), o.Minor.index(i, length)
+			}
 
-			d.img.(*RGB96).Set(x, y, RGB96Color{
-				R: r,
-				G: g,
-				B: b,
-			})
+			img.SetRGB96(x, y, c)
 		}
 	}
 
-	return nil
+	return img, nil
 }
 
-func readLine(r *bufio.Reader, line []byte) error {
-	var code byte
-	var value byte
-
-	lineLength := len(line) / 4
+// readScanline reads a scanline of RGBE pixels, which is run length encoded
+// per component, run length encoded by pixel in the old scheme, or flat.
+func readScanline(r *bufio.Reader, line []byte) error {
+	n := len(line) / 4
+	if n < minRLELength || n > maxRLELength {
+		return readFlatScanline(r, line)
+	}
 
-	lineHeader, err := r.Peek(4)
+	header, err := r.Peek(4)
 	if err != nil {
 		return err
 	}
-
-	if lineHeader[0] != 2 || lineHeader[1] != 2 || (lineHeader[2]&128) != 0 {
-		return readUncompressedData(r, line)
+	if header[0] != 2 || header[1] != 2 || header[2]&0x80 != 0 {
+		return readFlatScanline(r, line)
 	}
-
-	hlen := (int(lineHeader[2]) << 8) | int(lineHeader[3])
-	if hlen != lineLength {
-		return FormatError(fmt.Sprintf("scanline length mismatch. have: %d want: %d", hlen, lineLength))
+	if int(header[2])<<8|int(header[3]) != n {
+		return FormatError("scanline length mismatch")
 	}
-
-	if _, err := r.Read(lineHeader); err != nil {
+	if _, err := r.Discard(4); err != nil {
 		return err
 	}
 
-	for i := 0; i < 4; i++ {
-		j := 0
-
-		for j < lineLength {
-			if code, err = r.ReadByte(); err != nil {
+	// Each component is stored in turn, as runs of a repeated value and
+	// runs of literal values.
+	for c := 0; c < 4; c++ {
+		for i := 0; i < n; {
+			code, err := r.ReadByte()
+			if err != nil {
 				return err
 			}
 
 			if code > 128 {
-				code &= 127
-				if value, err = r.ReadByte(); err != nil {
+				count := int(code & 0x7f)
+				if i+count > n {
+					return FormatError("run overflows scanline")
+				}
+
+				value, err := r.ReadByte()
+				if err != nil {
 					return err
 				}
 
-				for k := 0; k < int(code); k++ {
-					line[j*4+i] = value
-					j++
+				for ; count > 0; count-- {
+					line[i*4+c] = value
+					i++
 				}
-			} else {
-				for k := 0; k < int(code); k++ {
-					if value, err = r.ReadByte(); err != nil {
-						return err
-					}
-
-					line[j*4+i] = value
-					j++
+				continue
+			}
+
+			count := int(code)
+			if count == 0 || i+count > n {
+				return FormatError("invalid run")
+			}
+
+			for ; count > 0; count-- {
+				value, err := r.ReadByte()
+				if err != nil {
+					return err
 				}
+				line[i*4+c] = value
+				i++
 			}
 		}
 	}
@@ -175,129 +214,141 @@ func readLine(r *bufio.Reader, line []byte) error {
 	return nil
 }
 
-func readUncompressedData(r *bufio.Reader, data []byte) error {
-	length := len(data) / 4
-
-	s := make([]byte, 4)
+// readFlatScanline reads a scanline of RGBE pixels, where a pixel of 1, 1, 1
+// repeats the previous pixel as many times as its exponent. The counts of
+// consecutive repeats are the successive bytes of a larger count.
+func readFlatScanline(r *bufio.Reader, line []byte) error {
+	n := len(line) / 4
 
-	rshift := uint(0)
-	l := 0
+	var p [4]byte
+	var shift uint
 
-	for l < length {
-		if _, err := r.Read(s); err != nil {
+	for i := 0; i < n; {
+		if _, err := io.ReadFull(r, p[:]); err != nil {
 			return err
 		}
 
-		if s[0] == 1 && s[1] == 1 && s[2] == 1 {
-			// Encoded
-			count := int(s[3]) << rshift
-			for i := 0; i < count; i++ {
-				data[(l+i)*4+0] = s[0]
-				data[(l+i)*4+1] = s[1]
-				data[(l+i)*4+2] = s[2]
-				data[(l+i)*4+3] = s[3]
+		if p[0] == 1 && p[1] == 1 && p[2] == 1 {
+			if i == 0 {
+				return FormatError("repeat of no pixel")
+			}
+
+			count := int(p[3]) << shift
+			if count > n-i {
+				return FormatError("run overflows scanline")
 			}
 
-			l += count
-			rshift += 8
-		} else {
-			data[l*4+0] = s[0]
-			data[l*4+1] = s[1]
-			data[l*4+2] = s[2]
-			data[l*4+3] = s[3]
-			l++
-			rshift = 0
+			for ; count > 0; count-- {
+				copy(line[i*4:i*4+4], line[(i-1)*4:i*4])
+				i++
+			}
+
+			shift += 8
+			continue
 		}
+
+		copy(line[i*4:i*4+4], p[:])
+		i++
+		shift = 0
 	}
 
 	return nil
 }
 
-func (d *decoder) Read(p []byte) (int, error) {
-	return 0, nil
-}
-
-func (d *decoder) checkHeader() error {
-	_, err := io.ReadFull(d.r, d.tmp[:len(radianceHeader)])
-	if err != nil {
-		return err
-	}
-
-	if string(d.tmp[:len(radianceHeader)]) != radianceHeader {
-		return FormatError("not an HDR file")
+// ReadHeader reads the header of an HDR image.
+func ReadHeader(r io.Reader) (*Header, error) {
+	h, err := readHeader(bufio.NewReader(r))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
 	}
 
-	return nil
+	return h, err
 }
 
-// Decode reads an HDR image from r and returns it as an image.Image.
-// The type of Image returned depends on the HDR contents.
-func Decode(r io.Reader) (image.Image, error) {
-	d := &decoder{
-		r: r,
-	}
+// DecodeRGB96 reads an HDR image from r, along with its header. The pixels
+// of the file are divided by its exposure and color correction, giving
+// their original radiance. XYZE pixels are converted to RGB.
+func DecodeRGB96(r io.Reader) (*RGB96, *Header, error) {
+	b := bufio.NewReader(r)
 
-	if err := d.checkHeader(); err != nil {
+	h, err := readHeader(b)
+	if err != nil {
 		if err == io.EOF {
 			err = io.ErrUnexpectedEOF
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
-	b := bufio.NewReader(d.r)
-
-	if err := d.parseHeader(b); err != nil {
+	img, err := readPixels(b, h)
+	if err != nil {
 		if err == io.EOF {
 			err = io.ErrUnexpectedEOF
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
-	d.img = NewRGB96(
-		image.Rectangle{
-			Min: image.Point{},
-			Max: image.Point{X: d.width, Y: d.height},
-		})
-
-	if err := d.parseData(b); err != nil {
-		return nil, err
+	var scale [3]float32
+	for i := range scale {
+		scale[i] = float32(1 / (h.Exposure * h.ColorCorr[i]))
+	}
+	if scale != [3]float32{1, 1, 1} {
+		for y := 0; y < h.Height; y++ {
+			for x := 0; x < h.Width; x++ {
+				c := img.RGB96At(x, y)
+				img.SetRGB96(x, y, RGB96Color{c.R * scale[0], c.G * scale[1], c.B * scale[2]})
+			}
+		}
 	}
 
-	return d.img, nil
+	return img, h, nil
 }
 
-// DecodeConfig returns the color model and dimensions of a PNG image without
-// decoding the entire image.
-func DecodeConfig(r io.Reader) (image.Config, error) {
-	d := &decoder{
-		r: r,
-	}
-
-	if err := d.checkHeader(); err != nil {
-		if err == io.EOF {
-			err = io.ErrUnexpectedEOF
-		}
-		return image.Config{}, err
+// Decode reads an HDR image from r and returns it as an *RGB96.
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := DecodeRGB96(r)
+	if err != nil {
+		return nil, err
 	}
 
-	b := bufio.NewReader(d.r)
+	return img, nil
+}
 
-	if err := d.parseHeader(b); err != nil {
-		if err == io.EOF {
-			err = io.ErrUnexpectedEOF
-		}
+// DecodeConfig returns the color model and dimensions of an HDR image
+// without decoding the entire image.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := ReadHeader(r)
+	if err != nil {
 		return image.Config{}, err
 	}
 
 	return image.Config{
 		ColorModel: RGB96Model,
-		Width:      d.width,
-		Height:     d.height,
+		Width:      h.Width,
+		Height:     h.Height,
 	}, nil
 }
 
-func ldexp(exp, val uint8) float32 {
-	f := float32(math.Ldexp(1.0, int(exp)-int(128+8)))
+// rgbeColor converts an RGBE pixel to a color, of mantissas scaled by the
+// shared exponent.
+func rgbeColor(p []byte) RGB96Color {
+	if p[3] == 0 {
+		return RGB96Color{}
+	}
 
-	return f * float32(val)
+	f := float32(math.Ldexp(1, int(p[3])-(128+8)))
+
+	return RGB96Color{
+		R: float32(p[0]) * f,
+		G: float32(p[1]) * f,
+		B: float32(p[2]) * f,
+	}
+}
+
+// xyzToRGB converts a CIE XYZ color to linear RGB of sRGB primaries.
+func xyzToRGB(c RGB96Color) RGB96Color {
+	return RGB96Color{
+		R: 3.2404542*c.R - 1.5371385*c.G - 0.4985314*c.B,
+		G: -0.9692660*c.R + 1.8760108*c.G + 0.0415560*c.B,
+		B: 0.0556434*c.R - 0.2040259*c.G + 1.0572252*c.B,
+	}
 }
@@ -0,0 +1,244 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package hdr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+
+	emath "github.com/haakenlabs/ember/pkg/math"
+)
+
+// minRun is the shortest run encoded as a run, rather than as literals.
+const minRun = 4
+
+// Options are the encoding parameters.
+type Options struct {
+	// Orientation is the order in which pixels are written. It defaults to
+	// Standard.
+	Orientation Orientation
+
+	// Exposure, if set, scales the pixels written and is recorded in the
+	// EXPOSURE variable, so that decoding restores the original values.
+	Exposure float64
+
+	// Software is recorded in the SOFTWARE variable.
+	Software string
+
+	// Flat disables run length encoding.
+	Flat bool
+}
+
+// Encode writes the image to w in the Radiance HDR format. Scanlines are
+// run length encoded per component. The color of images other than *RGB96
+// is taken as sRGB, converted to linear and composited over black.
+func Encode(w io.Writer, img image.Image, o *Options) error {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.Orientation == (Orientation{}) {
+		opts.Orientation = Standard
+	}
+	if !opts.Orientation.Valid() {
+		return errors.New("hdr: invalid orientation")
+	}
+	if opts.Exposure < 0 || math.IsNaN(opts.Exposure) || math.IsInf(opts.Exposure, 0) {
+		return errors.New("hdr: invalid exposure")
+	}
+
+	b := img.Bounds()
+	h := &Header{
+		Width:       b.Dx(),
+		Height:      b.Dy(),
+		Orientation: opts.Orientation,
+	}
+	if h.Width == 0 || h.Height == 0 {
+		return errors.New("hdr: empty image")
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "%s\n", radianceHeader)
+	if opts.Software != "" {
+		fmt.Fprintf(bw, "SOFTWARE=%s\n", opts.Software)
+	}
+	scale := float32(1)
+	if opts.Exposure > 0 {
+		scale = float32(opts.Exposure)
+		fmt.Fprintf(bw, "EXPOSURE=%s\n", strconv.FormatFloat(opts.Exposure, 'g', -1, 64))
+	}
+	fmt.Fprintf(bw, "FORMAT=%s\n\n%s\n", FormatRGBE, h.resolution())
+
+	at := colorFunc(img)
+
+	orient := h.Orientation
+	scanlines, length := h.Height, h.Width
+	if orient.Major.isX() {
+		scanlines, length = length, scanlines
+	}
+
+	line := make([]byte, length*4)
+
+	for s := 0; s < scanlines; s++ {
+		for i := 0; i < length; i++ {
+			x, y := orient.Minor.index(i, length), orient.Major.index(s, scanlines)
+			if orient.Major.isX() {
+				x, y = orient.Major.index(s, scanlines), orient.Minor.index(i, length)
+			}
+
+			c := at(b.Min.X+x, b.Min.Y+y)
+			rgbe(line[i*4:i*4+4], c.R*scale, c.G*scale, c.B*scale)
+		}
+
+		if opts.Flat || length < minRLELength || length > maxRLELength {
+			bw.Write(line)
+		} else {
+			writeScanline(bw, line)
+		}
+	}
+
+	// Write errors are kept by the buffered writer until flushed.
+	return bw.Flush()
+}
+
+// colorFunc returns a function giving the linear color of the pixels of an
+// image.
+func colorFunc(img image.Image) func(x, y int) RGB96Color {
+	if rgb, ok := img.(*RGB96); ok {
+		return rgb.RGB96At
+	}
+
+	return func(x, y int) RGB96Color {
+		c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+		a := float32(c.A) / 0xffff
+
+		return RGB96Color{
+			R: emath.SRGBToLinear(float32(c.R)/0xffff) * a,
+			G: emath.SRGBToLinear(float32(c.G)/0xffff) * a,
+			B: emath.SRGBToLinear(float32(c.B)/0xffff) * a,
+		}
+	}
+}
+
+// rgbe converts a color to an RGBE pixel, of mantissas sharing the exponent
+// of the largest component. Negative components are clamped to zero.
+func rgbe(p []byte, r, g, b float32) {
+	r, g, b = positive(r), positive(g), positive(b)
+
+	v := r
+	if g > v {
+		v = g
+	}
+	if b > v {
+		v = b
+	}
+
+	if v < 1e-32 {
+		p[0], p[1], p[2], p[3] = 0, 0, 0, 0
+		return
+	}
+
+	m, e := math.Frexp(float64(v))
+	f := float32(m * 256 / float64(v))
+
+	p[0] = uint8(r * f)
+	p[1] = uint8(g * f)
+	p[2] = uint8(b * f)
+	p[3] = uint8(e + 128)
+}
+
+func positive(v float32) float32 {
+	if v > 0 {
+		return v
+	}
+
+	return 0
+}
+
+// writeScanline writes a scanline of RGBE pixels run length encoded per
+// component.
+func writeScanline(w *bufio.Writer, line []byte) {
+	n := len(line) / 4
+
+	w.Write([]byte{2, 2, byte(n >> 8), byte(n)})
+
+	component := make([]byte, n)
+	for c := 0; c < 4; c++ {
+		for i := range component {
+			component[i] = line[i*4+c]
+		}
+
+		writeRuns(w, component)
+	}
+}
+
+// writeRuns writes the values of a component as runs of at least minRun
+// repeated values, and runs of literal values in between.
+func writeRuns(w *bufio.Writer, data []byte) {
+	n := len(data)
+
+	for cur := 0; cur < n; {
+		// Find the next run long enough to encode.
+		start, count, prevCount := cur, 0, 0
+		for count < minRun && start < n {
+			start += count
+			prevCount = count
+			count = 1
+			for start+count < n && count < 127 && data[start+count] == data[start] {
+				count++
+			}
+		}
+
+		// A short run just before it is encoded as a run as well.
+		if prevCount > 1 && prevCount == start-cur {
+			w.WriteByte(byte(128 + prevCount))
+			w.WriteByte(data[cur])
+			cur = start
+		}
+
+		for cur < start {
+			literal := start - cur
+			if literal > 128 {
+				literal = 128
+			}
+
+			w.WriteByte(byte(literal))
+			w.Write(data[cur : cur+literal])
+			cur += literal
+		}
+
+		if count >= minRun {
+			w.WriteByte(byte(128 + count))
+			w.WriteByte(data[start])
+			cur += count
+		}
+	}
+}
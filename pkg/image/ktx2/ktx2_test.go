@@ -0,0 +1,142 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package ktx2
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+
+	"github.com/haakenlabs/ember/pkg/image/surface"
+)
+
+// encode returns a KTX 2.0 file of the levels, each holding the images of
+// every layer and face.
+func encode(vkFormat, w, h, layers, faces, scheme uint32, levels [][]byte) []byte {
+	var buf bytes.Buffer
+
+	put := func(v ...uint64) {
+		for _, x := range v {
+			binary.Write(&buf, binary.LittleEndian, uint32(x))
+		}
+	}
+
+	buf.WriteString(Magic)
+	put(uint64(vkFormat), 1, uint64(w), uint64(h), 0, uint64(layers), uint64(faces), uint64(len(levels)), uint64(scheme))
+	put(0, 0, 0, 0)
+	binary.Write(&buf, binary.LittleEndian, [2]uint64{})
+
+	offset := uint64(len(Magic) + headerSize + len(levels)*levelIndexSize)
+	for _, level := range levels {
+		binary.Write(&buf, binary.LittleEndian, [3]uint64{offset, uint64(len(level)), uint64(len(level))})
+		offset += uint64(len(level))
+	}
+	for _, level := range levels {
+		buf.Write(level)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	// An sRGB BC7 cubemap array of two layers, 8x8 with four levels.
+	var levels [][]byte
+	for l, size := range []int{8, 4, 2, 1} {
+		var level []byte
+		for i := 0; i < 12; i++ {
+			level = append(level, bytes.Repeat([]byte{byte(l*16 + i)}, surface.FormatBC7.Size(size, size))...)
+		}
+		levels = append(levels, level)
+	}
+
+	img, err := Decode(bytes.NewReader(encode(146, 8, 8, 2, 6, SupercompressionNone, levels)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if img.Format != surface.FormatBC7 || !img.SRGB || !img.Cubemap || img.Layers != 2 || len(img.Levels) != 4 {
+		t.Fatalf("decoded %s, srgb %v, cubemap %v, %d layers, %d levels", img.Format, img.SRGB, img.Cubemap, img.Layers, len(img.Levels))
+	}
+	if got := img.Data(2, 1, 3)[0]; got != 2*16+9 {
+		t.Errorf("level 2, layer 1, face 3 holds %d", got)
+	}
+
+	// A zlib supercompressed RGBA8 image without layers or a height.
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	zw.Close()
+
+	img, err = Decode(bytes.NewReader(encode(37, 2, 0, 0, 1, SupercompressionZlib, [][]byte{z.Bytes()})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Format != surface.FormatRGBA8 || img.Width != 2 || img.Height != 1 || img.Layers != 1 {
+		t.Errorf("decoded %s, %dx%d with %d layers", img.Format, img.Width, img.Height, img.Layers)
+	}
+	if !bytes.Equal(img.Data(0, 0, 0), []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("data = %v", img.Data(0, 0, 0))
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	level := [][]byte{make([]byte, 16)}
+
+	var tests = [][]byte{
+		[]byte(Magic),
+		encode(0, 4, 4, 0, 1, SupercompressionNone, level),
+		encode(37, 4, 4, 0, 2, SupercompressionNone, level),
+		encode(37, 4, 4, 0, 1, SupercompressionZstd, level),
+		encode(37, 4, 4, 0, 1, SupercompressionNone, level),
+		encode(37, 4, 4, 0, 1, SupercompressionZlib, level),
+		encode(37, 0xffffffff, 0xffffffff, 0, 1, SupercompressionNone, level),
+		encode(37, surface.MaxDimension+1, 1, 0, 1, SupercompressionNone, level),
+		encode(37, 1, 1, 0xffffffff, 1, SupercompressionNone, level),
+		encode(37, 1, 1, surface.MaxLayers+1, 1, SupercompressionNone, level),
+		encode(37, 2, 2, 2, 6, SupercompressionNone, level),
+	}
+
+	for i, data := range tests {
+		if _, err := Decode(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s case %d: expected error", t.Name(), i)
+		}
+	}
+}
+
+func TestDecode_InflateLimit(t *testing.T) {
+	// A megabyte of zeros compresses to a kilobyte, but only the eight bytes
+	// of a 2x1 RGBA8 level are inflated.
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(make([]byte, 1<<20))
+	zw.Close()
+
+	img, err := Decode(bytes.NewReader(encode(37, 2, 1, 0, 1, SupercompressionZlib, [][]byte{z.Bytes()})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Data(0, 0, 0); len(got) != 8 || cap(got) > 1<<16 {
+		t.Errorf("level is %d bytes with capacity %d, want 8", len(got), cap(got))
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package ktx2
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/haakenlabs/ember/pkg/image/surface"
+)
+
+// Magic is the identifier at the start of KTX 2.0 files.
+const Magic = "\xabKTX 20\xbb\r\n\x1a\n"
+
+const (
+	headerSize     = 68
+	levelIndexSize = 24
+)
+
+// Supercompression schemes.
+const (
+	SupercompressionNone  = 0
+	SupercompressionBasis = 1
+	SupercompressionZstd  = 2
+	SupercompressionZlib  = 3
+)
+
+// KTX2 errors.
+var (
+	ErrFormat = errors.New("ktx2: invalid format")
+)
+
+// vkFormats maps Vulkan formats to surface formats. sRGB formats are marked
+// by the second value.
+var vkFormats = map[uint32]struct {
+	format surface.Format
+	srgb   bool
+}{
+	9:   {surface.FormatR8, false},
+	15:  {surface.FormatR8, true},
+	16:  {surface.FormatRG8, false},
+	22:  {surface.FormatRG8, true},
+	37:  {surface.FormatRGBA8, false},
+	43:  {surface.FormatRGBA8, true},
+	44:  {surface.FormatBGRA8, false},
+	50:  {surface.FormatBGRA8, true},
+	76:  {surface.FormatR16F, false},
+	83:  {surface.FormatRG16F, false},
+	97:  {surface.FormatRGBA16F, false},
+	100: {surface.FormatR32F, false},
+	103: {surface.FormatRG32F, false},
+	109: {surface.FormatRGBA32F, false},
+	131: {surface.FormatBC1, false},
+	132: {surface.FormatBC1, true},
+	133: {surface.FormatBC1, false},
+	134: {surface.FormatBC1, true},
+	135: {surface.FormatBC2, false},
+	136: {surface.FormatBC2, true},
+	137: {surface.FormatBC3, false},
+	138: {surface.FormatBC3, true},
+	139: {surface.FormatBC4, false},
+	141: {surface.FormatBC5, false},
+	143: {surface.FormatBC6H, false},
+	145: {surface.FormatBC7, false},
+	146: {surface.FormatBC7, true},
+	147: {surface.FormatETC2RGB8, false},
+	148: {surface.FormatETC2RGB8, true},
+	149: {surface.FormatETC2RGB8A1, false},
+	150: {surface.FormatETC2RGB8A1, true},
+	151: {surface.FormatETC2RGBA8, false},
+	152: {surface.FormatETC2RGBA8, true},
+}
+
+// Decode reads a KTX 2.0 file. Volume textures are not supported.
+func Decode(r io.Reader) (*surface.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < len(Magic)+headerSize || string(data[:len(Magic)]) != Magic {
+		return nil, ErrFormat
+	}
+
+	h := data[len(Magic):]
+	u32 := func(off int) uint32 { return binary.LittleEndian.Uint32(h[off:]) }
+
+	vkFormat := u32(0)
+	width, height, depth := u32(8), u32(12), u32(16)
+	layers, faces, levels := u32(20), u32(24), u32(28)
+	scheme := u32(32)
+
+	f, ok := vkFormats[vkFormat]
+	if !ok {
+		return nil, fmt.Errorf("ktx2: unsupported Vulkan format %d", vkFormat)
+	}
+	if depth > 1 {
+		return nil, fmt.Errorf("ktx2: volume textures are not supported")
+	}
+	if faces != 1 && faces != 6 {
+		return nil, ErrFormat
+	}
+	if scheme != SupercompressionNone && scheme != SupercompressionZlib {
+		return nil, fmt.Errorf("ktx2: unsupported supercompression scheme %d", scheme)
+	}
+
+	img := &surface.Image{
+		Format:  f.format,
+		SRGB:    f.srgb,
+		Width:   int(width),
+		Height:  int(height),
+		Layers:  int(layers),
+		Cubemap: faces == 6,
+	}
+
+	// One dimensional images have no height, images which are not arrays
+	// no layers, and images which leave their mip chain to be generated no
+	// levels.
+	if img.Height == 0 {
+		img.Height = 1
+	}
+	if img.Layers == 0 {
+		img.Layers = 1
+	}
+	if levels == 0 {
+		levels = 1
+	}
+
+	if _, err := img.DataSize(int(levels)); err != nil {
+		return nil, fmt.Errorf("ktx2: %v", err)
+	}
+
+	index := len(Magic) + headerSize
+	if len(data) < index+int(levels)*levelIndexSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	n := img.Layers * img.Faces()
+	img.Levels = make([][][]byte, levels)
+
+	for l := range img.Levels {
+		entry := data[index+l*levelIndexSize:]
+		offset := binary.LittleEndian.Uint64(entry[0:])
+		length := binary.LittleEndian.Uint64(entry[8:])
+
+		if offset > uint64(len(data)) || length > uint64(len(data))-offset {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		// Each level holds an image per layer and face, faces varying
+		// fastest.
+		size := img.Format.Size(img.LevelSize(l))
+
+		level := data[offset : offset+length]
+		if scheme == SupercompressionZlib {
+			if level, err = inflate(level, int64(n*size)); err != nil {
+				return nil, fmt.Errorf("ktx2: level %d: %v", l, err)
+			}
+		}
+
+		if len(level) < n*size {
+			return nil, fmt.Errorf("ktx2: level %d has %d bytes, want %d", l, len(level), n*size)
+		}
+
+		img.Levels[l] = make([][]byte, n)
+		for i := range img.Levels[l] {
+			img.Levels[l][i] = level[i*size : (i+1)*size]
+		}
+	}
+
+	return img, img.Validate()
+}
+
+// inflate decompresses a zlib supercompressed level, reading no more than
+// the size of the uncompressed level.
+func inflate(data []byte, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(io.LimitReader(zr, size))
+}
@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package image
+
+// MipOptions configures the generation of mip chains.
+type MipOptions struct {
+	// Filter downsamples each level from the one above it. The zero value
+	// selects Box.
+	Filter Filter
+
+	// Edge handles filter taps beyond the edges of each level. EdgeWrap
+	// suits tiling textures.
+	Edge Edge
+
+	// SRGB marks 8-bit data as sRGB color. Color is always filtered in
+	// linear space.
+	SRGB bool
+
+	// AlphaWeighted filters color premultiplied by alpha, so that the color
+	// of transparent pixels does not bleed into their neighbours.
+	AlphaWeighted bool
+
+	// Normals renormalizes each level of a normal map.
+	Normals bool
+}
+
+// Mipmaps returns the mip chain of an image, largest level first and down
+// to 1x1. The first level is the image itself.
+func Mipmaps(f *Float, opts MipOptions) []*Float {
+	filter := opts.Filter
+	if filter.Kernel == nil {
+		filter = Box
+	}
+
+	levels := []*Float{f}
+
+	for prev := f; prev.Width > 1 || prev.Height > 1; {
+		src := prev
+		if opts.AlphaWeighted {
+			src = &Float{Width: prev.Width, Height: prev.Height, Pix: append([]float32(nil), prev.Pix...)}
+			src.Premultiply()
+		}
+
+		level := Resize(src, half(prev.Width), half(prev.Height), filter, opts.Edge)
+		if opts.AlphaWeighted {
+			level.Unpremultiply()
+		}
+		if opts.Normals {
+			level.RenormalizeNormals()
+		}
+
+		levels = append(levels, level)
+		prev = level
+	}
+
+	return levels
+}
+
+// MipmapsRGBA8 returns the mip chain of 8-bit RGBA data, largest level first
+// and down to 1x1, as taken by gfx.Texture.SetMipData. The first level is
+// the data itself.
+func MipmapsRGBA8(data []byte, width, height int, opts MipOptions) ([][]byte, error) {
+	f, err := FromRGBA8(data, width, height, opts.SRGB)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := Mipmaps(f, opts)
+	out := make([][]byte, len(levels))
+
+	out[0] = data[:len(f.Pix)]
+	for i := 1; i < len(levels); i++ {
+		out[i] = levels[i].RGBA8(opts.SRGB)
+	}
+
+	return out, nil
+}
+
+// half returns the size of a dimension of the next mip level.
+func half(n int) int {
+	if n > 1 {
+		return n / 2
+	}
+
+	return 1
+}
@@ -0,0 +1,149 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package image
+
+import "math"
+
+// Edge is the handling of filter taps outside an image.
+type Edge uint8
+
+const (
+	// EdgeClamp repeats the pixels at the edges of the image.
+	EdgeClamp Edge = iota
+
+	// EdgeWrap wraps around to the opposite edge, for tiling textures.
+	EdgeWrap
+)
+
+// index maps a pixel index to one inside an image of n pixels.
+func (e Edge) index(i, n int) int {
+	if e == EdgeWrap {
+		return (i%n + n) % n
+	}
+
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+
+	return i
+}
+
+// tap is a weighted source pixel of a destination pixel.
+type tap struct {
+	index  int
+	weight float32
+}
+
+// Resize resamples an image to the given size with a filter. The filter is
+// widened when shrinking, so that every source pixel contributes. Ringing
+// filters can overshoot; color is clamped to be positive and alpha to
+// [0, 1].
+func Resize(f *Float, width, height int, filter Filter, edge Edge) *Float {
+	if width < 1 || height < 1 || f.Width < 1 || f.Height < 1 {
+		return NewFloat(0, 0)
+	}
+
+	// Resample rows, then columns.
+	rows := NewFloat(width, f.Height)
+	taps := weights(f.Width, width, filter, edge)
+
+	for y := 0; y < f.Height; y++ {
+		src := f.Pix[y*f.Width*4 : (y+1)*f.Width*4]
+		dst := rows.Pix[y*width*4 : (y+1)*width*4]
+
+		for x, pixel := range taps {
+			for _, t := range pixel {
+				for c := 0; c < 4; c++ {
+					dst[x*4+c] += src[t.index*4+c] * t.weight
+				}
+			}
+		}
+	}
+
+	out := NewFloat(width, height)
+	taps = weights(f.Height, height, filter, edge)
+
+	for y, pixel := range taps {
+		dst := out.Pix[y*width*4 : (y+1)*width*4]
+
+		for _, t := range pixel {
+			src := rows.Pix[t.index*width*4 : (t.index+1)*width*4]
+			for i := range dst {
+				dst[i] += src[i] * t.weight
+			}
+		}
+	}
+
+	for i, v := range out.Pix {
+		switch {
+		case v < 0:
+			out.Pix[i] = 0
+		case i%4 == 3 && v > 1:
+			out.Pix[i] = 1
+		}
+	}
+
+	return out
+}
+
+// weights returns the normalized taps of each of dst pixels resampled from
+// src pixels.
+func weights(src, dst int, filter Filter, edge Edge) [][]tap {
+	scale := float64(src) / float64(dst)
+	fscale := math.Max(scale, 1)
+	support := filter.Support * fscale
+
+	out := make([][]tap, dst)
+	for i := range out {
+		center := (float64(i) + 0.5) * scale
+		left := int(math.Floor(center - support))
+		right := int(math.Ceil(center + support))
+
+		var sum float64
+		taps := make([]tap, 0, right-left+1)
+		for j := left; j <= right; j++ {
+			w := filter.Kernel((float64(j) + 0.5 - center) / fscale)
+			if w == 0 {
+				continue
+			}
+
+			taps = append(taps, tap{index: edge.index(j, src), weight: float32(w)})
+			sum += w
+		}
+
+		if sum == 0 {
+			taps = []tap{{index: edge.index(int(center), src), weight: 1}}
+			sum = 1
+		}
+		for j := range taps {
+			taps[j].weight /= float32(sum)
+		}
+
+		out[i] = taps
+	}
+
+	return out
+}
@@ -0,0 +1,158 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package image
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	filters := map[string]Filter{
+		"box":      Box,
+		"triangle": Triangle,
+		"catmull":  CatmullRom,
+		"mitchell": MitchellNetravali,
+		"lanczos":  Lanczos3,
+		"kaiser":   Kaiser,
+	}
+
+	solid := NewFloat(7, 5)
+	for i := range solid.Pix {
+		solid.Pix[i] = 0.25
+	}
+
+	for name, filter := range filters {
+		for _, size := range [][2]int{{3, 2}, {7, 5}, {16, 9}, {1, 1}} {
+			out := Resize(solid, size[0], size[1], filter, EdgeClamp)
+			if out.Width != size[0] || out.Height != size[1] {
+				t.Fatalf("%s: size = %dx%d, want %dx%d", name, out.Width, out.Height, size[0], size[1])
+			}
+
+			for i, v := range out.Pix {
+				if math.Abs(float64(v)-0.25) > 1e-5 {
+					t.Errorf("%s %v: Pix[%d] = %v, want 0.25", name, size, i, v)
+					break
+				}
+			}
+		}
+	}
+
+	// Box at the same size copies the image.
+	ramp := NewFloat(4, 1)
+	for i := range ramp.Pix {
+		ramp.Pix[i] = float32(i) / 16
+	}
+	out := Resize(ramp, 4, 1, Box, EdgeClamp)
+	for i := range out.Pix {
+		if out.Pix[i] != ramp.Pix[i] {
+			t.Errorf("identity Pix[%d] = %v, want %v", i, out.Pix[i], ramp.Pix[i])
+		}
+	}
+}
+
+func TestEdge_index(t *testing.T) {
+	var tests = []struct {
+		edge Edge
+		i    int
+		want int
+	}{
+		{EdgeClamp, -2, 0},
+		{EdgeClamp, 2, 2},
+		{EdgeClamp, 5, 3},
+		{EdgeWrap, -1, 3},
+		{EdgeWrap, -5, 3},
+		{EdgeWrap, 4, 0},
+		{EdgeWrap, 9, 1},
+	}
+
+	for i, v := range tests {
+		if got := v.edge.index(v.i, 4); got != v.want {
+			t.Errorf("%s case %d: index(%d) = %d, want %d", t.Name(), i, v.i, got, v.want)
+		}
+	}
+}
+
+func TestMipmaps(t *testing.T) {
+	f := NewFloat(5, 3)
+	levels := Mipmaps(f, MipOptions{Filter: Kaiser})
+
+	want := [][2]int{{5, 3}, {2, 1}, {1, 1}}
+	if len(levels) != len(want) {
+		t.Fatalf("levels = %d, want %d", len(levels), len(want))
+	}
+	for i, v := range want {
+		if levels[i].Width != v[0] || levels[i].Height != v[1] {
+			t.Errorf("level %d size = %dx%d, want %dx%d", i, levels[i].Width, levels[i].Height, v[0], v[1])
+		}
+	}
+	if levels[0] != f {
+		t.Error("level 0 is not the image")
+	}
+
+	// Transparent pixels do not bleed into the color of weighted levels.
+	f = &Float{Width: 2, Height: 1, Pix: []float32{1, 0, 0, 1, 0, 1, 0, 0}}
+	levels = Mipmaps(f, MipOptions{AlphaWeighted: true})
+
+	wantPix := []float32{1, 0, 0, 0.5}
+	for i := range wantPix {
+		if levels[1].Pix[i] != wantPix[i] {
+			t.Errorf("alpha weighted Pix = %v, want %v", levels[1].Pix, wantPix)
+			break
+		}
+	}
+}
+
+func TestMipmapsRGBA8(t *testing.T) {
+	// Black and white average to linear gray, not to sRGB 128.
+	data := []byte{0, 0, 0, 255, 255, 255, 255, 255}
+
+	levels, err := MipmapsRGBA8(data, 2, 1, MipOptions{SRGB: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != 2 {
+		t.Fatalf("levels = %d, want 2", len(levels))
+	}
+
+	want := []byte{188, 188, 188, 255}
+	for i := range want {
+		if levels[1][i] != want[i] {
+			t.Errorf("sRGB level 1 = %v, want %v", levels[1], want)
+			break
+		}
+	}
+
+	levels, _ = MipmapsRGBA8(data, 2, 1, MipOptions{})
+	want = []byte{128, 128, 128, 255}
+	for i := range want {
+		if levels[1][i] != want[i] {
+			t.Errorf("linear level 1 = %v, want %v", levels[1], want)
+			break
+		}
+	}
+
+	if _, err := MipmapsRGBA8(data, 2, 2, MipOptions{}); err != ErrShortData {
+		t.Errorf("err = %v, want %v", err, ErrShortData)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package surface
+
+import "encoding/binary"
+
+// block is a decoded block of 4x4 RGBA pixels, in rows.
+type block [16][4]uint8
+
+// DecodeRGBA8 decodes an image in an 8 bit color format, including the
+// block compressed ones, to RGBA8. One and two channel formats leave the
+// missing color channels at zero and alpha opaque, as renderers sample them.
+func DecodeRGBA8(f Format, width, height int, data []byte) ([]byte, error) {
+	if len(data) < f.Size(width, height) {
+		return nil, ErrShortData
+	}
+
+	out := make([]byte, width*height*4)
+
+	switch f {
+	case FormatRGBA8:
+		copy(out, data)
+		return out, nil
+	case FormatBGRA8:
+		for i := 0; i < len(out); i += 4 {
+			out[i], out[i+1], out[i+2], out[i+3] = data[i+2], data[i+1], data[i], data[i+3]
+		}
+		return out, nil
+	case FormatR8:
+		for i := range data[:width*height] {
+			out[i*4], out[i*4+3] = data[i], 0xff
+		}
+		return out, nil
+	case FormatRG8:
+		for i := 0; i < width*height; i++ {
+			out[i*4], out[i*4+1], out[i*4+3] = data[i*2], data[i*2+1], 0xff
+		}
+		return out, nil
+	}
+
+	var decode func(src []byte, dst *block)
+
+	switch f {
+	case FormatBC1:
+		decode = func(src []byte, dst *block) { decodeBC1(src, dst, false) }
+	case FormatBC2:
+		decode = decodeBC2
+	case FormatBC3:
+		decode = decodeBC3
+	case FormatBC4:
+		decode = decodeBC4
+	case FormatBC5:
+		decode = decodeBC5
+	case FormatBC7:
+		decode = decodeBC7
+	case FormatETC2RGB8:
+		decode = func(src []byte, dst *block) { decodeETC2(src, dst, false) }
+	case FormatETC2RGB8A1:
+		decode = func(src []byte, dst *block) { decodeETC2(src, dst, true) }
+	case FormatETC2RGBA8:
+		decode = decodeETC2EAC
+	default:
+		return nil, ErrUnsupported
+	}
+
+	size := f.BlockSize()
+	var b block
+
+	for by := 0; by < (height+3)/4; by++ {
+		for bx := 0; bx < (width+3)/4; bx++ {
+			decode(data[(by*((width+3)/4)+bx)*size:], &b)
+
+			for y := 0; y < 4 && by*4+y < height; y++ {
+				for x := 0; x < 4 && bx*4+x < width; x++ {
+					copy(out[((by*4+y)*width+bx*4+x)*4:], b[y*4+x][:])
+				}
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// decodeBC1 decodes a BC1 color block.
+func decodeBC1(src []byte, dst *block, opaque bool) {
+	palette := bc1Palette(binary.LittleEndian.Uint16(src[0:]), binary.LittleEndian.Uint16(src[2:]), opaque)
+	indices := binary.LittleEndian.Uint32(src[4:])
+
+	for i := range dst {
+		dst[i] = palette[indices>>(uint(i)*2)&3]
+	}
+}
+
+// bc1Palette returns the colors of a BC1 block with the given endpoints.
+// Blocks whose first endpoint is not larger than the second have three
+// colors and transparent black, unless the block is part of a BC2 or BC3
+// block, which always have four colors.
+func bc1Palette(c0, c1 uint16, opaque bool) [4][4]uint8 {
+	var palette [4][4]uint8
+	palette[0] = rgb565(c0)
+	palette[1] = rgb565(c1)
+
+	if c0 > c1 || opaque {
+		for i := 0; i < 3; i++ {
+			palette[2][i] = uint8((2*int(palette[0][i]) + int(palette[1][i])) / 3)
+			palette[3][i] = uint8((int(palette[0][i]) + 2*int(palette[1][i])) / 3)
+		}
+		palette[2][3], palette[3][3] = 0xff, 0xff
+	} else {
+		for i := 0; i < 3; i++ {
+			palette[2][i] = uint8((int(palette[0][i]) + int(palette[1][i])) / 2)
+		}
+		palette[2][3] = 0xff
+	}
+
+	return palette
+}
+
+func decodeBC2(src []byte, dst *block) {
+	decodeBC1(src[8:], dst, true)
+
+	alpha := binary.LittleEndian.Uint64(src)
+	for i := range dst {
+		dst[i][3] = uint8(alpha>>(uint(i)*4)&0xf) * 17
+	}
+}
+
+func decodeBC3(src []byte, dst *block) {
+	decodeBC1(src[8:], dst, true)
+	decodeBC4Channel(src, dst, 3)
+}
+
+func decodeBC4(src []byte, dst *block) {
+	for i := range dst {
+		dst[i] = [4]uint8{0, 0, 0, 0xff}
+	}
+	decodeBC4Channel(src, dst, 0)
+}
+
+func decodeBC5(src []byte, dst *block) {
+	decodeBC4(src, dst)
+	decodeBC4Channel(src[8:], dst, 1)
+}
+
+// decodeBC4Channel decodes a BC4 block in to a channel of the pixels.
+func decodeBC4Channel(src []byte, dst *block, channel int) {
+	palette := bc4Palette(src[0], src[1])
+
+	var bits uint64
+	for i := 0; i < 6; i++ {
+		bits |= uint64(src[2+i]) << (uint(i) * 8)
+	}
+
+	for i := range dst {
+		dst[i][channel] = palette[bits>>(uint(i)*3)&7]
+	}
+}
+
+// bc4Palette returns the values of a BC4 block with the given endpoints.
+func bc4Palette(e0, e1 uint8) [8]uint8 {
+	var palette [8]uint8
+
+	a0, a1 := int(e0), int(e1)
+	palette[0], palette[1] = e0, e1
+
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			palette[i+1] = uint8(((7-i)*a0 + i*a1) / 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			palette[i+1] = uint8(((5-i)*a0 + i*a1) / 5)
+		}
+		palette[6], palette[7] = 0, 0xff
+	}
+
+	return palette
+}
+
+func rgb565(c uint16) [4]uint8 {
+	r := uint8(c >> 11 & 0x1f)
+	g := uint8(c >> 5 & 0x3f)
+	b := uint8(c & 0x1f)
+
+	return [4]uint8{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 0xff}
+}
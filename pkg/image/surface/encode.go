@@ -0,0 +1,254 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package surface
+
+import "encoding/binary"
+
+// EncodeRGBA8 compresses RGBA8 data of an image to BC1, BC2, BC3, BC4 or
+// BC5. BC4 keeps the red channel and BC5 the red and green channels.
+// Endpoints are fit to the range of each block, which is fast and good
+// enough for compression at import time.
+func EncodeRGBA8(f Format, width, height int, data []byte) ([]byte, error) {
+	if len(data) < width*height*4 {
+		return nil, ErrShortData
+	}
+
+	var encode func(src *block, dst []byte)
+
+	switch f {
+	case FormatBC1:
+		encode = func(src *block, dst []byte) { encodeBC1(src, dst, false) }
+	case FormatBC2:
+		encode = encodeBC2
+	case FormatBC3:
+		encode = encodeBC3
+	case FormatBC4:
+		encode = func(src *block, dst []byte) { encodeBC4Channel(src, dst, 0) }
+	case FormatBC5:
+		encode = encodeBC5
+	default:
+		return nil, ErrUnsupported
+	}
+
+	size := f.BlockSize()
+	bw, bh := (width+3)/4, (height+3)/4
+	out := make([]byte, bw*bh*size)
+
+	var b block
+
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			// Blocks past the edges of the image repeat its last pixels.
+			for y := 0; y < 4; y++ {
+				sy := clampDim(by*4+y, height)
+				for x := 0; x < 4; x++ {
+					sx := clampDim(bx*4+x, width)
+					copy(b[y*4+x][:], data[(sy*width+sx)*4:])
+				}
+			}
+
+			encode(&b, out[(by*bw+bx)*size:])
+		}
+	}
+
+	return out, nil
+}
+
+// encodeBC1 encodes a BC1 color block. Unless the block is part of a BC2 or
+// BC3 block, pixels with alpha below one half are made transparent.
+func encodeBC1(src *block, dst []byte, opaque bool) {
+	transparent := false
+	if !opaque {
+		for _, p := range src {
+			if p[3] < 0x80 {
+				transparent = true
+			}
+		}
+	}
+
+	lo, hi := [3]int{0xff, 0xff, 0xff}, [3]int{}
+	n := 0
+
+	for _, p := range src {
+		if transparent && p[3] < 0x80 {
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			if int(p[i]) < lo[i] {
+				lo[i] = int(p[i])
+			}
+			if int(p[i]) > hi[i] {
+				hi[i] = int(p[i])
+			}
+		}
+		n++
+	}
+
+	if n == 0 {
+		binary.LittleEndian.PutUint32(dst[0:], 0)
+		binary.LittleEndian.PutUint32(dst[4:], 0xffffffff)
+		return
+	}
+
+	// Pick the diagonal of the bounding box the colors vary along: red and
+	// blue are swapped when they fall as green rises.
+	var cov [3]int
+	for _, p := range src {
+		if transparent && p[3] < 0x80 {
+			continue
+		}
+		g := 2*int(p[1]) - lo[1] - hi[1]
+		cov[0] += (2*int(p[0]) - lo[0] - hi[0]) * g
+		cov[2] += (2*int(p[2]) - lo[2] - hi[2]) * g
+	}
+	for _, i := range []int{0, 2} {
+		if cov[i] < 0 {
+			lo[i], hi[i] = hi[i], lo[i]
+		}
+	}
+
+	// Inset the endpoints, so that the palette covers the block evenly.
+	for i := 0; i < 3; i++ {
+		d := (hi[i] - lo[i]) >> 4
+		lo[i] += d
+		hi[i] -= d
+	}
+
+	c0, c1 := pack565(hi), pack565(lo)
+	if transparent == (c0 > c1) {
+		c0, c1 = c1, c0
+	}
+
+	palette := bc1Palette(c0, c1, opaque)
+	colors := 4
+	if !opaque && c0 <= c1 {
+		colors = 3
+	}
+
+	var indices uint32
+	for i, p := range src {
+		idx := 3
+		if !transparent || p[3] >= 0x80 {
+			idx = nearestColor(p, palette[:colors])
+		}
+		indices |= uint32(idx) << (uint(i) * 2)
+	}
+
+	binary.LittleEndian.PutUint16(dst[0:], c0)
+	binary.LittleEndian.PutUint16(dst[2:], c1)
+	binary.LittleEndian.PutUint32(dst[4:], indices)
+}
+
+func encodeBC2(src *block, dst []byte) {
+	var alpha uint64
+	for i, p := range src {
+		alpha |= uint64((int(p[3])*15+127)/255) << (uint(i) * 4)
+	}
+
+	binary.LittleEndian.PutUint64(dst, alpha)
+	encodeBC1(src, dst[8:], true)
+}
+
+func encodeBC3(src *block, dst []byte) {
+	encodeBC4Channel(src, dst, 3)
+	encodeBC1(src, dst[8:], true)
+}
+
+func encodeBC5(src *block, dst []byte) {
+	encodeBC4Channel(src, dst, 0)
+	encodeBC4Channel(src, dst[8:], 1)
+}
+
+// encodeBC4Channel encodes a channel of the pixels as a BC4 block, with
+// eight interpolated values.
+func encodeBC4Channel(src *block, dst []byte, channel int) {
+	lo, hi := uint8(0xff), uint8(0)
+	for _, p := range src {
+		if p[channel] < lo {
+			lo = p[channel]
+		}
+		if p[channel] > hi {
+			hi = p[channel]
+		}
+	}
+
+	palette := bc4Palette(hi, lo)
+
+	var bits uint64
+	if hi > lo {
+		for i, p := range src {
+			best, bestDist := 0, 0x100
+			for j, v := range palette {
+				d := int(p[channel]) - int(v)
+				if d < 0 {
+					d = -d
+				}
+				if d < bestDist {
+					best, bestDist = j, d
+				}
+			}
+			bits |= uint64(best) << (uint(i) * 3)
+		}
+	}
+
+	dst[0], dst[1] = hi, lo
+	for i := 0; i < 6; i++ {
+		dst[2+i] = uint8(bits >> (uint(i) * 8))
+	}
+}
+
+// nearestColor returns the index of the color of the palette closest to the
+// color of the pixel.
+func nearestColor(p [4]uint8, palette [][4]uint8) int {
+	best, bestDist := 0, -1
+
+	for i, c := range palette {
+		dist := 0
+		for j := 0; j < 3; j++ {
+			d := int(p[j]) - int(c[j])
+			dist += d * d
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+
+	return best
+}
+
+// pack565 packs an 8-bit color to RGB565, rounding to nearest.
+func pack565(c [3]int) uint16 {
+	r := (c[0]*31 + 127) / 255
+	g := (c[1]*63 + 127) / 255
+	b := (c[2]*31 + 127) / 255
+
+	return uint16(r<<11 | g<<5 | b)
+}
+
+func clampDim(i, n int) int {
+	if i >= n {
+		return n - 1
+	}
+
+	return i
+}
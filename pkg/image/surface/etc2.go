@@ -0,0 +1,251 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package surface
+
+import "encoding/binary"
+
+var etcModifiers = [8][2]int{
+	{2, 8}, {5, 17}, {9, 29}, {13, 42}, {18, 60}, {24, 80}, {33, 106}, {47, 183},
+}
+
+var etcDistances = [8]int{3, 6, 11, 16, 23, 32, 41, 64}
+
+var eacModifiers = [16][8]int{
+	{-3, -6, -9, -15, 2, 5, 8, 14},
+	{-3, -7, -10, -13, 2, 6, 9, 12},
+	{-2, -5, -8, -13, 1, 4, 7, 12},
+	{-2, -4, -6, -13, 1, 3, 5, 12},
+	{-3, -6, -8, -12, 2, 5, 7, 11},
+	{-3, -7, -9, -11, 2, 6, 8, 10},
+	{-4, -7, -8, -11, 3, 6, 7, 10},
+	{-3, -5, -8, -11, 2, 4, 7, 10},
+	{-2, -6, -8, -10, 1, 5, 7, 9},
+	{-2, -5, -8, -10, 1, 4, 7, 9},
+	{-2, -4, -8, -10, 1, 3, 7, 9},
+	{-2, -5, -7, -10, 1, 4, 6, 9},
+	{-3, -4, -7, -10, 2, 3, 6, 9},
+	{-1, -2, -3, -10, 0, 1, 2, 9},
+	{-4, -6, -8, -9, 3, 5, 7, 8},
+	{-3, -5, -7, -9, 2, 4, 6, 8},
+}
+
+// decodeETC2 decodes an ETC2 color block. With punchthrough alpha, blocks
+// without their opaque bit set have transparent black pixels.
+func decodeETC2(src []byte, dst *block, punchthrough bool) {
+	bits := binary.BigEndian.Uint64(src)
+
+	field := func(hi, n uint) int {
+		return int(bits >> (hi - n + 1) & (1<<n - 1))
+	}
+
+	diff := field(33, 1) == 1
+	opaque := true
+	if punchthrough {
+		opaque, diff = diff, true
+	}
+
+	if !diff {
+		base := [2][3]int{
+			{extend4(field(63, 4)), extend4(field(55, 4)), extend4(field(47, 4))},
+			{extend4(field(59, 4)), extend4(field(51, 4)), extend4(field(43, 4))},
+		}
+		decodeETCSubblocks(bits, base, dst, opaque)
+		return
+	}
+
+	r, g, b := field(63, 5), field(55, 5), field(47, 5)
+	dr, dg, db := signed3(field(58, 3)), signed3(field(50, 3)), signed3(field(42, 3))
+
+	switch {
+	case r+dr < 0 || r+dr > 31:
+		decodeETCT(bits, field, dst, opaque)
+	case g+dg < 0 || g+dg > 31:
+		decodeETCH(bits, field, dst, opaque)
+	case b+db < 0 || b+db > 31:
+		decodeETCPlanar(field, dst)
+	default:
+		base := [2][3]int{
+			{extend5(r), extend5(g), extend5(b)},
+			{extend5(r + dr), extend5(g + dg), extend5(b + db)},
+		}
+		decodeETCSubblocks(bits, base, dst, opaque)
+	}
+}
+
+// decodeETCSubblocks decodes the individual and differential modes, which
+// split the block in to two halves of a base color each.
+func decodeETCSubblocks(bits uint64, base [2][3]int, dst *block, opaque bool) {
+	tables := [2]int{int(bits >> 37 & 7), int(bits >> 34 & 7)}
+	flip := bits>>32&1 == 1
+
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			sub := x / 2
+			if flip {
+				sub = y / 2
+			}
+
+			idx := etcIndex(bits, x, y)
+			mod := etcModifiers[tables[sub]]
+
+			var m int
+			switch idx {
+			case 0:
+				m = mod[0]
+			case 1:
+				m = mod[1]
+			case 2:
+				m = -mod[0]
+			case 3:
+				m = -mod[1]
+			}
+
+			if !opaque {
+				switch idx {
+				case 0:
+					m = 0
+				case 2:
+					dst[y*4+x] = [4]uint8{}
+					continue
+				}
+			}
+
+			c := base[sub]
+			dst[y*4+x] = [4]uint8{clamp8(c[0] + m), clamp8(c[1] + m), clamp8(c[2] + m), 0xff}
+		}
+	}
+}
+
+func decodeETCT(bits uint64, field func(hi, n uint) int, dst *block, opaque bool) {
+	c1 := [3]int{extend4(field(60, 2)<<2 | field(57, 2)), extend4(field(55, 4)), extend4(field(51, 4))}
+	c2 := [3]int{extend4(field(47, 4)), extend4(field(43, 4)), extend4(field(39, 4))}
+	d := etcDistances[field(35, 2)<<1|field(32, 1)]
+
+	paint := [4][3]int{c1, offset(c2, d), c2, offset(c2, -d)}
+	decodePaint(bits, paint, dst, opaque)
+}
+
+func decodeETCH(bits uint64, field func(hi, n uint) int, dst *block, opaque bool) {
+	r1, g1, b1 := field(62, 4), field(58, 3)<<1|field(52, 1), field(51, 1)<<3|field(49, 3)
+	r2, g2, b2 := field(46, 4), field(42, 4), field(38, 4)
+
+	i := field(34, 1)<<2 | field(32, 1)<<1
+	if r1<<8|g1<<4|b1 >= r2<<8|g2<<4|b2 {
+		i |= 1
+	}
+	d := etcDistances[i]
+
+	c1 := [3]int{extend4(r1), extend4(g1), extend4(b1)}
+	c2 := [3]int{extend4(r2), extend4(g2), extend4(b2)}
+
+	paint := [4][3]int{offset(c1, d), offset(c1, -d), offset(c2, d), offset(c2, -d)}
+	decodePaint(bits, paint, dst, opaque)
+}
+
+// decodePaint decodes the pixels of the T and H modes, which index one of
+// four paint colors.
+func decodePaint(bits uint64, paint [4][3]int, dst *block, opaque bool) {
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			idx := etcIndex(bits, x, y)
+			if !opaque && idx == 2 {
+				dst[y*4+x] = [4]uint8{}
+				continue
+			}
+
+			c := paint[idx]
+			dst[y*4+x] = [4]uint8{clamp8(c[0]), clamp8(c[1]), clamp8(c[2]), 0xff}
+		}
+	}
+}
+
+func decodeETCPlanar(field func(hi, n uint) int, dst *block) {
+	o := [3]int{
+		extend6(field(62, 6)),
+		extend7(field(56, 1)<<6 | field(54, 6)),
+		extend6(field(48, 1)<<5 | field(44, 2)<<3 | field(41, 3)),
+	}
+	h := [3]int{extend6(field(38, 5)<<1 | field(32, 1)), extend7(field(31, 7)), extend6(field(24, 6))}
+	v := [3]int{extend6(field(18, 6)), extend7(field(12, 7)), extend6(field(5, 6))}
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			var c [4]uint8
+			for i := 0; i < 3; i++ {
+				c[i] = clamp8((x*(h[i]-o[i]) + y*(v[i]-o[i]) + 4*o[i] + 2) >> 2)
+			}
+			c[3] = 0xff
+			dst[y*4+x] = c
+		}
+	}
+}
+
+// decodeETC2EAC decodes an ETC2 block with an EAC alpha block.
+func decodeETC2EAC(src []byte, dst *block) {
+	decodeETC2(src[8:], dst, false)
+
+	bits := binary.BigEndian.Uint64(src)
+	base := int(bits >> 56)
+	multiplier := int(bits >> 52 & 0xf)
+	table := eacModifiers[bits>>48&0xf]
+
+	for i := 0; i < 16; i++ {
+		x, y := i/4, i%4
+		idx := bits >> (45 - uint(i)*3) & 7
+		dst[y*4+x][3] = clamp8(base + table[idx]*multiplier)
+	}
+}
+
+// etcIndex returns the pixel index of a pixel, stored in columns.
+func etcIndex(bits uint64, x, y int) int {
+	i := uint(x*4 + y)
+	return int(bits>>(i+16)&1)<<1 | int(bits>>i&1)
+}
+
+func offset(c [3]int, d int) [3]int {
+	return [3]int{c[0] + d, c[1] + d, c[2] + d}
+}
+
+func signed3(v int) int {
+	if v >= 4 {
+		return v - 8
+	}
+
+	return v
+}
+
+func extend4(v int) int { return v<<4 | v }
+func extend5(v int) int { return v<<3 | v>>2 }
+func extend6(v int) int { return v<<2 | v>>4 }
+func extend7(v int) int { return v<<1 | v>>6 }
+
+func clamp8(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 0xff {
+		return 0xff
+	}
+
+	return uint8(v)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package surface
+
+import "fmt"
+
+// Format is the layout of the pixels of an image.
+type Format uint32
+
+const (
+	FormatUnknown Format = iota
+	FormatR8
+	FormatRG8
+	FormatRGBA8
+	FormatBGRA8
+	FormatR16F
+	FormatRG16F
+	FormatRGBA16F
+	FormatR32F
+	FormatRG32F
+	FormatRGBA32F
+	FormatBC1
+	FormatBC2
+	FormatBC3
+	FormatBC4
+	FormatBC5
+	FormatBC6H
+	FormatBC7
+	FormatETC2RGB8
+	FormatETC2RGB8A1
+	FormatETC2RGBA8
+)
+
+var formatNames = map[Format]string{
+	FormatR8:         "R8",
+	FormatRG8:        "RG8",
+	FormatRGBA8:      "RGBA8",
+	FormatBGRA8:      "BGRA8",
+	FormatR16F:       "R16F",
+	FormatRG16F:      "RG16F",
+	FormatRGBA16F:    "RGBA16F",
+	FormatR32F:       "R32F",
+	FormatRG32F:      "RG32F",
+	FormatRGBA32F:    "RGBA32F",
+	FormatBC1:        "BC1",
+	FormatBC2:        "BC2",
+	FormatBC3:        "BC3",
+	FormatBC4:        "BC4",
+	FormatBC5:        "BC5",
+	FormatBC6H:       "BC6H",
+	FormatBC7:        "BC7",
+	FormatETC2RGB8:   "ETC2RGB8",
+	FormatETC2RGB8A1: "ETC2RGB8A1",
+	FormatETC2RGBA8:  "ETC2RGBA8",
+}
+
+func (f Format) String() string {
+	if s, ok := formatNames[f]; ok {
+		return s
+	}
+
+	return fmt.Sprintf("Format(%d)", uint32(f))
+}
+
+// Compressed reports whether the format stores blocks of 4x4 pixels.
+func (f Format) Compressed() bool {
+	return f >= FormatBC1 && f <= FormatETC2RGBA8
+}
+
+// BlockSize returns the size in bytes of a block of 4x4 pixels for block
+// compressed formats, or of a pixel for other formats. It returns 0 for
+// unknown formats.
+func (f Format) BlockSize() int {
+	switch f {
+	case FormatR8:
+		return 1
+	case FormatRG8, FormatR16F:
+		return 2
+	case FormatRGBA8, FormatBGRA8, FormatRG16F, FormatR32F:
+		return 4
+	case FormatRGBA16F, FormatRG32F:
+		return 8
+	case FormatRGBA32F:
+		return 16
+	case FormatBC1, FormatBC4, FormatETC2RGB8, FormatETC2RGB8A1:
+		return 8
+	case FormatBC2, FormatBC3, FormatBC5, FormatBC6H, FormatBC7, FormatETC2RGBA8:
+		return 16
+	}
+
+	return 0
+}
+
+// Size returns the size in bytes of an image of the format. Block
+// compressed images are padded to whole blocks.
+func (f Format) Size(width, height int) int {
+	if f.Compressed() {
+		return ((width + 3) / 4) * ((height + 3) / 4) * f.BlockSize()
+	}
+
+	return width * height * f.BlockSize()
+}
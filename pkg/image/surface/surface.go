@@ -0,0 +1,209 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package surface
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Surface errors.
+var (
+	ErrUnsupported = errors.New("surface: unsupported format")
+	ErrShortData   = errors.New("surface: image data too short")
+)
+
+// Limits of the images decoders accept. Headers are checked against them
+// before any image data is allocated, so that corrupt files can neither
+// exhaust memory nor overflow the sizes computed from their headers.
+const (
+	MaxDimension = 1 << 15
+	MaxLayers    = 1 << 11
+)
+
+// Faces of a cubemap, in the order they are stored.
+const (
+	FacePositiveX = iota
+	FaceNegativeX
+	FacePositiveY
+	FaceNegativeY
+	FacePositiveZ
+	FaceNegativeZ
+)
+
+// Image is a texture image with its mip chain.
+type Image struct {
+	Format Format
+	Width  int
+	Height int
+
+	// Layers is the number of array layers. Images which are not arrays
+	// have one layer.
+	Layers int
+
+	// Cubemap is true for images with six faces per layer.
+	Cubemap bool
+
+	// SRGB is true for images of color encoded with the sRGB transfer
+	// function.
+	SRGB bool
+
+	// Levels holds the mip chain, largest level first. Each level holds an
+	// image per layer and face, with faces varying fastest.
+	Levels [][][]byte
+}
+
+// Faces returns the number of faces per layer: six for cubemaps, one
+// otherwise.
+func (m *Image) Faces() int {
+	if m.Cubemap {
+		return 6
+	}
+
+	return 1
+}
+
+// LevelSize returns the size of a mip level.
+func (m *Image) LevelSize(level int) (int, int) {
+	return levelDim(m.Width, level), levelDim(m.Height, level)
+}
+
+// Data returns the image of a layer and face of a mip level.
+func (m *Image) Data(level, layer, face int) []byte {
+	return m.Levels[level][layer*m.Faces()+face]
+}
+
+// DataSize checks the size, layers and format of an image with the number of
+// mip levels against the limits, and returns the size in bytes of those
+// levels of every layer and face.
+func (m *Image) DataSize(levels int) (int64, error) {
+	if m.Width <= 0 || m.Height <= 0 || m.Width > MaxDimension || m.Height > MaxDimension {
+		return 0, fmt.Errorf("surface: invalid size %dx%d", m.Width, m.Height)
+	}
+	if m.Layers <= 0 || m.Layers > MaxLayers {
+		return 0, fmt.Errorf("surface: invalid layer count %d", m.Layers)
+	}
+	if m.Format.BlockSize() == 0 {
+		return 0, ErrUnsupported
+	}
+	if levels <= 0 || levels > MaxLevels(m.Width, m.Height) {
+		return 0, fmt.Errorf("surface: invalid mip level count %d", levels)
+	}
+
+	var size int64
+	for l := 0; l < levels; l++ {
+		size += int64(m.Format.Size(m.LevelSize(l)))
+	}
+
+	return size * int64(m.Layers*m.Faces()), nil
+}
+
+// Validate checks that the image has a mip chain no longer than its size
+// allows, and that every image of the chain has the size its format
+// requires.
+func (m *Image) Validate() error {
+	if m.Width <= 0 || m.Height <= 0 {
+		return fmt.Errorf("surface: invalid size %dx%d", m.Width, m.Height)
+	}
+	if m.Layers <= 0 {
+		return fmt.Errorf("surface: invalid layer count %d", m.Layers)
+	}
+	if m.Format.BlockSize() == 0 {
+		return ErrUnsupported
+	}
+	if m.Cubemap && m.Width != m.Height {
+		return fmt.Errorf("surface: cubemap faces are %dx%d", m.Width, m.Height)
+	}
+	if len(m.Levels) == 0 || len(m.Levels) > MaxLevels(m.Width, m.Height) {
+		return fmt.Errorf("surface: invalid mip level count %d", len(m.Levels))
+	}
+
+	n := m.Layers * m.Faces()
+
+	for level, images := range m.Levels {
+		if len(images) != n {
+			return fmt.Errorf("surface: mip level %d has %d images, want %d", level, len(images), n)
+		}
+
+		size := m.Format.Size(m.LevelSize(level))
+		for i, data := range images {
+			if len(data) != size {
+				return fmt.Errorf("surface: image %d of mip level %d has %d bytes, want %d", i, level, len(data), size)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Decompress returns a copy of the image in a format a renderer without
+// support for block compression can sample. Images of 8 bit colors,
+// including block compressed ones, are converted to RGBA8. Images in other
+// formats are returned as they are. BC6H images hold high dynamic range
+// colors which do not fit RGBA8, and are not supported.
+func (m *Image) Decompress() (*Image, error) {
+	if !m.Format.Compressed() && m.Format != FormatBGRA8 {
+		return m, nil
+	}
+
+	out := *m
+	out.Format = FormatRGBA8
+	out.Levels = make([][][]byte, len(m.Levels))
+
+	for level, images := range m.Levels {
+		w, h := m.LevelSize(level)
+
+		out.Levels[level] = make([][]byte, len(images))
+		for i, data := range images {
+			rgba, err := DecodeRGBA8(m.Format, w, h, data)
+			if err != nil {
+				return nil, err
+			}
+			out.Levels[level][i] = rgba
+		}
+	}
+
+	return &out, nil
+}
+
+// MaxLevels returns the number of levels of a full mip chain for an image
+// of the size.
+func MaxLevels(width, height int) int {
+	n := 1
+	for width > 1 || height > 1 {
+		width /= 2
+		height /= 2
+		n++
+	}
+
+	return n
+}
+
+func levelDim(n, level int) int {
+	n >>= uint(level)
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
@@ -0,0 +1,431 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package surface
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// bitWriter writes the bits of a block, least significant first.
+type bitWriter struct {
+	data [16]byte
+	pos  uint
+}
+
+func (w *bitWriter) write(v int, n uint) {
+	for i := uint(0); i < n; i++ {
+		if v>>i&1 == 1 {
+			w.data[(w.pos+i)/8] |= 1 << ((w.pos + i) % 8)
+		}
+	}
+	w.pos += n
+}
+
+func TestFormat_Size(t *testing.T) {
+	var tests = []struct {
+		format Format
+		w, h   int
+		want   int
+	}{
+		{FormatRGBA8, 3, 2, 24},
+		{FormatRGBA32F, 1, 1, 16},
+		{FormatBC1, 4, 4, 8},
+		{FormatBC1, 5, 1, 16},
+		{FormatBC7, 1, 1, 16},
+		{FormatETC2RGBA8, 8, 8, 64},
+		{FormatUnknown, 4, 4, 0},
+	}
+
+	for i, v := range tests {
+		if got := v.format.Size(v.w, v.h); got != v.want {
+			t.Errorf("%s case %d: %s.Size(%d, %d) = %d, want %d", t.Name(), i, v.format, v.w, v.h, got, v.want)
+		}
+	}
+}
+
+func TestImage_Validate(t *testing.T) {
+	img := func(levels int) *Image {
+		m := &Image{Format: FormatBC1, Width: 8, Height: 8, Layers: 2, Cubemap: true}
+		for l := 0; l < levels; l++ {
+			w, h := m.LevelSize(l)
+			images := make([][]byte, 12)
+			for i := range images {
+				images[i] = make([]byte, FormatBC1.Size(w, h))
+			}
+			m.Levels = append(m.Levels, images)
+		}
+		return m
+	}
+
+	if err := img(4).Validate(); err != nil {
+		t.Error(err)
+	}
+	if err := img(5).Validate(); err == nil {
+		t.Error("expected error for too many levels")
+	}
+
+	m := img(2)
+	m.Levels[1][3] = m.Levels[1][3][:4]
+	if err := m.Validate(); err == nil {
+		t.Error("expected error for short image")
+	}
+
+	m = img(1)
+	m.Height = 4
+	if err := m.Validate(); err == nil {
+		t.Error("expected error for rectangular cubemap")
+	}
+}
+
+func TestImage_DataSize(t *testing.T) {
+	var tests = []struct {
+		img    Image
+		levels int
+		want   int64
+	}{
+		{img: Image{Format: FormatBC1, Width: 8, Height: 8, Layers: 2, Cubemap: true}, levels: 4, want: 12 * (32 + 8 + 8 + 8)},
+		{img: Image{Format: FormatRGBA8, Width: 4, Height: 1, Layers: 1}, levels: 3, want: 16 + 8 + 4},
+		{img: Image{Format: FormatRGBA32F, Width: MaxDimension, Height: MaxDimension, Layers: MaxLayers}, levels: 1, want: 16 << 41},
+		{img: Image{Format: FormatRGBA8, Width: 4, Height: 4, Layers: 1}, levels: 4, want: -1},
+		{img: Image{Format: FormatRGBA8, Width: 4, Height: 4, Layers: 1}, levels: 0, want: -1},
+		{img: Image{Format: FormatRGBA8, Width: 0, Height: 4, Layers: 1}, levels: 1, want: -1},
+		{img: Image{Format: FormatRGBA8, Width: MaxDimension + 1, Height: 4, Layers: 1}, levels: 1, want: -1},
+		{img: Image{Format: FormatRGBA8, Width: 4, Height: 4, Layers: 0}, levels: 1, want: -1},
+		{img: Image{Format: FormatRGBA8, Width: 4, Height: 4, Layers: MaxLayers + 1}, levels: 1, want: -1},
+		{img: Image{Format: FormatUnknown, Width: 4, Height: 4, Layers: 1}, levels: 1, want: -1},
+	}
+
+	for i, v := range tests {
+		got, err := v.img.DataSize(v.levels)
+		if v.want < 0 {
+			if err == nil {
+				t.Errorf("%s case %d: expected error", t.Name(), i)
+			}
+			continue
+		}
+		if err != nil || got != v.want {
+			t.Errorf("%s case %d: DataSize(%d) = %d, %v, want %d", t.Name(), i, v.levels, got, err, v.want)
+		}
+	}
+}
+
+func TestDecodeRGBA8_BC1(t *testing.T) {
+	src := make([]byte, 8)
+	binary.LittleEndian.PutUint16(src[0:], 0xf800)
+	binary.LittleEndian.PutUint16(src[2:], 0x001f)
+	binary.LittleEndian.PutUint32(src[4:], 0xe4) // Pixels 0-3 index 0-3.
+
+	out, err := DecodeRGBA8(FormatBC1, 4, 4, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][4]uint8{{255, 0, 0, 255}, {0, 0, 255, 255}, {170, 0, 85, 255}, {85, 0, 170, 255}, {255, 0, 0, 255}}
+	for i, w := range want {
+		if got := [4]uint8{out[i*4], out[i*4+1], out[i*4+2], out[i*4+3]}; got != w {
+			t.Errorf("pixel %d = %v, want %v", i, got, w)
+		}
+	}
+
+	// With the endpoints swapped the block has three colors and
+	// transparent black.
+	binary.LittleEndian.PutUint16(src[0:], 0x001f)
+	binary.LittleEndian.PutUint16(src[2:], 0xf800)
+
+	out, err = DecodeRGBA8(FormatBC1, 4, 4, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = [][4]uint8{{0, 0, 255, 255}, {255, 0, 0, 255}, {127, 0, 127, 255}, {}}
+	for i, w := range want {
+		if got := [4]uint8{out[i*4], out[i*4+1], out[i*4+2], out[i*4+3]}; got != w {
+			t.Errorf("three color pixel %d = %v, want %v", i, got, w)
+		}
+	}
+
+	// Images smaller than a block are cropped.
+	out, err = DecodeRGBA8(FormatBC1, 2, 2, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 16 {
+		t.Fatalf("decoded %d bytes, want 16", len(out))
+	}
+	if got := [4]uint8{out[4], out[5], out[6], out[7]}; got != [4]uint8{255, 0, 0, 255} {
+		t.Errorf("cropped pixel 1 = %v", got)
+	}
+}
+
+func TestDecodeRGBA8_BC3(t *testing.T) {
+	src := make([]byte, 16)
+	src[0], src[1] = 200, 100
+	src[2] = 0x0a // Pixel 0 index 2, pixel 1 index 1.
+	binary.LittleEndian.PutUint16(src[8:], 0xffff)
+	binary.LittleEndian.PutUint16(src[10:], 0xffff)
+
+	out, err := DecodeRGBA8(FormatBC3, 4, 4, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out[3] != (6*200+100)/7 || out[7] != 100 || out[11] != 200 {
+		t.Errorf("alpha = %d, %d, %d", out[3], out[7], out[11])
+	}
+	if out[0] != 255 || out[1] != 255 || out[2] != 255 {
+		t.Errorf("color = %v", out[:3])
+	}
+}
+
+func TestDecodeRGBA8_BC7(t *testing.T) {
+	// Mode 6: one subset of RGBA endpoints with a p-bit each and 4 bit
+	// indices.
+	w := &bitWriter{}
+	w.write(1<<6, 7)
+	for c := 0; c < 4; c++ {
+		w.write(0, 7)
+		w.write(127, 7)
+	}
+	w.write(0, 1)
+	w.write(1, 1)
+	w.write(0, 3)
+	w.write(15, 4)
+	w.write(8, 4)
+
+	out, err := DecodeRGBA8(FormatBC7, 4, 4, w.data[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []uint8{0, 255, 135} {
+		for c := 0; c < 4; c++ {
+			if out[i*4+c] != want {
+				t.Errorf("pixel %d channel %d = %d, want %d", i, c, out[i*4+c], want)
+			}
+		}
+	}
+
+	// Mode 5 with rotation swaps the interpolated alpha in to red.
+	w = &bitWriter{}
+	w.write(1<<5, 6)
+	w.write(1, 2)
+	for c := 0; c < 3; c++ {
+		w.write(0, 7)
+		w.write(0, 7)
+	}
+	w.write(10, 8)
+	w.write(20, 8)
+
+	out, err = DecodeRGBA8(FormatBC7, 4, 4, w.data[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out[0] != 10 || out[3] != 0 {
+		t.Errorf("rotated pixel = %v", out[:4])
+	}
+
+	// Blocks of no mode decode to transparent black.
+	out, _ = DecodeRGBA8(FormatBC7, 4, 4, make([]byte, 16))
+	for i, v := range out {
+		if v != 0 {
+			t.Fatalf("byte %d of invalid block = %d", i, v)
+		}
+	}
+}
+
+func TestBC7Anchors(t *testing.T) {
+	for p := 0; p < 64; p++ {
+		if s := bc7Partitions2[p][bc7Anchors2[p]]; s != 1 {
+			t.Errorf("anchor of partition %d of two subsets is in subset %d", p, s)
+		}
+		for i := 0; i < 2; i++ {
+			if s := bc7Partitions3[p][bc7Anchors3[i][p]]; int(s) != i+1 {
+				t.Errorf("anchor %d of partition %d of three subsets is in subset %d", i+1, p, s)
+			}
+		}
+		if bc7Partitions2[p][0] != 0 || bc7Partitions3[p][0] != 0 {
+			t.Errorf("partition %d does not start with subset 0", p)
+		}
+	}
+}
+
+func TestDecodeRGBA8_ETC2(t *testing.T) {
+	// Individual mode, both halves of base color 8 and table 0, with the
+	// pixel at (1, 0) using the larger negative modifier.
+	var bits uint64 = 0x88<<56 | 0x88<<48 | 0x88<<40
+	bits |= 1<<(4+16) | 1<<4
+
+	src := make([]byte, 16)
+	binary.BigEndian.PutUint64(src[8:], bits)
+
+	// EAC alpha of base 100 and multiplier 2, with the pixel at (0, 1)
+	// using the first modifier of table 0.
+	var alpha uint64 = 100<<56 | 2<<52
+	for i := 0; i < 16; i++ {
+		idx := uint64(4)
+		if i == 1 {
+			idx = 0
+		}
+		alpha |= idx << (45 - uint(i)*3)
+	}
+	binary.BigEndian.PutUint64(src, alpha)
+
+	out, err := DecodeRGBA8(FormatETC2RGBA8, 4, 4, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pixel := func(x, y int) [4]uint8 {
+		i := (y*4 + x) * 4
+		return [4]uint8{out[i], out[i+1], out[i+2], out[i+3]}
+	}
+
+	if got := pixel(0, 0); got != [4]uint8{138, 138, 138, 104} {
+		t.Errorf("pixel (0, 0) = %v", got)
+	}
+	if got := pixel(1, 0); got != [4]uint8{128, 128, 128, 104} {
+		t.Errorf("pixel (1, 0) = %v", got)
+	}
+	if got := pixel(0, 1); got != [4]uint8{138, 138, 138, 94} {
+		t.Errorf("pixel (0, 1) = %v", got)
+	}
+
+	// Without the opaque bit, punchthrough blocks have transparent pixels
+	// for index 2.
+	bits = 16<<59 | 16<<51 | 16<<43
+	bits |= 1 << 16
+	binary.BigEndian.PutUint64(src, bits)
+
+	out, err = DecodeRGBA8(FormatETC2RGB8A1, 4, 4, src[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := [4]uint8{out[0], out[1], out[2], out[3]}; got != [4]uint8{} {
+		t.Errorf("transparent pixel = %v", got)
+	}
+	if got := [4]uint8{out[16], out[17], out[18], out[19]}; got != [4]uint8{132, 132, 132, 255} {
+		t.Errorf("opaque pixel = %v", got)
+	}
+}
+
+func TestImage_Decompress(t *testing.T) {
+	m := &Image{Format: FormatBC1, Width: 6, Height: 3, Layers: 1}
+	for l := 0; l < MaxLevels(6, 3); l++ {
+		w, h := m.LevelSize(l)
+		m.Levels = append(m.Levels, [][]byte{make([]byte, FormatBC1.Size(w, h))})
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := m.Decompress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Format != FormatRGBA8 || len(out.Levels) != 3 {
+		t.Fatalf("decompressed %s with %d levels", out.Format, len(out.Levels))
+	}
+	if err := out.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	m.Format = FormatBC6H
+	m.Levels = [][][]byte{{make([]byte, 32)}}
+	if _, err := m.Decompress(); err != ErrUnsupported {
+		t.Errorf("err = %v, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestEncodeRGBA8(t *testing.T) {
+	// A 6x5 image of ramps between colors, falling in red and rising in
+	// green, with a transparent corner.
+	const w, h = 6, 5
+	data := make([]byte, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := (y*w + x) * 4
+			v := (x + y) % 4 * 72
+			data[i+0] = uint8(255 - v)
+			data[i+1] = uint8(v)
+			data[i+2] = 0x80
+			data[i+3] = uint8(x * 51)
+			if x < 2 && y < 2 {
+				data[i+3] = 0
+			}
+		}
+	}
+
+	var tests = []struct {
+		format   Format
+		channels []int
+	}{
+		{FormatBC1, []int{0, 1, 2}},
+		{FormatBC2, []int{0, 1, 2, 3}},
+		{FormatBC3, []int{0, 1, 2, 3}},
+		{FormatBC4, []int{0}},
+		{FormatBC5, []int{0, 1}},
+	}
+
+	for _, v := range tests {
+		enc, err := EncodeRGBA8(v.format, w, h, data)
+		if err != nil {
+			t.Fatalf("%s: %v", v.format, err)
+		}
+		if len(enc) != v.format.Size(w, h) {
+			t.Fatalf("%s: len = %d, want %d", v.format, len(enc), v.format.Size(w, h))
+		}
+
+		dec, err := DecodeRGBA8(v.format, w, h, enc)
+		if err != nil {
+			t.Fatalf("%s: %v", v.format, err)
+		}
+
+		for i := 0; i < len(data); i += 4 {
+			for _, c := range v.channels {
+				// BC1 drops the color of pixels with alpha below one half,
+				// and blocks with such pixels have three colors.
+				if v.format == FormatBC1 && (data[i+3] < 0x80 || i/4%w < 4 && i/4/w < 4) {
+					continue
+				}
+				d := int(dec[i+c]) - int(data[i+c])
+				if d < -24 || d > 24 {
+					t.Errorf("%s: pixel %d channel %d = %d, want %d", v.format, i/4, c, dec[i+c], data[i+c])
+				}
+			}
+		}
+
+		if v.format == FormatBC1 {
+			if dec[3] != 0 || dec[(3*w+3)*4+3] != 0xff {
+				t.Errorf("BC1: alpha = %d and %d, want 0 and 255", dec[3], dec[(3*w+3)*4+3])
+			}
+		}
+	}
+
+	if _, err := EncodeRGBA8(FormatBC7, w, h, data); err != ErrUnsupported {
+		t.Errorf("err = %v, want %v", err, ErrUnsupported)
+	}
+	if _, err := EncodeRGBA8(FormatBC1, w, h+1, data); err != ErrShortData {
+		t.Errorf("err = %v, want %v", err, ErrShortData)
+	}
+}
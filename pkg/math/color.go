@@ -24,6 +24,7 @@ package math
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/juju/errors"
@@ -96,6 +97,34 @@ func (c Color) Elem() (float32, float32, float32, float32) {
 	return c.R, c.G, c.B, c.A
 }
 
+// Linear converts the color from sRGB to linear. Alpha is left as it is.
+func (c Color) Linear() Color {
+	return Color{SRGBToLinear(c.R), SRGBToLinear(c.G), SRGBToLinear(c.B), c.A}
+}
+
+// SRGB converts the color from linear to sRGB. Alpha is left as it is.
+func (c Color) SRGB() Color {
+	return Color{LinearToSRGB(c.R), LinearToSRGB(c.G), LinearToSRGB(c.B), c.A}
+}
+
+// SRGBToLinear converts a color channel from sRGB to linear.
+func SRGBToLinear(v float32) float32 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return float32(math.Pow((float64(v)+0.055)/1.055, 2.4))
+}
+
+// LinearToSRGB converts a color channel from linear to sRGB.
+func LinearToSRGB(v float32) float32 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+
+	return float32(1.055*math.Pow(float64(v), 1/2.4) - 0.055)
+}
+
 var (
 	ColorBlack     = Color{0, 0, 0, 1}
 	ColorBlue      = Color{0, 0, 1, 1}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-5
+
+func TestSRGBToLinear(t *testing.T) {
+	var tests = []struct {
+		in, want float32
+	}{
+		{in: 0, want: 0},
+		{in: 0.02, want: 0.02 / 12.92},
+		{in: 0.04045, want: 0.0031308},
+		{in: 0.5, want: 0.2140411},
+		{in: 0.7353569, want: 0.5},
+		{in: 1, want: 1},
+	}
+
+	for i, v := range tests {
+		if got := SRGBToLinear(v.in); math.Abs(float64(got-v.want)) > epsilon {
+			t.Errorf("%s case %d: SRGBToLinear(%v) = %v, want %v", t.Name(), i, v.in, got, v.want)
+		}
+	}
+}
+
+func TestLinearToSRGB(t *testing.T) {
+	var tests = []struct {
+		in, want float32
+	}{
+		{in: 0, want: 0},
+		{in: 0.001, want: 0.01292},
+		{in: 0.0031308, want: 0.04045},
+		{in: 0.18, want: 0.4613561},
+		{in: 0.2140411, want: 0.5},
+		{in: 1, want: 1},
+	}
+
+	for i, v := range tests {
+		if got := LinearToSRGB(v.in); math.Abs(float64(got-v.want)) > epsilon {
+			t.Errorf("%s case %d: LinearToSRGB(%v) = %v, want %v", t.Name(), i, v.in, got, v.want)
+		}
+	}
+}
+
+func TestSRGB_Cutoffs(t *testing.T) {
+	// The linear segments meet the curves at the cutoffs, up to the rounding
+	// of the constants of the standard.
+	for _, v := range []struct {
+		name   string
+		f      func(float32) float32
+		cutoff float32
+	}{
+		{name: "SRGBToLinear", f: SRGBToLinear, cutoff: 0.04045},
+		{name: "LinearToSRGB", f: LinearToSRGB, cutoff: 0.0031308},
+	} {
+		below := v.f(v.cutoff)
+		above := v.f(math.Nextafter32(v.cutoff, 1))
+		if math.Abs(float64(above-below)) > epsilon {
+			t.Errorf("%s jumps from %v to %v at %v", v.name, below, above, v.cutoff)
+		}
+	}
+}
+
+func TestSRGB_RoundTrip(t *testing.T) {
+	for i := 0; i <= 1000; i++ {
+		v := float32(i) / 1000
+
+		if got := LinearToSRGB(SRGBToLinear(v)); math.Abs(float64(got-v)) > epsilon {
+			t.Errorf("LinearToSRGB(SRGBToLinear(%v)) = %v", v, got)
+		}
+		if got := SRGBToLinear(LinearToSRGB(v)); math.Abs(float64(got-v)) > epsilon {
+			t.Errorf("SRGBToLinear(LinearToSRGB(%v)) = %v", v, got)
+		}
+	}
+}
+
+func TestColor_Linear(t *testing.T) {
+	c := Color{R: 0.5, G: 1, B: 0, A: 0.5}
+
+	l := c.Linear()
+	if l.A != c.A || l.G != 1 || l.B != 0 || math.Abs(float64(l.R-0.2140411)) > epsilon {
+		t.Errorf("Linear() = %+v", l)
+	}
+
+	if s := l.SRGB(); s.A != c.A || math.Abs(float64(s.R-c.R)) > epsilon || s.G != 1 || s.B != 0 {
+		t.Errorf("SRGB() = %+v, want %+v", s, c)
+	}
+}
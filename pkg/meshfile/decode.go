@@ -0,0 +1,324 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package meshfile
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/pkg/geometry"
+)
+
+// Read reads a mesh from r.
+func Read(r io.Reader) (*Mesh, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(data)
+}
+
+// Decode decodes a mesh. Float streams and uint32 indices are used in place
+// on little-endian hosts, so the mesh may share memory with data.
+func Decode(data []byte) (*Mesh, error) {
+	if len(data) < headerSize {
+		return nil, ErrTruncated
+	}
+	if !IsMeshFile(data) {
+		return nil, ErrInvalidMagic
+	}
+
+	le := binary.LittleEndian
+
+	if v := le.Uint16(data[4:]); v != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, v)
+	}
+
+	streamCount := int(data[6])
+	indexEncoding := IndexEncoding(data[7])
+	count := int(le.Uint32(data[8:]))
+	indexCount := int(le.Uint32(data[12:]))
+
+	indexData, err := section(data, le.Uint32(data[16:]), le.Uint32(data[20:]))
+	if err != nil {
+		return nil, err
+	}
+	strs, err := section(data, le.Uint32(data[24:]), le.Uint32(data[28:]))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < headerSize+streamCount*recordSize {
+		return nil, ErrTruncated
+	}
+
+	m := &Mesh{Geometry: &geometry.Mesh{}}
+
+	names, err := decodeStrings(strs)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) > 0 {
+		m.Name, m.Materials = names[0], names[1:]
+	}
+
+	g := m.Geometry
+	seen := make(map[Attribute]bool)
+
+	for i := 0; i < streamCount; i++ {
+		r := data[headerSize+i*recordSize:]
+
+		s := &stream{
+			attribute:  Attribute(r[0]),
+			encoding:   Encoding(r[1]),
+			components: int(r[2]),
+		}
+		for c := 0; c < 4; c++ {
+			s.offset[c] = math.Float32frombits(le.Uint32(r[12+c*4:]))
+			s.scale[c] = math.Float32frombits(le.Uint32(r[28+c*4:]))
+		}
+
+		if s.attribute >= attributeCount || seen[s.attribute] {
+			return nil, fmt.Errorf("meshfile: invalid or repeated attribute %d", s.attribute)
+		}
+		seen[s.attribute] = true
+
+		if s.components != s.attribute.components() {
+			return nil, fmt.Errorf("meshfile: attribute %d has %d components", s.attribute, s.components)
+		}
+		if s.encoding > EncodingUint16 || (s.encoding == EncodingUint16) != (s.attribute == AttributeJoints) {
+			return nil, fmt.Errorf("meshfile: attribute %d has invalid encoding %d", s.attribute, s.encoding)
+		}
+
+		if s.data, err = section(data, le.Uint32(r[4:]), le.Uint32(r[8:])); err != nil {
+			return nil, err
+		}
+		if len(s.data) != count*s.components*s.encoding.size() {
+			return nil, fmt.Errorf("meshfile: attribute %d has %d bytes for %d vertices", s.attribute, len(s.data), count)
+		}
+
+		switch s.attribute {
+		case AttributePosition:
+			g.Positions = vec3s(s.floats(), count)
+		case AttributeNormal:
+			g.Normals = vec3s(s.floats(), count)
+		case AttributeUV:
+			g.UVs = vec2s(s.floats(), count)
+		case AttributeColor:
+			g.Colors = vec4s(s.floats(), count)
+		case AttributeTangent:
+			g.Tangents = vec4s(s.floats(), count)
+		case AttributeUV2:
+			g.UV2s = vec2s(s.floats(), count)
+		case AttributeJoints:
+			g.Joints = s.joints(count)
+		case AttributeWeights:
+			g.Weights = vec4s(s.floats(), count)
+		}
+	}
+
+	if !seen[AttributePosition] {
+		return nil, fmt.Errorf("meshfile: mesh has no positions")
+	}
+
+	if g.Indices, err = decodeIndices(indexEncoding, indexData, indexCount); err != nil {
+		return nil, err
+	}
+
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// section returns the bytes of a section, checking that it lies within the
+// data and is aligned.
+func section(data []byte, offset, length uint32) ([]byte, error) {
+	end := uint64(offset) + uint64(length)
+	if end > uint64(len(data)) {
+		return nil, ErrTruncated
+	}
+	if offset%4 != 0 {
+		return nil, fmt.Errorf("meshfile: unaligned section at %d", offset)
+	}
+
+	return data[offset:end], nil
+}
+
+// floats returns the decoded components of a float stream.
+func (s *stream) floats() []float32 {
+	n := len(s.data) / s.encoding.size()
+	if n == 0 {
+		return nil
+	}
+
+	if s.encoding == EncodingFloat32 && littleEndian {
+		return unsafe.Slice((*float32)(unsafe.Pointer(&s.data[0])), n)
+	}
+
+	f := make([]float32, n)
+	for i := range f {
+		c := i % s.components
+
+		switch s.encoding {
+		case EncodingFloat32:
+			f[i] = math.Float32frombits(binary.LittleEndian.Uint32(s.data[i*4:]))
+		case EncodingUnorm16:
+			v := float32(binary.LittleEndian.Uint16(s.data[i*2:])) / math.MaxUint16
+			f[i] = s.offset[c] + s.scale[c]*v
+		case EncodingSnorm16:
+			v := float32(int16(binary.LittleEndian.Uint16(s.data[i*2:]))) / math.MaxInt16
+			if v < -1 {
+				v = -1
+			}
+			f[i] = s.offset[c] + s.scale[c]*v
+		}
+	}
+
+	return f
+}
+
+// joints returns the joint indices of a joint stream.
+func (s *stream) joints(count int) [][4]uint16 {
+	j := make([][4]uint16, count)
+	for i := range j {
+		for c := 0; c < 4; c++ {
+			j[i][c] = binary.LittleEndian.Uint16(s.data[(i*4+c)*2:])
+		}
+	}
+
+	return j
+}
+
+func decodeIndices(e IndexEncoding, data []byte, count int) ([]uint32, error) {
+	var indices []uint32
+
+	switch e {
+	case IndexNone:
+		if count != 0 {
+			return nil, fmt.Errorf("meshfile: %d indices without encoding", count)
+		}
+
+		return nil, nil
+	case IndexUint16:
+		if len(data) != count*2 {
+			return nil, ErrTruncated
+		}
+
+		indices = make([]uint32, count)
+		for i := range indices {
+			indices[i] = uint32(binary.LittleEndian.Uint16(data[i*2:]))
+		}
+	case IndexUint32:
+		if len(data) != count*4 {
+			return nil, ErrTruncated
+		}
+
+		if littleEndian && count > 0 {
+			return unsafe.Slice((*uint32)(unsafe.Pointer(&data[0])), count), nil
+		}
+
+		indices = make([]uint32, count)
+		for i := range indices {
+			indices[i] = binary.LittleEndian.Uint32(data[i*4:])
+		}
+	case IndexDelta:
+		// Each index takes at least a byte.
+		if count > len(data) {
+			return nil, ErrTruncated
+		}
+
+		indices = make([]uint32, count)
+
+		var prev int64
+		for i := range indices {
+			u, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, ErrTruncated
+			}
+			data = data[n:]
+
+			prev += int64(u>>1) ^ -int64(u&1)
+			if prev < 0 || prev > math.MaxUint32 {
+				return nil, fmt.Errorf("meshfile: index %d out of range", prev)
+			}
+			indices[i] = uint32(prev)
+		}
+	default:
+		return nil, fmt.Errorf("meshfile: invalid index encoding %d", e)
+	}
+
+	return indices, nil
+}
+
+func decodeStrings(data []byte) ([]string, error) {
+	var strs []string
+
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, ErrTruncated
+		}
+
+		n := int(binary.LittleEndian.Uint16(data))
+		if len(data) < 2+n {
+			return nil, ErrTruncated
+		}
+
+		strs = append(strs, string(data[2:2+n]))
+		data = data[2+n:]
+	}
+
+	return strs, nil
+}
+
+// vec2s reinterprets interleaved components as vectors, without copying.
+func vec2s(f []float32, count int) []mgl32.Vec2 {
+	if count == 0 {
+		return nil
+	}
+
+	return unsafe.Slice((*mgl32.Vec2)(unsafe.Pointer(&f[0])), count)
+}
+
+func vec3s(f []float32, count int) []mgl32.Vec3 {
+	if count == 0 {
+		return nil
+	}
+
+	return unsafe.Slice((*mgl32.Vec3)(unsafe.Pointer(&f[0])), count)
+}
+
+func vec4s(f []float32, count int) []mgl32.Vec4 {
+	if count == 0 {
+		return nil
+	}
+
+	return unsafe.Slice((*mgl32.Vec4)(unsafe.Pointer(&f[0])), count)
+}
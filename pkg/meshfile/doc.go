@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+// Package meshfile implements the binary mesh format of the engine.
+//
+// A mesh file holds a single triangle mesh. All values are little-endian and
+// every section starts at a multiple of four bytes, so that float streams can
+// be used in place without copying. The file starts with a 32 byte header:
+//
+//	offset  type     field
+//	0       [4]byte  magic "EMSH"
+//	4       uint16   version, currently 1
+//	6       uint8    number of streams
+//	7       uint8    index encoding
+//	8       uint32   number of vertices
+//	12      uint32   number of indices, 0 when not indexed
+//	16      uint32   offset of the index data
+//	20      uint32   length of the index data in bytes
+//	24      uint32   offset of the strings
+//	28      uint32   length of the strings in bytes
+//
+// The header is followed by a table of 44 byte stream records, one for each
+// vertex attribute present:
+//
+//	offset  type        field
+//	0       uint8       attribute
+//	1       uint8       encoding
+//	2       uint8       number of components
+//	3       uint8       reserved, 0
+//	4       uint32      offset of the stream data
+//	8       uint32      length of the stream data in bytes
+//	12      [4]float32  dequantization offset per component
+//	28      [4]float32  dequantization scale per component
+//
+// Normalized encodings are decoded as offset + scale * n, where n is the
+// stored value mapped to [0, 1] for unsigned and [-1, 1] for signed
+// encodings. Float and integer streams ignore offset and scale.
+//
+// The strings hold the mesh name followed by the names of its materials,
+// each as a uint16 length followed by that many bytes of UTF-8.
+//
+// Indices are stored as uint16 or uint32 values, or compressed as the
+// zigzag encoded differences between consecutive indices in unsigned
+// varint form.
+package meshfile
@@ -0,0 +1,285 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package meshfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// stream is an encoded vertex stream.
+type stream struct {
+	attribute  Attribute
+	encoding   Encoding
+	components int
+	offset     [4]float32
+	scale      [4]float32
+	data       []byte
+}
+
+// Encode writes the mesh to w.
+func Encode(w io.Writer, m *Mesh, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	g := m.Geometry
+	if g == nil || len(g.Positions) == 0 {
+		return errors.New("meshfile: mesh has no vertices")
+	}
+	if err := g.Validate(); err != nil {
+		return err
+	}
+
+	count := len(g.Positions)
+
+	var streams []*stream
+	add := func(a Attribute, values []float32) {
+		if len(values) != 0 {
+			streams = append(streams, encodeStream(a, values, opts.Quantize))
+		}
+	}
+
+	add(AttributePosition, flatten3(g.Positions))
+	add(AttributeNormal, flatten3(g.Normals))
+	add(AttributeUV, flatten2(g.UVs))
+	add(AttributeColor, flatten4(g.Colors))
+	add(AttributeTangent, flatten4(g.Tangents))
+	add(AttributeUV2, flatten2(g.UV2s))
+
+	if len(g.Joints) != 0 {
+		s := &stream{attribute: AttributeJoints, encoding: EncodingUint16, components: 4}
+		s.data = make([]byte, 0, count*8)
+		for _, j := range g.Joints {
+			for _, v := range j {
+				s.data = binary.LittleEndian.AppendUint16(s.data, v)
+			}
+		}
+		streams = append(streams, s)
+	}
+
+	add(AttributeWeights, flatten4(g.Weights))
+
+	strs, err := encodeStrings(append([]string{m.Name}, m.Materials...))
+	if err != nil {
+		return err
+	}
+
+	indexEncoding, indices := encodeIndices(g.Indices, count, opts.CompressIndices)
+
+	// Sections follow the stream table in order, each aligned to four bytes.
+	offset := headerSize + recordSize*len(streams)
+
+	stringsOffset := offset
+	offset = align(offset + len(strs))
+
+	dataOffsets := make([]int, len(streams))
+	for i, s := range streams {
+		dataOffsets[i] = offset
+		offset = align(offset + len(s.data))
+	}
+
+	indexOffset := offset
+	offset = align(offset + len(indices))
+
+	buf := make([]byte, offset)
+	le := binary.LittleEndian
+
+	copy(buf, Magic)
+	le.PutUint16(buf[4:], Version)
+	buf[6] = uint8(len(streams))
+	buf[7] = uint8(indexEncoding)
+	le.PutUint32(buf[8:], uint32(count))
+	le.PutUint32(buf[12:], uint32(len(g.Indices)))
+	le.PutUint32(buf[16:], uint32(indexOffset))
+	le.PutUint32(buf[20:], uint32(len(indices)))
+	le.PutUint32(buf[24:], uint32(stringsOffset))
+	le.PutUint32(buf[28:], uint32(len(strs)))
+
+	for i, s := range streams {
+		r := buf[headerSize+i*recordSize:]
+
+		r[0] = uint8(s.attribute)
+		r[1] = uint8(s.encoding)
+		r[2] = uint8(s.components)
+		le.PutUint32(r[4:], uint32(dataOffsets[i]))
+		le.PutUint32(r[8:], uint32(len(s.data)))
+		for c := 0; c < 4; c++ {
+			le.PutUint32(r[12+c*4:], math.Float32bits(s.offset[c]))
+			le.PutUint32(r[28+c*4:], math.Float32bits(s.scale[c]))
+		}
+
+		copy(buf[dataOffsets[i]:], s.data)
+	}
+
+	copy(buf[stringsOffset:], strs)
+	copy(buf[indexOffset:], indices)
+
+	_, err = w.Write(buf)
+
+	return err
+}
+
+// encodeStream encodes the interleaved components of a float attribute.
+func encodeStream(a Attribute, values []float32, quantize bool) *stream {
+	n := a.components()
+	s := &stream{attribute: a, encoding: EncodingFloat32, components: n}
+
+	if !quantize {
+		s.data = make([]byte, 0, len(values)*4)
+		for _, v := range values {
+			s.data = binary.LittleEndian.AppendUint32(s.data, math.Float32bits(v))
+		}
+
+		return s
+	}
+
+	s.data = make([]byte, 0, len(values)*2)
+
+	if a == AttributeNormal || a == AttributeTangent {
+		s.encoding = EncodingSnorm16
+		for c := 0; c < n; c++ {
+			s.scale[c] = 1
+		}
+
+		for _, v := range values {
+			v = float32(math.Max(-1, math.Min(1, float64(v))))
+			q := int16(math.Round(float64(v) * math.MaxInt16))
+			s.data = binary.LittleEndian.AppendUint16(s.data, uint16(q))
+		}
+
+		return s
+	}
+
+	s.encoding = EncodingUnorm16
+
+	// Values are normalized to the range of each component.
+	for c := 0; c < n; c++ {
+		lo, hi := float32(math.Inf(1)), float32(math.Inf(-1))
+		for i := c; i < len(values); i += n {
+			lo = float32(math.Min(float64(lo), float64(values[i])))
+			hi = float32(math.Max(float64(hi), float64(values[i])))
+		}
+
+		s.offset[c], s.scale[c] = lo, hi-lo
+	}
+
+	for i, v := range values {
+		c := i % n
+
+		var q uint16
+		if s.scale[c] > 0 {
+			q = uint16(math.Round(float64((v - s.offset[c]) / s.scale[c] * math.MaxUint16)))
+		}
+		s.data = binary.LittleEndian.AppendUint16(s.data, q)
+	}
+
+	return s
+}
+
+// encodeIndices encodes the indices in the smallest fixed size, or as
+// variable length differences.
+func encodeIndices(indices []uint32, count int, compress bool) (IndexEncoding, []byte) {
+	if len(indices) == 0 {
+		return IndexNone, nil
+	}
+
+	var data []byte
+
+	switch {
+	case compress:
+		var prev int64
+		for _, idx := range indices {
+			d := int64(idx) - prev
+			prev = int64(idx)
+			data = binary.AppendUvarint(data, uint64((d<<1)^(d>>63)))
+		}
+
+		return IndexDelta, data
+	case count <= math.MaxUint16+1:
+		data = make([]byte, 0, len(indices)*2)
+		for _, idx := range indices {
+			data = binary.LittleEndian.AppendUint16(data, uint16(idx))
+		}
+
+		return IndexUint16, data
+	}
+
+	data = make([]byte, 0, len(indices)*4)
+	for _, idx := range indices {
+		data = binary.LittleEndian.AppendUint32(data, idx)
+	}
+
+	return IndexUint32, data
+}
+
+func encodeStrings(strs []string) ([]byte, error) {
+	var data []byte
+
+	for _, s := range strs {
+		if len(s) > math.MaxUint16 {
+			return nil, fmt.Errorf("meshfile: string of %d bytes is too long", len(s))
+		}
+
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(s)))
+		data = append(data, s...)
+	}
+
+	return data, nil
+}
+
+func align(n int) int {
+	return (n + 3) &^ 3
+}
+
+func flatten2(v []mgl32.Vec2) []float32 {
+	f := make([]float32, 0, len(v)*2)
+	for i := range v {
+		f = append(f, v[i][:]...)
+	}
+
+	return f
+}
+
+func flatten3(v []mgl32.Vec3) []float32 {
+	f := make([]float32, 0, len(v)*3)
+	for i := range v {
+		f = append(f, v[i][:]...)
+	}
+
+	return f
+}
+
+func flatten4(v []mgl32.Vec4) []float32 {
+	f := make([]float32, 0, len(v)*4)
+	for i := range v {
+		f = append(f, v[i][:]...)
+	}
+
+	return f
+}
@@ -0,0 +1,139 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package meshfile
+
+import (
+	"errors"
+	"unsafe"
+
+	"github.com/haakenlabs/ember/pkg/geometry"
+)
+
+// Magic is the signature at the start of every mesh file.
+const Magic = "EMSH"
+
+// Version is the version of the format written by Encode.
+const Version = 1
+
+const (
+	headerSize = 32
+	recordSize = 44
+)
+
+// Attribute identifies a vertex stream.
+type Attribute uint8
+
+// Vertex attributes.
+const (
+	AttributePosition Attribute = iota
+	AttributeNormal
+	AttributeUV
+	AttributeColor
+	AttributeTangent
+	AttributeUV2
+	AttributeJoints
+	AttributeWeights
+
+	attributeCount
+)
+
+// components returns the number of components of the attribute.
+func (a Attribute) components() int {
+	switch a {
+	case AttributePosition, AttributeNormal:
+		return 3
+	case AttributeUV, AttributeUV2:
+		return 2
+	}
+
+	return 4
+}
+
+// Encoding is the storage of the components of a stream.
+type Encoding uint8
+
+// Stream encodings.
+const (
+	EncodingFloat32 Encoding = iota
+	EncodingUnorm16
+	EncodingSnorm16
+	EncodingUint16
+)
+
+// size returns the size of a component in bytes.
+func (e Encoding) size() int {
+	if e == EncodingFloat32 {
+		return 4
+	}
+
+	return 2
+}
+
+// IndexEncoding is the storage of the indices.
+type IndexEncoding uint8
+
+// Index encodings.
+const (
+	IndexNone IndexEncoding = iota
+	IndexUint16
+	IndexUint32
+	IndexDelta
+)
+
+// Mesh file errors.
+var (
+	ErrInvalidMagic       = errors.New("meshfile: invalid magic")
+	ErrUnsupportedVersion = errors.New("meshfile: unsupported version")
+	ErrTruncated          = errors.New("meshfile: truncated data")
+)
+
+// Mesh is a named mesh with the names of the materials it uses.
+type Mesh struct {
+	Name      string
+	Materials []string
+	Geometry  *geometry.Mesh
+}
+
+// Options control the encoding of a mesh. The zero value stores every
+// attribute losslessly and indices uncompressed.
+type Options struct {
+	// Quantize stores positions, uvs, colors and weights as 16 bit values
+	// normalized to their range, and normals and tangents as 16 bit signed
+	// normalized values.
+	Quantize bool
+
+	// CompressIndices stores indices as variable length differences.
+	CompressIndices bool
+}
+
+// IsMeshFile reports whether the data starts with the mesh file signature.
+func IsMeshFile(data []byte) bool {
+	return len(data) >= len(Magic) && string(data[:len(Magic)]) == Magic
+}
+
+// littleEndian reports whether the host is little-endian, in which case
+// float streams are used in place.
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
@@ -0,0 +1,232 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package meshfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/pkg/geometry"
+)
+
+// skinned returns an icosphere with every optional stream set.
+func skinned() *Mesh {
+	g := geometry.Icosphere(2, 2)
+	if err := g.GenerateTangents(); err != nil {
+		panic(err)
+	}
+
+	n := len(g.Positions)
+	g.Colors = make([]mgl32.Vec4, n)
+	g.UV2s = make([]mgl32.Vec2, n)
+	g.Joints = make([][4]uint16, n)
+	g.Weights = make([]mgl32.Vec4, n)
+
+	for i, p := range g.Positions {
+		g.Colors[i] = mgl32.Vec4{p.X()/4 + 0.5, p.Y()/4 + 0.5, p.Z()/4 + 0.5, 1}
+		g.UV2s[i] = g.UVs[i].Mul(2)
+		g.Joints[i] = [4]uint16{uint16(i % 3), uint16(i % 5), 0, 300}
+		g.Weights[i] = mgl32.Vec4{0.5, 0.25, 0.25, 0}
+	}
+
+	return &Mesh{Name: "sphere", Materials: []string{"skin", "eyes"}, Geometry: g}
+}
+
+func encode(t *testing.T, m *Mesh, opts *Options) []byte {
+	var buf bytes.Buffer
+	if err := Encode(&buf, m, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestEncode_Lossless(t *testing.T) {
+	for _, opts := range []*Options{nil, {CompressIndices: true}} {
+		m := skinned()
+
+		got, err := Decode(encode(t, m, opts))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Name != m.Name || !reflect.DeepEqual(got.Materials, m.Materials) {
+			t.Errorf("names = %q %q, want %q %q", got.Name, got.Materials, m.Name, m.Materials)
+		}
+		if !reflect.DeepEqual(got.Geometry, m.Geometry) {
+			t.Errorf("geometry differs after round trip with %+v", opts)
+		}
+	}
+}
+
+func TestEncode_Quantized(t *testing.T) {
+	m := skinned()
+
+	data := encode(t, m, &Options{Quantize: true, CompressIndices: true})
+	if lossless := encode(t, m, nil); len(data) >= len(lossless) {
+		t.Errorf("quantized file is %d bytes, lossless %d", len(data), len(lossless))
+	}
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, want := got.Geometry, m.Geometry
+
+	if !reflect.DeepEqual(g.Indices, want.Indices) || !reflect.DeepEqual(g.Joints, want.Joints) {
+		t.Error("indices or joints differ after round trip")
+	}
+
+	// Unorm16 values are within a step of the range of their component,
+	// snorm16 values within a step of [-1, 1]. Positions span 4 units, uv2s
+	// 2 and the other attributes at most 1.
+	const (
+		positionStep = 4.0 / math.MaxUint16
+		unitStep     = 1.0 / math.MaxUint16
+		snormStep    = 1.0 / math.MaxInt16
+	)
+
+	for i := range want.Positions {
+		if !near(g.Positions[i][:], want.Positions[i][:], positionStep) {
+			t.Errorf("position %d = %v, want %v", i, g.Positions[i], want.Positions[i])
+		}
+		if !near(g.Normals[i][:], want.Normals[i][:], snormStep) {
+			t.Errorf("normal %d = %v, want %v", i, g.Normals[i], want.Normals[i])
+		}
+		if !near(g.Tangents[i][:], want.Tangents[i][:], snormStep) {
+			t.Errorf("tangent %d = %v, want %v", i, g.Tangents[i], want.Tangents[i])
+		}
+		if !near(g.UVs[i][:], want.UVs[i][:], unitStep) || !near(g.UV2s[i][:], want.UV2s[i][:], 2*unitStep) {
+			t.Errorf("uvs %d = %v %v, want %v %v", i, g.UVs[i], g.UV2s[i], want.UVs[i], want.UV2s[i])
+		}
+		if !near(g.Colors[i][:], want.Colors[i][:], unitStep) || !near(g.Weights[i][:], want.Weights[i][:], unitStep) {
+			t.Errorf("color and weights %d = %v %v", i, g.Colors[i], g.Weights[i])
+		}
+	}
+}
+
+// near reports whether every component of a is within tol of b.
+func near(a, b []float32, tol float64) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > tol {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestEncode_Indices(t *testing.T) {
+	var tests = []struct {
+		mesh *geometry.Mesh
+		opts *Options
+		want IndexEncoding
+	}{
+		{mesh: geometry.Quad(1, 1), want: IndexUint16},
+		{mesh: geometry.Plane(1, 1, 300), want: IndexUint32},
+		{mesh: geometry.Plane(1, 1, 300), opts: &Options{CompressIndices: true}, want: IndexDelta},
+		{mesh: &geometry.Mesh{Positions: make([]mgl32.Vec3, 3)}, want: IndexNone},
+	}
+
+	for i, v := range tests {
+		data := encode(t, &Mesh{Geometry: v.mesh}, v.opts)
+		if e := IndexEncoding(data[7]); e != v.want {
+			t.Errorf("%s case %d: encoding = %d, want %d", t.Name(), i, e, v.want)
+		}
+
+		got, err := Decode(data)
+		if err != nil {
+			t.Errorf("%s case %d: %v", t.Name(), i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Geometry.Indices, v.mesh.Indices) {
+			t.Errorf("%s case %d: indices differ", t.Name(), i)
+		}
+	}
+}
+
+func TestDecode_InPlace(t *testing.T) {
+	if !littleEndian {
+		t.Skip("streams are copied on big-endian hosts")
+	}
+
+	data := encode(t, &Mesh{Geometry: geometry.Plane(1, 1, 300)}, nil)
+
+	m, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	within := func(p unsafe.Pointer) bool {
+		start := uintptr(unsafe.Pointer(&data[0]))
+		return uintptr(p) >= start && uintptr(p) < start+uintptr(len(data))
+	}
+
+	if !within(unsafe.Pointer(&m.Geometry.Positions[0])) || !within(unsafe.Pointer(&m.Geometry.Indices[0])) {
+		t.Error("positions or indices were copied")
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	valid := encode(t, &Mesh{Name: "quad", Geometry: geometry.Quad(1, 1)}, nil)
+
+	corrupt := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte(nil), valid...))
+	}
+
+	var tests = []struct {
+		data []byte
+		want error
+	}{
+		{data: valid[:20], want: ErrTruncated},
+		{data: corrupt(func(b []byte) []byte { b[0] = 'X'; return b }), want: ErrInvalidMagic},
+		{data: corrupt(func(b []byte) []byte { b[4] = 9; return b }), want: ErrUnsupportedVersion},
+		{data: valid[:len(valid)-4], want: ErrTruncated},
+		{data: corrupt(func(b []byte) []byte { b[headerSize] = 42; return b })},
+		{data: corrupt(func(b []byte) []byte { b[headerSize+2] = 2; return b })},
+		{data: corrupt(func(b []byte) []byte { b[len(b)-2] = 9; return b })},
+		// Delta indices are counted before they are allocated.
+		{data: func() []byte {
+			b := encode(t, &Mesh{Geometry: geometry.Quad(1, 1)}, &Options{CompressIndices: true})
+			binary.LittleEndian.PutUint32(b[12:], 0xfffffff0)
+			return b
+		}(), want: ErrTruncated},
+	}
+
+	for i, v := range tests {
+		_, err := Decode(v.data)
+		if err == nil {
+			t.Errorf("%s case %d: expected error", t.Name(), i)
+		} else if v.want != nil && !errors.Is(err, v.want) {
+			t.Errorf("%s case %d: err = %v, want %v", t.Name(), i, err, v.want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/pkg/animation"
+	"github.com/haakenlabs/ember/system/instance"
+	"github.com/haakenlabs/ember/system/time"
+)
+
+// localTransform is implemented by transforms which can be set without
+// being recomputed.
+type localTransform interface {
+	SetPositionN(mgl32.Vec3)
+	SetRotationN(mgl32.Quat)
+	SetScaleN(mgl32.Vec3)
+}
+
+// Animator is a component which plays animation clips on a set of target
+// objects, usually the joints of a skinned mesh. The channels of a clip
+// refer to the targets by index. Weight channels set the morph target
+// weights of the SkinnedMeshRenderers of their target. Targets must be the object of the animator
+// or its descendants; nil targets are skipped.
+type Animator struct {
+	BaseScriptComponent
+
+	targets []*GameObject
+	rest    animation.Pose
+	pose    animation.Pose
+	from    animation.Pose
+
+	weights     map[int][]float32
+	fromWeights map[int][]float32
+
+	clip *animation.Clip
+	time float32
+	loop bool
+
+	prev     *animation.Clip
+	prevTime float32
+	prevLoop bool
+
+	fade     float32
+	fadeTime float32
+	speed    float32
+}
+
+// Targets returns the objects animated by this component.
+func (a *Animator) Targets() []*GameObject {
+	return a.targets
+}
+
+// Clip returns the clip being played, or nil.
+func (a *Animator) Clip() *animation.Clip {
+	return a.clip
+}
+
+// Time returns the time within the clip being played.
+func (a *Animator) Time() float32 {
+	return a.time
+}
+
+// Speed returns the playback speed.
+func (a *Animator) Speed() float32 {
+	return a.speed
+}
+
+// SetSpeed sets the playback speed. A speed of one plays clips in real time.
+func (a *Animator) SetSpeed(speed float32) {
+	a.speed = speed
+}
+
+// Play starts playing a clip from the start, stopping any other clip.
+func (a *Animator) Play(clip *animation.Clip, loop bool) {
+	a.clip, a.time, a.loop = clip, 0, loop
+	a.prev = nil
+}
+
+// CrossFade starts playing a clip from the start, blending from the clip
+// being played over the given duration in seconds.
+func (a *Animator) CrossFade(clip *animation.Clip, duration float32, loop bool) {
+	if a.clip == nil || duration <= 0 {
+		a.Play(clip, loop)
+		return
+	}
+
+	a.prev, a.prevTime, a.prevLoop = a.clip, a.time, a.loop
+	a.clip, a.time, a.loop = clip, 0, loop
+	a.fade, a.fadeTime = duration, 0
+}
+
+// Stop stops playback and returns the targets to their rest pose.
+func (a *Animator) Stop() {
+	a.clip, a.prev = nil, nil
+	a.apply(a.rest)
+}
+
+// Advance moves playback forward by dt seconds and poses the targets.
+func (a *Animator) Advance(dt float32) {
+	if a.clip == nil {
+		return
+	}
+
+	dt *= a.speed
+
+	a.time = a.clip.Wrap(a.time+dt, a.loop)
+	copy(a.pose, a.rest)
+	a.clip.Sample(a.time, a.pose)
+	sampleWeights(a.clip, a.time, a.weights)
+
+	if a.prev != nil {
+		a.fadeTime += dt
+		if a.fadeTime >= a.fade {
+			a.prev = nil
+		} else {
+			w := a.fadeTime / a.fade
+
+			a.prevTime = a.prev.Wrap(a.prevTime+dt, a.prevLoop)
+			copy(a.from, a.rest)
+			a.prev.Sample(a.prevTime, a.from)
+			a.pose.Blend(a.from, a.pose, w)
+
+			sampleWeights(a.prev, a.prevTime, a.fromWeights)
+			for target, to := range a.weights {
+				if from := a.fromWeights[target]; len(from) == len(to) {
+					for k := range to {
+						to[k] = from[k] + (to[k]-from[k])*w
+					}
+				}
+			}
+		}
+	}
+
+	a.apply(a.pose)
+	a.applyWeights()
+}
+
+// Update advances playback by the frame time.
+func (a *Animator) Update() {
+	a.Advance(float32(time.DeltaTime()))
+}
+
+// apply sets the local transforms of the targets to the pose.
+func (a *Animator) apply(pose animation.Pose) {
+	for i, g := range a.targets {
+		if g == nil {
+			continue
+		}
+		if t, ok := g.Transform().(localTransform); ok {
+			t.SetPositionN(pose[i].Translation)
+			t.SetRotationN(pose[i].Rotation)
+			t.SetScaleN(pose[i].Scale)
+		}
+	}
+
+	if a.GetTransform() != nil {
+		a.GetTransform().Recompute(true)
+	}
+}
+
+// applyWeights sets the morph target weights of the renderers of the
+// targets.
+func (a *Animator) applyWeights() {
+	for target, weights := range a.weights {
+		if target >= len(a.targets) || a.targets[target] == nil {
+			continue
+		}
+
+		for _, c := range a.targets[target].Components() {
+			if r, ok := c.(*SkinnedMeshRenderer); ok {
+				r.SetMorphWeights(weights)
+			}
+		}
+	}
+}
+
+// sampleWeights replaces the contents of dst with the morph target weights
+// of the weight channels of the clip at time t, by target.
+func sampleWeights(clip *animation.Clip, t float32, dst map[int][]float32) {
+	for target := range dst {
+		delete(dst, target)
+	}
+
+	for i := range clip.Channels {
+		ch := &clip.Channels[i]
+		if ch.Path != animation.PathWeights {
+			continue
+		}
+
+		w := make([]float32, ch.Width())
+		ch.SampleWeights(t, w)
+		dst[ch.Target] = w
+	}
+}
+
+// NewAnimator creates an animator for the targets. Their current local
+// transforms become the rest pose, which channels of clips override.
+func NewAnimator(targets []*GameObject) *Animator {
+	a := &Animator{
+		targets: targets,
+		rest:    make(animation.Pose, len(targets)),
+		pose:    make(animation.Pose, len(targets)),
+		from:    make(animation.Pose, len(targets)),
+		speed:   1,
+
+		weights:     make(map[int][]float32),
+		fromWeights: make(map[int][]float32),
+	}
+
+	for i, g := range targets {
+		if g == nil {
+			a.rest[i] = animation.Identity()
+			continue
+		}
+
+		t := g.Transform()
+		a.rest[i] = animation.Transform{
+			Translation: t.Position(),
+			Rotation:    t.Rotation(),
+			Scale:       t.Scale(),
+		}
+	}
+
+	a.SetName("Animator")
+	instance.MustAssign(a)
+
+	return a
+}
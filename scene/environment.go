@@ -23,11 +23,24 @@ SOFTWARE.
 package scene
 
 import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/ember/core"
 	"github.com/haakenlabs/ember/gfx"
 	"github.com/haakenlabs/ember/pkg/math"
+	"github.com/haakenlabs/ember/system/asset"
 	"github.com/haakenlabs/ember/system/asset/shader"
 )
 
+const (
+	// assetNameSkybox is the asset kind of skyboxes. It mirrors the name
+	// of the skybox handler, which cannot be imported from here.
+	assetNameSkybox = "skybox"
+
+	// defaultSkyboxName is the name of the builtin skybox.
+	defaultSkyboxName = "default"
+)
+
 type EnvLightingSource int
 
 const (
@@ -56,7 +69,19 @@ func NewEnvironment() *Environment {
 	return e
 }
 
+// DefaultSkybox returns the builtin skybox, or nil if it is not loaded.
 func DefaultSkybox() *Skybox {
-	//return GetAsset().MustGet(AssetNameSkybox, "default").(*Skybox)
-	return nil
+	a, err := asset.Get(assetNameSkybox, defaultSkyboxName)
+	if err != nil {
+		logrus.Error(err)
+		return nil
+	}
+
+	s, ok := a.(*Skybox)
+	if !ok {
+		logrus.Error(core.ErrAssetType(defaultSkyboxName))
+		return nil
+	}
+
+	return s
 }
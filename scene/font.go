@@ -26,7 +26,6 @@ import (
 	"image"
 	"image/draw"
 	"math"
-	"sort"
 	"unicode"
 
 	"github.com/go-gl/mathgl/mgl32"
@@ -38,6 +37,7 @@ import (
 
 	"github.com/haakenlabs/ember/core"
 	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/atlas"
 	"github.com/haakenlabs/ember/system/instance"
 
 	emath "github.com/haakenlabs/ember/pkg/math"
@@ -126,7 +126,7 @@ func (f *Font) generateAtlas(size float64) *Atlas {
 		GlyphCacheEntries: 1,
 	})
 
-	fixedMapping, fixedBounds := makeSquareMapping(face, f.runes, fixed.I(2))
+	fixedMapping, fixedBounds := makeMapping(face, f.runes, 2)
 
 	atlasImg := image.NewRGBA(image.Rect(
 		fixedBounds.Min.X.Floor(),
@@ -338,62 +338,59 @@ func (a *Atlas) DrawRune(prev, r rune, dot mgl64.Vec2) (rect, frame, bounds emat
 	return rect, glyph.Frame, bounds, dot
 }
 
-func makeSquareMapping(face font.Face, runes []rune, padding fixed.Int26_6) (map[rune]fixedGlyph, fixed.Rectangle26_6) {
-	width := sort.Search(int(fixed.I(1024*1024)), func(i int) bool {
-		width := fixed.Int26_6(i)
-		_, bounds := makeMapping(face, runes, padding, width)
-		return bounds.Max.X-bounds.Min.X >= bounds.Max.Y-bounds.Min.Y
-	})
-	return makeMapping(face, runes, padding, fixed.Int26_6(width))
-}
-
-func makeMapping(face font.Face, runes []rune, padding, width fixed.Int26_6) (map[rune]fixedGlyph, fixed.Rectangle26_6) {
-	mapping := make(map[rune]fixedGlyph)
-	bounds := fixed.Rectangle26_6{}
-
-	dot := fixed.P(0, 0)
+// makeMapping packs the glyphs of the runes on a square atlas, returning
+// the placement of each glyph and the bounds of the atlas.
+func makeMapping(face font.Face, runes []rune, padding int) (map[rune]fixedGlyph, fixed.Rectangle26_6) {
+	var packed []rune
+	var frames []image.Rectangle
+	var advances []fixed.Int26_6
 
 	for _, r := range runes {
 		b, advance, ok := face.GlyphBounds(r)
 		if !ok {
-			logrus.Error("Missing rune: %v", r)
+			logrus.Errorf("Missing rune: %v", r)
 			continue
 		}
 
 		// this is important for drawing, artifacts arise otherwise
-		frame := fixed.Rectangle26_6{
-			Min: fixed.P(b.Min.X.Floor(), b.Min.Y.Floor()),
-			Max: fixed.P(b.Max.X.Ceil(), b.Max.Y.Ceil()),
-		}
-
-		dot.X -= frame.Min.X
-		frame = frame.Add(dot)
+		frame := image.Rect(b.Min.X.Floor(), b.Min.Y.Floor(), b.Max.X.Ceil(), b.Max.Y.Ceil())
 
-		mapping[r] = fixedGlyph{
-			dot:     dot,
-			frame:   frame,
-			advance: advance,
-		}
-		bounds = bounds.Union(frame)
+		packed = append(packed, r)
+		frames = append(frames, frame)
+		advances = append(advances, advance)
+	}
 
-		dot.X = frame.Max.X
+	sizes := make([]image.Point, len(frames))
+	for i, frame := range frames {
+		sizes[i] = frame.Size()
+	}
 
-		// padding + align to integer
-		dot.X += padding
-		dot.X = fixed.I(dot.X.Ceil())
+	packing, err := atlas.Pack(sizes, atlas.Options{
+		Algorithm: atlas.Skyline,
+		Padding:   padding,
+	})
+	if err != nil {
+		logrus.Error(err)
+		return nil, fixed.Rectangle26_6{}
+	}
 
-		// width exceeded, new row
-		if frame.Max.X >= width {
-			dot.X = 0
-			dot.Y += face.Metrics().Ascent + face.Metrics().Descent
+	mapping := make(map[rune]fixedGlyph)
+	for i, r := range packed {
+		rect := packing.Placements[i].Rect
 
-			// padding + align to integer
-			dot.Y += padding
-			dot.Y = fixed.I(dot.Y.Ceil())
+		mapping[r] = fixedGlyph{
+			dot:     fixed.P(rect.Min.X-frames[i].Min.X, rect.Min.Y-frames[i].Min.Y),
+			frame:   fixed.R(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y),
+			advance: advances[i],
 		}
 	}
 
-	return mapping, bounds
+	var size image.Point
+	if len(packing.Pages) > 0 {
+		size = packing.Pages[0]
+	}
+
+	return mapping, fixed.R(0, 0, size.X, size.Y)
 }
 
 func i2f(i fixed.Int26_6) float64 {
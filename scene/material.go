@@ -46,6 +46,16 @@ const (
 	MaterialTextureMetallic
 )
 
+// ColorSpace returns the color space of the textures of the slot. Albedo
+// maps hold sRGB color; the other slots hold linear data.
+func (t MaterialTexture) ColorSpace() gfx.ColorSpace {
+	if t == MaterialTextureAlbedo {
+		return gfx.ColorSpaceSRGB
+	}
+
+	return gfx.ColorSpaceLinear
+}
+
 // MaterialMaxTextures is the maximum number of textures supported
 // by a material.
 const MaterialMaxTextures = 16
@@ -0,0 +1,68 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/system/instance"
+)
+
+// MeshRenderer is a component which renders a mesh with a material.
+type MeshRenderer struct {
+	BaseComponent
+
+	mesh     gfx.Mesh
+	material *Material
+}
+
+// Mesh returns the mesh rendered by this component.
+func (r *MeshRenderer) Mesh() gfx.Mesh {
+	return r.mesh
+}
+
+// Material returns the material the mesh is rendered with.
+func (r *MeshRenderer) Material() *Material {
+	return r.material
+}
+
+// SetMesh sets the mesh rendered by this component.
+func (r *MeshRenderer) SetMesh(mesh gfx.Mesh) {
+	r.mesh = mesh
+}
+
+// SetMaterial sets the material the mesh is rendered with.
+func (r *MeshRenderer) SetMaterial(material *Material) {
+	r.material = material
+}
+
+func NewMeshRenderer(mesh gfx.Mesh, material *Material) *MeshRenderer {
+	r := &MeshRenderer{
+		mesh:     mesh,
+		material: material,
+	}
+
+	r.SetName("MeshRenderer")
+	instance.MustAssign(r)
+
+	return r
+}
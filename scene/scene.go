@@ -22,7 +22,11 @@ SOFTWARE.
 
 package scene
 
-import "github.com/haakenlabs/arc/core"
+import (
+	"github.com/haakenlabs/arc/core"
+
+	"github.com/haakenlabs/ember/system/asset"
+)
 
 var _ core.Scene = &Scene{}
 
@@ -44,6 +48,14 @@ func (s *Scene) Name() string {
 	return s.name
 }
 
+// Reference records that this scene uses the asset of the given kind and
+// name, so the asset is not reported as unused. References are not recorded
+// when components look up assets, so a scene must reference every asset it
+// uses, usually from its LoadFunc.
+func (s *Scene) Reference(kind, name string) {
+	asset.Reference(s.name, kind, name)
+}
+
 // OnActivate is called when the scene transitions to the active state.
 func (s *Scene) OnActivate() {
 	if s.OnActivateFunc != nil {
@@ -0,0 +1,241 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/animation"
+	"github.com/haakenlabs/ember/system/instance"
+)
+
+// SkinnedMeshRenderer is a component which renders a mesh deformed by its
+// morph targets and by the joints of a skeleton. The joints are objects of
+// the scene, matching the joints of the skeleton by index, so the mesh
+// follows them as they are moved or animated. Either deformation may be
+// absent: meshes without a skeleton are only morphed. Deformation is done on
+// the CPU, in to a mesh owned by the component.
+type SkinnedMeshRenderer struct {
+	BaseScriptComponent
+
+	source   gfx.Mesh
+	mesh     gfx.Mesh
+	material *Material
+	skeleton *animation.Skeleton
+	joints   []*GameObject
+	weights  []float32
+
+	matrices       []mgl32.Mat4
+	morphPositions []mgl32.Vec3
+	morphNormals   []mgl32.Vec3
+	skinPositions  []mgl32.Vec3
+	skinNormals    []mgl32.Vec3
+	allocated      bool
+}
+
+// Mesh returns the deformed mesh rendered by this component.
+func (r *SkinnedMeshRenderer) Mesh() gfx.Mesh {
+	return r.mesh
+}
+
+// SourceMesh returns the mesh in its bind pose, without morphing.
+func (r *SkinnedMeshRenderer) SourceMesh() gfx.Mesh {
+	return r.source
+}
+
+// Material returns the material the mesh is rendered with.
+func (r *SkinnedMeshRenderer) Material() *Material {
+	return r.material
+}
+
+// SetMaterial sets the material the mesh is rendered with.
+func (r *SkinnedMeshRenderer) SetMaterial(material *Material) {
+	r.material = material
+}
+
+// Skeleton returns the skeleton the mesh is bound to, or nil.
+func (r *SkinnedMeshRenderer) Skeleton() *animation.Skeleton {
+	return r.skeleton
+}
+
+// Joints returns the objects posing the joints of the skeleton.
+func (r *SkinnedMeshRenderer) Joints() []*GameObject {
+	return r.joints
+}
+
+// MorphWeights returns the weight of each morph target of the mesh.
+func (r *SkinnedMeshRenderer) MorphWeights() []float32 {
+	return r.weights
+}
+
+// SetMorphWeights sets the weights of the morph targets, in order. Extra
+// weights are ignored.
+func (r *SkinnedMeshRenderer) SetMorphWeights(weights []float32) {
+	copy(r.weights, weights)
+}
+
+// SetMorphWeight sets the weight of the i-th morph target.
+func (r *SkinnedMeshRenderer) SetMorphWeight(i int, weight float32) {
+	if i >= 0 && i < len(r.weights) {
+		r.weights[i] = weight
+	}
+}
+
+// MorphTargetIndex returns the index of the morph target with the name, or
+// -1 if there is none.
+func (r *SkinnedMeshRenderer) MorphTargetIndex(name string) int {
+	for i, t := range r.source.MorphTargets() {
+		if t.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// LateUpdate deforms the mesh once the joints and weights have been
+// animated.
+func (r *SkinnedMeshRenderer) LateUpdate() {
+	if !r.allocated {
+		if err := r.mesh.Alloc(); err != nil {
+			logrus.Error(err)
+			return
+		}
+		r.allocated = true
+	}
+
+	r.Deform()
+	if err := r.mesh.Upload(); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// Deform morphs the vertices of the mesh by the weights of its morph targets,
+// then skins them to the current pose of the joints. Vertices are left in
+// the space of the object of the component. Meshes without joints and
+// weights are not skinned.
+func (r *SkinnedMeshRenderer) Deform() {
+	src := r.source
+	count := len(src.Vertices())
+
+	positions := src.Vertices()
+	normals := src.Normals()
+	if len(normals) != count {
+		normals = nil
+	}
+
+	if r.morphed() {
+		animation.Morph(positions, normals, src.MorphTargets(), r.weights, r.morphPositions, r.morphNormals)
+		positions = r.morphPositions
+		if normals != nil {
+			normals = r.morphNormals
+		}
+	}
+
+	if r.skeleton != nil && len(src.Joints()) == count && len(src.Weights()) == count {
+		r.updateMatrices()
+
+		animation.Skin(positions, normals, src.Joints(), src.Weights(), r.matrices, r.skinPositions, r.skinNormals)
+		positions = r.skinPositions
+		if normals != nil {
+			normals = r.skinNormals
+		}
+	}
+
+	r.mesh.SetVertices(positions)
+	if normals != nil {
+		r.mesh.SetNormals(normals)
+	}
+}
+
+// morphed reports whether any morph target has weight.
+func (r *SkinnedMeshRenderer) morphed() bool {
+	for _, w := range r.weights {
+		if w != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateMatrices computes the skin matrices from the joint objects, relative
+// to the object of the component.
+func (r *SkinnedMeshRenderer) updateMatrices() {
+	root := mgl32.Ident4()
+	if t := r.GetTransform(); t != nil {
+		root = t.ActiveMatrix().Inv()
+	}
+
+	for i := range r.matrices {
+		r.matrices[i] = r.skeleton.Joints[i].InverseBind
+		if i < len(r.joints) && r.joints[i] != nil {
+			world := r.joints[i].Transform().ActiveMatrix()
+			r.matrices[i] = root.Mul4(world).Mul4(r.matrices[i])
+		}
+	}
+}
+
+// NewSkinnedMeshRenderer creates a renderer for a mesh bound to the skeleton
+// and posed by the joint objects. The skeleton may be nil for meshes which
+// are only morphed. Morph target weights start at zero.
+func NewSkinnedMeshRenderer(mesh gfx.Mesh, material *Material, skeleton *animation.Skeleton, joints []*GameObject) *SkinnedMeshRenderer {
+	n := len(mesh.Vertices())
+
+	r := &SkinnedMeshRenderer{
+		source:   mesh,
+		mesh:     core.GetWindowSystem().Renderer().MakeMesh(),
+		material: material,
+		skeleton: skeleton,
+		joints:   joints,
+		weights:  make([]float32, len(mesh.MorphTargets())),
+	}
+
+	if len(mesh.MorphTargets()) > 0 {
+		r.morphPositions = make([]mgl32.Vec3, n)
+		r.morphNormals = make([]mgl32.Vec3, n)
+	}
+	if skeleton != nil {
+		r.matrices = make([]mgl32.Mat4, len(skeleton.Joints))
+		r.skinPositions = make([]mgl32.Vec3, n)
+		r.skinNormals = make([]mgl32.Vec3, n)
+	}
+
+	r.mesh.SetVertices(mesh.Vertices())
+	r.mesh.SetNormals(mesh.Normals())
+	r.mesh.SetUVs(mesh.UVs())
+	r.mesh.SetColors(mesh.Colors())
+	r.mesh.SetTangents(mesh.Tangents())
+	r.mesh.SetUV2s(mesh.UV2s())
+	r.mesh.SetTriangles(mesh.Triangles())
+	r.mesh.SetReversedWinding(mesh.ReversedWinding())
+	r.mesh.SetTopology(mesh.Topology())
+
+	r.SetName("SkinnedMeshRenderer")
+	instance.MustAssign(r)
+
+	return r
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package scene
+
+import (
+	"image"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/system/instance"
+)
+
+// Sprite is the location of an image packed in a sprite atlas.
+type Sprite struct {
+	// Page is the index of the texture holding the sprite.
+	Page int
+
+	// Rect is the pixel rectangle of the sprite on its page.
+	Rect image.Rectangle
+
+	// UV holds the texture coordinates of the sprite on its page: the
+	// minimum U and V, followed by the maximum U and V.
+	UV mgl32.Vec4
+}
+
+// SpriteAtlas is a set of images packed on one or more textures, looked up
+// by name.
+type SpriteAtlas struct {
+	core.BaseObject
+
+	pages   []gfx.Texture
+	sprites map[string]Sprite
+}
+
+func NewSpriteAtlas(pages []gfx.Texture, sprites map[string]Sprite) *SpriteAtlas {
+	a := &SpriteAtlas{
+		pages:   pages,
+		sprites: sprites,
+	}
+
+	a.SetName("SpriteAtlas")
+	instance.MustAssign(a)
+
+	return a
+}
+
+// Sprite returns the location of the named sprite.
+func (a *SpriteAtlas) Sprite(name string) (Sprite, bool) {
+	s, ok := a.sprites[name]
+
+	return s, ok
+}
+
+// SpriteTexture returns the named sprite along with the texture of its
+// page.
+func (a *SpriteAtlas) SpriteTexture(name string) (gfx.Texture, Sprite, bool) {
+	s, ok := a.sprites[name]
+	if !ok {
+		return nil, Sprite{}, false
+	}
+
+	return a.pages[s.Page], s, true
+}
+
+// Names returns the names of the sprites, sorted.
+func (a *SpriteAtlas) Names() []string {
+	names := make([]string, 0, len(a.sprites))
+	for name := range a.sprites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Page returns the texture of a page.
+func (a *SpriteAtlas) Page(i int) gfx.Texture {
+	if i < 0 || i >= len(a.pages) {
+		return nil
+	}
+
+	return a.pages[i]
+}
+
+// PageCount returns the number of pages.
+func (a *SpriteAtlas) PageCount() int {
+	return len(a.pages)
+}
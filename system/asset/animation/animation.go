@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package animation
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/juju/errors"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/pkg/animation"
+	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/instance"
+)
+
+const (
+	AssetNameAnimation = "animation"
+)
+
+var _ core.Importer = &Handler{}
+
+// item holds a clip as an object of the instance system.
+type item struct {
+	core.BaseObject
+
+	clip *animation.Clip
+}
+
+type Handler struct {
+	core.BaseAssetHandler
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+	h.Items = make(map[string]int32)
+	h.Mu = &sync.RWMutex{}
+
+	return h
+}
+
+func (h *Handler) Name() string {
+	return AssetNameAnimation
+}
+
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".anim"}
+}
+
+// Signatures returns the signatures of the file formats accepted by this
+// handler. Animation clips are JSON, which has no signature.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return nil
+}
+
+// Load will load data from the reader.
+func (h *Handler) Load(r *core.Resource) error {
+	c := &animation.Clip{}
+
+	if err := json.Unmarshal(r.Bytes(), c); err != nil {
+		return err
+	}
+
+	return h.Add(c.Name, c)
+}
+
+// Add adds an animation clip to the handler.
+func (h *Handler) Add(name string, clip *animation.Clip) error {
+	if err := clip.Validate(); err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	i := &item{clip: clip}
+	instance.MustAssign(i)
+
+	h.Items[name] = i.ID()
+
+	return nil
+}
+
+// Get gets an asset by name.
+func (h *Handler) Get(name string) (*animation.Clip, error) {
+	h.Mu.RLock()
+	defer h.Mu.RUnlock()
+
+	a, err := asset.Resolve(h, name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*item)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	return a2.clip, nil
+}
+
+// MustGet is like GetAsset, but panics if an error occurs.
+func (h *Handler) MustGet(name string) *animation.Clip {
+	a, err := h.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+func Get(name string) (*animation.Clip, error) {
+	return mustHandler().Get(name)
+}
+
+func MustGet(name string) *animation.Clip {
+	return mustHandler().MustGet(name)
+}
+
+func mustHandler() *Handler {
+	h, err := asset.GetHandler(AssetNameAnimation)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.(*Handler)
+}
@@ -1,6 +1,8 @@
 package asset
 
 import (
+	"io"
+
 	"github.com/haakenlabs/ember/core"
 )
 
@@ -43,6 +45,86 @@ func LoadManifest(files ...string) error {
 	return core.GetAssetSystem().LoadManifest(files...)
 }
 
+// Import loads the asset at the given path, detecting its kind.
+func Import(filename string) error {
+	return core.GetAssetSystem().Import(filename)
+}
+
+// ImportDir loads every asset below the given directory, detecting the kind
+// of each asset.
+func ImportDir(dir string) error {
+	return core.GetAssetSystem().ImportDir(dir)
+}
+
+// ListDir lists the resource paths of all files below the given directory.
+func ListDir(dir string) ([]string, error) {
+	return core.GetAssetSystem().ListDir(dir)
+}
+
+// DetectKind detects the kind of a resource which has been read.
+func DetectKind(r *core.Resource) (string, error) {
+	return core.GetAssetSystem().DetectKind(r)
+}
+
+// OpenResource opens the resource as a stream. The caller must close the stream.
+func OpenResource(r *core.Resource) (io.ReadSeekCloser, error) {
+	return core.GetAssetSystem().OpenResource(r)
+}
+
 func ReadResource(r *core.Resource) error {
 	return core.GetAssetSystem().ReadResource(r)
 }
+
+// Resolve gets an asset by name from the given handler, serving the fallback
+// of the handler if the asset is missing and the asset system is lenient.
+func Resolve(h core.AssetHandler, name string) (core.Object, error) {
+	return core.GetAssetSystem().Resolve(h, name)
+}
+
+// Lenient reports whether fallbacks are served for missing assets.
+func Lenient() bool {
+	return core.GetAssetSystem().Lenient()
+}
+
+// SetLenient sets whether fallbacks are served for missing assets.
+func SetLenient(lenient bool) {
+	core.GetAssetSystem().SetLenient(lenient)
+}
+
+// Fallbacks reports all fallbacks served for missing assets.
+func Fallbacks() []core.AssetFallback {
+	return core.GetAssetSystem().Fallbacks()
+}
+
+// Graph returns the dependency graph of the loaded assets.
+func Graph() *core.AssetGraph {
+	return core.GetAssetSystem().Graph()
+}
+
+// AddDependency records that the asset from depends on the asset to.
+func AddDependency(from, to core.AssetRef) {
+	core.GetAssetSystem().AddDependency(from, to)
+}
+
+// Require records that the asset from depends on the asset of the given kind
+// and name, loading it from the given path if it is not loaded.
+func Require(from core.AssetRef, kind, name, filename string) error {
+	return core.GetAssetSystem().Require(from, kind, name, filename)
+}
+
+// Unused reports the loaded assets which are not referenced by a scene or
+// by another asset. Scenes reference only the assets passed to Reference.
+func Unused() []core.AssetRef {
+	return core.GetAssetSystem().Unused()
+}
+
+// WriteDOT writes the dependency graph in the Graphviz DOT language.
+func WriteDOT(w io.Writer) error {
+	return core.GetAssetSystem().Graph().WriteDOT(w)
+}
+
+// Reference records that the named scene references the asset of the given
+// kind and name.
+func Reference(scene, kind, name string) {
+	AddDependency(core.AssetRef{Kind: core.AssetKindScene, Name: scene}, core.AssetRef{Kind: kind, Name: name})
+}
@@ -0,0 +1,296 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package atlas
+
+import (
+	"encoding/json"
+	"image"
+	"image/draw"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/gfx"
+	packer "github.com/haakenlabs/ember/pkg/atlas"
+	"github.com/haakenlabs/ember/pkg/math"
+	"github.com/haakenlabs/ember/scene"
+	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/renderer"
+
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+const AssetNameAtlas = "atlas"
+
+var _ core.Importer = &Handler{}
+
+// Metadata describes a sprite atlas: the images of a directory packed on
+// one or more textures. Sprites are named after their file, without its
+// extension. The directory should not be imported itself, or its images are
+// loaded as textures as well.
+//
+//	{
+//		"name": "ui",
+//		"dir": "sprites/ui",
+//		"algorithm": "maxrects",
+//		"padding": 2,
+//		"power_of_two": true,
+//		"page_size": 1024
+//	}
+type Metadata struct {
+	// Name defaults to the name of the metadata file.
+	Name string `json:"name"`
+
+	// Dir is the directory of the sprites, relative to the metadata file.
+	Dir string `json:"dir"`
+
+	// Algorithm is "skyline" or "maxrects", the default.
+	Algorithm string `json:"algorithm"`
+
+	Padding    int  `json:"padding"`
+	PowerOfTwo bool `json:"power_of_two"`
+
+	// PageSize limits the width and height of pages. Without it, sprites
+	// are packed on a single page.
+	PageSize int `json:"page_size"`
+}
+
+// algorithms maps the algorithms of metadata to packing algorithms.
+var algorithms = map[string]packer.Algorithm{
+	"":         packer.MaxRects,
+	"maxrects": packer.MaxRects,
+	"skyline":  packer.Skyline,
+}
+
+// spriteExts are the extensions of the images packed in atlases.
+var spriteExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+}
+
+type Handler struct {
+	core.BaseAssetHandler
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+	h.Items = make(map[string]int32)
+	h.Mu = &sync.RWMutex{}
+
+	return h
+}
+
+func (h *Handler) Name() string {
+	return AssetNameAtlas
+}
+
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".atlas"}
+}
+
+// Signatures returns the signatures of the file formats accepted by this
+// handler. Atlas metadata is JSON, which has no signature.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return nil
+}
+
+// Load will load data from the reader.
+func (h *Handler) Load(r *core.Resource) error {
+	m := &Metadata{}
+
+	if err := json.Unmarshal(r.Bytes(), m); err != nil {
+		return errors.Annotate(err, r.Base())
+	}
+	if m.Name == "" {
+		m.Name = strings.TrimSuffix(r.Base(), filepath.Ext(r.Base()))
+	}
+
+	if _, dup := h.Items[m.Name]; dup {
+		return core.ErrAssetExists(m.Name)
+	}
+
+	self := core.AssetRef{Kind: AssetNameAtlas, Name: m.Name}
+
+	a, err := makeAtlas(m, filepath.Join(r.DirPrefix(), m.Dir), self)
+	if err != nil {
+		return errors.Annotate(err, m.Name)
+	}
+	a.SetName(m.Name)
+
+	h.Items[m.Name] = a.ID()
+
+	asset.AddDependency(self, core.ResourceRef(r))
+
+	return nil
+}
+
+// makeAtlas packs the images of a directory on textures.
+func makeAtlas(m *Metadata, dir string, self core.AssetRef) (*scene.SpriteAtlas, error) {
+	algorithm, ok := algorithms[m.Algorithm]
+	if !ok {
+		return nil, errors.Errorf("invalid algorithm: %s", m.Algorithm)
+	}
+
+	files, err := asset.ListDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var names []string
+	var images []image.Image
+	seen := make(map[string]bool)
+
+	for _, f := range files {
+		if !spriteExts[strings.ToLower(filepath.Ext(f))] {
+			continue
+		}
+
+		r, err := core.NewResource(f)
+		if err != nil {
+			return nil, err
+		}
+		if err := asset.ReadResource(r); err != nil {
+			return nil, err
+		}
+
+		img, _, err := image.Decode(r.Reader())
+		if err != nil {
+			return nil, errors.Annotate(err, r.Base())
+		}
+
+		name := strings.TrimSuffix(r.Base(), filepath.Ext(r.Base()))
+		if seen[name] {
+			return nil, errors.Errorf("duplicate sprite: %s", name)
+		}
+		seen[name] = true
+
+		names = append(names, name)
+		images = append(images, img)
+
+		asset.AddDependency(self, core.ResourceRef(r))
+	}
+
+	if len(images) == 0 {
+		return nil, errors.Errorf("no sprites in %s", dir)
+	}
+
+	sizes := make([]image.Point, len(images))
+	for i, img := range images {
+		sizes[i] = img.Bounds().Size()
+	}
+
+	packing, err := packer.Pack(sizes, packer.Options{
+		Algorithm:  algorithm,
+		Width:      m.PageSize,
+		Height:     m.PageSize,
+		Padding:    m.Padding,
+		PowerOfTwo: m.PowerOfTwo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]*image.NRGBA, len(packing.Pages))
+	for i, size := range packing.Pages {
+		pages[i] = image.NewNRGBA(image.Rectangle{Max: size})
+	}
+
+	sprites := make(map[string]scene.Sprite)
+	for i, img := range images {
+		p := packing.Placements[i]
+		draw.Draw(pages[p.Page], p.Rect, img, img.Bounds().Min, draw.Src)
+
+		sprites[names[i]] = scene.Sprite{
+			Page: p.Page,
+			Rect: p.Rect,
+			UV:   packing.UV(i),
+		}
+	}
+
+	textures := make([]gfx.Texture, len(pages))
+	for i, page := range pages {
+		textures[i] = renderer.MakeTexture(&gfx.TextureConfig{
+			Type:       gfx.Texture2D,
+			Format:     gfx.TextureFormatRGBA8,
+			ColorSpace: gfx.ColorSpaceSRGB,
+			Size:       math.IVec2{int32(page.Rect.Dx()), int32(page.Rect.Dy())},
+		})
+		textures[i].SetData(page.Pix)
+
+		if err := textures[i].Alloc(); err != nil {
+			return nil, err
+		}
+	}
+
+	return scene.NewSpriteAtlas(textures, sprites), nil
+}
+
+// Get gets an asset by name.
+func (h *Handler) Get(name string) (*scene.SpriteAtlas, error) {
+	a, err := asset.Resolve(h, name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*scene.SpriteAtlas)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	return a2, nil
+}
+
+// MustGet is like GetAsset, but panics if an error occurs.
+func (h *Handler) MustGet(name string) *scene.SpriteAtlas {
+	a, err := h.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+func Get(name string) (*scene.SpriteAtlas, error) {
+	return mustHandler().Get(name)
+}
+
+func MustGet(name string) *scene.SpriteAtlas {
+	return mustHandler().MustGet(name)
+}
+
+func mustHandler() *Handler {
+	h, err := asset.GetHandler(AssetNameAtlas)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.(*Handler)
+}
@@ -24,7 +24,6 @@ package audio
 
 import (
 	"fmt"
-	"path/filepath"
 	"sync"
 
 	"github.com/faiface/beep"
@@ -38,10 +37,28 @@ import (
 
 const AssetNameAudio = "audio"
 
-var _ core.AssetHandler = &Handler{}
+// fallbackFormat is the format of the silent sound served for missing sounds.
+var fallbackFormat = beep.Format{
+	SampleRate:  44100,
+	NumChannels: 2,
+	Precision:   2,
+}
+
+var _ core.FallbackHandler = &Handler{}
+var _ core.Importer = &Handler{}
+var _ core.StreamHandler = &Handler{}
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	fallback     core.Object
+	fallbackOnce sync.Once
+}
+
+// Access returns how the given resource is accessed. Sounds are streamed, so
+// they are decoded from their resource while playing.
+func (h *Handler) Access(r *core.Resource) core.ResourceAccess {
+	return core.ResourceStreamed
 }
 
 func (h *Handler) Load(r *core.Resource) error {
@@ -50,24 +67,30 @@ func (h *Handler) Load(r *core.Resource) error {
 	var err error
 
 	name := r.Base()
-	ext := filepath.Ext(name)
+	ext := r.Ext()
 
 	if _, dup := h.Items[name]; dup {
 		return core.ErrAssetExists(name)
 	}
 
+	rc, err := asset.OpenResource(r)
+	if err != nil {
+		return err
+	}
+
 	switch ext {
-	case "mp3":
-		streamer, format, err = mp3.Decode(r.ReadCloser())
-	case "wav":
-		streamer, format, err = wav.Decode(r.ReadCloser())
-	case "flac":
-		streamer, format, err = flac.Decode(r.ReadCloser())
+	case ".mp3":
+		streamer, format, err = mp3.Decode(rc)
+	case ".wav":
+		streamer, format, err = wav.Decode(rc)
+	case ".flac":
+		streamer, format, err = flac.Decode(rc)
 	default:
-		return fmt.Errorf("unknown audio type: %s", ext)
+		err = fmt.Errorf("unknown audio type: %s", ext)
 	}
 
 	if err != nil {
+		rc.Close()
 		return err
 	}
 
@@ -77,6 +100,18 @@ func (h *Handler) Load(r *core.Resource) error {
 	return h.Add(name, s)
 }
 
+// Fallback returns a silent sound, served in place of missing sounds.
+func (h *Handler) Fallback() (core.Object, error) {
+	h.fallbackOnce.Do(func() {
+		s := core.NewSound(beep.Silence(0), fallbackFormat)
+		s.SetName("fallback")
+
+		h.fallback = s
+	})
+
+	return h.fallback, nil
+}
+
 func (h *Handler) Add(name string, sound *core.Sound) error {
 	if _, dup := h.Items[name]; dup {
 		return core.ErrAssetExists(name)
@@ -92,7 +127,7 @@ func (h *Handler) Add(name string, sound *core.Sound) error {
 }
 
 func (h *Handler) Get(name string) (*core.Sound, error) {
-	a, err := h.GetAsset(name)
+	a, err := asset.Resolve(h, name)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +153,24 @@ func (h *Handler) Name() string {
 	return AssetNameAudio
 }
 
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".mp3", ".wav", ".flac"}
+}
+
+// Signatures returns the signatures of the file formats accepted by
+// this handler.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return []core.AssetSignature{
+		{Magic: []byte("ID3")},
+		{Magic: []byte("\xff\xfb")},
+		{Magic: []byte("\xff\xf3")},
+		{Magic: []byte("\xff\xf2")},
+		{Offset: 8, Magic: []byte("WAVE")},
+		{Magic: []byte("fLaC")},
+	}
+}
+
 func NewHandler() *Handler {
 	h := &Handler{}
 	h.Items = make(map[string]int32)
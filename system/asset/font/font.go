@@ -28,18 +28,27 @@ import (
 	"github.com/golang/freetype/truetype"
 
 	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/internal/builtin"
 	"github.com/haakenlabs/ember/scene"
 	"github.com/haakenlabs/ember/system/asset"
 )
 
 const (
 	AssetNameFont = "font"
+
+	// fallbackFont is the builtin font served for missing fonts.
+	fallbackFont = "fonts/SourceCodePro-Regular.ttf"
 )
 
-var _ core.AssetHandler = &Handler{}
+var _ core.FallbackHandler = &Handler{}
+var _ core.Importer = &Handler{}
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	fallback     core.Object
+	fallbackErr  error
+	fallbackOnce sync.Once
 }
 
 // Load will load data from the reader.
@@ -61,6 +70,29 @@ func (h *Handler) Load(r *core.Resource) error {
 	return h.Add(name, f)
 }
 
+// Fallback returns the default font, served in place of missing fonts.
+func (h *Handler) Fallback() (core.Object, error) {
+	h.fallbackOnce.Do(func() {
+		h.fallback, h.fallbackErr = makeFallback()
+	})
+
+	return h.fallback, h.fallbackErr
+}
+
+func makeFallback() (core.Object, error) {
+	ttf, err := truetype.Parse(builtin.MustAsset(fallbackFont))
+	if err != nil {
+		return nil, err
+	}
+
+	f := scene.NewFont(ttf, scene.ASCII)
+	if err := f.Alloc(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
 func (h *Handler) Add(name string, font *scene.Font) error {
 	if _, dup := h.Items[name]; dup {
 		return core.ErrAssetExists(name)
@@ -77,7 +109,7 @@ func (h *Handler) Add(name string, font *scene.Font) error {
 
 // Get gets an asset by name.
 func (h *Handler) Get(name string) (*scene.Font, error) {
-	a, err := h.GetAsset(name)
+	a, err := asset.Resolve(h, name)
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +136,21 @@ func (h *Handler) Name() string {
 	return AssetNameFont
 }
 
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".ttf", ".otf"}
+}
+
+// Signatures returns the signatures of the file formats accepted by
+// this handler.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return []core.AssetSignature{
+		{Magic: []byte("\x00\x01\x00\x00")},
+		{Magic: []byte("OTTO")},
+		{Magic: []byte("true")},
+	}
+}
+
 func NewHandler() *Handler {
 	h := &Handler{}
 	h.Items = make(map[string]int32)
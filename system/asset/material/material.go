@@ -0,0 +1,167 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package material
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/scene"
+	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/asset/shader"
+	"github.com/haakenlabs/ember/system/asset/texture"
+)
+
+const (
+	AssetNameMaterial = "material"
+
+	// shaderExt is the extension of shader metadata, used to locate shaders
+	// which are required by a material but not yet loaded.
+	shaderExt = ".shader"
+)
+
+var _ core.Importer = &Handler{}
+
+// Metadata describes a material. Shaders and textures are referenced by
+// name; those not yet loaded are loaded relative to the material.
+type Metadata struct {
+	Name string `json:"name"`
+
+	scene.MaterialData
+}
+
+type Handler struct {
+	core.BaseAssetHandler
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+	h.Items = make(map[string]int32)
+	h.Mu = &sync.RWMutex{}
+
+	return h
+}
+
+func (h *Handler) Name() string {
+	return AssetNameMaterial
+}
+
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".material"}
+}
+
+// Signatures returns the signatures of the file formats accepted by this
+// handler. Material metadata is JSON, which has no signature.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return nil
+}
+
+// Load will load data from the reader.
+func (h *Handler) Load(r *core.Resource) error {
+	m := &Metadata{}
+
+	if err := json.Unmarshal(r.Bytes(), m); err != nil {
+		return err
+	}
+
+	if _, dup := h.Items[m.Name]; dup {
+		return core.ErrAssetExists(m.Name)
+	}
+
+	self := core.AssetRef{Kind: AssetNameMaterial, Name: m.Name}
+
+	err := asset.Require(self, shader.AssetNameShader, m.Shader, filepath.Join(r.DirPrefix(), m.Shader+shaderExt))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range m.Textures {
+		if err := asset.Require(self, texture.AssetNameTexture, name, filepath.Join(r.DirPrefix(), name)); err != nil {
+			return err
+		}
+	}
+
+	mat, err := scene.BuildMaterial(&m.MaterialData)
+	if err != nil {
+		return err
+	}
+
+	mat.SetName(m.Name)
+
+	return h.Add(m.Name, mat)
+}
+
+func (h *Handler) Add(name string, material *scene.Material) error {
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	h.Items[name] = material.ID()
+
+	return nil
+}
+
+// Get gets an asset by name.
+func (h *Handler) Get(name string) (*scene.Material, error) {
+	a, err := asset.Resolve(h, name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*scene.Material)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	return a2, nil
+}
+
+// MustGet is like GetAsset, but panics if an error occurs.
+func (h *Handler) MustGet(name string) *scene.Material {
+	a, err := h.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+func Get(name string) (*scene.Material, error) {
+	return mustHandler().Get(name)
+}
+
+func MustGet(name string) *scene.Material {
+	return mustHandler().MustGet(name)
+}
+
+func mustHandler() *Handler {
+	h, err := asset.GetHandler(AssetNameMaterial)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.(*Handler)
+}
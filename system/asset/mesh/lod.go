@@ -0,0 +1,96 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mesh
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/pkg/geometry"
+	"github.com/haakenlabs/ember/system/asset"
+)
+
+// ImportOptions control how imported meshes are processed.
+type ImportOptions struct {
+	// LODs are the fractions of the triangles of a mesh kept by each of its
+	// levels of detail, in decreasing order. Level i is added to the
+	// handler under LODName(name, i+1).
+	LODs []float32 `json:"lods"`
+
+	// MaxError bounds the quadric error of the edge collapses made for
+	// levels of detail. Zero leaves it unbounded.
+	MaxError float32 `json:"max_error"`
+}
+
+// LODName returns the name of a level of detail of a mesh. Level zero is the
+// mesh itself.
+func LODName(name string, level int) string {
+	if level == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s/lod%d", name, level)
+}
+
+// ImportOptions returns the options applied to imported meshes.
+func (h *Handler) ImportOptions() ImportOptions {
+	return h.options
+}
+
+// SetImportOptions sets the options applied to meshes imported from then
+// on. Meshes which give their own levels of detail override them.
+func (h *Handler) SetImportOptions(options ImportOptions) {
+	h.options = options
+}
+
+// addLODs simplifies the geometry of a mesh and adds each level of detail to
+// the handler. Each level depends on the mesh it was generated from.
+func (h *Handler) addLODs(name string, g *geometry.Mesh, materials []string, ratios []float32) error {
+	if len(ratios) == 0 {
+		ratios = h.options.LODs
+	}
+	if len(ratios) == 0 {
+		return nil
+	}
+
+	lods, err := g.LODs(ratios, h.options.MaxError)
+	if err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	base := core.AssetRef{Kind: AssetNameMesh, Name: name}
+
+	for i, lod := range lods {
+		lodName := LODName(name, i+1)
+
+		if err := h.addGeometry(lodName, lod, materials); err != nil {
+			return err
+		}
+
+		asset.AddDependency(core.AssetRef{Kind: AssetNameMesh, Name: lodName}, base)
+	}
+
+	return nil
+}
@@ -24,6 +24,8 @@ package mesh
 
 import (
 	"encoding/gob"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/go-gl/mathgl/mgl32"
@@ -31,9 +33,11 @@ import (
 
 	"github.com/haakenlabs/ember/core"
 	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/geometry"
 	"github.com/haakenlabs/ember/pkg/math"
+	"github.com/haakenlabs/ember/pkg/meshfile"
 	"github.com/haakenlabs/ember/system/asset"
-	"github.com/haakenlabs/ember/system/renderer"
+	"github.com/haakenlabs/ember/system/asset/material"
 )
 
 const (
@@ -44,6 +48,7 @@ const (
 var (
 	ErrMeshInvalidFaceType = errors.New("invalid model face type")
 	ErrMeshMissingFaces    = errors.New("model has no faces")
+	ErrMeshInvalidStream   = errors.New("model vertex stream does not match vertices")
 )
 
 const (
@@ -70,18 +75,86 @@ type Metadata struct {
 	N     []mgl32.Vec3 `json:"n"`
 	T     []mgl32.Vec2 `json:"t"`
 	F     []Face       `json:"f"`
+
+	// Optional vertex streams, with one element per element of V.
+	Colors   []mgl32.Vec4 `json:"colors"`
+	Tangents []mgl32.Vec4 `json:"tangents"`
+	UV2s     []mgl32.Vec2 `json:"uv2s"`
+	Joints   [][4]uint16  `json:"joints"`
+	Weights  []mgl32.Vec4 `json:"weights"`
+
+	// Materials are the names of the materials used by the mesh.
+	Materials []string `json:"materials"`
+
+	// LODs override the levels of detail of the import options.
+	LODs []float32 `json:"lods"`
+}
+
+// streams returns the optional vertex streams of the faces as a mesh.
+func (m *Metadata) streams() (*geometry.Mesh, error) {
+	count := len(m.V)
+
+	for _, n := range []int{len(m.Colors), len(m.Tangents), len(m.UV2s), len(m.Joints), len(m.Weights)} {
+		if n != 0 && n != count {
+			return nil, ErrMeshInvalidStream
+		}
+	}
+
+	g := &geometry.Mesh{}
+	for i := range m.F {
+		for j := range m.F[i] {
+			v := m.F[i][j][FaceVertex]
+
+			if len(m.Colors) != 0 {
+				g.Colors = append(g.Colors, m.Colors[v])
+			}
+			if len(m.Tangents) != 0 {
+				g.Tangents = append(g.Tangents, m.Tangents[v])
+			}
+			if len(m.UV2s) != 0 {
+				g.UV2s = append(g.UV2s, m.UV2s[v])
+			}
+			if len(m.Joints) != 0 {
+				g.Joints = append(g.Joints, m.Joints[v])
+			}
+			if len(m.Weights) != 0 {
+				g.Weights = append(g.Weights, m.Weights[v])
+			}
+		}
+	}
+
+	return g, nil
 }
 
-var _ core.AssetHandler = &Handler{}
+var _ core.FallbackHandler = &Handler{}
+var _ core.Importer = &Handler{}
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	options ImportOptions
+
+	fallback     core.Object
+	fallbackErr  error
+	fallbackOnce sync.Once
 }
 
 // Load will load data from the reader.
 func (h *Handler) Load(r *core.Resource) error {
+	switch r.Ext() {
+	case ".obj":
+		return h.loadOBJ(r)
+	case ".primitive":
+		return h.loadPrimitive(r)
+	case ".mesh":
+		return h.loadMeshFile(r)
+	case ".stl":
+		return h.loadSTL(r)
+	case ".ply":
+		return h.loadPLY(r)
+	}
+
 	metadata := &Metadata{}
-	m := renderer.MakeMesh()
 
 	dec := gob.NewDecoder(r.Reader())
 	err := dec.Decode(&metadata)
@@ -89,46 +162,134 @@ func (h *Handler) Load(r *core.Resource) error {
 		return err
 	}
 
-	name := metadata.Name
-
-	if _, dup := h.Items[name]; dup {
-		return core.ErrAssetExists(name)
-	}
+	return h.loadMetadata(metadata)
+}
 
-	if len(metadata.F) == 0 {
-		return ErrMeshMissingFaces
+// Geometry returns the geometry of the faces. Face vertices with identical
+// attributes are shared, and smooth normals are generated for face types
+// without normals.
+func (m *Metadata) Geometry() (*geometry.Mesh, error) {
+	if len(m.F) == 0 {
+		return nil, ErrMeshMissingFaces
 	}
 
-	v := make([]mgl32.Vec3, len(metadata.F)*3)
-	n := make([]mgl32.Vec3, len(metadata.F)*3)
-	t := make([]mgl32.Vec2, len(metadata.F)*3)
+	v := make([]mgl32.Vec3, len(m.F)*3)
+	n := make([]mgl32.Vec3, len(m.F)*3)
+	t := make([]mgl32.Vec2, len(m.F)*3)
 
-	for i := range metadata.F {
-		for j := range metadata.F[i] {
-			switch metadata.FType {
+	for i := range m.F {
+		for j := range m.F[i] {
+			switch m.FType {
 			case FaceTypeV:
-				v[i*3+j] = metadata.V[metadata.F[i][j][FaceVertex]]
+				v[i*3+j] = m.V[m.F[i][j][FaceVertex]]
 			case FaceTypeVT:
-				v[i*3+j] = metadata.V[metadata.F[i][j][FaceVertex]]
-				t[i*3+j] = metadata.T[metadata.F[i][j][FaceTexture]]
+				v[i*3+j] = m.V[m.F[i][j][FaceVertex]]
+				t[i*3+j] = m.T[m.F[i][j][FaceTexture]]
 			case FaceTypeVN:
-				v[i*3+j] = metadata.V[metadata.F[i][j][FaceVertex]]
-				n[i*3+j] = metadata.N[metadata.F[i][j][FaceNormal]]
+				v[i*3+j] = m.V[m.F[i][j][FaceVertex]]
+				n[i*3+j] = m.N[m.F[i][j][FaceNormal]]
 			case FaceTypeVTN:
-				v[i*3+j] = metadata.V[metadata.F[i][j][FaceVertex]]
-				t[i*3+j] = metadata.T[metadata.F[i][j][FaceTexture]]
-				n[i*3+j] = metadata.N[metadata.F[i][j][FaceNormal]]
+				v[i*3+j] = m.V[m.F[i][j][FaceVertex]]
+				t[i*3+j] = m.T[m.F[i][j][FaceTexture]]
+				n[i*3+j] = m.N[m.F[i][j][FaceNormal]]
 			default:
-				return ErrMeshInvalidFaceType
+				return nil, ErrMeshInvalidFaceType
 			}
 		}
 	}
 
-	m.SetVertices(v)
-	m.SetNormals(n)
-	m.SetUVs(t)
+	g, err := m.streams()
+	if err != nil {
+		return nil, err
+	}
+
+	g.Positions, g.Normals, g.UVs = v, n, t
+	if m.FType == FaceTypeV || m.FType == FaceTypeVT {
+		g.SmoothNormals()
+	}
+	if _, err := g.Weld(0); err != nil {
+		return nil, err
+	}
 
-	return h.Add(name, m)
+	return g, nil
+}
+
+// loadMetadata creates a mesh from the metadata and adds it to the handler.
+func (h *Handler) loadMetadata(metadata *Metadata) error {
+	name := metadata.Name
+
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	g, err := metadata.Geometry()
+	if err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	if err := h.addGeometry(name, g, metadata.Materials); err != nil {
+		return err
+	}
+
+	return h.addLODs(name, g, metadata.Materials, metadata.LODs)
+}
+
+// loadMeshFile loads a mesh in the binary mesh format. Meshes without a name
+// are named after the file.
+func (h *Handler) loadMeshFile(r *core.Resource) error {
+	m, err := meshfile.Decode(r.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if m.Name == "" {
+		m.Name = trimExt(r.Base())
+	}
+
+	if err := h.addGeometry(m.Name, m.Geometry, m.Materials); err != nil {
+		return err
+	}
+
+	return h.addLODs(m.Name, m.Geometry, m.Materials, nil)
+}
+
+// addGeometry adds a mesh of the geometry to the handler, recording the
+// materials it uses.
+func (h *Handler) addGeometry(name string, g *geometry.Mesh, materials []string) error {
+	if err := h.Add(name, MakeMesh(g)); err != nil {
+		return err
+	}
+
+	self := core.AssetRef{Kind: AssetNameMesh, Name: name}
+	for _, mat := range materials {
+		asset.AddDependency(self, core.AssetRef{Kind: material.AssetNameMaterial, Name: mat})
+	}
+
+	return nil
+}
+
+// Fallback returns a unit cube, served in place of missing meshes.
+func (h *Handler) Fallback() (core.Object, error) {
+	h.fallbackOnce.Do(func() {
+		h.fallback, h.fallbackErr = makeFallback()
+	})
+
+	return h.fallback, h.fallbackErr
+}
+
+func makeFallback() (core.Object, error) {
+	m := MakeMesh(geometry.Cube(1))
+
+	obj, ok := m.(core.Object)
+	if !ok {
+		return nil, core.ErrAssetType("fallback")
+	}
+
+	if err := m.Alloc(); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
 }
 
 func (h *Handler) Add(name string, mesh gfx.Mesh) error {
@@ -153,7 +314,7 @@ func (h *Handler) Get(name string) (gfx.Mesh, error) {
 	h.Mu.RLock()
 	defer h.Mu.RUnlock()
 
-	a, err := h.GetAsset(name)
+	a, err := asset.Resolve(h, name)
 	if err != nil {
 		return nil, err
 	}
@@ -180,6 +341,25 @@ func (h *Handler) Name() string {
 	return AssetNameMesh
 }
 
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".mdl", ".mesh", ".obj", ".ply", ".primitive", ".stl"}
+}
+
+// Signatures returns the signatures of the file formats accepted by this
+// handler: the binary mesh format and PLY.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return []core.AssetSignature{
+		{Magic: []byte(meshfile.Magic)},
+		{Magic: []byte("ply")},
+	}
+}
+
+// trimExt returns the file name without its extension.
+func trimExt(base string) string {
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
 func NewHandler() *Handler {
 	h := &Handler{}
 	h.Items = make(map[string]int32)
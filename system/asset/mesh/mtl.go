@@ -0,0 +1,290 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/scene"
+	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/asset/material"
+	"github.com/haakenlabs/ember/system/asset/shader"
+	"github.com/haakenlabs/ember/system/asset/texture"
+)
+
+// mtlShader is the shader used by materials imported from MTL files.
+const mtlShader = "standard"
+
+// MTLMaterial is a material parsed from a Wavefront MTL file. Besides the
+// classic Phong statements, the PBR extension statements Pr and Pm are read.
+type MTLMaterial struct {
+	Name      string
+	Diffuse   mgl32.Vec3
+	Specular  mgl32.Vec3
+	Shininess float32
+	Dissolve  float32
+	Roughness float32
+	Metallic  float32
+
+	DiffuseMap  string
+	NormalMap   string
+	MetallicMap string
+
+	hasRoughness bool
+}
+
+// ParseMTL parses a Wavefront MTL file.
+func ParseMTL(r io.Reader) ([]*MTLMaterial, error) {
+	var mats []*MTLMaterial
+	var m *MTLMaterial
+
+	s := bufio.NewScanner(r)
+
+	var lineNo int
+
+	for s.Scan() {
+		lineNo++
+
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "newmtl" {
+			m = &MTLMaterial{
+				Name:     strings.Join(fields[1:], " "),
+				Diffuse:  mgl32.Vec3{1, 1, 1},
+				Dissolve: 1,
+			}
+			mats = append(mats, m)
+			continue
+		}
+
+		if m == nil {
+			return nil, fmt.Errorf("mtl: line %d: statement outside of material", lineNo)
+		}
+
+		if err := m.parseStatement(fields[0], fields[1:]); err != nil {
+			return nil, fmt.Errorf("mtl: line %d: %v", lineNo, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range mats {
+		if !m.hasRoughness {
+			// Convert the Phong exponent to a roughness.
+			m.Roughness = float32(math.Sqrt(2 / (float64(m.Shininess) + 2)))
+		}
+	}
+
+	return mats, nil
+}
+
+func (m *MTLMaterial) parseStatement(key string, args []string) error {
+	switch key {
+	case "Kd", "Ks":
+		v, err := parseFloats(args, 1, 3)
+		if err != nil {
+			return err
+		}
+		// A single value sets all components.
+		if len(args) < 3 {
+			v[1], v[2] = v[0], v[0]
+		}
+		if key == "Kd" {
+			m.Diffuse = mgl32.Vec3{v[0], v[1], v[2]}
+		} else {
+			m.Specular = mgl32.Vec3{v[0], v[1], v[2]}
+		}
+	case "Ns", "d", "Tr", "Pr", "Pm":
+		v, err := parseFloats(args, 1, 1)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "Ns":
+			m.Shininess = v[0]
+		case "d":
+			m.Dissolve = v[0]
+		case "Tr":
+			m.Dissolve = 1 - v[0]
+		case "Pr":
+			m.Roughness = v[0]
+			m.hasRoughness = true
+		case "Pm":
+			m.Metallic = v[0]
+		}
+	case "map_Kd":
+		m.DiffuseMap = mapFile(args)
+	case "map_Bump", "map_bump", "bump", "norm":
+		m.NormalMap = mapFile(args)
+	case "map_Pm":
+		m.MetallicMap = mapFile(args)
+	}
+
+	return nil
+}
+
+// mapFile returns the file name of a texture map statement, skipping any
+// options preceding it.
+func mapFile(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	return args[len(args)-1]
+}
+
+// loadOBJ loads the meshes of an OBJ file, along with the materials of the
+// material libraries it references.
+func (h *Handler) loadOBJ(r *core.Resource) error {
+	o, err := ParseOBJ(r.Reader(), r.Base())
+	if err != nil {
+		return err
+	}
+
+	for _, lib := range o.Libraries {
+		if err := loadMaterialLibrary(filepath.Join(r.DirPrefix(), lib)); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range o.Meshes {
+		if err := h.loadMetadata(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadMaterialLibrary creates a material for each material of an MTL file
+// which is not already loaded. Textures are loaded relative to the file.
+func loadMaterialLibrary(filename string) error {
+	h, err := asset.GetHandler(material.AssetNameMaterial)
+	if err != nil {
+		logrus.Warnf("mesh: skipping material library %s: %v", filename, err)
+		return nil
+	}
+	mh, ok := h.(*material.Handler)
+	if !ok {
+		return core.ErrAssetType(material.AssetNameMaterial)
+	}
+
+	r, err := core.NewResource(filename)
+	if err != nil {
+		return err
+	}
+	if err := asset.ReadResource(r); err != nil {
+		return err
+	}
+
+	mats, err := ParseMTL(r.Reader())
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mats {
+		if _, err := mh.GetAsset(m.Name); err == nil {
+			continue
+		}
+
+		mat, err := makeMaterial(m, r)
+		if err != nil {
+			return err
+		}
+
+		if err := mh.Add(m.Name, mat); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func makeMaterial(m *MTLMaterial, r *core.Resource) (*scene.Material, error) {
+	self := core.AssetRef{Kind: material.AssetNameMaterial, Name: m.Name}
+
+	s, err := shader.Get(mtlShader)
+	if err != nil {
+		return nil, err
+	}
+
+	mat := scene.NewMaterial()
+	mat.SetName(m.Name)
+	mat.SetShader(s)
+	mat.SetProperty("f_albedo", m.Diffuse)
+	mat.SetProperty("f_roughness", m.Roughness)
+	mat.SetProperty("f_metallic", m.Metallic)
+
+	asset.AddDependency(self, core.ResourceRef(r))
+	asset.AddDependency(self, core.AssetRef{Kind: shader.AssetNameShader, Name: mtlShader})
+
+	maps := []struct {
+		id   scene.MaterialTexture
+		file string
+	}{
+		{scene.MaterialTextureAlbedo, m.DiffuseMap},
+		{scene.MaterialTextureNormal, m.NormalMap},
+		{scene.MaterialTextureMetallic, m.MetallicMap},
+	}
+
+	for _, v := range maps {
+		if v.file == "" {
+			continue
+		}
+
+		name := filepath.Base(v.file)
+		filename := filepath.Join(r.DirPrefix(), v.file)
+
+		texture.SetColorSpace(name, v.id.ColorSpace())
+		if err := asset.Require(self, texture.AssetNameTexture, name, filename); err != nil {
+			return nil, err
+		}
+
+		t, err := texture.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		mat.SetTexture(v.id, t)
+	}
+
+	return mat, nil
+}
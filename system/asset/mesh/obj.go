@@ -0,0 +1,557 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/haakenlabs/ember/pkg/math"
+)
+
+// objIndex is a vertex of an OBJ face, given by zero based indices into the
+// position, texture coordinate and normal lists. Missing elements are -1.
+type objIndex struct {
+	v, t, n int
+}
+
+type objFace struct {
+	verts    []objIndex
+	smooth   int
+	material string
+}
+
+type objGroup struct {
+	name  string
+	faces []objFace
+}
+
+// OBJ is a parsed Wavefront OBJ file.
+type OBJ struct {
+	// Meshes holds one mesh per object or group in the file.
+	Meshes []*Metadata
+
+	// Libraries are the material libraries referenced by the file.
+	Libraries []string
+}
+
+type objParser struct {
+	name      string
+	v         []mgl32.Vec3
+	t         []mgl32.Vec2
+	n         []mgl32.Vec3
+	groups    []*objGroup
+	current   *objGroup
+	object    string
+	group     string
+	smooth    int
+	material  string
+	libraries []string
+}
+
+// ParseOBJ parses a Wavefront OBJ file. Polygons are triangulated, and
+// normals missing from faces are generated from their smoothing groups.
+// Each object and group becomes a separate mesh, named after the given name
+// followed by the object and group names.
+func ParseOBJ(r io.Reader, name string) (*OBJ, error) {
+	p := &objParser{name: name}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var line string
+	var lineNo int
+
+	for s.Scan() {
+		lineNo++
+
+		line += s.Text()
+		if strings.HasSuffix(line, "\\") {
+			line = strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+
+		if err := p.parseLine(line); err != nil {
+			return nil, fmt.Errorf("obj: line %d: %v", lineNo, err)
+		}
+
+		line = ""
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return p.build()
+}
+
+func (p *objParser) parseLine(line string) error {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	args := fields[1:]
+
+	switch fields[0] {
+	case "v":
+		v, err := parseFloats(args, 3, 3)
+		if err != nil {
+			return err
+		}
+		p.v = append(p.v, mgl32.Vec3{v[0], v[1], v[2]})
+	case "vt":
+		v, err := parseFloats(args, 1, 2)
+		if err != nil {
+			return err
+		}
+		p.t = append(p.t, mgl32.Vec2{v[0], v[1]})
+	case "vn":
+		v, err := parseFloats(args, 3, 3)
+		if err != nil {
+			return err
+		}
+		p.n = append(p.n, mgl32.Vec3{v[0], v[1], v[2]})
+	case "f":
+		return p.parseFace(args)
+	case "o":
+		p.object = strings.Join(args, " ")
+		p.setGroup("")
+	case "g":
+		p.setGroup(strings.Join(args, " "))
+	case "s":
+		p.smooth = 0
+		if len(args) > 0 && args[0] != "off" {
+			s, err := strconv.Atoi(args[0])
+			if err != nil {
+				return err
+			}
+			p.smooth = s
+		}
+	case "usemtl":
+		p.material = strings.Join(args, " ")
+	case "mtllib":
+		p.libraries = append(p.libraries, args...)
+	}
+
+	return nil
+}
+
+// setGroup starts a group of the current object. Faces which follow are
+// added to the group, which is created when the first face is added.
+func (p *objParser) setGroup(group string) {
+	p.group = p.object
+	if group != "" && group != "default" {
+		if p.group != "" {
+			p.group += "/"
+		}
+		p.group += group
+	}
+
+	p.current = nil
+}
+
+func (p *objParser) parseFace(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face has %d vertices", len(args))
+	}
+
+	f := objFace{
+		verts:    make([]objIndex, len(args)),
+		smooth:   p.smooth,
+		material: p.material,
+	}
+
+	for i, arg := range args {
+		refs := strings.Split(arg, "/")
+		if len(refs) > 3 {
+			return fmt.Errorf("invalid face vertex: %s", arg)
+		}
+
+		idx := objIndex{v: -1, t: -1, n: -1}
+
+		var err error
+		if idx.v, err = resolveIndex(refs[0], len(p.v)); err != nil {
+			return err
+		}
+		if idx.v < 0 {
+			return fmt.Errorf("face vertex has no position: %s", arg)
+		}
+		if len(refs) > 1 {
+			if idx.t, err = resolveIndex(refs[1], len(p.t)); err != nil {
+				return err
+			}
+		}
+		if len(refs) > 2 {
+			if idx.n, err = resolveIndex(refs[2], len(p.n)); err != nil {
+				return err
+			}
+		}
+
+		f.verts[i] = idx
+	}
+
+	if p.current == nil {
+		for _, g := range p.groups {
+			if g.name == p.group {
+				p.current = g
+			}
+		}
+
+		if p.current == nil {
+			p.current = &objGroup{name: p.group}
+			p.groups = append(p.groups, p.current)
+		}
+	}
+
+	p.current.faces = append(p.current.faces, f)
+
+	return nil
+}
+
+// resolveIndex converts a one based, possibly negative OBJ index into a zero
+// based index. Empty indices resolve to -1.
+func resolveIndex(s string, count int) (int, error) {
+	if s == "" {
+		return -1, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case i > 0 && i <= count:
+		return i - 1, nil
+	case i < 0 && -i <= count:
+		return count + i, nil
+	}
+
+	return 0, fmt.Errorf("index out of range: %d", i)
+}
+
+func parseFloats(args []string, min, max int) ([]float32, error) {
+	if len(args) < min {
+		return nil, fmt.Errorf("expected %d values, got %d", min, len(args))
+	}
+
+	v := make([]float32, max)
+
+	for i := 0; i < max && i < len(args); i++ {
+		f, err := strconv.ParseFloat(args[i], 32)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = float32(f)
+	}
+
+	return v, nil
+}
+
+func (p *objParser) build() (*OBJ, error) {
+	o := &OBJ{Libraries: p.libraries}
+
+	for _, g := range p.groups {
+		if len(g.faces) == 0 {
+			continue
+		}
+
+		name := p.name
+		if g.name != "" && len(p.groups) > 1 {
+			name += "/" + g.name
+		}
+
+		o.Meshes = append(o.Meshes, p.buildMesh(name, g))
+	}
+
+	if len(o.Meshes) == 0 {
+		return nil, ErrMeshMissingFaces
+	}
+
+	return o, nil
+}
+
+// smoothKey identifies a generated normal shared by the faces of a
+// smoothing group around a position.
+type smoothKey struct {
+	v, smooth int
+}
+
+func (p *objParser) buildMesh(name string, g *objGroup) *Metadata {
+	m := &Metadata{
+		Name:  name,
+		FType: FaceTypeVTN,
+	}
+
+	vmap := make(map[int]int32)
+	tmap := make(map[int]int32)
+	nmap := make(map[int]int32)
+	smap := make(map[smoothKey]int32)
+	mats := make(map[string]bool)
+
+	missingT := int32(-1)
+
+	// Accumulate area weighted face normals of smoothing groups.
+	smoothed := make(map[smoothKey]mgl32.Vec3)
+	for _, f := range g.faces {
+		if f.smooth == 0 {
+			continue
+		}
+
+		fn := p.faceNormal(f)
+		for _, idx := range f.verts {
+			if idx.n < 0 {
+				k := smoothKey{idx.v, f.smooth}
+				smoothed[k] = smoothed[k].Add(fn)
+			}
+		}
+	}
+
+	for _, f := range g.faces {
+		if f.material != "" && !mats[f.material] {
+			mats[f.material] = true
+			m.Materials = append(m.Materials, f.material)
+		}
+
+		flat := int32(-1)
+
+		verts := make([]math.IVec3, len(f.verts))
+		for i, idx := range f.verts {
+			var vi, ti, ni int32
+
+			if j, ok := vmap[idx.v]; ok {
+				vi = j
+			} else {
+				vi = int32(len(m.V))
+				vmap[idx.v] = vi
+				m.V = append(m.V, p.v[idx.v])
+			}
+
+			switch {
+			case idx.t >= 0:
+				if j, ok := tmap[idx.t]; ok {
+					ti = j
+				} else {
+					ti = int32(len(m.T))
+					tmap[idx.t] = ti
+					m.T = append(m.T, p.t[idx.t])
+				}
+			default:
+				if missingT < 0 {
+					missingT = int32(len(m.T))
+					m.T = append(m.T, mgl32.Vec2{})
+				}
+				ti = missingT
+			}
+
+			switch {
+			case idx.n >= 0:
+				if j, ok := nmap[idx.n]; ok {
+					ni = j
+				} else {
+					ni = int32(len(m.N))
+					nmap[idx.n] = ni
+					m.N = append(m.N, p.n[idx.n])
+				}
+			case f.smooth != 0:
+				k := smoothKey{idx.v, f.smooth}
+				if j, ok := smap[k]; ok {
+					ni = j
+				} else {
+					ni = int32(len(m.N))
+					smap[k] = ni
+					m.N = append(m.N, normalize(smoothed[k]))
+				}
+			default:
+				if flat < 0 {
+					flat = int32(len(m.N))
+					m.N = append(m.N, normalize(p.faceNormal(f)))
+				}
+				ni = flat
+			}
+
+			verts[i] = math.IVec3{vi, ti, ni}
+		}
+
+		for _, tri := range p.triangulate(f) {
+			m.F = append(m.F, Face{verts[tri[0]], verts[tri[1]], verts[tri[2]]})
+		}
+	}
+
+	return m
+}
+
+// faceNormal returns the area weighted normal of a face, computed with
+// Newell's method so that non-planar polygons are handled.
+func (p *objParser) faceNormal(f objFace) mgl32.Vec3 {
+	var n mgl32.Vec3
+
+	for i := range f.verts {
+		a := p.v[f.verts[i].v]
+		b := p.v[f.verts[(i+1)%len(f.verts)].v]
+
+		n[0] += (a[1] - b[1]) * (a[2] + b[2])
+		n[1] += (a[2] - b[2]) * (a[0] + b[0])
+		n[2] += (a[0] - b[0]) * (a[1] + b[1])
+	}
+
+	return n.Mul(0.5)
+}
+
+// triangulate splits a face into triangles by ear clipping in the plane of
+// the face. Triangles are given as indices into the vertices of the face.
+func (p *objParser) triangulate(f objFace) [][3]int {
+	count := len(f.verts)
+	if count == 3 {
+		return [][3]int{{0, 1, 2}}
+	}
+
+	// Project the polygon on to the plane of its dominant axis.
+	n := p.faceNormal(f)
+	u, v := 0, 1
+	switch {
+	case abs(n[0]) >= abs(n[1]) && abs(n[0]) >= abs(n[2]):
+		u, v = 1, 2
+	case abs(n[1]) >= abs(n[2]):
+		u, v = 2, 0
+	}
+
+	pts := make([]mgl32.Vec2, count)
+	for i, idx := range f.verts {
+		pts[i] = mgl32.Vec2{p.v[idx.v][u], p.v[idx.v][v]}
+	}
+
+	// Orient the projection counter-clockwise.
+	var area float32
+	for i := range pts {
+		area += cross2(pts[i], pts[(i+1)%count])
+	}
+
+	remaining := make([]int, count)
+	for i := range remaining {
+		remaining[i] = i
+	}
+	if area < 0 {
+		for i, j := 0, count-1; i < j; i, j = i+1, j-1 {
+			remaining[i], remaining[j] = remaining[j], remaining[i]
+		}
+	}
+
+	tris := make([][3]int, 0, count-2)
+
+	for len(remaining) > 3 {
+		ear := -1
+
+		for i := range remaining {
+			a := remaining[(i+len(remaining)-1)%len(remaining)]
+			b := remaining[i]
+			c := remaining[(i+1)%len(remaining)]
+
+			if isEar(pts, remaining, a, b, c) {
+				ear = i
+				break
+			}
+		}
+
+		// Degenerate polygons have no ears; fan the remainder.
+		if ear < 0 {
+			break
+		}
+
+		a := remaining[(ear+len(remaining)-1)%len(remaining)]
+		c := remaining[(ear+1)%len(remaining)]
+		tris = append(tris, orient([3]int{a, remaining[ear], c}, area < 0))
+
+		remaining = append(remaining[:ear], remaining[ear+1:]...)
+	}
+
+	for i := 1; i+1 < len(remaining); i++ {
+		tris = append(tris, orient([3]int{remaining[0], remaining[i], remaining[i+1]}, area < 0))
+	}
+
+	return tris
+}
+
+// orient restores the winding of the face for triangles clipped from a
+// reversed polygon.
+func orient(tri [3]int, reversed bool) [3]int {
+	if reversed {
+		return [3]int{tri[2], tri[1], tri[0]}
+	}
+
+	return tri
+}
+
+func isEar(pts []mgl32.Vec2, remaining []int, a, b, c int) bool {
+	pa, pb, pc := pts[a], pts[b], pts[c]
+
+	if cross2(pb.Sub(pa), pc.Sub(pb)) <= 0 {
+		return false
+	}
+
+	for _, i := range remaining {
+		if i == a || i == b || i == c {
+			continue
+		}
+		if inTriangle(pts[i], pa, pb, pc) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func inTriangle(p, a, b, c mgl32.Vec2) bool {
+	return cross2(b.Sub(a), p.Sub(a)) >= 0 &&
+		cross2(c.Sub(b), p.Sub(b)) >= 0 &&
+		cross2(a.Sub(c), p.Sub(c)) >= 0
+}
+
+func cross2(a, b mgl32.Vec2) float32 {
+	return a[0]*b[1] - a[1]*b[0]
+}
+
+func abs(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+
+	return f
+}
+
+func normalize(v mgl32.Vec3) mgl32.Vec3 {
+	if v.Len() == 0 {
+		return v
+	}
+
+	return v.Normalize()
+}
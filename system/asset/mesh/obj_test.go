@@ -0,0 +1,411 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mesh
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const epsilon = 1e-5
+
+// objTriangle is the positions of a triangle, prepended to OBJ files of
+// tests that only care about faces.
+const objTriangle = "v 0 0 0\nv 1 0 0\nv 0 1 0\n"
+
+func parseOBJ(t *testing.T, in string) *OBJ {
+	o, err := ParseOBJ(strings.NewReader(in), "tri")
+	if err != nil {
+		t.Fatalf("ParseOBJ: %v", err)
+	}
+
+	return o
+}
+
+func vec3Equal(a, b mgl32.Vec3) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > epsilon {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestParseOBJ_Indices(t *testing.T) {
+	const attribs = objTriangle + "vt 0 0\nvt 1 0\nvt 0 1\nvn 0 0 1\n"
+
+	var tests = []struct {
+		name string
+		in   string
+		v    []mgl32.Vec3
+		t    []mgl32.Vec2
+		n    []mgl32.Vec3
+		f    []Face
+	}{
+		{
+			name: "positive",
+			in:   attribs + "f 1/1/1 2/2/1 3/3/1",
+			v:    []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			t:    []mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}},
+			n:    []mgl32.Vec3{{0, 0, 1}},
+			f:    []Face{{{0, 0, 0}, {1, 1, 0}, {2, 2, 0}}},
+		},
+		{
+			name: "negative",
+			in:   attribs + "f -3/-3/-1 -2/-2/-1 -1/-1/-1",
+			v:    []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			t:    []mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}},
+			n:    []mgl32.Vec3{{0, 0, 1}},
+			f:    []Face{{{0, 0, 0}, {1, 1, 0}, {2, 2, 0}}},
+		},
+		{
+			name: "relative",
+			in:   "v 5 5 5\n" + objTriangle + "f -3 -2 -1\nv 9 9 9\nf -2 -3 -4",
+			v:    []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+			t:    []mgl32.Vec2{{0, 0}},
+			n:    []mgl32.Vec3{{0, 0, 1}, {0, 0, -1}},
+			f: []Face{
+				{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}},
+				{{2, 0, 1}, {1, 0, 1}, {0, 0, 1}},
+			},
+		},
+		{
+			name: "normals only",
+			in:   attribs + "f 3//1 1//1 2//1",
+			v:    []mgl32.Vec3{{0, 1, 0}, {0, 0, 0}, {1, 0, 0}},
+			t:    []mgl32.Vec2{{0, 0}},
+			n:    []mgl32.Vec3{{0, 0, 1}},
+			f:    []Face{{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}}},
+		},
+	}
+
+	for _, v := range tests {
+		o := parseOBJ(t, v.in)
+		if len(o.Meshes) != 1 {
+			t.Fatalf("%s: %d meshes, want 1", v.name, len(o.Meshes))
+		}
+
+		m := o.Meshes[0]
+		if len(m.V) != len(v.v) || len(m.T) != len(v.t) || len(m.N) != len(v.n) || len(m.F) != len(v.f) {
+			t.Errorf("%s: %d positions, %d uvs, %d normals, %d faces, want %d, %d, %d, %d", v.name,
+				len(m.V), len(m.T), len(m.N), len(m.F), len(v.v), len(v.t), len(v.n), len(v.f))
+			continue
+		}
+		for i := range v.v {
+			if m.V[i] != v.v[i] {
+				t.Errorf("%s: position %d = %v, want %v", v.name, i, m.V[i], v.v[i])
+			}
+		}
+		for i := range v.t {
+			if m.T[i] != v.t[i] {
+				t.Errorf("%s: uv %d = %v, want %v", v.name, i, m.T[i], v.t[i])
+			}
+		}
+		for i := range v.n {
+			if !vec3Equal(m.N[i], v.n[i]) {
+				t.Errorf("%s: normal %d = %v, want %v", v.name, i, m.N[i], v.n[i])
+			}
+		}
+		for i := range v.f {
+			if m.F[i] != v.f[i] {
+				t.Errorf("%s: face %d = %v, want %v", v.name, i, m.F[i], v.f[i])
+			}
+		}
+	}
+}
+
+func TestParseOBJ_Triangulate(t *testing.T) {
+	var tests = []struct {
+		name   string
+		in     string
+		area   float32
+		normal mgl32.Vec3
+	}{
+		{
+			name:   "quad",
+			in:     "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nf 1 2 3 4",
+			area:   1,
+			normal: mgl32.Vec3{0, 0, 1},
+		},
+		{
+			// A fan from the first vertex would fold over the notch.
+			name:   "concave",
+			in:     "v 0 0 0\nv 3 0 0\nv 3 2 0\nv 2 2 0\nv 2 1 0\nv 1 1 0\nv 1 2 0\nv 0 2 0\nf 1 2 3 4 5 6 7 8",
+			area:   5,
+			normal: mgl32.Vec3{0, 0, 1},
+		},
+		{
+			name:   "clockwise",
+			in:     "v 0 0 0\nv 0 2 0\nv 1 2 0\nv 1 1 0\nv 2 1 0\nv 2 0 0\nf 1 2 3 4 5 6",
+			area:   3,
+			normal: mgl32.Vec3{0, 0, -1},
+		},
+		{
+			name:   "vertical",
+			in:     "v 0 0 0\nv 0 0 1\nv 0 1 1\nv 0 1 0\nv 0 0.5 0.5\nf 1 2 3 4 5",
+			area:   0.75,
+			normal: mgl32.Vec3{-1, 0, 0},
+		},
+	}
+
+	for _, v := range tests {
+		m := parseOBJ(t, v.in).Meshes[0]
+
+		if want := strings.Count(v.in, "v ") - 2; len(m.F) != want {
+			t.Errorf("%s: %d triangles, want %d", v.name, len(m.F), want)
+		}
+
+		var area float32
+		for i, f := range m.F {
+			a, b, c := m.V[f[0][0]], m.V[f[1][0]], m.V[f[2][0]]
+			n := b.Sub(a).Cross(c.Sub(a))
+			if n.Dot(v.normal) <= 0 {
+				t.Errorf("%s: triangle %d faces %v, want %v", v.name, i, n, v.normal)
+			}
+			area += n.Len() / 2
+		}
+		if math.Abs(float64(area-v.area)) > epsilon {
+			t.Errorf("%s: area = %v, want %v", v.name, area, v.area)
+		}
+	}
+}
+
+func TestParseOBJ_Groups(t *testing.T) {
+	var tests = []struct {
+		in    string
+		names []string
+		faces []int
+	}{
+		{in: "g only\nf 1 2 3", names: []string{"tri"}, faces: []int{1}},
+		{in: "g a\ng b\nf 1 2 3", names: []string{"tri"}, faces: []int{1}},
+		{
+			in:    "f 1 2 3\no box\nf 1 2 3\ng lid\nf 1 2 3\ng default\nf 1 2 3",
+			names: []string{"tri", "tri/box", "tri/box/lid"},
+			faces: []int{1, 2, 1},
+		},
+		{
+			in:    "g a\nf 1 2 3\ng b\nf 1 2 3\ng a\nf 1 2 3",
+			names: []string{"tri/a", "tri/b"},
+			faces: []int{2, 1},
+		},
+		{
+			in:    "o one\nf 1 2 3\no two\ng a b\nf 1 2 3",
+			names: []string{"tri/one", "tri/two/a b"},
+			faces: []int{1, 1},
+		},
+	}
+
+	for i, v := range tests {
+		o := parseOBJ(t, objTriangle+v.in)
+
+		if len(o.Meshes) != len(v.names) {
+			t.Errorf("%d: %d meshes, want %d", i, len(o.Meshes), len(v.names))
+			continue
+		}
+		for j, m := range o.Meshes {
+			if m.Name != v.names[j] || len(m.F) != v.faces[j] {
+				t.Errorf("%d: mesh %d is %q with %d faces, want %q with %d", i, j, m.Name, len(m.F), v.names[j], v.faces[j])
+			}
+		}
+	}
+}
+
+func TestParseOBJ_Smoothing(t *testing.T) {
+	// Two triangles folded along the edge from vertex 1 to 2, facing +Z and
+	// -Y.
+	const folded = objTriangle + "v 0.5 0 -1\n"
+
+	s := float32(math.Sqrt(0.5))
+	shared := mgl32.Vec3{0, -s, s}
+	up := mgl32.Vec3{0, 0, 1}
+	down := mgl32.Vec3{0, -1, 0}
+
+	var tests = []struct {
+		name    string
+		in      string
+		normals int
+		want    [2][3]mgl32.Vec3
+	}{
+		{
+			name:    "smooth",
+			in:      "s 1\nf 1 2 3\nf 2 1 4",
+			normals: 4,
+			want:    [2][3]mgl32.Vec3{{shared, shared, up}, {shared, shared, down}},
+		},
+		{
+			name:    "flat",
+			in:      "s 1\ns off\nf 1 2 3\nf 2 1 4",
+			normals: 2,
+			want:    [2][3]mgl32.Vec3{{up, up, up}, {down, down, down}},
+		},
+		{
+			name:    "separate groups",
+			in:      "s 1\nf 1 2 3\ns 2\nf 2 1 4",
+			normals: 6,
+			want:    [2][3]mgl32.Vec3{{up, up, up}, {down, down, down}},
+		},
+		{
+			name:    "explicit",
+			in:      "vn 1 0 0\ns 1\nf 1//1 2//1 3//1\nf 2 1 4",
+			normals: 4,
+			want:    [2][3]mgl32.Vec3{{{1, 0, 0}, {1, 0, 0}, {1, 0, 0}}, {down, down, down}},
+		},
+	}
+
+	for _, v := range tests {
+		m := parseOBJ(t, folded+v.in).Meshes[0]
+
+		if len(m.N) != v.normals {
+			t.Errorf("%s: %d normals, want %d", v.name, len(m.N), v.normals)
+		}
+		for i, f := range m.F {
+			for j := range f {
+				if n := m.N[f[j][2]]; !vec3Equal(n, v.want[i][j]) {
+					t.Errorf("%s: face %d vertex %d normal = %v, want %v", v.name, i, j, n, v.want[i][j])
+				}
+			}
+		}
+	}
+}
+
+func TestParseOBJ_Materials(t *testing.T) {
+	const in = `# materials
+mtllib a.mtl b.mtl
+v 0 0 0
+v 1 0 0 # comment
+v 0 1 0
+usemtl red
+f 1 2 3
+usemtl blue
+f 1 \
+  2 3
+usemtl red
+f 1 2 3
+`
+
+	o := parseOBJ(t, in)
+
+	if strings.Join(o.Libraries, ",") != "a.mtl,b.mtl" {
+		t.Errorf("libraries = %v, want [a.mtl b.mtl]", o.Libraries)
+	}
+
+	m := o.Meshes[0]
+	if strings.Join(m.Materials, ",") != "red,blue" {
+		t.Errorf("materials = %v, want [red blue]", m.Materials)
+	}
+	if len(m.F) != 3 {
+		t.Errorf("%d faces, want 3", len(m.F))
+	}
+}
+
+func TestParseOBJ_Errors(t *testing.T) {
+	var tests = []string{
+		"f 1 2",
+		"f 1 2 4",
+		"f 0 1 2",
+		"f 1/1 2 3",
+		"f /1 2 3",
+		"f 1/1/1/1 2 3",
+		"f 1 2 x",
+		"v 1 2",
+		"v a b c",
+		"vn 0 0",
+		"s x",
+	}
+
+	for _, in := range tests {
+		if _, err := ParseOBJ(strings.NewReader(objTriangle+in), "tri"); err == nil {
+			t.Errorf("%q: no error", in)
+		}
+	}
+
+	if _, err := ParseOBJ(strings.NewReader(objTriangle), "tri"); err != ErrMeshMissingFaces {
+		t.Errorf("no faces: err = %v, want %v", err, ErrMeshMissingFaces)
+	}
+}
+
+func TestParseMTL(t *testing.T) {
+	const in = `# materials
+newmtl red
+Kd 1 0 0
+Ks 0.5
+Ns 98
+d 0.5
+map_Kd -bm 0.5 textures/red.png
+bump -bm 2 red_n.png
+
+newmtl brushed metal
+Pr 0.25
+Pm 1
+Tr 0.25
+map_Pm metal_m.png
+norm metal_n.png
+`
+
+	mats, err := ParseMTL(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []MTLMaterial{
+		{
+			Name:       "red",
+			Diffuse:    mgl32.Vec3{1, 0, 0},
+			Specular:   mgl32.Vec3{0.5, 0.5, 0.5},
+			Shininess:  98,
+			Dissolve:   0.5,
+			Roughness:  float32(math.Sqrt(2.0 / 100)),
+			DiffuseMap: "textures/red.png",
+			NormalMap:  "red_n.png",
+		},
+		{
+			Name:         "brushed metal",
+			Diffuse:      mgl32.Vec3{1, 1, 1},
+			Dissolve:     0.75,
+			Roughness:    0.25,
+			Metallic:     1,
+			NormalMap:    "metal_n.png",
+			MetallicMap:  "metal_m.png",
+			hasRoughness: true,
+		},
+	}
+
+	if len(mats) != len(want) {
+		t.Fatalf("%d materials, want %d", len(mats), len(want))
+	}
+	for i := range want {
+		if *mats[i] != want[i] {
+			t.Errorf("material %d = %+v, want %+v", i, *mats[i], want[i])
+		}
+	}
+
+	for _, in := range []string{"Kd 1 0 0", "newmtl a\nNs x", "newmtl a\nKd"} {
+		if _, err := ParseMTL(strings.NewReader(in)); err == nil {
+			t.Errorf("%q: no error", in)
+		}
+	}
+}
@@ -0,0 +1,573 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mesh
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/juju/errors"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/geometry"
+)
+
+// PLY is a parsed PLY file.
+type PLY struct {
+	// Mesh holds the vertices of the file with their normals, texture
+	// coordinates and colors when present, and the triangulated faces.
+	Mesh *geometry.Mesh
+
+	// Points is true for point clouds, files without faces. The mesh of a
+	// point cloud has no indices.
+	Points bool
+}
+
+type plyType struct {
+	name  string
+	size  int
+	float bool
+	max   float64 // Largest value of unsigned integer types, for normalizing.
+}
+
+var plyTypes = map[string]plyType{
+	"char":    {name: "int8", size: 1, max: math.MaxInt8},
+	"int8":    {name: "int8", size: 1, max: math.MaxInt8},
+	"uchar":   {name: "uint8", size: 1, max: math.MaxUint8},
+	"uint8":   {name: "uint8", size: 1, max: math.MaxUint8},
+	"short":   {name: "int16", size: 2, max: math.MaxInt16},
+	"int16":   {name: "int16", size: 2, max: math.MaxInt16},
+	"ushort":  {name: "uint16", size: 2, max: math.MaxUint16},
+	"uint16":  {name: "uint16", size: 2, max: math.MaxUint16},
+	"int":     {name: "int32", size: 4, max: math.MaxInt32},
+	"int32":   {name: "int32", size: 4, max: math.MaxInt32},
+	"uint":    {name: "uint32", size: 4, max: math.MaxUint32},
+	"uint32":  {name: "uint32", size: 4, max: math.MaxUint32},
+	"float":   {name: "float32", size: 4, float: true},
+	"float32": {name: "float32", size: 4, float: true},
+	"double":  {name: "float64", size: 8, float: true},
+	"float64": {name: "float64", size: 8, float: true},
+}
+
+type plyProperty struct {
+	name  string
+	typ   plyType
+	list  bool
+	count plyType
+}
+
+type plyElement struct {
+	name  string
+	count int
+	props []plyProperty
+}
+
+// maxPLYListLength limits the length of lists, such as the vertices of a
+// face.
+const maxPLYListLength = 1 << 16
+
+// plyValues reads the values of the body of a PLY file.
+type plyValues interface {
+	next(t plyType) (float64, error)
+
+	// size returns the fewest bytes a value of a type takes, and remaining
+	// the most bytes left to read.
+	size(t plyType) int
+	remaining() int
+}
+
+// ParsePLY parses a PLY file, in either its ASCII or binary forms. Faces are
+// triangulated as fans. The position, normal (nx, ny, nz), texture
+// coordinate (u, v or s, t) and color (red, green, blue, alpha) properties
+// of vertices are read; integer colors are normalized to the range of their
+// type. Other properties and elements are skipped.
+func ParsePLY(r io.Reader) (*PLY, error) {
+	br := bufio.NewReader(r)
+
+	elements, format, err := parsePLYHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var values plyValues
+	switch format {
+	case "ascii":
+		s := bufio.NewScanner(bytes.NewReader(body))
+		s.Buffer(make([]byte, 64*1024), 1024*1024)
+		s.Split(bufio.ScanWords)
+		values = &plyASCII{s: s, n: len(body)}
+	case "binary_little_endian":
+		values = &plyBinary{r: bytes.NewReader(body), order: binary.LittleEndian}
+	case "binary_big_endian":
+		values = &plyBinary{r: bytes.NewReader(body), order: binary.BigEndian}
+	default:
+		return nil, fmt.Errorf("ply: unsupported format %q", format)
+	}
+
+	p := &PLY{Mesh: &geometry.Mesh{}, Points: true}
+
+	for _, e := range elements {
+		// Elements are counted before their buffers are made.
+		if n := elementSize(values, e); e.count > values.remaining()/n {
+			return nil, fmt.Errorf("ply: %s: %d elements exceed the file", e.name, e.count)
+		}
+
+		var err error
+
+		switch e.name {
+		case "vertex":
+			err = readPLYVertices(values, e, p.Mesh)
+		case "face":
+			if e.count > 0 {
+				p.Points = false
+			}
+			err = readPLYFaces(values, e, p.Mesh)
+		default:
+			err = skipPLYElement(values, e)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("ply: %s: %v", e.name, err)
+		}
+	}
+
+	for _, i := range p.Mesh.Indices {
+		if int(i) >= len(p.Mesh.Positions) {
+			return nil, fmt.Errorf("ply: face: index out of range: %d", i)
+		}
+	}
+
+	if len(p.Mesh.Positions) == 0 {
+		return nil, fmt.Errorf("ply: no vertices")
+	}
+
+	return p, nil
+}
+
+// loadPLY loads a PLY file as a mesh named after the file. Meshes without
+// normals are given smooth normals. Point clouds are drawn as points, with
+// zero normals when the file has none.
+func (h *Handler) loadPLY(r *core.Resource) error {
+	name := trimExt(r.Base())
+
+	p, err := ParsePLY(r.Reader())
+	if err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	g := p.Mesh
+	if g.UVs == nil {
+		g.UVs = make([]mgl32.Vec2, len(g.Positions))
+	}
+
+	if p.Points {
+		if g.Normals == nil {
+			g.Normals = make([]mgl32.Vec3, len(g.Positions))
+		}
+
+		m := MakeMesh(g)
+		m.SetTopology(gfx.TopologyPoints)
+
+		return h.Add(name, m)
+	}
+
+	if g.Normals == nil {
+		g.SmoothNormals()
+	}
+	if err := g.Validate(); err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	if err := h.addGeometry(name, g, nil); err != nil {
+		return err
+	}
+
+	return h.addLODs(name, g, nil, nil)
+}
+
+// parsePLYHeader reads the header, returning its elements and the format of
+// the body.
+func parsePLYHeader(r *bufio.Reader) ([]*plyElement, string, error) {
+	var elements []*plyElement
+	var format string
+
+	for lineNo := 1; ; lineNo++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, "", fmt.Errorf("ply: header: %v", err)
+		}
+
+		fields := strings.Fields(line)
+
+		if lineNo == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return nil, "", fmt.Errorf("ply: not a ply file")
+			}
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				err = fmt.Errorf("invalid format: %s", line)
+				break
+			}
+			format = fields[1]
+		case "element":
+			if len(fields) != 3 {
+				err = fmt.Errorf("invalid element: %s", line)
+				break
+			}
+			var n int
+			if n, err = strconv.Atoi(fields[2]); err == nil && n < 0 {
+				err = fmt.Errorf("negative element count: %d", n)
+			}
+			elements = append(elements, &plyElement{name: fields[1], count: n})
+		case "property":
+			if len(elements) == 0 {
+				err = fmt.Errorf("property outside of an element")
+				break
+			}
+			var prop plyProperty
+			if prop, err = parsePLYProperty(fields[1:]); err == nil {
+				e := elements[len(elements)-1]
+				e.props = append(e.props, prop)
+			}
+		case "end_header":
+			if format == "" {
+				return nil, "", fmt.Errorf("ply: header has no format")
+			}
+			return elements, format, nil
+		}
+
+		if err != nil {
+			return nil, "", fmt.Errorf("ply: line %d: %v", lineNo, err)
+		}
+	}
+}
+
+func parsePLYProperty(args []string) (plyProperty, error) {
+	var p plyProperty
+	var ok bool
+
+	if len(args) == 4 && args[0] == "list" {
+		p.list = true
+		p.name = args[3]
+		if p.count, ok = plyTypes[args[1]]; !ok || p.count.float {
+			return p, fmt.Errorf("invalid list count type: %s", args[1])
+		}
+		if p.typ, ok = plyTypes[args[2]]; !ok {
+			return p, fmt.Errorf("invalid type: %s", args[2])
+		}
+		return p, nil
+	}
+
+	if len(args) != 2 {
+		return p, fmt.Errorf("invalid property: %s", strings.Join(args, " "))
+	}
+
+	p.name = args[1]
+	if p.typ, ok = plyTypes[args[0]]; !ok {
+		return p, fmt.Errorf("invalid type: %s", args[0])
+	}
+
+	return p, nil
+}
+
+func readPLYVertices(values plyValues, e *plyElement, g *geometry.Mesh) error {
+	index := make(map[string]int, len(e.props))
+	for i, prop := range e.props {
+		if !prop.list {
+			index[prop.name] = i
+		}
+	}
+
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := index[name]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !has("x", "y", "z") {
+		return fmt.Errorf("missing position")
+	}
+
+	u, v := "u", "v"
+	if !has(u, v) {
+		u, v = "s", "t"
+	}
+	if !has(u, v) {
+		u, v = "texture_u", "texture_v"
+	}
+
+	normals := has("nx", "ny", "nz")
+	uvs := has(u, v)
+	colors := has("red", "green", "blue")
+
+	g.Positions = make([]mgl32.Vec3, e.count)
+	if normals {
+		g.Normals = make([]mgl32.Vec3, e.count)
+	}
+	if uvs {
+		g.UVs = make([]mgl32.Vec2, e.count)
+	}
+	if colors {
+		g.Colors = make([]mgl32.Vec4, e.count)
+	}
+
+	row := make([]float64, len(e.props))
+
+	for i := 0; i < e.count; i++ {
+		for j, prop := range e.props {
+			var err error
+			if prop.list {
+				err = skipPLYList(values, prop)
+			} else {
+				row[j], err = values.next(prop.typ)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		get := func(name string) float32 {
+			return float32(row[index[name]])
+		}
+		color := func(name string) float32 {
+			prop := e.props[index[name]]
+			if prop.typ.float {
+				return float32(row[index[name]])
+			}
+			return float32(row[index[name]] / prop.typ.max)
+		}
+
+		g.Positions[i] = mgl32.Vec3{get("x"), get("y"), get("z")}
+		if normals {
+			g.Normals[i] = mgl32.Vec3{get("nx"), get("ny"), get("nz")}
+		}
+		if uvs {
+			g.UVs[i] = mgl32.Vec2{get(u), get(v)}
+		}
+		if colors {
+			g.Colors[i] = mgl32.Vec4{color("red"), color("green"), color("blue"), 1}
+			if has("alpha") {
+				g.Colors[i][3] = color("alpha")
+			}
+		}
+	}
+
+	return nil
+}
+
+func readPLYFaces(values plyValues, e *plyElement, g *geometry.Mesh) error {
+	for i := 0; i < e.count; i++ {
+		for _, prop := range e.props {
+			if !prop.list || (prop.name != "vertex_indices" && prop.name != "vertex_index") {
+				if err := skipPLYProperty(values, prop); err != nil {
+					return err
+				}
+				continue
+			}
+
+			n, err := listLength(values, prop)
+			if err != nil {
+				return err
+			}
+
+			poly := make([]uint32, n)
+			for j := range poly {
+				idx, err := values.next(prop.typ)
+				if err != nil {
+					return err
+				}
+				if idx < 0 {
+					return fmt.Errorf("negative index: %v", idx)
+				}
+				poly[j] = uint32(idx)
+			}
+
+			for j := 2; j < len(poly); j++ {
+				g.Indices = append(g.Indices, poly[0], poly[j-1], poly[j])
+			}
+		}
+	}
+
+	return nil
+}
+
+func skipPLYElement(values plyValues, e *plyElement) error {
+	for i := 0; i < e.count; i++ {
+		for _, prop := range e.props {
+			if err := skipPLYProperty(values, prop); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func skipPLYProperty(values plyValues, prop plyProperty) error {
+	if prop.list {
+		return skipPLYList(values, prop)
+	}
+
+	_, err := values.next(prop.typ)
+	return err
+}
+
+func skipPLYList(values plyValues, prop plyProperty) error {
+	n, err := listLength(values, prop)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		if _, err := values.next(prop.typ); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listLength reads the length of a list, which must be an integer between
+// 0 and maxPLYListLength.
+func listLength(values plyValues, prop plyProperty) (int, error) {
+	n, err := values.next(prop.count)
+	if err != nil {
+		return 0, err
+	}
+	if !(n >= 0 && n <= maxPLYListLength) || n != math.Trunc(n) {
+		return 0, fmt.Errorf("invalid list length: %v", n)
+	}
+
+	return int(n), nil
+}
+
+// elementSize returns the fewest bytes an element takes, and at least 1.
+func elementSize(values plyValues, e *plyElement) int {
+	n := 0
+	for _, prop := range e.props {
+		if prop.list {
+			n += values.size(prop.count)
+		} else {
+			n += values.size(prop.typ)
+		}
+	}
+
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
+
+type plyASCII struct {
+	s *bufio.Scanner
+	n int // Length of the body.
+}
+
+// size returns 1, the length of a value of a single digit. Values are
+// separated by at least a byte, but the last may not be.
+func (a *plyASCII) size(plyType) int {
+	return 1
+}
+
+// remaining returns the length of the body, as the scanner reads ahead.
+func (a *plyASCII) remaining() int {
+	return a.n
+}
+
+func (a *plyASCII) next(t plyType) (float64, error) {
+	if !a.s.Scan() {
+		if err := a.s.Err(); err != nil {
+			return 0, err
+		}
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	return strconv.ParseFloat(a.s.Text(), 64)
+}
+
+type plyBinary struct {
+	r     *bytes.Reader
+	order binary.ByteOrder
+	buf   [8]byte
+}
+
+func (b *plyBinary) size(t plyType) int {
+	return t.size
+}
+
+func (b *plyBinary) remaining() int {
+	return b.r.Len()
+}
+
+func (b *plyBinary) next(t plyType) (float64, error) {
+	buf := b.buf[:t.size]
+	if _, err := io.ReadFull(b.r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	switch t.name {
+	case "int8":
+		return float64(int8(buf[0])), nil
+	case "uint8":
+		return float64(buf[0]), nil
+	case "int16":
+		return float64(int16(b.order.Uint16(buf))), nil
+	case "uint16":
+		return float64(b.order.Uint16(buf)), nil
+	case "int32":
+		return float64(int32(b.order.Uint32(buf))), nil
+	case "uint32":
+		return float64(b.order.Uint32(buf)), nil
+	case "float32":
+		return float64(math.Float32frombits(b.order.Uint32(buf))), nil
+	}
+
+	return math.Float64frombits(b.order.Uint64(buf)), nil
+}
@@ -0,0 +1,242 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const asciiPLY = `ply
+format ascii 1.0
+comment a quad with a skipped element
+element vertex 4
+property float x
+property float y
+property float z
+property float nx
+property float ny
+property float nz
+property float s
+property float t
+property list uchar int tags
+property uchar red
+property uchar green
+property uchar blue
+element face 1
+property uchar flags
+property list uchar int vertex_indices
+element material 1
+property float shininess
+end_header
+0 0 0 0 0 1 0 0 0 255 0 0
+1 0 0 0 0 1 1 0 2 7 8 0 255 0
+1 1 0 0 0 1 1 1 0 0 0 255
+0 1 0 0 0 1 0 1 1 9 255 255 255
+3 4 0 1 2 3
+0.5
+`
+
+// binaryPLY encodes a quad split in two triangles, with double positions
+// and ushort colors with alpha.
+func binaryPLY(format string, order binary.ByteOrder) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("ply\nformat " + format + " 1.0\n" +
+		"element vertex 4\nproperty double x\nproperty double y\nproperty double z\n" +
+		"property float texture_u\nproperty float texture_v\n" +
+		"property ushort red\nproperty ushort green\nproperty ushort blue\nproperty ushort alpha\n" +
+		"element face 2\nproperty list uchar uint vertex_index\nend_header\n")
+
+	positions := [][3]float64{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}
+	for i, p := range positions {
+		binary.Write(&b, order, p)
+		binary.Write(&b, order, [2]float32{float32(p[0]), float32(p[1])})
+		binary.Write(&b, order, [4]uint16{65535, uint16(i * 21845), 0, 65535})
+	}
+	for _, f := range [][3]uint32{{0, 1, 2}, {0, 2, 3}} {
+		b.WriteByte(3)
+		binary.Write(&b, order, f)
+	}
+
+	return b.Bytes()
+}
+
+func vec4Equal(a, b mgl32.Vec4) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > epsilon {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestParsePLY(t *testing.T) {
+	quad := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}
+	quadUVs := []mgl32.Vec2{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+	quadIndices := []uint32{0, 1, 2, 0, 2, 3}
+	binaryColors := []mgl32.Vec4{{1, 0, 0, 1}, {1, 1.0 / 3, 0, 1}, {1, 2.0 / 3, 0, 1}, {1, 1, 0, 1}}
+
+	var tests = []struct {
+		name    string
+		in      []byte
+		points  bool
+		normals []mgl32.Vec3
+		uvs     []mgl32.Vec2
+		colors  []mgl32.Vec4
+		indices []uint32
+	}{
+		{
+			name:    "ascii",
+			in:      []byte(asciiPLY),
+			normals: []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+			uvs:     quadUVs,
+			colors:  []mgl32.Vec4{{1, 0, 0, 1}, {0, 1, 0, 1}, {0, 0, 1, 1}, {1, 1, 1, 1}},
+			indices: quadIndices,
+		},
+		{
+			name:    "binary little endian",
+			in:      binaryPLY("binary_little_endian", binary.LittleEndian),
+			uvs:     quadUVs,
+			colors:  binaryColors,
+			indices: quadIndices,
+		},
+		{
+			name:    "binary big endian",
+			in:      binaryPLY("binary_big_endian", binary.BigEndian),
+			uvs:     quadUVs,
+			colors:  binaryColors,
+			indices: quadIndices,
+		},
+		{
+			name:   "point cloud",
+			in:     []byte("ply\nformat ascii 1.0\nelement vertex 4\nproperty float x\nproperty float y\nproperty float z\nend_header\n0 0 0\n1 0 0\n1 1 0\n0 1 0\n"),
+			points: true,
+		},
+		{
+			name:   "empty faces",
+			in:     []byte("ply\nformat ascii 1.0\nelement vertex 4\nproperty float x\nproperty float y\nproperty float z\nelement face 0\nproperty list uchar int vertex_indices\nend_header\n0 0 0\n1 0 0\n1 1 0\n0 1 0\n"),
+			points: true,
+		},
+	}
+
+	for _, v := range tests {
+		p, err := ParsePLY(bytes.NewReader(v.in))
+		if err != nil {
+			t.Errorf("%s: %v", v.name, err)
+			continue
+		}
+
+		g := p.Mesh
+		if p.Points != v.points {
+			t.Errorf("%s: points = %v, want %v", v.name, p.Points, v.points)
+		}
+		if len(g.Positions) != len(quad) {
+			t.Errorf("%s: %d vertices, want %d", v.name, len(g.Positions), len(quad))
+			continue
+		}
+		for i := range quad {
+			if g.Positions[i] != quad[i] {
+				t.Errorf("%s: position %d = %v, want %v", v.name, i, g.Positions[i], quad[i])
+			}
+		}
+
+		if len(g.Normals) != len(v.normals) || len(g.UVs) != len(v.uvs) || len(g.Colors) != len(v.colors) || len(g.Indices) != len(v.indices) {
+			t.Errorf("%s: %d normals, %d uvs, %d colors, %d indices, want %d, %d, %d, %d", v.name,
+				len(g.Normals), len(g.UVs), len(g.Colors), len(g.Indices), len(v.normals), len(v.uvs), len(v.colors), len(v.indices))
+			continue
+		}
+		for i := range v.normals {
+			if g.Normals[i] != v.normals[i] {
+				t.Errorf("%s: normal %d = %v, want %v", v.name, i, g.Normals[i], v.normals[i])
+			}
+		}
+		for i := range v.uvs {
+			if g.UVs[i] != v.uvs[i] {
+				t.Errorf("%s: uv %d = %v, want %v", v.name, i, g.UVs[i], v.uvs[i])
+			}
+		}
+		for i := range v.colors {
+			if !vec4Equal(g.Colors[i], v.colors[i]) {
+				t.Errorf("%s: color %d = %v, want %v", v.name, i, g.Colors[i], v.colors[i])
+			}
+		}
+		for i := range v.indices {
+			if g.Indices[i] != v.indices[i] {
+				t.Errorf("%s: index %d = %v, want %v", v.name, i, g.Indices[i], v.indices[i])
+			}
+		}
+	}
+}
+
+func TestParsePLY_Errors(t *testing.T) {
+	const header = "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\n"
+	const vertices = "0 0 0\n1 0 0\n0 1 0\n"
+
+	var tests = []string{
+		"",
+		"plyx\nformat ascii 1.0\nend_header\n",
+		"ply\nend_header\n",
+		"ply\nformat binary_middle_endian 1.0\nend_header\n",
+		"ply\nformat ascii 1.0\nproperty float x\nend_header\n",
+		"ply\nformat ascii 1.0\nelement vertex -1\nend_header\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty half x\nend_header\n",
+		"ply\nformat ascii 1.0\nelement face 1\nproperty list float int vertex_indices\nend_header\n",
+		"ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nend_header\n0 0\n",
+		"ply\nformat ascii 1.0\nelement vertex 0\nproperty float x\nproperty float y\nproperty float z\nend_header\n",
+		header + "end_header\n0 0 0\n1 0 0\n",
+		header + "end_header\n0 0 0\n1 x 0\n0 1 0\n",
+		header + "element face 1\nproperty list uchar int vertex_indices\nend_header\n" + vertices + "3 0 1 3\n",
+		header + "element face 1\nproperty list uchar int vertex_indices\nend_header\n" + vertices + "3 0 1 -2\n",
+		header + "element face 1\nproperty list uchar int vertex_indices\nend_header\n" + vertices + "3 0 1\n",
+		strings.Replace(string(binaryPLY("binary_little_endian", binary.LittleEndian)), "element face 2", "element face 3", 1),
+
+		// Invalid list lengths.
+		header + "element face 1\nproperty list uchar int vertex_indices\nend_header\n" + vertices + "-1 0 1 2\n",
+		header + "element face 1\nproperty list uchar int vertex_indices\nend_header\n" + vertices + "nan 0 1 2\n",
+		header + "element face 1\nproperty list uchar int vertex_indices\nend_header\n" + vertices + "2.5 0 1 2\n",
+		header + "element face 1\nproperty list uchar int vertex_indices\nend_header\n" + vertices + "1e300 0 1 2\n",
+		header + "element face 1\nproperty list uint int vertex_indices\nend_header\n" + vertices + "4294967295 0 1 2\n",
+		header + "property list uchar int tags\nend_header\n0 0 0 -1\n1 0 0 0\n0 1 0 0\n",
+		header + "property list uchar int tags\nend_header\n0 0 0 inf\n1 0 0 0\n0 1 0 0\n",
+
+		// Element counts beyond the size of the file.
+		"ply\nformat ascii 1.0\nelement vertex 1000000000000\nproperty float x\nproperty float y\nproperty float z\nend_header\n0 0 0\n",
+		header + "element point 1000000000000000000\nend_header\n" + vertices,
+		strings.Replace(string(binaryPLY("binary_little_endian", binary.LittleEndian)), "element vertex 4", "element vertex 4000000000", 1),
+		strings.Replace(string(binaryPLY("binary_big_endian", binary.BigEndian)), "element face 2", "element face 2000000000", 1),
+	}
+
+	for i, in := range tests {
+		if _, err := ParsePLY(strings.NewReader(in)); err == nil {
+			t.Errorf("%d: no error", i)
+		}
+	}
+}
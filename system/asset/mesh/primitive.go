@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mesh
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/juju/errors"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/geometry"
+	"github.com/haakenlabs/ember/system/renderer"
+)
+
+// Primitive shapes.
+const (
+	ShapeQuad      = "quad"
+	ShapePlane     = "plane"
+	ShapeCube      = "cube"
+	ShapeUVSphere  = "uv_sphere"
+	ShapeIcosphere = "icosphere"
+	ShapeCylinder  = "cylinder"
+	ShapeCone      = "cone"
+	ShapeCapsule   = "capsule"
+	ShapeTorus     = "torus"
+)
+
+// Primitive describes a procedurally generated mesh. Dimensions which are
+// not given default to those of a shape fitting in a unit cube.
+type Primitive struct {
+	Name  string `json:"name"`
+	Shape string `json:"shape"`
+
+	Width  float32 `json:"width"`
+	Height float32 `json:"height"`
+	Depth  float32 `json:"depth"`
+	Size   float32 `json:"size"`
+	Radius float32 `json:"radius"`
+	Tube   float32 `json:"tube"`
+
+	Segments     int `json:"segments"`
+	Rings        int `json:"rings"`
+	Sides        int `json:"sides"`
+	Subdivisions int `json:"subdivisions"`
+}
+
+// Geometry generates the geometry of the primitive.
+func (p *Primitive) Geometry() (*geometry.Mesh, error) {
+	switch p.Shape {
+	case ShapeQuad:
+		return geometry.Quad(or(p.Width, 1), or(p.Height, 1)), nil
+	case ShapePlane:
+		return geometry.Plane(or(p.Width, 1), or(p.Depth, 1), p.Subdivisions), nil
+	case ShapeCube:
+		return geometry.Cube(or(p.Size, 1)), nil
+	case ShapeUVSphere:
+		return geometry.UVSphere(or(p.Radius, 0.5), orInt(p.Segments, 32), orInt(p.Rings, 16)), nil
+	case ShapeIcosphere:
+		return geometry.Icosphere(or(p.Radius, 0.5), orInt(p.Subdivisions, 3)), nil
+	case ShapeCylinder:
+		return geometry.Cylinder(or(p.Radius, 0.5), or(p.Height, 1), orInt(p.Segments, 32)), nil
+	case ShapeCone:
+		return geometry.Cone(or(p.Radius, 0.5), or(p.Height, 1), orInt(p.Segments, 32)), nil
+	case ShapeCapsule:
+		return geometry.Capsule(or(p.Radius, 0.25), or(p.Height, 1), orInt(p.Segments, 32), orInt(p.Rings, 8)), nil
+	case ShapeTorus:
+		return geometry.Torus(or(p.Radius, 0.375), or(p.Tube, 0.125), orInt(p.Segments, 32), orInt(p.Sides, 16)), nil
+	}
+
+	return nil, fmt.Errorf("unknown primitive shape: %s", p.Shape)
+}
+
+// MakeMesh creates a mesh from the geometry. The mesh is not allocated.
+func MakeMesh(g *geometry.Mesh) gfx.Mesh {
+	m := renderer.MakeMesh()
+
+	m.SetVertices(g.Positions)
+	m.SetNormals(g.Normals)
+	m.SetUVs(g.UVs)
+	m.SetColors(g.Colors)
+	m.SetTangents(g.Tangents)
+	m.SetUV2s(g.UV2s)
+	m.SetJoints(g.Joints)
+	m.SetWeights(g.Weights)
+	m.SetMorphTargets(g.Targets)
+	m.SetTriangles(g.Indices)
+
+	return m
+}
+
+// AddPrimitive generates a primitive and adds it to the handler.
+func (h *Handler) AddPrimitive(p *Primitive) error {
+	if p.Name == "" {
+		return errors.New("primitive has no name")
+	}
+
+	g, err := p.Geometry()
+	if err != nil {
+		return errors.Annotate(err, p.Name)
+	}
+
+	return h.Add(p.Name, MakeMesh(g))
+}
+
+// loadPrimitive loads a primitive description.
+func (h *Handler) loadPrimitive(r *core.Resource) error {
+	p := &Primitive{}
+
+	if err := json.Unmarshal(r.Bytes(), p); err != nil {
+		return err
+	}
+
+	return h.AddPrimitive(p)
+}
+
+func or(v, fallback float32) float32 {
+	if v <= 0 {
+		return fallback
+	}
+
+	return v
+}
+
+func orInt(v, fallback int) int {
+	if v <= 0 {
+		return fallback
+	}
+
+	return v
+}
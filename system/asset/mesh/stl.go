@@ -0,0 +1,243 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mesh
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/juju/errors"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/pkg/geometry"
+)
+
+const (
+	stlHeaderSize = 84
+	stlFacetSize  = 50
+
+	// stlColorValid marks the attribute of a binary facet as holding a
+	// VisCAM/SolidView color.
+	stlColorValid = 1 << 15
+)
+
+// ParseSTL parses an STL file, either in its ASCII or binary form. Facet
+// normals missing from the file are generated from the winding of the facet,
+// and the colors of binary files in the VisCAM/SolidView convention are
+// kept. Vertices shared by facets of the same normal are welded, so the
+// mesh keeps the flat shading of the facets. STL files have no texture
+// coordinates, so those of every vertex are zero.
+func ParseSTL(data []byte) (*geometry.Mesh, error) {
+	var g *geometry.Mesh
+	var err error
+
+	if isBinarySTL(data) {
+		g, err = parseBinarySTL(data)
+	} else {
+		g, err = parseASCIISTL(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(g.Positions) == 0 {
+		return nil, ErrMeshMissingFaces
+	}
+
+	g.UVs = make([]mgl32.Vec2, len(g.Positions))
+	if _, err := g.Weld(0); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// loadSTL loads an STL file as a mesh named after the file.
+func (h *Handler) loadSTL(r *core.Resource) error {
+	name := trimExt(r.Base())
+
+	g, err := ParseSTL(r.Bytes())
+	if err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	if err := h.addGeometry(name, g, nil); err != nil {
+		return err
+	}
+
+	return h.addLODs(name, g, nil, nil)
+}
+
+// isBinarySTL reports whether the data is a binary STL file. Binary files
+// may also begin with "solid", so the size given by the facet count of the
+// header is checked first.
+func isBinarySTL(data []byte) bool {
+	if len(data) >= stlHeaderSize {
+		n := binary.LittleEndian.Uint32(data[80:stlHeaderSize])
+		if uint64(len(data)) == stlHeaderSize+uint64(n)*stlFacetSize {
+			return true
+		}
+	}
+
+	return !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid"))
+}
+
+func parseBinarySTL(data []byte) (*geometry.Mesh, error) {
+	if len(data) < stlHeaderSize {
+		return nil, fmt.Errorf("stl: file too short")
+	}
+
+	n := int(binary.LittleEndian.Uint32(data[80:stlHeaderSize]))
+	if len(data) < stlHeaderSize+n*stlFacetSize {
+		return nil, fmt.Errorf("stl: %d facets in a file of %d bytes", n, len(data))
+	}
+
+	g := &geometry.Mesh{
+		Positions: make([]mgl32.Vec3, 0, n*3),
+		Normals:   make([]mgl32.Vec3, 0, n*3),
+	}
+	colors := make([]mgl32.Vec4, 0, n*3)
+	colored := false
+
+	for i := 0; i < n; i++ {
+		facet := data[stlHeaderSize+i*stlFacetSize:]
+
+		var v [4]mgl32.Vec3
+		for j := range v {
+			for k := 0; k < 3; k++ {
+				v[j][k] = math.Float32frombits(binary.LittleEndian.Uint32(facet[j*12+k*4:]))
+			}
+		}
+
+		addFacet(g, v[0], v[1], v[2], v[3])
+
+		c := mgl32.Vec4{1, 1, 1, 1}
+		if attr := binary.LittleEndian.Uint16(facet[48:]); attr&stlColorValid != 0 {
+			c = mgl32.Vec4{
+				float32(attr>>10&0x1f) / 31,
+				float32(attr>>5&0x1f) / 31,
+				float32(attr&0x1f) / 31,
+				1,
+			}
+			colored = true
+		}
+		colors = append(colors, c, c, c)
+	}
+
+	if colored {
+		g.Colors = colors
+	}
+
+	return g, nil
+}
+
+func parseASCIISTL(data []byte) (*geometry.Mesh, error) {
+	g := &geometry.Mesh{}
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var normal mgl32.Vec3
+	var facet []mgl32.Vec3
+	var lineNo int
+
+	for s.Scan() {
+		lineNo++
+
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+
+		switch fields[0] {
+		case "facet":
+			facet = facet[:0]
+			if len(fields) != 5 || fields[1] != "normal" {
+				err = fmt.Errorf("invalid facet: %s", s.Text())
+				break
+			}
+			normal, err = parseSTLVec3(fields[2:])
+		case "vertex":
+			var v mgl32.Vec3
+			if v, err = parseSTLVec3(fields[1:]); err == nil {
+				facet = append(facet, v)
+			}
+		case "endloop":
+			if len(facet) != 3 {
+				err = fmt.Errorf("facet has %d vertices", len(facet))
+				break
+			}
+			addFacet(g, normal, facet[0], facet[1], facet[2])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("stl: line %d: %v", lineNo, err)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func parseSTLVec3(args []string) (mgl32.Vec3, error) {
+	var v mgl32.Vec3
+
+	if len(args) != 3 {
+		return v, fmt.Errorf("expected 3 values, got %d", len(args))
+	}
+
+	for i, arg := range args {
+		f, err := strconv.ParseFloat(arg, 32)
+		if err != nil {
+			return v, err
+		}
+		v[i] = float32(f)
+	}
+
+	return v, nil
+}
+
+// addFacet appends a facet to the mesh. Facets without a normal take the
+// normal of their counter-clockwise winding.
+func addFacet(g *geometry.Mesh, normal, a, b, c mgl32.Vec3) {
+	if normal.Len() == 0 {
+		normal = b.Sub(a).Cross(c.Sub(a))
+	}
+	if l := normal.Len(); l != 0 {
+		normal = normal.Mul(1 / l)
+	}
+
+	g.Positions = append(g.Positions, a, b, c)
+	g.Normals = append(g.Normals, normal, normal, normal)
+}
@@ -0,0 +1,220 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mesh
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type stlFacet struct {
+	normal mgl32.Vec3
+	v      [3]mgl32.Vec3
+	attr   uint16
+}
+
+// quadFacets are two facets of a unit quad facing +Z, sharing an edge.
+var quadFacets = []stlFacet{
+	{normal: mgl32.Vec3{0, 0, 1}, v: [3]mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}}},
+	{normal: mgl32.Vec3{0, 0, 1}, v: [3]mgl32.Vec3{{0, 0, 0}, {1, 1, 0}, {0, 1, 0}}},
+}
+
+const asciiQuad = `solid quad
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 1 1 0
+    endloop
+  endfacet
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 1 0
+      vertex 0 1 0
+    endloop
+  endfacet
+endsolid quad
+`
+
+// binarySTL encodes facets as a binary STL file with the given header.
+func binarySTL(header string, facets []stlFacet) []byte {
+	data := make([]byte, stlHeaderSize+len(facets)*stlFacetSize)
+	copy(data, header)
+	binary.LittleEndian.PutUint32(data[80:], uint32(len(facets)))
+
+	for i, f := range facets {
+		b := data[stlHeaderSize+i*stlFacetSize:]
+		for j, v := range append([]mgl32.Vec3{f.normal}, f.v[:]...) {
+			for k := range v {
+				binary.LittleEndian.PutUint32(b[j*12+k*4:], math.Float32bits(v[k]))
+			}
+		}
+		binary.LittleEndian.PutUint16(b[48:], f.attr)
+	}
+
+	return data
+}
+
+func TestParseSTL(t *testing.T) {
+	red := uint16(stlColorValid | 0x1f<<10)
+	folded := []stlFacet{
+		quadFacets[0],
+		{v: [3]mgl32.Vec3{{0, 0, 0}, {1, 1, 0}, {0, 1, 1}}},
+	}
+
+	var tests = []struct {
+		name     string
+		in       []byte
+		vertices int
+		normals  []mgl32.Vec3
+		colors   []mgl32.Vec4
+	}{
+		{
+			name:     "ascii",
+			in:       []byte(asciiQuad),
+			vertices: 4,
+			normals:  []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}},
+		},
+		{
+			name:     "ascii generated normals",
+			in:       []byte("solid\nfacet normal 0 0 0\nouter loop\nvertex 0 0 0\nvertex 0 1 0\nvertex 1 0 0\nendloop\nendfacet\nendsolid\n"),
+			vertices: 3,
+			normals:  []mgl32.Vec3{{0, 0, -1}},
+		},
+		{
+			name:     "ascii unnormalized normals",
+			in:       []byte("solid\nfacet normal 0 0 5\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 0 1 0\nendloop\nendfacet\nendsolid\n"),
+			vertices: 3,
+			normals:  []mgl32.Vec3{{0, 0, 1}},
+		},
+		{
+			name:     "binary",
+			in:       binarySTL("quad", quadFacets),
+			vertices: 4,
+			normals:  []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}},
+		},
+		{
+			name:     "binary solid header",
+			in:       binarySTL("solid quad", quadFacets),
+			vertices: 4,
+			normals:  []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}},
+		},
+		{
+			name:     "binary folded",
+			in:       binarySTL("folded", folded),
+			vertices: 6,
+			normals:  []mgl32.Vec3{{0, 0, 1}, {float32(math.Sqrt(1.0 / 3)), float32(-math.Sqrt(1.0 / 3)), float32(math.Sqrt(1.0 / 3))}},
+		},
+		{
+			name: "binary colors",
+			in: binarySTL("colors", []stlFacet{
+				{normal: quadFacets[0].normal, v: quadFacets[0].v, attr: red},
+				{normal: quadFacets[1].normal, v: quadFacets[1].v, attr: 0x1f},
+			}),
+			vertices: 6,
+			normals:  []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}},
+			colors:   []mgl32.Vec4{{1, 0, 0, 1}, {1, 1, 1, 1}},
+		},
+	}
+
+	for _, v := range tests {
+		g, err := ParseSTL(v.in)
+		if err != nil {
+			t.Errorf("%s: %v", v.name, err)
+			continue
+		}
+
+		if len(g.Positions) != v.vertices {
+			t.Errorf("%s: %d vertices, want %d", v.name, len(g.Positions), v.vertices)
+		}
+		if len(g.UVs) != len(g.Positions) {
+			t.Errorf("%s: %d uvs, want %d", v.name, len(g.UVs), len(g.Positions))
+		}
+		if len(g.Indices) != len(v.normals)*3 {
+			t.Errorf("%s: %d indices, want %d", v.name, len(g.Indices), len(v.normals)*3)
+			continue
+		}
+		if (g.Colors != nil) != (v.colors != nil) {
+			t.Errorf("%s: colors = %v, want %v", v.name, g.Colors, v.colors)
+			continue
+		}
+
+		for i, idx := range g.Indices {
+			if n := g.Normals[idx]; !vec3Equal(n, v.normals[i/3]) {
+				t.Errorf("%s: normal of index %d = %v, want %v", v.name, i, n, v.normals[i/3])
+			}
+			if v.colors != nil && g.Colors[idx] != v.colors[i/3] {
+				t.Errorf("%s: color of index %d = %v, want %v", v.name, i, g.Colors[idx], v.colors[i/3])
+			}
+		}
+	}
+}
+
+func TestIsBinarySTL(t *testing.T) {
+	var tests = []struct {
+		in   []byte
+		want bool
+	}{
+		{in: []byte(asciiQuad), want: false},
+		{in: []byte("\n  solid quad\nendsolid quad\n"), want: false},
+		{in: binarySTL("quad", quadFacets), want: true},
+		{in: binarySTL("solid quad", quadFacets), want: true},
+		{in: binarySTL("solid quad", quadFacets)[:stlHeaderSize+stlFacetSize], want: false},
+		{in: []byte("quad"), want: true},
+	}
+
+	for i, v := range tests {
+		if got := isBinarySTL(v.in); got != v.want {
+			t.Errorf("%d: isBinarySTL = %v, want %v", i, got, v.want)
+		}
+	}
+}
+
+func TestParseSTL_Errors(t *testing.T) {
+	var tests = []string{
+		"solid\nfacet normal 0 0\nendsolid\n",
+		"solid\nfacet 0 0 1\nendsolid\n",
+		"solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0\nendloop\nendfacet\nendsolid\n",
+		"solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 x\nendloop\nendfacet\nendsolid\n",
+		"solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nendloop\nendfacet\nendsolid\n",
+		"quad",
+		string(binarySTL("quad", quadFacets)[:stlHeaderSize+stlFacetSize]),
+	}
+
+	for i, in := range tests {
+		if _, err := ParseSTL([]byte(in)); err == nil {
+			t.Errorf("%d: no error", i)
+		}
+	}
+
+	if _, err := ParseSTL([]byte("solid empty\nendsolid empty\n")); err != ErrMeshMissingFaces {
+		t.Errorf("no facets: err = %v, want %v", err, ErrMeshMissingFaces)
+	}
+	if _, err := ParseSTL(binarySTL("empty", nil)); err != ErrMeshMissingFaces {
+		t.Errorf("no binary facets: err = %v, want %v", err, ErrMeshMissingFaces)
+	}
+}
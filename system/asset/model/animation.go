@@ -0,0 +1,286 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package model
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/pkg/animation"
+	"github.com/haakenlabs/ember/pkg/gltf"
+	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/asset/skeleton"
+
+	animasset "github.com/haakenlabs/ember/system/asset/animation"
+)
+
+// loadSkeletons creates a skeleton for each skin of the document. Skins
+// whose joints are not ordered parents first are skipped, leaving their
+// meshes unskinned.
+func (l *loader) loadSkeletons() ([]*animation.Skeleton, error) {
+	if len(l.doc.Skins) == 0 {
+		return nil, nil
+	}
+
+	sh, err := skeletonHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	parents := nodeParents(l.doc)
+	skeletons := make([]*animation.Skeleton, len(l.doc.Skins))
+
+	for i := range l.doc.Skins {
+		skin := &l.doc.Skins[i]
+
+		name := skin.Name
+		if name == "" {
+			name = fmt.Sprintf("skin%d", i)
+		}
+		name = l.name + "/" + name
+
+		s, err := l.makeSkeleton(name, skin, parents)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if err := s.Validate(); err != nil {
+			logrus.Warnf("model: skipping %s: %v", name, err)
+			continue
+		}
+
+		if err := sh.Add(name, s); err != nil {
+			return nil, err
+		}
+		asset.AddDependency(l.self, core.AssetRef{Kind: skeleton.AssetNameSkeleton, Name: name})
+
+		skeletons[i] = s
+	}
+
+	return skeletons, nil
+}
+
+// makeSkeleton creates the skeleton of a skin. The parent of a joint is its
+// nearest ancestor node which is also a joint.
+func (l *loader) makeSkeleton(name string, skin *gltf.Skin, parents []int) (*animation.Skeleton, error) {
+	joint := make(map[int]int, len(skin.Joints))
+	for j, n := range skin.Joints {
+		if n < 0 || n >= len(l.doc.Nodes) {
+			return nil, fmt.Errorf("joint %d refers to missing node %d", j, n)
+		}
+		joint[n] = j
+	}
+
+	var inverseBinds []mgl32.Mat4
+	if skin.InverseBindMatrices != nil {
+		var err error
+		if inverseBinds, err = l.doc.Mat4s(*skin.InverseBindMatrices); err != nil {
+			return nil, err
+		}
+		if len(inverseBinds) < len(skin.Joints) {
+			return nil, fmt.Errorf("%d inverse bind matrices for %d joints", len(inverseBinds), len(skin.Joints))
+		}
+	}
+
+	s := &animation.Skeleton{
+		Name:   name,
+		Joints: make([]animation.Joint, len(skin.Joints)),
+	}
+
+	for j, n := range skin.Joints {
+		node := &l.doc.Nodes[n]
+
+		jt := &s.Joints[j]
+		jt.Name = node.Name
+		jt.Parent = -1
+		jt.InverseBind = mgl32.Ident4()
+		if inverseBinds != nil {
+			jt.InverseBind = inverseBinds[j]
+		}
+
+		t, r, sc := node.TRS()
+		jt.Rest = animation.Transform{Translation: t, Rotation: r, Scale: sc}
+
+		for p, k := parents[n], 0; p >= 0 && k < len(parents); p, k = parents[p], k+1 {
+			if pj, ok := joint[p]; ok {
+				jt.Parent = pj
+				break
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// loadClips creates a clip for each animation of the document. Channels
+// target nodes by index.
+func (l *loader) loadClips() ([]*animation.Clip, error) {
+	if len(l.doc.Animations) == 0 {
+		return nil, nil
+	}
+
+	ah, err := animationHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	clips := make([]*animation.Clip, len(l.doc.Animations))
+
+	for i := range l.doc.Animations {
+		a := &l.doc.Animations[i]
+
+		name := a.Name
+		if name == "" {
+			name = fmt.Sprintf("animation%d", i)
+		}
+		name = l.name + "/" + name
+
+		c, err := l.makeClip(name, a)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		if err := ah.Add(name, c); err != nil {
+			return nil, err
+		}
+		asset.AddDependency(l.self, core.AssetRef{Kind: animasset.AssetNameAnimation, Name: name})
+
+		clips[i] = c
+	}
+
+	return clips, nil
+}
+
+// makeClip creates the clip of an animation.
+func (l *loader) makeClip(name string, a *gltf.Animation) (*animation.Clip, error) {
+	c := &animation.Clip{Name: name}
+
+	for j, ch := range a.Channels {
+		var path animation.Path
+
+		switch ch.Target.Path {
+		case gltf.PathTranslation:
+			path = animation.PathTranslation
+		case gltf.PathRotation:
+			path = animation.PathRotation
+		case gltf.PathScale:
+			path = animation.PathScale
+		case gltf.PathWeights:
+			path = animation.PathWeights
+		default:
+			continue
+		}
+
+		if ch.Target.Node == nil {
+			continue
+		}
+		if ch.Sampler < 0 || ch.Sampler >= len(a.Samplers) {
+			return nil, fmt.Errorf("channel %d refers to missing sampler %d", j, ch.Sampler)
+		}
+		s := &a.Samplers[ch.Sampler]
+
+		var interp animation.Interpolation
+		switch s.InterpolationMode() {
+		case gltf.InterpolationLinear:
+			interp = animation.InterpolationLinear
+		case gltf.InterpolationStep:
+			interp = animation.InterpolationStep
+		case gltf.InterpolationCubicSpline:
+			interp = animation.InterpolationCubicSpline
+		default:
+			return nil, fmt.Errorf("channel %d has unknown interpolation %s", j, s.Interpolation)
+		}
+
+		times, _, err := l.doc.Floats(s.Input)
+		if err != nil {
+			return nil, err
+		}
+		values, _, err := l.doc.Floats(s.Output)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Channels = append(c.Channels, animation.Channel{
+			Target:        *ch.Target.Node,
+			Path:          path,
+			Interpolation: interp,
+			Times:         times,
+			Values:        values,
+		})
+
+		if n := len(times); n > 0 && times[n-1] > c.Duration {
+			c.Duration = times[n-1]
+		}
+	}
+
+	return c, nil
+}
+
+// nodeParents returns the index of the parent of each node, or -1 for root
+// nodes.
+func nodeParents(doc *gltf.Document) []int {
+	parents := make([]int, len(doc.Nodes))
+	for i := range parents {
+		parents[i] = -1
+	}
+
+	for i, n := range doc.Nodes {
+		for _, c := range n.Children {
+			if c >= 0 && c < len(parents) && parents[c] < 0 && c != i {
+				parents[c] = i
+			}
+		}
+	}
+
+	return parents
+}
+
+func skeletonHandler() (*skeleton.Handler, error) {
+	h, err := asset.GetHandler(skeleton.AssetNameSkeleton)
+	if err != nil {
+		return nil, err
+	}
+
+	sh, ok := h.(*skeleton.Handler)
+	if !ok {
+		return nil, core.ErrAssetType(skeleton.AssetNameSkeleton)
+	}
+
+	return sh, nil
+}
+
+func animationHandler() (*animasset.Handler, error) {
+	h, err := asset.GetHandler(animasset.AssetNameAnimation)
+	if err != nil {
+		return nil, err
+	}
+
+	ah, ok := h.(*animasset.Handler)
+	if !ok {
+		return nil, core.ErrAssetType(animasset.AssetNameAnimation)
+	}
+
+	return ah, nil
+}
@@ -0,0 +1,535 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"net/url"
+	"path/filepath"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/geometry"
+	"github.com/haakenlabs/ember/pkg/gltf"
+	"github.com/haakenlabs/ember/scene"
+	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/asset/material"
+	"github.com/haakenlabs/ember/system/asset/mesh"
+	"github.com/haakenlabs/ember/system/asset/shader"
+	"github.com/haakenlabs/ember/system/asset/texture"
+
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// textureSlot binds a texture of a glTF material to a material texture.
+type textureSlot struct {
+	id   scene.MaterialTexture
+	info *gltf.TextureInfo
+}
+
+// loader creates the assets of a glTF document.
+type loader struct {
+	doc  *gltf.Document
+	dir  string
+	name string
+	self core.AssetRef
+
+	textures     map[int]gfx.Texture
+	textureNames map[int]string
+	materials    []*scene.Material
+	fallback     *scene.Material
+}
+
+// readBuffer reads an external buffer relative to the document.
+func (l *loader) readBuffer(uri string) ([]byte, error) {
+	r, err := core.NewResource(filepath.Join(l.dir, uri))
+	if err != nil {
+		return nil, err
+	}
+	if err := asset.ReadResource(r); err != nil {
+		return nil, err
+	}
+
+	asset.AddDependency(l.self, core.ResourceRef(r))
+
+	return r.Bytes(), nil
+}
+
+func (l *loader) loadMeshes() ([][]Primitive, error) {
+	mh, err := meshHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	prims := make([][]Primitive, len(l.doc.Meshes))
+
+	for i, m := range l.doc.Meshes {
+		meshName := m.Name
+		if meshName == "" {
+			meshName = fmt.Sprintf("mesh%d", i)
+		}
+		meshName = l.name + "/" + meshName
+
+		for j := range m.Primitives {
+			p := &m.Primitives[j]
+
+			name := meshName
+			if len(m.Primitives) > 1 {
+				name = fmt.Sprintf("%s/%d", meshName, j)
+			}
+
+			gm, err := l.makeMesh(&m, p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			if gm == nil {
+				logrus.Warnf("model: skipping %s: unsupported primitive mode %d", name, p.PrimitiveMode())
+				continue
+			}
+
+			mat, matName, err := l.material(p.Material)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := mh.Add(name, gm); err != nil {
+				return nil, err
+			}
+
+			meshRef := core.AssetRef{Kind: mesh.AssetNameMesh, Name: name}
+			asset.AddDependency(l.self, meshRef)
+			asset.AddDependency(meshRef, core.AssetRef{Kind: material.AssetNameMaterial, Name: matName})
+
+			prims[i] = append(prims[i], Primitive{Mesh: gm, Material: mat})
+		}
+	}
+
+	return prims, nil
+}
+
+// makeMesh creates a mesh from a primitive of a mesh, naming its morph
+// targets after those of the mesh. Primitives which are not made of
+// triangles return a nil mesh.
+func (l *loader) makeMesh(m *gltf.Mesh, p *gltf.Primitive) (gfx.Mesh, error) {
+	g, err := PrimitiveGeometry(l.doc, p)
+	if g == nil || err != nil {
+		return nil, err
+	}
+
+	names := m.TargetNames()
+	for i := range g.Targets {
+		if i < len(names) && names[i] != "" {
+			g.Targets[i].Name = names[i]
+		}
+	}
+
+	return mesh.MakeMesh(g), nil
+}
+
+// PrimitiveGeometry returns the geometry of a primitive of the document,
+// which must have its buffers resolved. Primitives which are not made of
+// triangles return nil geometry.
+func PrimitiveGeometry(doc *gltf.Document, p *gltf.Primitive) (*geometry.Mesh, error) {
+	pos, ok := p.Attributes[gltf.AttributePosition]
+	if !ok {
+		return nil, fmt.Errorf("primitive has no positions")
+	}
+
+	positions, err := doc.Vec3s(pos)
+	if err != nil {
+		return nil, err
+	}
+
+	var normals []mgl32.Vec3
+	if a, ok := p.Attributes[gltf.AttributeNormal]; ok {
+		if normals, err = doc.Vec3s(a); err != nil {
+			return nil, err
+		}
+	}
+
+	var uvs []mgl32.Vec2
+	if a, ok := p.Attributes[gltf.AttributeTexCoord0]; ok {
+		if uvs, err = doc.Vec2s(a); err != nil {
+			return nil, err
+		}
+	}
+
+	var indices []uint32
+	if p.Indices != nil {
+		if indices, err = doc.Indices(*p.Indices); err != nil {
+			return nil, err
+		}
+	} else {
+		indices = make([]uint32, len(positions))
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+
+	switch p.PrimitiveMode() {
+	case gltf.ModeTriangles, gltf.ModeTriangleStrip, gltf.ModeTriangleFan:
+	default:
+		return nil, nil
+	}
+
+	if indices, err = gltf.Triangulate(p.PrimitiveMode(), indices); err != nil {
+		return nil, err
+	}
+
+	for _, idx := range indices {
+		if int(idx) >= len(positions) {
+			return nil, fmt.Errorf("index %d out of range", idx)
+		}
+	}
+
+	if uvs == nil {
+		uvs = make([]mgl32.Vec2, len(positions))
+	}
+
+	g := &geometry.Mesh{Positions: positions, Normals: normals, UVs: uvs, Indices: indices}
+	if err := readStreams(doc, p, g); err != nil {
+		return nil, err
+	}
+
+	// Flat normals are used when the primitive has none, which requires
+	// unshared vertices. Tangents and normal deltas are ignored without
+	// normals.
+	if normals == nil {
+		g.Tangents = nil
+		for i := range g.Targets {
+			g.Targets[i].Normals = nil
+		}
+		g.FlatNormals()
+	}
+
+	return g, nil
+}
+
+// readStreams reads the optional vertex streams of a primitive in to the
+// mesh.
+func readStreams(doc *gltf.Document, p *gltf.Primitive, g *geometry.Mesh) error {
+	var err error
+
+	if a, ok := p.Attributes[gltf.AttributeColor0]; ok {
+		f, n, err := doc.Floats(a)
+		if err != nil {
+			return err
+		}
+
+		// Colors without alpha are opaque.
+		g.Colors = make([]mgl32.Vec4, len(f)/n)
+		for i := range g.Colors {
+			c := mgl32.Vec4{1, 1, 1, 1}
+			copy(c[:], f[i*n:i*n+n])
+			g.Colors[i] = c
+		}
+	}
+
+	if a, ok := p.Attributes[gltf.AttributeTangent]; ok {
+		if g.Tangents, err = doc.Vec4s(a); err != nil {
+			return err
+		}
+	}
+
+	if a, ok := p.Attributes[gltf.AttributeTexCoord1]; ok {
+		if g.UV2s, err = doc.Vec2s(a); err != nil {
+			return err
+		}
+	}
+
+	if a, ok := p.Attributes[gltf.AttributeJoints0]; ok {
+		f, n, err := doc.Floats(a)
+		if err != nil {
+			return err
+		}
+		if n != 4 {
+			return fmt.Errorf("joints have %d components", n)
+		}
+
+		g.Joints = make([][4]uint16, len(f)/4)
+		for i := range g.Joints {
+			for k := 0; k < 4; k++ {
+				g.Joints[i][k] = uint16(f[i*4+k])
+			}
+		}
+	}
+
+	if a, ok := p.Attributes[gltf.AttributeWeights0]; ok {
+		if g.Weights, err = doc.Vec4s(a); err != nil {
+			return err
+		}
+	}
+
+	if err := readTargets(doc, p, g); err != nil {
+		return err
+	}
+
+	return g.Validate()
+}
+
+// readTargets reads the position and normal deltas of the morph targets of
+// a primitive in to the mesh. Targets are named by index; the names given
+// by the mesh are applied by the caller.
+func readTargets(doc *gltf.Document, p *gltf.Primitive, g *geometry.Mesh) error {
+	var err error
+
+	for i, attrs := range p.Targets {
+		t := geometry.MorphTarget{Name: fmt.Sprintf("target%d", i)}
+
+		if a, ok := attrs[gltf.AttributePosition]; ok {
+			if t.Positions, err = doc.Vec3s(a); err != nil {
+				return err
+			}
+		} else {
+			t.Positions = make([]mgl32.Vec3, len(g.Positions))
+		}
+
+		if a, ok := attrs[gltf.AttributeNormal]; ok && g.Normals != nil {
+			if t.Normals, err = doc.Vec3s(a); err != nil {
+				return err
+			}
+		}
+
+		g.Targets = append(g.Targets, t)
+	}
+
+	return nil
+}
+
+// material returns the material of a primitive, creating it on first use.
+// Primitives without a material use a default white material.
+func (l *loader) material(i *int) (*scene.Material, string, error) {
+	if l.materials == nil {
+		l.materials = make([]*scene.Material, len(l.doc.Materials))
+	}
+
+	if i == nil || *i < 0 || *i >= len(l.doc.Materials) {
+		name := l.name + "/default"
+		if l.fallback == nil {
+			m, err := l.makeMaterial(name, &gltf.Material{})
+			if err != nil {
+				return nil, "", err
+			}
+			l.fallback = m
+		}
+		return l.fallback, name, nil
+	}
+
+	gm := &l.doc.Materials[*i]
+
+	name := gm.Name
+	if name == "" {
+		name = fmt.Sprintf("material%d", *i)
+	}
+	name = l.name + "/" + name
+
+	if l.materials[*i] == nil {
+		m, err := l.makeMaterial(name, gm)
+		if err != nil {
+			return nil, "", err
+		}
+		l.materials[*i] = m
+	}
+
+	return l.materials[*i], name, nil
+}
+
+func (l *loader) makeMaterial(name string, gm *gltf.Material) (*scene.Material, error) {
+	self := core.AssetRef{Kind: material.AssetNameMaterial, Name: name}
+
+	s, err := shader.Get(modelShader)
+	if err != nil {
+		return nil, err
+	}
+
+	m := scene.NewMaterial()
+	m.SetName(name)
+	m.SetShader(s)
+	m.SetProperty("f_albedo", gm.BaseColor().Vec3())
+	m.SetProperty("f_metallic", gm.Metallic())
+	m.SetProperty("f_roughness", gm.Roughness())
+
+	asset.AddDependency(self, core.AssetRef{Kind: shader.AssetNameShader, Name: modelShader})
+
+	maps := []textureSlot{{scene.MaterialTextureNormal, gm.NormalTexture}}
+	if pbr := gm.PBRMetallicRoughness; pbr != nil {
+		maps = append(maps,
+			textureSlot{scene.MaterialTextureAlbedo, pbr.BaseColorTexture},
+			textureSlot{scene.MaterialTextureMetallic, pbr.MetallicRoughnessTexture},
+		)
+	}
+
+	for _, v := range maps {
+		if v.info == nil {
+			continue
+		}
+
+		t, texName, err := l.texture(v.info.Index, v.id.ColorSpace())
+		if err != nil {
+			return nil, err
+		}
+
+		m.SetTexture(v.id, t)
+		asset.AddDependency(self, core.AssetRef{Kind: texture.AssetNameTexture, Name: texName})
+	}
+
+	mh, err := materialHandler()
+	if err != nil {
+		return nil, err
+	}
+	if err := mh.Add(name, m); err != nil {
+		return nil, err
+	}
+
+	asset.AddDependency(l.self, self)
+
+	return m, nil
+}
+
+// texture returns a texture of the document, loading its image through the
+// texture handler in the given color space. External images are named after
+// their file, embedded images after the model.
+func (l *loader) texture(i int, space gfx.ColorSpace) (gfx.Texture, string, error) {
+	if i < 0 || i >= len(l.doc.Textures) || l.doc.Textures[i].Source == nil {
+		return nil, "", fmt.Errorf("gltf: invalid texture %d", i)
+	}
+
+	if t, ok := l.textures[i]; ok {
+		return t, l.textureNames[i], nil
+	}
+
+	src := *l.doc.Textures[i].Source
+	if src < 0 || src >= len(l.doc.Images) {
+		return nil, "", fmt.Errorf("gltf: invalid image %d", src)
+	}
+
+	img := l.doc.Images[src]
+
+	var name string
+
+	data, err := l.doc.ImageData(src)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if data == nil {
+		uri, err := url.PathUnescape(img.URI)
+		if err != nil {
+			return nil, "", err
+		}
+
+		name = filepath.Base(uri)
+		texture.SetColorSpace(name, space)
+		if err := asset.Require(l.self, texture.AssetNameTexture, name, filepath.Join(l.dir, uri)); err != nil {
+			return nil, "", err
+		}
+	} else {
+		name = img.Name
+		if name == "" {
+			name = fmt.Sprintf("image%d", src)
+		}
+		name = l.name + "/" + name
+
+		th, err := asset.GetHandler(texture.AssetNameTexture)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if _, err := th.GetAsset(name); err != nil {
+			texture.SetColorSpace(name, space)
+
+			decoded, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				return nil, "", err
+			}
+			if err := texture.AddImage(name, decoded); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	t, err := texture.Get(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if s := l.doc.Textures[i].Sampler; s != nil && *s >= 0 && *s < len(l.doc.Samplers) {
+		applySampler(t, l.doc.Samplers[*s])
+	}
+
+	l.textures[i] = t
+	l.textureNames[i] = name
+
+	return t, name, nil
+}
+
+// applySampler sets the filtering and wrapping of a texture. glTF samplers
+// use OpenGL enumerants, where zero means undefined.
+func applySampler(t gfx.Texture, s gltf.Sampler) {
+	if s.MagFilter != 0 {
+		t.SetMagFilter(int32(s.MagFilter))
+	}
+	if s.MinFilter != 0 {
+		t.SetMinFilter(int32(s.MinFilter))
+	}
+	if s.WrapS != 0 {
+		t.SetWrapS(int32(s.WrapS))
+	}
+	if s.WrapT != 0 {
+		t.SetWrapT(int32(s.WrapT))
+	}
+}
+
+func meshHandler() (*mesh.Handler, error) {
+	h, err := asset.GetHandler(mesh.AssetNameMesh)
+	if err != nil {
+		return nil, err
+	}
+
+	mh, ok := h.(*mesh.Handler)
+	if !ok {
+		return nil, core.ErrAssetType(mesh.AssetNameMesh)
+	}
+
+	return mh, nil
+}
+
+func materialHandler() (*material.Handler, error) {
+	h, err := asset.GetHandler(material.AssetNameMaterial)
+	if err != nil {
+		return nil, err
+	}
+
+	mh, ok := h.(*material.Handler)
+	if !ok {
+		return nil, core.ErrAssetType(material.AssetNameMaterial)
+	}
+
+	return mh, nil
+}
@@ -0,0 +1,374 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package model
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/animation"
+	"github.com/haakenlabs/ember/pkg/gltf"
+	"github.com/haakenlabs/ember/scene"
+	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/instance"
+)
+
+const (
+	AssetNameModel = "model"
+
+	// modelShader is the shader used by materials imported from models.
+	modelShader = "standard"
+)
+
+var _ core.Importer = &Handler{}
+
+// Primitive is a mesh drawn with a material.
+type Primitive struct {
+	Mesh     gfx.Mesh
+	Material *scene.Material
+}
+
+// Model is a hierarchy of meshes imported from a glTF file. The meshes,
+// materials, textures, skeletons and animation clips of the model are added
+// to their asset handlers, named after the model.
+type Model struct {
+	core.BaseObject
+
+	name       string
+	doc        *gltf.Document
+	primitives [][]Primitive
+	skeletons  []*animation.Skeleton
+	clips      []*animation.Clip
+}
+
+// Name returns the name of this model.
+func (m *Model) Name() string {
+	return m.name
+}
+
+// Document returns the glTF document the model was imported from.
+func (m *Model) Document() *gltf.Document {
+	return m.doc
+}
+
+// Primitives returns the primitives of a glTF mesh of the model.
+func (m *Model) Primitives(mesh int) []Primitive {
+	if mesh < 0 || mesh >= len(m.primitives) {
+		return nil
+	}
+
+	return m.primitives[mesh]
+}
+
+// Skeleton returns the skeleton of a glTF skin of the model, or nil if the
+// skin could not be imported.
+func (m *Model) Skeleton(skin int) *animation.Skeleton {
+	if skin < 0 || skin >= len(m.skeletons) {
+		return nil
+	}
+
+	return m.skeletons[skin]
+}
+
+// Clips returns the animation clips of the model, one per glTF animation.
+// Channels of the clips target the nodes of the document by index.
+func (m *Model) Clips() []*animation.Clip {
+	return m.clips
+}
+
+// Instantiate creates a new GameObject hierarchy for the default scene of
+// the model. Nodes with meshes are given a MeshRenderer per primitive, or a
+// SkinnedMeshRenderer if the node has a skin or the mesh has morph targets. If the model has animation
+// clips, the root is given an Animator targeting the nodes.
+func (m *Model) Instantiate() *scene.GameObject {
+	root := scene.NewGameObject(m.name)
+
+	var nodes []int
+
+	if s := m.doc.DefaultScene(); s >= 0 && s < len(m.doc.Scenes) {
+		nodes = m.doc.Scenes[s].Nodes
+	} else {
+		nodes = m.rootNodes()
+	}
+
+	objects := make([]*scene.GameObject, len(m.doc.Nodes))
+	for _, n := range nodes {
+		if g := m.instantiateNode(n, objects); g != nil {
+			root.AddChild(g)
+		}
+	}
+
+	m.addRenderers(objects)
+
+	if len(m.clips) > 0 {
+		root.AddComponent(scene.NewAnimator(objects))
+	}
+
+	return root
+}
+
+// AddTo instantiates the model and adds it to the scene under the parent,
+// or at the root of the scene if parent is nil.
+func (m *Model) AddTo(s *scene.Scene, parent *scene.GameObject) (*scene.GameObject, error) {
+	root := m.Instantiate()
+
+	if err := s.AddObject(root, parent); err != nil {
+		return nil, err
+	}
+
+	root.Transform().Recompute(true)
+	s.Reference(AssetNameModel, m.name)
+
+	return root, nil
+}
+
+func (m *Model) instantiateNode(i int, objects []*scene.GameObject) *scene.GameObject {
+	if i < 0 || i >= len(m.doc.Nodes) || objects[i] != nil {
+		return nil
+	}
+
+	n := &m.doc.Nodes[i]
+
+	name := n.Name
+	if name == "" {
+		name = fmt.Sprintf("node%d", i)
+	}
+
+	g := scene.NewGameObject(name)
+	objects[i] = g
+
+	t, r, s := n.TRS()
+	g.Transform().SetPosition(t)
+	g.Transform().SetRotation(r)
+	g.Transform().SetScale(s)
+
+	for _, c := range n.Children {
+		if child := m.instantiateNode(c, objects); child != nil {
+			g.AddChild(child)
+		}
+	}
+
+	return g
+}
+
+// addRenderers adds the renderers of the instantiated nodes. They are added
+// once all nodes exist, as skinned meshes refer to joints elsewhere in the
+// hierarchy.
+func (m *Model) addRenderers(objects []*scene.GameObject) {
+	for i, g := range objects {
+		n := &m.doc.Nodes[i]
+		if g == nil || n.Mesh == nil {
+			continue
+		}
+
+		var sk *animation.Skeleton
+		var joints []*scene.GameObject
+
+		if n.Skin != nil {
+			if sk = m.Skeleton(*n.Skin); sk != nil {
+				for _, j := range m.doc.Skins[*n.Skin].Joints {
+					joints = append(joints, objects[j])
+				}
+			}
+		}
+
+		// The weights of the node override the default weights of the
+		// mesh.
+		weights := n.Weights
+		if weights == nil && *n.Mesh >= 0 && *n.Mesh < len(m.doc.Meshes) {
+			weights = m.doc.Meshes[*n.Mesh].Weights
+		}
+
+		for _, p := range m.Primitives(*n.Mesh) {
+			skinned := sk != nil && len(p.Mesh.Joints()) != 0
+			if !skinned && len(p.Mesh.MorphTargets()) == 0 {
+				g.AddComponent(scene.NewMeshRenderer(p.Mesh, p.Material))
+				continue
+			}
+
+			// Primitives without joints are only morphed.
+			var r *scene.SkinnedMeshRenderer
+			if skinned {
+				r = scene.NewSkinnedMeshRenderer(p.Mesh, p.Material, sk, joints)
+			} else {
+				r = scene.NewSkinnedMeshRenderer(p.Mesh, p.Material, nil, nil)
+			}
+			r.SetMorphWeights(weights)
+
+			g.AddComponent(r)
+		}
+	}
+}
+
+// rootNodes returns the nodes which are not the child of another node.
+func (m *Model) rootNodes() []int {
+	child := make(map[int]bool)
+	for _, n := range m.doc.Nodes {
+		for _, c := range n.Children {
+			child[c] = true
+		}
+	}
+
+	var roots []int
+	for i := range m.doc.Nodes {
+		if !child[i] {
+			roots = append(roots, i)
+		}
+	}
+
+	return roots
+}
+
+type Handler struct {
+	core.BaseAssetHandler
+}
+
+func NewHandler() *Handler {
+	h := &Handler{}
+	h.Items = make(map[string]int32)
+	h.Mu = &sync.RWMutex{}
+
+	return h
+}
+
+func (h *Handler) Name() string {
+	return AssetNameModel
+}
+
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".gltf", ".glb"}
+}
+
+// Signatures returns the signatures of the file formats accepted by this
+// handler. Only binary glTF has a signature.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return []core.AssetSignature{{Magic: []byte("glTF")}}
+}
+
+// Load will load data from the reader.
+func (h *Handler) Load(r *core.Resource) error {
+	name := r.Base()
+
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	doc, err := gltf.Decode(r.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if len(doc.ExtensionsRequired) > 0 {
+		return fmt.Errorf("gltf: unsupported extensions %v", doc.ExtensionsRequired)
+	}
+
+	l := &loader{
+		doc:          doc,
+		dir:          r.DirPrefix(),
+		name:         name,
+		self:         core.AssetRef{Kind: AssetNameModel, Name: name},
+		textures:     make(map[int]gfx.Texture),
+		textureNames: make(map[int]string),
+	}
+
+	if err := doc.ResolveBuffers(l.readBuffer); err != nil {
+		return err
+	}
+
+	m := &Model{
+		name: name,
+		doc:  doc,
+	}
+
+	if m.primitives, err = l.loadMeshes(); err != nil {
+		return err
+	}
+	if m.skeletons, err = l.loadSkeletons(); err != nil {
+		return err
+	}
+	if m.clips, err = l.loadClips(); err != nil {
+		return err
+	}
+
+	instance.MustAssign(m)
+
+	return h.Add(name, m)
+}
+
+func (h *Handler) Add(name string, model *Model) error {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	h.Items[name] = model.ID()
+
+	return nil
+}
+
+// Get gets an asset by name.
+func (h *Handler) Get(name string) (*Model, error) {
+	a, err := asset.Resolve(h, name)
+	if err != nil {
+		return nil, err
+	}
+
+	a2, ok := a.(*Model)
+	if !ok {
+		return nil, core.ErrAssetType(name)
+	}
+
+	return a2, nil
+}
+
+// MustGet is like GetAsset, but panics if an error occurs.
+func (h *Handler) MustGet(name string) *Model {
+	a, err := h.Get(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+func Get(name string) (*Model, error) {
+	return mustHandler().Get(name)
+}
+
+func MustGet(name string) *Model {
+	return mustHandler().MustGet(name)
+}
+
+func mustHandler() *Handler {
+	h, err := asset.GetHandler(AssetNameModel)
+	if err != nil {
+		panic(err)
+	}
+
+	return h.(*Handler)
+}
@@ -30,18 +30,28 @@ import (
 
 	"github.com/haakenlabs/ember/core"
 	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/internal/builtin"
 	"github.com/haakenlabs/ember/system/asset"
 	"github.com/haakenlabs/ember/system/renderer"
 )
 
 const (
 	AssetNameShader = "shader"
+
+	// fallbackShader is the builtin source of the shader served for
+	// missing shaders. It renders everything in magenta.
+	fallbackShader = "shaders/utils/error.glsl"
 )
 
-var _ core.AssetHandler = &Handler{}
+var _ core.FallbackHandler = &Handler{}
+var _ core.Importer = &Handler{}
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	fallback     core.Object
+	fallbackErr  error
+	fallbackOnce sync.Once
 }
 
 type Metadata struct {
@@ -71,6 +81,8 @@ func (h *Handler) Load(r *core.Resource) error {
 
 	//s.SetName(m.Name)
 
+	deps := make([]*core.Resource, 0, len(m.Files))
+
 	// Populate shader data.
 	for i := range m.Files {
 		r, err := core.NewResource(filepath.Join(r.DirPrefix(), m.Files[i]))
@@ -82,9 +94,44 @@ func (h *Handler) Load(r *core.Resource) error {
 		}
 
 		s.AddData(r.Bytes())
+		deps = append(deps, r)
+	}
+
+	if err := h.Add(name, s); err != nil {
+		return err
+	}
+
+	self := core.AssetRef{Kind: AssetNameShader, Name: name}
+	for _, dep := range deps {
+		asset.AddDependency(self, core.ResourceRef(dep))
+	}
+
+	return nil
+}
+
+// Fallback returns the error shader, served in place of missing shaders.
+func (h *Handler) Fallback() (core.Object, error) {
+	h.fallbackOnce.Do(func() {
+		h.fallback, h.fallbackErr = makeFallback()
+	})
+
+	return h.fallback, h.fallbackErr
+}
+
+func makeFallback() (core.Object, error) {
+	s := renderer.MakeShader(false)
+	s.AddData(builtin.MustAsset(fallbackShader))
+
+	obj, ok := s.(core.Object)
+	if !ok {
+		return nil, core.ErrAssetType(fallbackShader)
 	}
 
-	return h.Add(name, s)
+	if err := s.Alloc(); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
 }
 
 func (h *Handler) Add(name string, shader gfx.Shader) error {
@@ -103,7 +150,7 @@ func (h *Handler) Add(name string, shader gfx.Shader) error {
 
 // Get gets an asset by name.
 func (h *Handler) Get(name string) (gfx.Shader, error) {
-	a, err := h.GetAsset(name)
+	a, err := asset.Resolve(h, name)
 	if err != nil {
 		return nil, err
 	}
@@ -130,6 +177,17 @@ func (h *Handler) Name() string {
 	return AssetNameShader
 }
 
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".shader"}
+}
+
+// Signatures returns the signatures of the file formats accepted by this
+// handler. Shader metadata is JSON, which has no signature.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return nil
+}
+
 func NewHandler() *Handler {
 	h := &Handler{}
 	h.Items = make(map[string]int32)
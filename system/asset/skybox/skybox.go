@@ -37,19 +37,27 @@ import (
 
 	"github.com/haakenlabs/ember/core"
 	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/geometry"
+	pimage "github.com/haakenlabs/ember/pkg/image"
 	"github.com/haakenlabs/ember/pkg/image/hdr"
 	"github.com/haakenlabs/ember/pkg/math"
 	"github.com/haakenlabs/ember/scene"
 	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/asset/mesh"
 	"github.com/haakenlabs/ember/system/asset/shader"
 	"github.com/haakenlabs/ember/system/renderer"
 
 	_ "image/jpeg"
 	_ "image/png"
+
+	_ "github.com/haakenlabs/ember/pkg/image/exr"
 )
 
 const (
 	AssetNameSkybox = "skybox"
+
+	// fallbackSkybox is the builtin skybox served for missing skyboxes.
+	fallbackSkybox = "<builtin>:skyboxes/default.json"
 )
 
 var rotMatrices = [6]mgl32.Mat4{
@@ -64,17 +72,35 @@ var rotMatrices = [6]mgl32.Mat4{
 	mgl32.LookAtV(mgl32.Vec3{}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0}),
 }
 
-var _ core.AssetHandler = &Handler{}
+var _ core.FallbackHandler = &Handler{}
+var _ core.Importer = &Handler{}
 
 type Metadata struct {
 	Name       string `json:"name"`
 	Radiance   string `json:"radiance"`
 	Specular   string `json:"specular"`
 	Irradiance string `json:"irradiance"`
+
+	// ColorSpace is the color space of 8-bit images, sRGB if unset. HDR
+	// images are always linear.
+	ColorSpace *gfx.ColorSpace `json:"color_space"`
+}
+
+// colorSpace returns the color space of the 8-bit images of the skybox.
+func (m *Metadata) colorSpace() gfx.ColorSpace {
+	if m.ColorSpace != nil {
+		return *m.ColorSpace
+	}
+
+	return gfx.ColorSpaceSRGB
 }
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	fallback     core.Object
+	fallbackErr  error
+	fallbackOnce sync.Once
 }
 
 func NewHandler() *Handler {
@@ -89,6 +115,17 @@ func (h *Handler) Name() string {
 	return AssetNameSkybox
 }
 
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".skybox"}
+}
+
+// Signatures returns the signatures of the file formats accepted by this
+// handler. Skybox metadata is JSON, which has no signature.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return nil
+}
+
 func (h *Handler) Load(r *core.Resource) error {
 	m := &Metadata{}
 
@@ -107,12 +144,56 @@ func (h *Handler) Load(r *core.Resource) error {
 
 	h.Items[m.Name] = skybox.ID()
 
+	self := core.AssetRef{Kind: AssetNameSkybox, Name: m.Name}
+	for _, f := range []string{m.Radiance, m.Specular, m.Irradiance} {
+		if len(f) == 0 {
+			continue
+		}
+		if dep, err := core.NewResource(filepath.Join(r.DirPrefix(), f)); err == nil {
+			asset.AddDependency(self, core.ResourceRef(dep))
+		}
+	}
+
 	return nil
 }
 
+// Fallback returns the builtin skybox, served in place of missing skyboxes.
+func (h *Handler) Fallback() (core.Object, error) {
+	h.fallbackOnce.Do(func() {
+		h.fallback, h.fallbackErr = h.makeFallback()
+	})
+
+	return h.fallback, h.fallbackErr
+}
+
+func (h *Handler) makeFallback() (core.Object, error) {
+	m := &Metadata{}
+
+	r, err := core.NewResource(fallbackSkybox)
+	if err != nil {
+		return nil, err
+	}
+	if err := asset.ReadResource(r); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(r.Bytes(), m); err != nil {
+		return nil, err
+	}
+
+	skybox, err := h.loadMap(m, r.DirPrefix())
+	if err != nil {
+		return nil, err
+	}
+	if skybox == nil {
+		return nil, core.ErrAssetType(fallbackSkybox)
+	}
+
+	return skybox, nil
+}
+
 func (h *Handler) loadMap(m *Metadata, dir string) (skybox *scene.Skybox, err error) {
 	var specR, irrdR *core.Resource
-	var specTex, irrdTex gfx.Texture
 	var radiance, specular, irradiance gfx.Texture
 
 	genSpecular := len(m.Specular) == 0
@@ -149,44 +230,45 @@ func (h *Handler) loadMap(m *Metadata, dir string) (skybox *scene.Skybox, err er
 	simg := loadImage(specR)
 	iimg := loadImage(irrdR)
 
-	radiTex, err := loadTexture(rimg)
-	if err != nil {
-		return nil, err
-	}
-	if !genSpecular {
-		specTex, err = loadTexture(simg)
-		if err != nil {
-			return nil, err
+	// The framebuffer is only made by the GPU passes, so that headless
+	// renderers load skyboxes without a GL context.
+	var fbo gfx.Framebuffer
+	framebuffer := func() gfx.Framebuffer {
+		if fbo == nil {
+			fbo = renderer.MakeFramebuffer(math.IVec2{})
 		}
+		return fbo
 	}
-	if !genIrradiance {
-		irrdTex, err = loadTexture(iimg)
-		if err != nil {
-			return nil, err
+	defer func() {
+		if fbo != nil {
+			fbo.Dealloc()
 		}
-	}
+	}()
 
-	fbo := renderer.MakeFramebuffer(math.IVec2{})
-	defer fbo.Dealloc()
+	space := m.colorSpace()
 
-	radiance, err = makeCubemap(radiTex, fbo, radiTex.Size().Y()/2)
+	radiance, err = convertCubemap(rimg, space, framebuffer)
 	if err != nil {
 		return nil, err
 	}
 
-	if genSpecular {
-		specular, err = generateSpecular(radiance, fbo)
-	} else {
-		specular, err = makeCubemap(specTex, fbo, specTex.Size().Y()/2)
+	// Missing maps are generated on the GPU. Headless renderers leave them
+	// out.
+	headless := renderer.Headless()
+
+	if !genSpecular {
+		specular, err = convertCubemap(simg, space, framebuffer)
+	} else if !headless {
+		specular, err = generateSpecular(radiance, framebuffer)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	if genIrradiance {
-		irradiance, err = generateIrradiance(radiance, fbo)
-	} else {
-		irradiance, err = makeCubemap(irrdTex, fbo, irrdTex.Size().Y()/2)
+	if !genIrradiance {
+		irradiance, err = convertCubemap(iimg, space, framebuffer)
+	} else if !headless {
+		irradiance, err = generateIrradiance(radiance, framebuffer)
 	}
 	if err != nil {
 		return nil, err
@@ -199,7 +281,7 @@ func (h *Handler) loadMap(m *Metadata, dir string) (skybox *scene.Skybox, err er
 
 // Get gets an asset by name.
 func (h *Handler) Get(name string) (*scene.Skybox, error) {
-	a, err := h.GetAsset(name)
+	a, err := asset.Resolve(h, name)
 	if err != nil {
 		return nil, err
 	}
@@ -232,7 +314,9 @@ func loadImage(r *core.Resource) (img image.Image) {
 	return img
 }
 
-func loadTexture(img image.Image) (tex gfx.Texture, err error) {
+// loadTexture creates a 2D texture from an image. The color of 8-bit RGBA
+// images is in the given color space.
+func loadTexture(img image.Image, space gfx.ColorSpace) (tex gfx.Texture, err error) {
 	x := int32(img.Bounds().Dx())
 	y := int32(img.Bounds().Dy())
 
@@ -248,7 +332,7 @@ func loadTexture(img image.Image) (tex gfx.Texture, err error) {
 	case color.YCbCrModel:
 		rgba := image.NewRGBA(img.Bounds())
 		draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
-		tex.SetFormat(gfx.TextureFormatRGBA8)
+		tex.SetFormat(gfx.TextureFormatRGBA8.InColorSpace(space))
 		tex.SetData(rgba.Pix)
 	case color.RGBA64Model:
 		rgba := image.NewRGBA64(img.Bounds())
@@ -258,7 +342,7 @@ func loadTexture(img image.Image) (tex gfx.Texture, err error) {
 	case color.RGBAModel:
 		rgba := image.NewRGBA(img.Bounds())
 		draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
-		tex.SetFormat(gfx.TextureFormatRGBA8)
+		tex.SetFormat(gfx.TextureFormatRGBA8.InColorSpace(space))
 		tex.SetData(rgba.Pix)
 	case color.NRGBA64Model:
 		rgba := image.NewNRGBA64(img.Bounds())
@@ -268,7 +352,7 @@ func loadTexture(img image.Image) (tex gfx.Texture, err error) {
 	case color.NRGBAModel:
 		rgba := image.NewNRGBA(img.Bounds())
 		draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
-		tex.SetFormat(gfx.TextureFormatRGBA8)
+		tex.SetFormat(gfx.TextureFormatRGBA8.InColorSpace(space))
 		tex.SetData(rgba.Pix)
 	case hdr.RGB96Model:
 		rgba := hdr.NewRGB96(img.Bounds())
@@ -297,6 +381,74 @@ func loadTexture(img image.Image) (tex gfx.Texture, err error) {
 	return tex, err
 }
 
+// convertCubemap creates a cubemap from an image of any cubemap layout.
+// Equirectangular panoramas are converted on the GPU, unless the renderer
+// is headless; the other layouts are converted on the CPU. Images of no
+// layout are stretched over the sphere by the GPU conversion. The color of
+// 8-bit images is in the given color space.
+func convertCubemap(img image.Image, space gfx.ColorSpace, fbo func() gfx.Framebuffer) (gfx.Texture, error) {
+	if img == nil {
+		return nil, errors.New("no skybox image")
+	}
+
+	b := img.Bounds()
+	layout, err := pimage.DetectCubeLayout(b.Dx(), b.Dy())
+	if err != nil && renderer.Headless() {
+		return nil, err
+	}
+
+	if err != nil || (layout == pimage.CubeEquirect && !renderer.Headless()) {
+		tex, err := loadTexture(img, space)
+		if err != nil {
+			return nil, err
+		}
+
+		return makeCubemap(tex, fbo(), tex.Size().Y()/2)
+	}
+
+	return makeCPUCubemap(img, layout, space)
+}
+
+// makeCPUCubemap creates a cubemap from an image of the layout, converted
+// on the CPU with bilinear sampling. HDR images give float cubemaps; the
+// color of other images is in the given color space, and is resampled
+// linearly.
+func makeCPUCubemap(img image.Image, layout pimage.CubeLayout, space gfx.ColorSpace) (gfx.Texture, error) {
+	_, isFloat := img.(*hdr.RGB96)
+	srgb := !isFloat && space == gfx.ColorSpaceSRGB
+
+	faces, err := pimage.CubeFaces(pimage.FromImage(img, srgb), layout, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	format := gfx.TextureFormatRGBA8.InColorSpace(space)
+	if isFloat {
+		format = gfx.TextureFormatRGB32
+	}
+
+	size := int32(faces[0].Width)
+	cubemap := renderer.MakeTexture(&gfx.TextureConfig{
+		Type:   gfx.TextureCubemap,
+		Size:   math.IVec2{size, size},
+		Format: format,
+	})
+
+	for i, face := range faces {
+		if isFloat {
+			cubemap.SetHDRLayerData(face.RGB32(), int32(i))
+		} else {
+			cubemap.SetLayerData(face.RGBA8(srgb), int32(i))
+		}
+	}
+
+	if err := cubemap.Alloc(); err != nil {
+		return nil, err
+	}
+
+	return cubemap, nil
+}
+
 func makeCubemap(tex gfx.Texture, fbo gfx.Framebuffer, faceSize int32) (cubemap gfx.Texture, err error) {
 	fbo.Bind()
 	fbo.SetSize(math.IVec2{faceSize, faceSize})
@@ -310,9 +462,18 @@ func makeCubemap(tex gfx.Texture, fbo gfx.Framebuffer, faceSize int32) (cubemap
 		return nil, err
 	}
 
-	//mesh := graphics.NewMeshQuadBack()
-	mesh := renderer.MakeMesh()
-	mesh.Bind()
+	// The conversion shader mirrors the quad horizontally, which turns it
+	// around.
+	g := geometry.Quad(2, 2)
+	g.FlipWinding()
+
+	quad := mesh.MakeMesh(g)
+	if err := quad.Alloc(); err != nil {
+		return nil, err
+	}
+	defer quad.Dealloc()
+
+	quad.Bind()
 
 	gl.Disable(gl.DEPTH_TEST)
 	gl.DepthMask(false)
@@ -327,7 +488,7 @@ func makeCubemap(tex gfx.Texture, fbo gfx.Framebuffer, faceSize int32) (cubemap
 	for i := uint32(0); i < 6; i++ {
 		s.SetUniform("v_view_matrix", rotMatrices[i])
 		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_CUBE_MAP_POSITIVE_X+i, cubemap.Reference(), 0)
-		mesh.Draw()
+		quad.Draw()
 	}
 
 	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, 0, 0)
@@ -336,19 +497,19 @@ func makeCubemap(tex gfx.Texture, fbo gfx.Framebuffer, faceSize int32) (cubemap
 	gl.Enable(gl.DEPTH_TEST)
 
 	s.Unbind()
-	mesh.Unbind()
+	quad.Unbind()
 	fbo.Unbind()
 
 	return
 }
 
-func generateSpecular(radiance gfx.Texture, fbo gfx.Framebuffer) (spec gfx.Texture, err error) {
+func generateSpecular(radiance gfx.Texture, fbo func() gfx.Framebuffer) (spec gfx.Texture, err error) {
 	//return nil, ErrNotImplemented
 
 	return nil, nil
 }
 
-func generateIrradiance(radiance gfx.Texture, fbo gfx.Framebuffer) (irrd gfx.Texture, err error) {
+func generateIrradiance(radiance gfx.Texture, fbo func() gfx.Framebuffer) (irrd gfx.Texture, err error) {
 	//return nil, ErrNotImplemented
 
 	return nil, nil
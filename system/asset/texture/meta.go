@@ -0,0 +1,322 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package texture
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/gfx"
+	pimage "github.com/haakenlabs/ember/pkg/image"
+	"github.com/haakenlabs/ember/pkg/image/hdr"
+	"github.com/haakenlabs/ember/pkg/image/surface"
+	"github.com/haakenlabs/ember/pkg/math"
+	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/renderer"
+)
+
+// MetaExt is the extension of the import settings sidecar of an image,
+// appended to its file name: the settings of "brick.png" are read from
+// "brick.png.meta".
+const MetaExt = ".meta"
+
+// Meta holds the import settings of a texture, read from a JSON sidecar next
+// to its image. Unset settings keep their defaults.
+//
+//	{
+//		"filter": "trilinear",
+//		"wrap_s": "repeat",
+//		"wrap_t": "clamp",
+//		"mipmaps": true,
+//		"max_size": 1024,
+//		"color_space": "srgb",
+//		"compression": "bc3"
+//	}
+type Meta struct {
+	// Filter is "nearest", "bilinear" or "trilinear".
+	Filter string `json:"filter"`
+
+	// WrapS and WrapT are "repeat", "mirror", "clamp" or "border".
+	WrapS string `json:"wrap_s"`
+	WrapT string `json:"wrap_t"`
+
+	// Mipmaps generates the mip chain of the texture.
+	Mipmaps bool `json:"mipmaps"`
+
+	// MaxSize limits the width and height of the texture. Larger images
+	// are scaled down, keeping their aspect ratio.
+	MaxSize int `json:"max_size"`
+
+	// ColorSpace overrides the color space of the import options.
+	ColorSpace *gfx.ColorSpace `json:"color_space"`
+
+	// Format is "rgba8", "rg8" or "r8". Smaller formats keep the first
+	// channels of the image.
+	Format string `json:"format"`
+
+	// Compression is "none", "bc1", "bc2", "bc3", "bc4" or "bc5", and
+	// overrides Format. Textures are left uncompressed on renderers which
+	// cannot sample the format.
+	Compression string `json:"compression"`
+}
+
+// metaFormats maps the formats of import settings to texture formats.
+var metaFormats = map[string]surface.Format{
+	"rgba8": surface.FormatRGBA8,
+	"rg8":   surface.FormatRG8,
+	"r8":    surface.FormatR8,
+}
+
+// metaCompressions maps the compressions of import settings to texture
+// formats.
+var metaCompressions = map[string]surface.Format{
+	"bc1": surface.FormatBC1,
+	"bc2": surface.FormatBC2,
+	"bc3": surface.FormatBC3,
+	"bc4": surface.FormatBC4,
+	"bc5": surface.FormatBC5,
+}
+
+// metaFilters maps the filters of import settings to the magnification
+// filter, and the minification filters without and with mip levels.
+var metaFilters = map[string][3]int32{
+	"nearest":   {gfx.FilterNearest, gfx.FilterNearest, gfx.FilterNearestMipmapNearest},
+	"bilinear":  {gfx.FilterLinear, gfx.FilterLinear, gfx.FilterLinearMipmapNearest},
+	"trilinear": {gfx.FilterLinear, gfx.FilterLinear, gfx.FilterLinearMipmapLinear},
+}
+
+// metaWraps maps the wrap modes of import settings to texture wrap modes.
+var metaWraps = map[string]int32{
+	"repeat": gfx.WrapRepeat,
+	"mirror": gfx.WrapMirroredRepeat,
+	"clamp":  gfx.WrapClampToEdge,
+	"border": gfx.WrapClampToBorder,
+}
+
+// readMeta reads the import settings sidecar of the image of a resource.
+// Images without one have no settings.
+func readMeta(r *core.Resource) (*Meta, error) {
+	mr, err := core.NewResource(filepath.Join(r.DirPrefix(), r.Base()+MetaExt))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := asset.ReadResource(mr); err != nil {
+		if core.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	m := &Meta{}
+	if err := json.Unmarshal(mr.Bytes(), m); err != nil {
+		return nil, fmt.Errorf("%s: %v", mr.Location(), err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", mr.Location(), err)
+	}
+
+	asset.AddDependency(core.AssetRef{Kind: AssetNameTexture, Name: r.Base()}, core.ResourceRef(mr))
+
+	return m, nil
+}
+
+// Validate checks that the settings have known values.
+func (m *Meta) Validate() error {
+	if _, ok := metaFilters[m.Filter]; !ok && m.Filter != "" {
+		return fmt.Errorf("invalid filter: %s", m.Filter)
+	}
+	for _, wrap := range []string{m.WrapS, m.WrapT} {
+		if _, ok := metaWraps[wrap]; !ok && wrap != "" {
+			return fmt.Errorf("invalid wrap mode: %s", wrap)
+		}
+	}
+	if m.MaxSize < 0 {
+		return fmt.Errorf("invalid max size: %d", m.MaxSize)
+	}
+	if _, ok := metaFormats[m.Format]; !ok && m.Format != "" {
+		return fmt.Errorf("invalid format: %s", m.Format)
+	}
+	if _, ok := metaCompressions[m.Compression]; !ok && m.Compression != "" && m.Compression != "none" {
+		return fmt.Errorf("invalid compression: %s", m.Compression)
+	}
+
+	return nil
+}
+
+// processed reports whether the settings ask for the image to be processed
+// before it is uploaded.
+func (m *Meta) processed() bool {
+	if m == nil {
+		return false
+	}
+
+	_, compressed := metaCompressions[m.Compression]
+
+	return m.Mipmaps || m.MaxSize > 0 || m.Format != "" || compressed
+}
+
+// format returns the format of textures created with the settings.
+func (m *Meta) format() surface.Format {
+	if f, ok := metaCompressions[m.Compression]; ok {
+		return f
+	}
+	if f, ok := metaFormats[m.Format]; ok {
+		return f
+	}
+
+	return surface.FormatRGBA8
+}
+
+// apply sets the filtering and wrapping of a texture. The texture must be
+// allocated.
+func (m *Meta) apply(texture gfx.Texture) {
+	if m == nil {
+		return
+	}
+
+	texture.Bind()
+
+	if f, ok := metaFilters[m.Filter]; ok {
+		texture.SetMagFilter(f[0])
+		if texture.MipLevels() > 1 {
+			texture.SetMinFilter(f[2])
+		} else {
+			texture.SetMinFilter(f[1])
+		}
+	} else if texture.MipLevels() > 1 {
+		// Without a filter the mip chain would never be sampled.
+		texture.SetMinFilter(metaFilters["trilinear"][2])
+	}
+
+	wrapS, wrapT := texture.WrapS(), texture.WrapT()
+	if w, ok := metaWraps[m.WrapS]; ok {
+		wrapS = w
+	}
+	if w, ok := metaWraps[m.WrapT]; ok {
+		wrapT = w
+	}
+	if m.WrapS != "" || m.WrapT != "" {
+		texture.SetWrapST(wrapS, wrapT)
+	}
+}
+
+// makeImportedTexture creates a 2D texture from the given image, scaled,
+// mipmapped, converted and compressed as the settings ask.
+func makeImportedTexture(img image.Image, m *Meta, space gfx.ColorSpace) (gfx.Texture, error) {
+	if _, ok := img.(*hdr.RGB96); ok {
+		return nil, fmt.Errorf("cannot process HDR images")
+	}
+
+	format := m.format()
+	if format.Compressed() && !renderer.SupportsTextureFormat(surfaceFormats[format].InColorSpace(space)) {
+		logrus.Warnf("texture: %s is not supported, leaving texture uncompressed", format)
+
+		switch format {
+		case surface.FormatBC4:
+			format = surface.FormatR8
+		case surface.FormatBC5:
+			format = surface.FormatRG8
+		default:
+			format = surface.FormatRGBA8
+		}
+	}
+
+	// Formats without an sRGB variant hold linear data.
+	if surfaceFormats[format].InColorSpace(gfx.ColorSpaceSRGB) == surfaceFormats[format] {
+		space = gfx.ColorSpaceLinear
+	}
+	srgb := space == gfx.ColorSpaceSRGB
+
+	f := pimage.FromImage(img, srgb)
+	if m.MaxSize > 0 && (f.Width > m.MaxSize || f.Height > m.MaxSize) {
+		w, h := fitSize(f.Width, f.Height, m.MaxSize)
+		f = pimage.Resize(f, w, h, pimage.Kaiser, pimage.EdgeClamp)
+	}
+
+	levels := []*pimage.Float{f}
+	if m.Mipmaps {
+		levels = pimage.Mipmaps(f, pimage.MipOptions{Filter: pimage.Kaiser})
+	}
+
+	texture := renderer.MakeTexture(&gfx.TextureConfig{
+		Type:       gfx.Texture2D,
+		Format:     surfaceFormats[format],
+		ColorSpace: space,
+		Size:       math.IVec2{int32(f.Width), int32(f.Height)},
+	})
+
+	for i, level := range levels {
+		data := level.RGBA8(srgb)
+
+		switch format {
+		case surface.FormatRG8:
+			data = packChannels(data, 2)
+		case surface.FormatR8:
+			data = packChannels(data, 1)
+		case surface.FormatRGBA8:
+		default:
+			var err error
+			if data, err = surface.EncodeRGBA8(format, level.Width, level.Height, data); err != nil {
+				return nil, err
+			}
+		}
+
+		texture.SetMipData(data, int32(i))
+	}
+
+	return texture, nil
+}
+
+// fitSize scales a size down to fit in a square, keeping its aspect ratio.
+func fitSize(width, height, size int) (int, int) {
+	if width >= height {
+		h := height * size / width
+		if h < 1 {
+			h = 1
+		}
+		return size, h
+	}
+
+	w := width * size / height
+	if w < 1 {
+		w = 1
+	}
+
+	return w, size
+}
+
+// packChannels keeps the first n channels of each pixel of RGBA8 data.
+func packChannels(data []byte, n int) []byte {
+	out := make([]byte, len(data)/4*n)
+	for i := 0; i < len(data)/4; i++ {
+		copy(out[i*n:(i+1)*n], data[i*4:])
+	}
+
+	return out
+}
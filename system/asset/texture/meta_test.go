@@ -0,0 +1,142 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package texture
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/haakenlabs/ember/gfx"
+)
+
+// filterTexture records the filters set on a texture.
+type filterTexture struct {
+	gfx.Texture
+
+	levels    uint32
+	minFilter int32
+	magFilter int32
+}
+
+func (t *filterTexture) Bind()                {}
+func (t *filterTexture) MipLevels() uint32    { return t.levels }
+func (t *filterTexture) SetMinFilter(f int32) { t.minFilter = f }
+func (t *filterTexture) SetMagFilter(f int32) { t.magFilter = f }
+func (t *filterTexture) WrapS() int32         { return gfx.WrapRepeat }
+func (t *filterTexture) WrapT() int32         { return gfx.WrapRepeat }
+
+func TestMeta_Validate(t *testing.T) {
+	var tests = []struct {
+		in  Meta
+		err bool
+	}{
+		{Meta{}, false},
+		{Meta{Filter: "trilinear", WrapS: "repeat", WrapT: "border", MaxSize: 1024, Format: "rg8"}, false},
+		{Meta{Compression: "none"}, false},
+		{Meta{Compression: "bc5"}, false},
+		{Meta{Filter: "linear"}, true},
+		{Meta{WrapS: "wrap"}, true},
+		{Meta{WrapT: "clamp_to_edge"}, true},
+		{Meta{MaxSize: -1}, true},
+		{Meta{Format: "rgb8"}, true},
+		{Meta{Compression: "bc7"}, true},
+	}
+
+	for i, v := range tests {
+		err := v.in.Validate()
+		if (err != nil) != v.err {
+			t.Errorf("%s case %d: got error %v, want error %t", t.Name(), i, err, v.err)
+		}
+	}
+}
+
+func TestMeta_ApplyFilter(t *testing.T) {
+	var tests = []struct {
+		filter string
+		levels uint32
+		min    int32
+		mag    int32
+	}{
+		{"", 1, 0, 0},
+		{"", 4, gfx.FilterLinearMipmapLinear, 0},
+		{"nearest", 1, gfx.FilterNearest, gfx.FilterNearest},
+		{"nearest", 4, gfx.FilterNearestMipmapNearest, gfx.FilterNearest},
+		{"bilinear", 4, gfx.FilterLinearMipmapNearest, gfx.FilterLinear},
+		{"trilinear", 1, gfx.FilterLinear, gfx.FilterLinear},
+		{"trilinear", 4, gfx.FilterLinearMipmapLinear, gfx.FilterLinear},
+	}
+
+	for i, v := range tests {
+		texture := &filterTexture{levels: v.levels}
+		m := &Meta{Filter: v.filter, Mipmaps: v.levels > 1}
+		m.apply(texture)
+
+		if texture.minFilter != v.min {
+			t.Errorf("%s case %d: min filter got %d, want %d", t.Name(), i, texture.minFilter, v.min)
+		}
+		if texture.magFilter != v.mag {
+			t.Errorf("%s case %d: mag filter got %d, want %d", t.Name(), i, texture.magFilter, v.mag)
+		}
+	}
+}
+
+func TestFitSize(t *testing.T) {
+	var tests = []struct {
+		width, height, size int
+		w, h                int
+	}{
+		{2048, 2048, 1024, 1024, 1024},
+		{2048, 1024, 1024, 1024, 512},
+		{1024, 2048, 1024, 512, 1024},
+		{1000, 300, 256, 256, 76},
+		{4096, 1, 256, 256, 1},
+		{1, 4096, 256, 1, 256},
+	}
+
+	for i, v := range tests {
+		w, h := fitSize(v.width, v.height, v.size)
+		if w != v.w || h != v.h {
+			t.Errorf("%s case %d: got %dx%d, want %dx%d", t.Name(), i, w, h, v.w, v.h)
+		}
+	}
+}
+
+func TestPackChannels(t *testing.T) {
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	var tests = []struct {
+		n    int
+		want []byte
+	}{
+		{1, []byte{1, 5}},
+		{2, []byte{1, 2, 5, 6}},
+		{3, []byte{1, 2, 3, 5, 6, 7}},
+		{4, data},
+	}
+
+	for i, v := range tests {
+		if got := packChannels(data, v.n); !bytes.Equal(got, v.want) {
+			t.Errorf("%s case %d: got %v, want %v", t.Name(), i, got, v.want)
+		}
+	}
+}
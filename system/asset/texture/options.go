@@ -0,0 +1,74 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package texture
+
+import (
+	"github.com/haakenlabs/ember/gfx"
+)
+
+// ImportOptions control how imported textures are created.
+type ImportOptions struct {
+	// ColorSpace is the color space of imported 8-bit images. Float images
+	// are always linear, and texture containers record their own color
+	// space.
+	ColorSpace gfx.ColorSpace `json:"color_space"`
+}
+
+// ImportOptions returns the options applied to imported textures.
+func (h *Handler) ImportOptions() ImportOptions {
+	return h.options
+}
+
+// SetImportOptions sets the options applied to textures imported from then
+// on.
+func (h *Handler) SetImportOptions(options ImportOptions) {
+	h.options = options
+}
+
+// SetColorSpace sets the color space of the texture of a name imported from
+// then on, overriding the import options. Importers call it for textures
+// holding data rather than color, like normal maps.
+func (h *Handler) SetColorSpace(name string, space gfx.ColorSpace) {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+
+	h.spaces[name] = space
+}
+
+// colorSpace returns the color space of the texture of a name.
+func (h *Handler) colorSpace(name string) gfx.ColorSpace {
+	h.Mu.RLock()
+	defer h.Mu.RUnlock()
+
+	if space, ok := h.spaces[name]; ok {
+		return space
+	}
+
+	return h.options.ColorSpace
+}
+
+// SetColorSpace sets the color space of the texture of a name imported from
+// then on.
+func SetColorSpace(name string, space gfx.ColorSpace) {
+	mustHandler().SetColorSpace(name, space)
+}
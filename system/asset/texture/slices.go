@@ -0,0 +1,214 @@
+/*
+Copyright (c) 2018 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package texture
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+
+	"github.com/juju/errors"
+
+	"github.com/haakenlabs/ember/core"
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/image/cube"
+	"github.com/haakenlabs/ember/pkg/math"
+	"github.com/haakenlabs/ember/system/asset"
+	"github.com/haakenlabs/ember/system/renderer"
+)
+
+// SlicesExt is the extension of layered texture descriptors.
+const SlicesExt = ".slices"
+
+// Slices describes a layered texture, a 2D texture array or a volume
+// texture, built from images of the same size and format. The layers are
+// either separate images, or stacked vertically in a strip.
+//
+//	{
+//		"type": "3d",
+//		"slices": ["noise0.png", "noise1.png", "noise2.png"]
+//	}
+//
+//	{
+//		"type": "array",
+//		"strip": "terrain.png",
+//		"slice_height": 256
+//	}
+type Slices struct {
+	// Type is "array" for a 2D texture array, or "3d" for a volume
+	// texture.
+	Type string `json:"type"`
+
+	// Slices lists the images of the layers, relative to the descriptor.
+	Slices []string `json:"slices"`
+
+	// Strip is an image of the layers stacked top to bottom, used in place
+	// of Slices.
+	Strip string `json:"strip"`
+
+	// SliceHeight is the height of the layers of a strip. It defaults to
+	// the width of the strip, for square layers.
+	SliceHeight int `json:"slice_height"`
+}
+
+// sliceTypes maps the types of layered texture descriptors to texture
+// types.
+var sliceTypes = map[string]gfx.TextureType{
+	"array": gfx.Texture2DArray,
+	"3d":    gfx.Texture3D,
+}
+
+// loadSlices creates the layered texture of a descriptor.
+func (h *Handler) loadSlices(r *core.Resource, meta *Meta) (gfx.Texture, error) {
+	s := &Slices{}
+	if err := json.Unmarshal(r.Bytes(), s); err != nil {
+		return nil, err
+	}
+
+	textureType, ok := sliceTypes[s.Type]
+	if !ok {
+		return nil, fmt.Errorf("invalid type: %s", s.Type)
+	}
+	if (len(s.Slices) == 0) == (s.Strip == "") {
+		return nil, errors.New("either slices or a strip is required")
+	}
+
+	space := h.importColorSpace(r.Base(), meta)
+	self := core.AssetRef{Kind: AssetNameTexture, Name: r.Base()}
+
+	var format gfx.TextureFormat
+	var size image.Point
+	var layers [][]uint8
+
+	if s.Strip != "" {
+		img, err := readImage(filepath.Join(r.DirPrefix(), s.Strip), self)
+		if err != nil {
+			return nil, err
+		}
+
+		var data []uint8
+		if format, data, err = imageData(img, space); err != nil {
+			return nil, errors.Annotate(err, s.Strip)
+		}
+
+		w, height := img.Bounds().Dx(), img.Bounds().Dy()
+		sliceHeight := s.SliceHeight
+		if sliceHeight == 0 {
+			sliceHeight = w
+		}
+		if sliceHeight <= 0 || height%sliceHeight != 0 {
+			return nil, fmt.Errorf("%s: height %d is not a multiple of the slice height %d", s.Strip, height, sliceHeight)
+		}
+
+		// Layers are runs of rows of the strip.
+		stride := len(data) / height * sliceHeight
+		for i := 0; i < height/sliceHeight; i++ {
+			layers = append(layers, data[i*stride:(i+1)*stride])
+		}
+
+		size = image.Pt(w, sliceHeight)
+	}
+
+	for i, f := range s.Slices {
+		img, err := readImage(filepath.Join(r.DirPrefix(), f), self)
+		if err != nil {
+			return nil, err
+		}
+
+		sliceFormat, data, err := imageData(img, space)
+		if err != nil {
+			return nil, errors.Annotate(err, f)
+		}
+
+		if i == 0 {
+			format, size = sliceFormat, img.Bounds().Size()
+		} else if img.Bounds().Size() != size {
+			return nil, fmt.Errorf("%s: size %v differs from the size %v of %s", f, img.Bounds().Size(), size, s.Slices[0])
+		} else if sliceFormat != format {
+			return nil, fmt.Errorf("%s: format differs from the format of %s", f, s.Slices[0])
+		}
+
+		layers = append(layers, data)
+	}
+
+	texture := renderer.MakeTexture(&gfx.TextureConfig{
+		Type:   textureType,
+		Format: format,
+		Size:   math.IVec2{int32(size.X), int32(size.Y)},
+		Layers: int32(len(layers)),
+	})
+
+	for i, data := range layers {
+		texture.SetLayerData(data, int32(i))
+	}
+
+	return texture, nil
+}
+
+// readImage decodes the image of a file, recording the file as a
+// dependency of the given asset.
+func readImage(filename string, self core.AssetRef) (image.Image, error) {
+	r, err := core.NewResource(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := asset.ReadResource(r); err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(r.Reader())
+	if err != nil {
+		return nil, errors.Annotate(err, r.Base())
+	}
+
+	asset.AddDependency(self, core.ResourceRef(r))
+
+	return img, nil
+}
+
+// loadCube creates a volume texture from a 3D color lookup table, indexed
+// by input color. Tables with a domain other than [0, 1] must have their
+// input scaled by the shader sampling them.
+func loadCube(r io.Reader) (gfx.Texture, error) {
+	l, err := cube.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	n := int32(l.Size)
+
+	texture := renderer.MakeTexture(&gfx.TextureConfig{
+		Type:   gfx.Texture3D,
+		Format: gfx.TextureFormatRGB32,
+		Size:   math.IVec2{n, n},
+		Layers: n,
+	})
+
+	for b := 0; b < l.Size; b++ {
+		texture.SetHDRLayerData(l.Slice(b), int32(b))
+	}
+
+	return texture, nil
+}
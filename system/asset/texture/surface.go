@@ -0,0 +1,120 @@
+/*
+Copyright (c) 2017 HaakenLabs
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package texture
+
+import (
+	"io"
+
+	"github.com/juju/errors"
+
+	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/pkg/image/surface"
+	"github.com/haakenlabs/ember/pkg/math"
+	"github.com/haakenlabs/ember/system/renderer"
+)
+
+// surfaceFormats maps the formats of texture containers to texture formats.
+var surfaceFormats = map[surface.Format]gfx.TextureFormat{
+	surface.FormatR8:         gfx.TextureFormatR8,
+	surface.FormatRG8:        gfx.TextureFormatRG8,
+	surface.FormatRGBA8:      gfx.TextureFormatRGBA8,
+	surface.FormatR16F:       gfx.TextureFormatR16,
+	surface.FormatRG16F:      gfx.TextureFormatRG16,
+	surface.FormatRGBA16F:    gfx.TextureFormatRGBA16,
+	surface.FormatR32F:       gfx.TextureFormatR32,
+	surface.FormatRG32F:      gfx.TextureFormatRG32,
+	surface.FormatRGBA32F:    gfx.TextureFormatRGBA32,
+	surface.FormatBC1:        gfx.TextureFormatBC1,
+	surface.FormatBC2:        gfx.TextureFormatBC2,
+	surface.FormatBC3:        gfx.TextureFormatBC3,
+	surface.FormatBC4:        gfx.TextureFormatBC4,
+	surface.FormatBC5:        gfx.TextureFormatBC5,
+	surface.FormatBC6H:       gfx.TextureFormatBC6H,
+	surface.FormatBC7:        gfx.TextureFormatBC7,
+	surface.FormatETC2RGB8:   gfx.TextureFormatETC2RGB8,
+	surface.FormatETC2RGB8A1: gfx.TextureFormatETC2RGB8A1,
+	surface.FormatETC2RGBA8:  gfx.TextureFormatETC2RGBA8,
+}
+
+// loadSurface decodes a texture container and creates its texture.
+func loadSurface(r io.Reader, decode func(io.Reader) (*surface.Image, error)) (gfx.Texture, error) {
+	img, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeSurfaceTexture(img)
+}
+
+// makeSurfaceTexture creates a texture of the image with its mip chain.
+// Images with six faces become cubemaps, and images with several layers 2D
+// texture arrays. Images in formats the renderer cannot sample are
+// decompressed to RGBA8. The color space is the one the image records.
+func makeSurfaceTexture(img *surface.Image) (gfx.Texture, error) {
+	format, ok := surfaceFormats[img.Format]
+	if !ok || !renderer.SupportsTextureFormat(format) {
+		var err error
+		if img, err = img.Decompress(); err != nil {
+			return nil, err
+		}
+
+		format, ok = surfaceFormats[img.Format]
+		if !ok || !renderer.SupportsTextureFormat(format) {
+			return nil, errors.Errorf("unsupported texture format: %s", img.Format)
+		}
+	}
+
+	cfg := &gfx.TextureConfig{
+		Type:   gfx.Texture2D,
+		Format: format,
+		Layers: int32(img.Layers),
+		Size:   math.IVec2{int32(img.Width), int32(img.Height)},
+	}
+
+	if img.SRGB {
+		cfg.ColorSpace = gfx.ColorSpaceSRGB
+	}
+
+	switch {
+	case img.Cubemap && img.Layers > 1:
+		return nil, errors.New("cubemap arrays are not supported")
+	case img.Cubemap:
+		cfg.Type = gfx.TextureCubemap
+	case img.Layers > 1:
+		cfg.Type = gfx.Texture2DArray
+	}
+
+	texture := renderer.MakeTexture(cfg)
+
+	for level, images := range img.Levels {
+		for i, data := range images {
+			if cfg.Type == gfx.Texture2D {
+				texture.SetMipData(data, int32(level))
+			} else {
+				texture.SetLayerMipData(data, int32(i), int32(level))
+			}
+		}
+	}
+
+	return texture, nil
+}
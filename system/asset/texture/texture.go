@@ -23,114 +23,245 @@ SOFTWARE.
 package texture
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
+	"io"
 	"sync"
 
 	"github.com/juju/errors"
 
 	"github.com/haakenlabs/ember/core"
 	"github.com/haakenlabs/ember/gfx"
+	"github.com/haakenlabs/ember/internal/builtin"
+	"github.com/haakenlabs/ember/pkg/image/dds"
+	"github.com/haakenlabs/ember/pkg/image/exr"
+	"github.com/haakenlabs/ember/pkg/image/hdr"
+	"github.com/haakenlabs/ember/pkg/image/ktx2"
 	"github.com/haakenlabs/ember/pkg/math"
 	"github.com/haakenlabs/ember/system/asset"
 	"github.com/haakenlabs/ember/system/renderer"
 
 	_ "image/jpeg"
 	_ "image/png"
-
-	_ "github.com/haakenlabs/ember/pkg/image/hdr"
 )
 
 const (
 	AssetNameTexture = "texture"
+
+	// fallbackTexture is the builtin image served for missing textures.
+	fallbackTexture = "textures/checkerboard.png"
 )
 
-var _ core.AssetHandler = &Handler{}
+var _ core.FallbackHandler = &Handler{}
+var _ core.Importer = &Handler{}
 
 type Handler struct {
 	core.BaseAssetHandler
+
+	fallback     core.Object
+	fallbackErr  error
+	fallbackOnce sync.Once
+
+	options ImportOptions
+	spaces  map[string]gfx.ColorSpace
 }
 
 // Load will load data from the reader.
 func (h *Handler) Load(r *core.Resource) error {
-	var texture gfx.Texture
-	var img image.Image
-
 	name := r.Base()
 
 	if _, dup := h.Items[r.Base()]; dup {
 		return core.ErrAssetExists(name)
 	}
 
-	img, _, err := image.Decode(r.Reader())
+	meta, err := readMeta(r)
 	if err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	var texture gfx.Texture
+
+	switch r.Ext() {
+	case ".dds":
+		texture, err = loadSurface(r.Reader(), dds.Decode)
+	case ".ktx2":
+		texture, err = loadSurface(r.Reader(), ktx2.Decode)
+	case ".cube":
+		texture, err = loadCube(r.Reader())
+	case SlicesExt:
+		texture, err = h.loadSlices(r, meta)
+	default:
+		texture, err = h.loadImage(name, r.Reader(), meta)
+	}
+	if err != nil {
+		return errors.Annotate(err, name)
+	}
+
+	if err := h.Add(name, texture); err != nil {
 		return err
 	}
 
-	x := int32(img.Bounds().Dx())
-	y := int32(img.Bounds().Dy())
+	meta.apply(texture)
+
+	return nil
+}
+
+// loadImage decodes an image and creates its texture, processed as its
+// import settings ask.
+func (h *Handler) loadImage(name string, r io.Reader, meta *Meta) (gfx.Texture, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	space := h.importColorSpace(name, meta)
+
+	if meta.processed() {
+		return makeImportedTexture(img, meta, space)
+	}
+
+	return makeTexture(img, space)
+}
+
+// importColorSpace returns the color space of the named texture, which its
+// import settings may override.
+func (h *Handler) importColorSpace(name string, meta *Meta) gfx.ColorSpace {
+	if meta != nil && meta.ColorSpace != nil {
+		return *meta.ColorSpace
+	}
+
+	return h.colorSpace(name)
+}
+
+// Fallback returns a checkerboard texture, served in place of
+// missing textures.
+func (h *Handler) Fallback() (core.Object, error) {
+	h.fallbackOnce.Do(func() {
+		h.fallback, h.fallbackErr = makeFallback()
+	})
+
+	return h.fallback, h.fallbackErr
+}
+
+func makeFallback() (core.Object, error) {
+	img, _, err := image.Decode(bytes.NewReader(builtin.MustAsset(fallbackTexture)))
+	if err != nil {
+		return nil, err
+	}
+
+	texture, err := makeTexture(img, gfx.ColorSpaceSRGB)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := texture.(core.Object)
+	if !ok {
+		return nil, core.ErrAssetType(fallbackTexture)
+	}
+
+	if err := texture.Alloc(); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// makeTexture creates a 2D texture from the given image. The color of 8-bit
+// RGBA images is in the given color space; HDR images are linear.
+func makeTexture(img image.Image, space gfx.ColorSpace) (gfx.Texture, error) {
+	cfg := &gfx.TextureConfig{
+		Type: gfx.Texture2D,
+		Size: math.IVec2{int32(img.Bounds().Dx()), int32(img.Bounds().Dy())},
+	}
+
+	if rgb, ok := img.(*hdr.RGB96); ok {
+		cfg.Format = gfx.TextureFormatRGB32
+
+		texture := renderer.MakeTexture(cfg)
+		texture.SetHDRData(hdrData(rgb))
+
+		return texture, nil
+	}
 
-	texture = renderer.MakeTexture(
-		&gfx.TextureConfig{
-			Type:   gfx.Texture2D,
-			Format: gfx.TextureFormatDefaultColor,
-			Size:   math.IVec2{x, y},
-		})
+	format, data, err := imageData(img, space)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Format = format
+
+	texture := renderer.MakeTexture(cfg)
+	texture.SetData(data)
+
+	return texture, nil
+}
+
+// hdrData returns the pixels of an HDR image as float RGB data, as taken
+// by gfx.Texture.SetHDRData.
+func hdrData(img *hdr.RGB96) []float32 {
+	b := img.Bounds()
+	data := make([]float32, 0, b.Dx()*b.Dy()*3)
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGB96At(x, y)
+			data = append(data, c.R, c.G, c.B)
+		}
+	}
+
+	return data
+}
+
+// imageData returns the pixel data of an image along with its texture
+// format. The color of 8-bit RGBA images is in the given color space.
+func imageData(img image.Image, space gfx.ColorSpace) (gfx.TextureFormat, []uint8, error) {
+	b := img.Bounds()
 
 	switch img.ColorModel() {
 	// 4 channels, 16 bits per channel
 	case color.RGBA64Model:
-		rgba := image.NewRGBA64(img.Bounds())
-		draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
-		texture.SetFormat(gfx.TextureFormatRGBA16)
-		texture.SetData(rgba.Pix)
+		rgba := image.NewRGBA64(b)
+		draw.Draw(rgba, b, img, b.Min, draw.Src)
+		return gfx.TextureFormatRGBA16, rgba.Pix, nil
 		// 4 channels, 8 bits per channel
 	case color.RGBAModel:
-		rgba := image.NewRGBA(img.Bounds())
-		draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
-		texture.SetFormat(gfx.TextureFormatRGBA8)
-		texture.SetData(rgba.Pix)
+		rgba := image.NewRGBA(b)
+		draw.Draw(rgba, b, img, b.Min, draw.Src)
+		return gfx.TextureFormatRGBA8.InColorSpace(space), rgba.Pix, nil
 		// 2 channels, 16 bits per channel
 	case color.Alpha16Model:
-		alpha := image.NewAlpha16(img.Bounds())
-		draw.Draw(alpha, alpha.Bounds(), img, image.Point{}, draw.Src)
-		texture.SetFormat(gfx.TextureFormatRG16)
-		texture.SetData(alpha.Pix)
+		alpha := image.NewAlpha16(b)
+		draw.Draw(alpha, b, img, b.Min, draw.Src)
+		return gfx.TextureFormatRG16, alpha.Pix, nil
 		// 2 channels, 8 bits per channel
 	case color.AlphaModel:
-		alpha := image.NewAlpha(img.Bounds())
-		draw.Draw(alpha, alpha.Bounds(), img, image.Point{}, draw.Src)
-		texture.SetFormat(gfx.TextureFormatRG8)
-		texture.SetData(alpha.Pix)
+		alpha := image.NewAlpha(b)
+		draw.Draw(alpha, b, img, b.Min, draw.Src)
+		return gfx.TextureFormatRG8, alpha.Pix, nil
 		// 1 channel, 16 bits per channel
 	case color.Gray16Model:
-		gray := image.NewGray16(img.Bounds())
-		draw.Draw(gray, gray.Bounds(), img, image.Point{}, draw.Src)
-		texture.SetFormat(gfx.TextureFormatR16)
-		texture.SetData(gray.Pix)
+		gray := image.NewGray16(b)
+		draw.Draw(gray, b, img, b.Min, draw.Src)
+		return gfx.TextureFormatR16, gray.Pix, nil
 		// 1 channel, 16 bits per channel
 	case color.GrayModel:
-		gray := image.NewGray(img.Bounds())
-		draw.Draw(gray, gray.Bounds(), img, image.Point{}, draw.Src)
-		texture.SetFormat(gfx.TextureFormatR8)
-		texture.SetData(gray.Pix)
+		gray := image.NewGray(b)
+		draw.Draw(gray, b, img, b.Min, draw.Src)
+		return gfx.TextureFormatR8, gray.Pix, nil
 	case color.NRGBA64Model:
-		rgba := image.NewNRGBA64(img.Bounds())
-		draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
-		texture.SetFormat(gfx.TextureFormatRGBA16)
-		texture.SetData(rgba.Pix)
+		rgba := image.NewNRGBA64(b)
+		draw.Draw(rgba, b, img, b.Min, draw.Src)
+		return gfx.TextureFormatRGBA16, rgba.Pix, nil
 	case color.NRGBAModel:
-		rgba := image.NewNRGBA(img.Bounds())
-		draw.Draw(rgba, rgba.Bounds(), img, image.Point{}, draw.Src)
-		texture.SetFormat(gfx.TextureFormatRGBA8)
-		texture.SetData(rgba.Pix)
-	default:
-		return fmt.Errorf("invalid color format: %v", img.ColorModel())
+		rgba := image.NewNRGBA(b)
+		draw.Draw(rgba, b, img, b.Min, draw.Src)
+		return gfx.TextureFormatRGBA8.InColorSpace(space), rgba.Pix, nil
 	}
 
-	return h.Add(name, texture)
+	return 0, nil, fmt.Errorf("invalid color format: %v", img.ColorModel())
 }
 
 func (h *Handler) Add(name string, texture gfx.Texture) error {
@@ -138,7 +269,9 @@ func (h *Handler) Add(name string, texture gfx.Texture) error {
 		return core.ErrAssetExists(name)
 	}
 
-	if texture.Type() != gfx.Texture2D {
+	switch texture.Type() {
+	case gfx.Texture2D, gfx.Texture2DArray, gfx.Texture3D, gfx.TextureCubemap:
+	default:
 		return errors.New("invalid texture type")
 	}
 
@@ -151,9 +284,23 @@ func (h *Handler) Add(name string, texture gfx.Texture) error {
 	return nil
 }
 
+// AddImage creates a 2D texture from the image and adds it by name.
+func (h *Handler) AddImage(name string, img image.Image) error {
+	if _, dup := h.Items[name]; dup {
+		return core.ErrAssetExists(name)
+	}
+
+	texture, err := makeTexture(img, h.colorSpace(name))
+	if err != nil {
+		return err
+	}
+
+	return h.Add(name, texture)
+}
+
 // Get gets an asset by name.
 func (h *Handler) Get(name string) (gfx.Texture, error) {
-	a, err := h.GetAsset(name)
+	a, err := asset.Resolve(h, name)
 	if err != nil {
 		return nil, err
 	}
@@ -180,10 +327,31 @@ func (h *Handler) Name() string {
 	return AssetNameTexture
 }
 
+// Extensions returns the file extensions accepted by this handler.
+func (h *Handler) Extensions() []string {
+	return []string{".png", ".jpg", ".jpeg", ".hdr", ".exr", ".dds", ".ktx2", ".cube", SlicesExt}
+}
+
+// Signatures returns the signatures of the file formats accepted by
+// this handler.
+func (h *Handler) Signatures() []core.AssetSignature {
+	return []core.AssetSignature{
+		{Magic: []byte("\x89PNG\r\n\x1a\n")},
+		{Magic: []byte("\xff\xd8\xff")},
+		{Magic: []byte("#?RADIANCE")},
+		{Magic: []byte("#?RGBE")},
+		{Magic: []byte(exr.Magic)},
+		{Magic: []byte(dds.Magic)},
+		{Magic: []byte(ktx2.Magic)},
+	}
+}
+
 func NewHandler() *Handler {
 	h := &Handler{}
 	h.Items = make(map[string]int32)
 	h.Mu = &sync.RWMutex{}
+	h.options = ImportOptions{ColorSpace: gfx.ColorSpaceSRGB}
+	h.spaces = make(map[string]gfx.ColorSpace)
 
 	return h
 }
@@ -196,6 +364,10 @@ func MustGet(name string) gfx.Texture {
 	return mustHandler().MustGet(name)
 }
 
+func AddImage(name string, img image.Image) error {
+	return mustHandler().AddImage(name, img)
+}
+
 func mustHandler() *Handler {
 	h, err := asset.GetHandler(AssetNameTexture)
 	if err != nil {
@@ -45,6 +45,17 @@ func MakeTexture(cfg *gfx.TextureConfig) gfx.Texture {
 
 }
 
+// SupportsTextureFormat reports whether the renderer can sample textures of
+// the format.
+func SupportsTextureFormat(format gfx.TextureFormat) bool {
+	return core.GetWindowSystem().Renderer().SupportsTextureFormat(format)
+}
+
+// Headless reports whether the renderer runs without a GPU.
+func Headless() bool {
+	return core.GetWindowSystem().Renderer().Headless()
+}
+
 func MakeAttachment(cfg *gfx.AttachmentConfig) gfx.Attachment {
 	return core.GetWindowSystem().Renderer().MakeAttachment(cfg)
 }